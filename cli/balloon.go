@@ -0,0 +1,43 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var balloonCLICommand = cli.Command{
+	Name:  "balloon",
+	Usage: "reclaim memory from a sandbox's VM via virtio-balloon",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The balloon command asks the VM backing a sandbox to reclaim memory
+down to the given target, via its virtio-balloon device. It requires the
+sandbox's hypervisor to support memory ballooning, which confidential
+guests may not.`,
+	Flags: []cli.Flag{
+		cli.UintFlag{
+			Name:  "target",
+			Usage: "target memory size in MiB to balloon the sandbox's VM down to",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		if !context.IsSet("target") {
+			return fmt.Errorf("--target is required")
+		}
+
+		return vc.SetBalloonTarget(args.First(), uint32(context.Uint("target")))
+	},
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var capabilitiesCLICommand = cli.Command{
+	Name:  "capabilities",
+	Usage: "show the feature set supported by a sandbox's agent",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The capabilities command connects to the agent running inside a
+sandbox's VM and prints the feature set it reports supporting. For a
+stopped sandbox, it instead reports the capabilities that were recorded
+when the sandbox was last started.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: `select one of: ` + formatOptions,
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		caps, err := vc.GetAgentCapabilities(args.First())
+		if err != nil {
+			return err
+		}
+
+		switch context.String("format") {
+		case "table":
+			return writeAgentCapabilitiesTable(caps, defaultOutputFile)
+		case "json":
+			return json.NewEncoder(defaultOutputFile).Encode(caps)
+		default:
+			return fmt.Errorf("invalid format option")
+		}
+	},
+}
+
+func writeAgentCapabilitiesTable(caps vc.AgentCapabilities, file *os.File) error {
+	w := tabwriter.NewWriter(file, 12, 1, 3, ' ', 0)
+
+	fmt.Fprint(w, "CAPABILITY\tSUPPORTED\n")
+	fmt.Fprintf(w, "block-device\t%v\n", caps.BlockDeviceSupport)
+	fmt.Fprintf(w, "block-device-hotplug\t%v\n", caps.BlockDeviceHotplugSupport)
+	fmt.Fprintf(w, "memory-balloon\t%v\n", caps.MemoryBalloonSupport)
+	fmt.Fprintf(w, "cpu-hotplug\t%v\n", caps.CPUHotplugSupport)
+	fmt.Fprintf(w, "memory-hotplug\t%v\n", caps.MemoryHotplugSupport)
+	fmt.Fprintf(w, "online-cpu-mem\t%v\n", caps.OnlineCPUMemSupport)
+
+	return w.Flush()
+}
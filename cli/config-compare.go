@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var configCompareCLICommand = cli.Command{
+	Name:  "config-compare",
+	Usage: "show a structured diff between two sandboxes' configurations",
+	ArgsUsage: `<sandbox-id-1> <sandbox-id-2>
+
+   <sandbox-id-1> and <sandbox-id-2> are sandbox names as provided to the create command.`,
+	Description: `The config-compare command fetches the persisted configurations of two
+sandboxes and reports which hypervisor paths, kernel parameters,
+vcpu/memory sizing, devices, and mounts differ between them. It is
+intended for debugging why two supposedly identical sandboxes behave
+differently.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: "select one of: table or json",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 2 {
+			return fmt.Errorf("Expecting two sandbox IDs, got %d: %v", len(args), []string(args))
+		}
+
+		diff, err := vc.CompareSandboxConfigs(args.Get(0), args.Get(1))
+		if err != nil {
+			return err
+		}
+
+		switch format := context.String("format"); format {
+		case "json":
+			return printConfigCompareJSON(diff)
+		case "table":
+			printConfigCompareTable(diff)
+		default:
+			return fmt.Errorf("invalid format %q, expecting table or json", format)
+		}
+
+		return nil
+	},
+}
+
+func printConfigCompareJSON(diff vc.SandboxConfigDiff) error {
+	bytes, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(bytes))
+
+	return nil
+}
+
+func printConfigCompareTable(diff vc.SandboxConfigDiff) {
+	if len(diff.Entries) == 0 {
+		fmt.Printf("%s and %s have identical configurations\n", diff.FirstID, diff.SecondID)
+		return
+	}
+
+	fmt.Printf("%-20s%-30v%-30v\n", "FIELD", diff.FirstID, diff.SecondID)
+	for _, e := range diff.Entries {
+		fmt.Printf("%-20s%-30v%-30v\n", e.Field, e.First, e.Second)
+	}
+}
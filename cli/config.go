@@ -65,28 +65,39 @@ type tomlConfig struct {
 }
 
 type hypervisor struct {
-	Path                  string `toml:"path"`
-	Kernel                string `toml:"kernel"`
-	Initrd                string `toml:"initrd"`
-	Image                 string `toml:"image"`
-	Firmware              string `toml:"firmware"`
-	MachineAccelerators   string `toml:"machine_accelerators"`
-	KernelParams          string `toml:"kernel_params"`
-	MachineType           string `toml:"machine_type"`
-	DefaultVCPUs          int32  `toml:"default_vcpus"`
-	DefaultMaxVCPUs       uint32 `toml:"default_maxvcpus"`
-	DefaultMemSz          uint32 `toml:"default_memory"`
-	DefaultBridges        uint32 `toml:"default_bridges"`
-	Msize9p               uint32 `toml:"msize_9p"`
-	BlockDeviceDriver     string `toml:"block_device_driver"`
-	DisableBlockDeviceUse bool   `toml:"disable_block_device_use"`
-	MemPrealloc           bool   `toml:"enable_mem_prealloc"`
-	HugePages             bool   `toml:"enable_hugepages"`
-	Swap                  bool   `toml:"enable_swap"`
-	Debug                 bool   `toml:"enable_debug"`
-	DisableNestingChecks  bool   `toml:"disable_nesting_checks"`
-	EnableIOThreads       bool   `toml:"enable_iothreads"`
-	MemEncrypt            bool   `toml:"enable_mem_encryption"`
+	Path                   string `toml:"path"`
+	Kernel                 string `toml:"kernel"`
+	Initrd                 string `toml:"initrd"`
+	Image                  string `toml:"image"`
+	Firmware               string `toml:"firmware"`
+	KernelHash             string `toml:"kernel_hash"`
+	InitrdHash             string `toml:"initrd_hash"`
+	ImageHash              string `toml:"image_hash"`
+	FirmwareHash           string `toml:"firmware_hash"`
+	HypervisorHash         string `toml:"path_hash"`
+	AssetHashType          string `toml:"asset_hash_type"`
+	MachineAccelerators    string `toml:"machine_accelerators"`
+	KernelParams           string `toml:"kernel_params"`
+	MachineType            string `toml:"machine_type"`
+	DefaultVCPUs           int32  `toml:"default_vcpus"`
+	DefaultMaxVCPUs        uint32 `toml:"default_maxvcpus"`
+	DefaultMemSz           uint32 `toml:"default_memory"`
+	DefaultBridges         uint32 `toml:"default_bridges"`
+	Msize9p                uint32 `toml:"msize_9p"`
+	BlockDeviceDriver      string `toml:"block_device_driver"`
+	DisableBlockDeviceUse  bool   `toml:"disable_block_device_use"`
+	MemPrealloc            bool   `toml:"enable_mem_prealloc"`
+	HugePages              bool   `toml:"enable_hugepages"`
+	Swap                   bool   `toml:"enable_swap"`
+	Debug                  bool   `toml:"enable_debug"`
+	DisableNestingChecks   bool   `toml:"disable_nesting_checks"`
+	EnableIOThreads        bool   `toml:"enable_iothreads"`
+	MemEncrypt             bool   `toml:"enable_mem_encryption"`
+	SEVCertChain           string `toml:"sev_cert_chain"`
+	SEVExpectedMeasurement string `toml:"sev_expected_measurement"`
+	SEVAllowDisable        bool   `toml:"sev_allow_disable"`
+	PrefaultMemory         bool   `toml:"enable_prefault_memory"`
+	EnableGuestConsoleLog  bool   `toml:"enable_guest_console_log"`
 }
 
 type proxy struct {
@@ -95,8 +106,11 @@ type proxy struct {
 }
 
 type runtime struct {
-	Debug             bool   `toml:"enable_debug"`
-	InterNetworkModel string `toml:"internetworking_model"`
+	Debug                  bool   `toml:"enable_debug"`
+	InterNetworkModel      string `toml:"internetworking_model"`
+	MaxConcurrentLaunches  uint32 `toml:"max_concurrent_launches"`
+	AssetManifest          string `toml:"asset_manifest"`
+	AssetManifestPublicKey string `toml:"asset_manifest_public_key"`
 }
 
 type shim struct {
@@ -105,6 +119,10 @@ type shim struct {
 }
 
 type agent struct {
+	// VSOCKPort overrides the vsock port the kata agent listens on, for
+	// hosts where the default collides with other vsock-based tooling.
+	// Zero means use the default.
+	VSOCKPort uint32 `toml:"vsock_port"`
 }
 
 func (h hypervisor) path() (string, error) {
@@ -263,6 +281,26 @@ func (h hypervisor) msize9p() uint32 {
 	return h.Msize9p
 }
 
+func (h hypervisor) sevCertChainPath() (string, error) {
+	if !h.MemEncrypt {
+		return h.SEVCertChain, nil
+	}
+
+	if h.SEVCertChain == "" {
+		return "", errors.New("SEV memory encryption is enabled but no sev_cert_chain path was configured")
+	}
+
+	return resolvePath(h.SEVCertChain)
+}
+
+func (h hypervisor) sevExpectedMeasurementPath() (string, error) {
+	if h.SEVExpectedMeasurement == "" {
+		return "", nil
+	}
+
+	return resolvePath(h.SEVExpectedMeasurement)
+}
+
 func (p proxy) path() string {
 	if p.Path == "" {
 		return defaultProxyPath
@@ -329,29 +367,50 @@ func newQemuHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		return vc.HypervisorConfig{}, err
 	}
 
+	sevCertChain, err := h.sevCertChainPath()
+	if err != nil {
+		return vc.HypervisorConfig{}, err
+	}
+
+	sevExpectedMeasurement, err := h.sevExpectedMeasurementPath()
+	if err != nil {
+		return vc.HypervisorConfig{}, err
+	}
+
 	return vc.HypervisorConfig{
-		HypervisorPath:        hypervisor,
-		KernelPath:            kernel,
-		InitrdPath:            initrd,
-		ImagePath:             image,
-		FirmwarePath:          firmware,
-		MachineAccelerators:   machineAccelerators,
-		KernelParams:          vc.DeserializeParams(strings.Fields(kernelParams)),
-		HypervisorMachineType: machineType,
-		DefaultVCPUs:          h.defaultVCPUs(),
-		DefaultMaxVCPUs:       h.defaultMaxVCPUs(),
-		DefaultMemSz:          h.defaultMemSz(),
-		DefaultBridges:        h.defaultBridges(),
-		DisableBlockDeviceUse: h.DisableBlockDeviceUse,
-		MemPrealloc:           h.MemPrealloc,
-		HugePages:             h.HugePages,
-		Mlock:                 !h.Swap,
-		Debug:                 h.Debug,
-		DisableNestingChecks:  h.DisableNestingChecks,
-		BlockDeviceDriver:     blockDriver,
-		EnableIOThreads:       h.EnableIOThreads,
-		Msize9p:               h.msize9p(),
-		MemEncrypt:            h.MemEncrypt,
+		HypervisorPath:             hypervisor,
+		KernelPath:                 kernel,
+		InitrdPath:                 initrd,
+		ImagePath:                  image,
+		FirmwarePath:               firmware,
+		MachineAccelerators:        machineAccelerators,
+		KernelParams:               vc.DeserializeParams(strings.Fields(kernelParams)),
+		HypervisorMachineType:      machineType,
+		DefaultVCPUs:               h.defaultVCPUs(),
+		DefaultMaxVCPUs:            h.defaultMaxVCPUs(),
+		DefaultMemSz:               h.defaultMemSz(),
+		DefaultBridges:             h.defaultBridges(),
+		DisableBlockDeviceUse:      h.DisableBlockDeviceUse,
+		MemPrealloc:                h.MemPrealloc,
+		HugePages:                  h.HugePages,
+		Mlock:                      !h.Swap,
+		Debug:                      h.Debug,
+		DisableNestingChecks:       h.DisableNestingChecks,
+		BlockDeviceDriver:          blockDriver,
+		EnableIOThreads:            h.EnableIOThreads,
+		Msize9p:                    h.msize9p(),
+		MemEncrypt:                 h.MemEncrypt,
+		SEVCertChainPath:           sevCertChain,
+		SEVExpectedMeasurementPath: sevExpectedMeasurement,
+		SEVAllowDisable:            h.SEVAllowDisable,
+		PrefaultMemory:             h.PrefaultMemory,
+		EnableGuestConsoleLog:      h.EnableGuestConsoleLog,
+		KernelHash:                 h.KernelHash,
+		InitrdHash:                 h.InitrdHash,
+		ImageHash:                  h.ImageHash,
+		FirmwareHash:               h.FirmwareHash,
+		HypervisorHash:             h.HypervisorHash,
+		AssetHashType:              h.AssetHashType,
 	}, nil
 }
 
@@ -396,7 +455,7 @@ func updateRuntimeConfig(configPath string, tomlConf tomlConfig, config *oci.Run
 		}
 	}
 
-	for k := range tomlConf.Agent {
+	for k, agent := range tomlConf.Agent {
 		switch k {
 		case hyperstartAgentTableType:
 			config.AgentType = hyperstartAgentTableType
@@ -404,7 +463,9 @@ func updateRuntimeConfig(configPath string, tomlConf tomlConfig, config *oci.Run
 
 		case kataAgentTableType:
 			config.AgentType = kataAgentTableType
-			config.AgentConfig = vc.KataAgentConfig{}
+			config.AgentConfig = vc.KataAgentConfig{
+				AgentVSOCKPort: agent.VSOCKPort,
+			}
 
 		}
 	}
@@ -514,6 +575,14 @@ func loadConfiguration(configPath string, ignoreLogging bool) (resolvedConfigPat
 		}
 	}
 
+	config.MaxConcurrentLaunches = tomlConf.Runtime.MaxConcurrentLaunches
+
+	if tomlConf.Runtime.AssetManifest != "" {
+		if err := vc.RegisterAssetManifest(tomlConf.Runtime.AssetManifest, tomlConf.Runtime.AssetManifestPublicKey); err != nil {
+			return "", config, err
+		}
+	}
+
 	if !ignoreLogging {
 		err = handleSystemLog("", "")
 		if err != nil {
@@ -1117,6 +1117,25 @@ func TestUpdateRuntimeConfiguration(t *testing.T) {
 	assert.Equal(config.AgentConfig, vc.KataAgentConfig{})
 }
 
+func TestUpdateRuntimeConfigurationAgentVSOCKPort(t *testing.T) {
+	assert := assert.New(t)
+
+	config := oci.RuntimeConfig{}
+
+	tomlConf := tomlConfig{
+		Agent: map[string]agent{
+			kataAgentTableType: {
+				VSOCKPort: 2048,
+			},
+		},
+	}
+
+	err := updateRuntimeConfig("", tomlConf, &config)
+	assert.NoError(err)
+
+	assert.Equal(config.AgentConfig, vc.KataAgentConfig{AgentVSOCKPort: 2048})
+}
+
 func TestUpdateRuntimeConfigurationVMConfig(t *testing.T) {
 	assert := assert.New(t)
 
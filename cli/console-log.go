@@ -0,0 +1,65 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var consoleLogCLICommand = cli.Command{
+	Name:  "console-log",
+	Usage: "show a sandbox's persisted guest boot console log",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The console-log command prints the guest's boot console output mirrored to
+the host, which carries early kernel and init output from before the agent
+came up. It is only available for sandboxes started with
+EnableGuestConsoleLog, and is never produced for SEV-encrypted sandboxes.
+With --follow it streams new output as it is appended, like tail -f, until
+interrupted.`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "tail",
+			Value: 10,
+			Usage: "print only the last N lines (0 prints the whole log)",
+		},
+		cli.BoolFlag{
+			Name:  "follow, f",
+			Usage: "stream new log output as it is appended, until cancelled",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		path, err := vc.GetConsoleLogPath(args.First())
+		if err != nil {
+			return err
+		}
+
+		if err := tailFile(path, context.Int("tail"), defaultOutputFile); err != nil {
+			return err
+		}
+
+		if !context.Bool("follow") {
+			return nil
+		}
+
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		defer signal.Stop(sigCh)
+
+		return followFile(path, defaultOutputFile, sigCh)
+	},
+}
@@ -32,7 +32,7 @@ EXAMPLE:
 	Flags: []cli.Flag{
 		cli.BoolFlag{
 			Name:  "force, f",
-			Usage: "Forcibly deletes the container if it is still running (uses SIGKILL)",
+			Usage: "Forcibly deletes the container if it is still running (uses SIGKILL). For a sandbox, bypasses the normal stop-then-delete sequence entirely and removes it regardless of its persisted state",
 		},
 	},
 	Action: func(context *cli.Context) error {
@@ -88,7 +88,7 @@ func delete(containerID string, force bool) error {
 
 	switch containerType {
 	case vc.PodSandbox:
-		if err := deleteSandbox(sandboxID); err != nil {
+		if err := deleteSandbox(sandboxID, force); err != nil {
 			return err
 		}
 	case vc.PodContainer:
@@ -114,7 +114,21 @@ func delete(containerID string, force bool) error {
 	return removeCgroupsPath(containerID, cgroupsPathList)
 }
 
-func deleteSandbox(sandboxID string) error {
+func deleteSandbox(sandboxID string, force bool) error {
+	// A forced delete bypasses the normal stop-then-delete sequence
+	// entirely: it does not trust the sandbox's persisted state enough
+	// to ask it to stop gracefully, so it kills the VMM and removes all
+	// resources directly. This is the path for a sandbox whose state is
+	// too inconsistent for the normal delete to trust, e.g. because the
+	// VMM died without the runtime observing it.
+	if force {
+		if _, err := vci.ForceDeleteSandbox(sandboxID); err != nil {
+			return err
+		}
+
+		return nil
+	}
+
 	status, err := vci.StatusSandbox(sandboxID)
 	if err != nil {
 		return err
@@ -291,35 +291,19 @@ func TestDeleteSandboxRunning(t *testing.T) {
 	assert.Error(err)
 	assert.False(vcmock.IsMockError(err))
 
-	testingImpl.StatusSandboxFunc = func(sandboxID string) (vc.SandboxStatus, error) {
-		return vc.SandboxStatus{
-			ID: sandbox.ID(),
-			State: vc.State{
-				State: vc.StateRunning,
-			},
-		}, nil
-	}
-
-	testingImpl.StopSandboxFunc = func(sandboxID string) (vc.VCSandbox, error) {
-		return sandbox, nil
-	}
-
-	defer func() {
-		testingImpl.StatusSandboxFunc = nil
-		testingImpl.StopSandboxFunc = nil
-	}()
-
-	// Force delete a running sandbox
+	// Force delete a running sandbox: with no ForceDeleteSandboxFunc set
+	// yet, this should fail with a mock error rather than going through
+	// the normal stop-then-delete path.
 	err = delete(sandbox.ID(), true)
 	assert.Error(err)
 	assert.True(vcmock.IsMockError(err))
 
-	testingImpl.DeleteSandboxFunc = func(sandboxID string) (vc.VCSandbox, error) {
+	testingImpl.ForceDeleteSandboxFunc = func(sandboxID string) (vc.VCSandbox, error) {
 		return sandbox, nil
 	}
 
 	defer func() {
-		testingImpl.DeleteSandboxFunc = nil
+		testingImpl.ForceDeleteSandboxFunc = nil
 	}()
 
 	err = delete(sandbox.ID(), true)
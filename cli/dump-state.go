@@ -0,0 +1,137 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var dumpStateCLICommand = cli.Command{
+	Name:  "dump-state",
+	Usage: "dump diagnostic state recorded for a sandbox",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The dump-state command prints diagnostic state recorded for a sandbox,
+including the per-phase timing breakdown of its launch. It is intended
+for performance investigations and bug reports.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: "select one of: table or json",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		timing, err := vc.GetLaunchTiming(args.First())
+		if err != nil {
+			return err
+		}
+
+		hooks, err := vc.GetHookExecutions(args.First())
+		if err != nil {
+			return err
+		}
+
+		// The guest may not be reachable (e.g. the sandbox is stopped),
+		// in which case the bundle is still useful without load info.
+		load, _ := vc.GetGuestLoad(args.First())
+
+		cgroupPath, err := vc.GetSandboxCgroupPath(args.First())
+		if err != nil {
+			return err
+		}
+
+		confidentialMode, err := vc.GetConfidentialMode(args.First())
+		if err != nil {
+			return err
+		}
+
+		switch format := context.String("format"); format {
+		case "json":
+			return printDumpStateJSON(timing, hooks, load, cgroupPath, confidentialMode)
+		case "table":
+			printDumpStateTable(timing, hooks, load, cgroupPath, confidentialMode)
+		default:
+			return fmt.Errorf("invalid format %q, expecting table or json", format)
+		}
+
+		return nil
+	},
+}
+
+func printDumpStateJSON(timing vc.LaunchTiming, hooks []vc.HookExecution, load *vc.LoadInfo, cgroupPath, confidentialMode string) error {
+	bytes, err := json.MarshalIndent(struct {
+		LaunchTiming     vc.LaunchTiming    `json:"launchTiming"`
+		Hooks            []vc.HookExecution `json:"hooks"`
+		Load             *vc.LoadInfo       `json:"load,omitempty"`
+		CgroupPath       string             `json:"cgroupPath,omitempty"`
+		ConfidentialMode string             `json:"confidentialMode"`
+	}{timing, hooks, load, cgroupPath, confidentialMode}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(bytes))
+
+	return nil
+}
+
+func printDumpStateTable(timing vc.LaunchTiming, hooks []vc.HookExecution, load *vc.LoadInfo, cgroupPath, confidentialMode string) {
+	phases := []struct {
+		name     string
+		duration time.Duration
+	}{
+		{"asset-hash", timing.AssetHash},
+		{"vmm-spawn", timing.VMMSpawn},
+		{"agent-connect", timing.AgentConnect},
+		{"create-sandbox", timing.CreateSandbox},
+		{"start-container", timing.StartContainer},
+	}
+
+	for _, p := range phases {
+		fmt.Printf("%-16s %s\n", p.name, p.duration.String())
+	}
+
+	fmt.Printf("%-16s %s\n", "total", timing.Total())
+
+	fmt.Println()
+	fmt.Printf("confidential mode: %s\n", confidentialMode)
+
+	if load != nil {
+		fmt.Println()
+		fmt.Printf("guest uptime:    %.0fs\n", load.UptimeSeconds)
+		fmt.Printf("guest load avg:  %.2f %.2f %.2f\n", load.Load1, load.Load5, load.Load15)
+	}
+
+	if cgroupPath != "" {
+		fmt.Println()
+		fmt.Printf("cgroup path:     %s\n", cgroupPath)
+	}
+
+	if len(hooks) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println("hooks:")
+	for _, h := range hooks {
+		fmt.Printf("%-10s %-40s exit=%d\n", h.HookType, h.Path, h.ExitCode)
+		if h.Error != "" {
+			fmt.Printf("  error: %s\n", h.Error)
+		}
+	}
+}
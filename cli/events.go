@@ -97,6 +97,8 @@ type memory struct {
 	Kernel    memoryEntry       `json:"kernel,omitempty"`
 	KernelTCP memoryEntry       `json:"kernelTCP,omitempty"`
 	Raw       map[string]uint64 `json:"raw,omitempty"`
+	// Peak is the high-water mark of the container's memory usage.
+	Peak uint64 `json:"peak,omitempty"`
 }
 
 type l3CacheInfo struct {
@@ -134,6 +136,10 @@ information is displayed once every 5 seconds.`,
 			Name:  "stats",
 			Usage: "display the container's stats then exit",
 		},
+		cli.BoolFlag{
+			Name:  "mem-info",
+			Usage: "display the guest's /proc/meminfo then exit",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		containerID := context.Args().First()
@@ -187,6 +193,17 @@ information is displayed once every 5 seconds.`,
 			return nil
 		}
 
+		if context.Bool("mem-info") {
+			memInfo, err := vc.GetGuestMemInfo(sandboxID)
+			if err != nil {
+				return err
+			}
+			events <- &event{Type: "meminfo", ID: status.ID, Data: memInfo}
+			close(events)
+			group.Wait()
+			return nil
+		}
+
 		go func() {
 			for range time.Tick(context.Duration("interval")) {
 				s, err := vci.StatsContainer(sandboxID, containerID)
@@ -226,6 +243,7 @@ func convertVirtcontainerStats(containerStats *vc.ContainerStats) *stats {
 	s.Memory.Swap = convertMemoryEntry(cg.MemoryStats.SwapUsage)
 	s.Memory.Usage = convertMemoryEntry(cg.MemoryStats.Usage)
 	s.Memory.Raw = cg.MemoryStats.Stats
+	s.Memory.Peak = containerStats.MemoryPeak
 
 	s.Blkio.IoServiceBytesRecursive = convertBlkioEntry(cg.BlkioStats.IoServiceBytesRecursive)
 	s.Blkio.IoServicedRecursive = convertBlkioEntry(cg.BlkioStats.IoServicedRecursive)
@@ -253,6 +253,7 @@ func execute(context *cli.Context) error {
 	}
 
 	if params.detach {
+		fmt.Fprintln(os.Stdout, process.Pid)
 		return nil
 	}
 
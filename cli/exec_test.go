@@ -7,6 +7,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -374,7 +375,12 @@ func TestExecuteWithFlagsDetached(t *testing.T) {
 		testingImpl.StatusContainerFunc = nil
 	}()
 
+	var calledWithDetach bool
+	var gotPID int
+
 	testingImpl.EnterContainerFunc = func(sandboxID, containerID string, cmd vc.Cmd) (vc.VCSandbox, vc.VCContainer, *vc.Process, error) {
+		calledWithDetach = cmd.Detach
+
 		// create a fake container process
 		workload := []string{"cat", "/dev/null"}
 		command := exec.Command(workload[0], workload[1:]...)
@@ -383,6 +389,7 @@ func TestExecuteWithFlagsDetached(t *testing.T) {
 
 		vcProcess := vc.Process{}
 		vcProcess.Pid = command.Process.Pid
+		gotPID = vcProcess.Pid
 		return &vcmock.Sandbox{}, &vcmock.Container{}, &vcProcess, nil
 	}
 
@@ -394,8 +401,22 @@ func TestExecuteWithFlagsDetached(t *testing.T) {
 	fn, ok := execCLICommand.Action.(func(context *cli.Context) error)
 	assert.True(ok)
 
+	oldStdout := os.Stdout
+	r, w, err := os.Pipe()
+	assert.NoError(err)
+	os.Stdout = w
+
 	err = fn(ctx)
+
+	w.Close()
+	os.Stdout = oldStdout
+
+	assert.NoError(err)
+	assert.True(calledWithDetach, "exec --detach should call EnterContainer with Cmd.Detach set, so no stdio is wired")
+
+	output, err := ioutil.ReadAll(r)
 	assert.NoError(err)
+	assert.Equal(fmt.Sprintf("%d\n", gotPID), string(output))
 }
 
 func TestExecuteWithInvalidProcessJson(t *testing.T) {
@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var fstrimCLICommand = cli.Command{
+	Name:  "fstrim",
+	Usage: "discard unused blocks on a sandbox's guest filesystem",
+	ArgsUsage: `<sandbox-id> [mountpoint]
+
+   <sandbox-id> is the sandbox name as provided to the create command.
+   [mountpoint] is the guest path to trim, defaulting to the guest rootfs.`,
+	Description: `The fstrim command asks the agent running inside a sandbox's VM to run
+fstrim against mountpoint, discarding blocks no longer in use by the guest
+filesystem. This keeps a thin-provisioned rootfs image from growing
+unbounded.`,
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) < 1 || len(args) > 2 {
+			return fmt.Errorf("Expecting a sandbox ID and an optional mountpoint, got %d arguments: %v", len(args), []string(args))
+		}
+
+		var mountpoint string
+		if len(args) == 2 {
+			mountpoint = args[1]
+		}
+
+		return vc.TrimGuestFS(args.First(), mountpoint)
+	},
+}
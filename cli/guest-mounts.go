@@ -0,0 +1,72 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var guestMountsCLICommand = cli.Command{
+	Name:  "guest-mounts",
+	Usage: "show a sandbox's guest mount points",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The guest-mounts command asks the agent running inside a sandbox's VM to
+report its mounts, as parsed from /proc/self/mountinfo. It is intended for
+verifying that a shared mount actually landed in the guest.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: "select one of: table or json",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		mounts, err := vc.GetGuestMounts(args.First())
+		if err != nil {
+			return err
+		}
+
+		switch format := context.String("format"); format {
+		case "json":
+			return printGuestMountsJSON(mounts)
+		case "table":
+			printGuestMountsTable(mounts)
+		default:
+			return fmt.Errorf("invalid format %q, expecting table or json", format)
+		}
+
+		return nil
+	},
+}
+
+func printGuestMountsJSON(mounts []vc.GuestMount) error {
+	bytes, err := json.MarshalIndent(mounts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(bytes))
+
+	return nil
+}
+
+func printGuestMountsTable(mounts []vc.GuestMount) {
+	for _, m := range mounts {
+		fmt.Printf("%-30s on %-30s type %-10s (%s)\n", m.Source, m.MountPoint, m.FSType, strings.Join(m.Options, ","))
+	}
+}
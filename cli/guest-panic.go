@@ -0,0 +1,45 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var guestPanicCLICommand = cli.Command{
+	Name:  "check-guest-panic",
+	Usage: "check whether a sandbox's guest kernel has panicked",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The check-guest-panic command looks for signs that a sandbox's guest kernel
+has panicked: either a direct report from the hypervisor's pvpanic device,
+or the agent having gone silent while the VMM process is still alive. If a
+panic is found, the sandbox's persisted state is transitioned to "panicked"
+and the reason is recorded.`,
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		result, err := vc.CheckGuestPanic(args.First())
+		if err != nil {
+			return err
+		}
+
+		if result.Panicked {
+			fmt.Printf("guest panic detected: %s\n", result.Reason)
+		} else {
+			fmt.Println("no guest panic detected")
+		}
+
+		return nil
+	},
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var healthCLICommand = cli.Command{
+	Name:  "health",
+	Usage: "check the health of a sandbox's storage, agent, hypervisor and guest clock",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The health command checks whether a sandbox's persisted state can be
+read, whether its agent is responding, whether its VMM process is still
+running, and whether its guest clock has drifted from the host's, then
+reports a combined healthy/unhealthy status.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: "select one of: table or json",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		health, err := vc.CheckSandboxHealth(args.First())
+		if err != nil {
+			return err
+		}
+
+		switch format := context.String("format"); format {
+		case "json":
+			if err := printHealthJSON(health); err != nil {
+				return err
+			}
+		case "table":
+			printHealthTable(health)
+		default:
+			return fmt.Errorf("invalid format %q, expecting table or json", format)
+		}
+
+		if !health.Healthy() {
+			return fmt.Errorf("sandbox is unhealthy")
+		}
+
+		return nil
+	},
+}
+
+func printHealthJSON(health *vc.SandboxHealth) error {
+	bytes, err := json.MarshalIndent(health, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(bytes))
+
+	return nil
+}
+
+func printHealthTable(health *vc.SandboxHealth) {
+	components := []struct {
+		name   string
+		health vc.ComponentHealth
+	}{
+		{"storage", health.Storage},
+		{"agent", health.Agent},
+		{"hypervisor", health.Hypervisor},
+		{"clock skew", health.ClockSkew},
+	}
+
+	for _, c := range components {
+		status := "unhealthy"
+		if c.health.Healthy {
+			status = "healthy"
+		}
+
+		fmt.Printf("%-10s %-9s %s\n", c.name, status, c.health.Message)
+	}
+}
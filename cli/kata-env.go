@@ -22,7 +22,7 @@ import (
 //
 // XXX: Increment for every change to the output format
 // (meaning any change to the EnvInfo type).
-const formatVersion = "1.0.12"
+const formatVersion = "1.0.13"
 
 // MetaInfo stores information on the format of the output itself
 type MetaInfo struct {
@@ -62,6 +62,10 @@ type RuntimeInfo struct {
 	Version RuntimeVersionInfo
 	Config  RuntimeConfigInfo
 	Debug   bool
+
+	// Root is the effective runtime root directory, after validation
+	// (see validateRootDirectory).
+	Root string
 }
 
 // RuntimeVersionInfo stores details of the runtime version
@@ -79,6 +83,20 @@ type HypervisorInfo struct {
 	BlockDeviceDriver string
 	Msize9p           uint32
 	Debug             bool
+
+	// SEVCertChainSubjects holds the Subject of each certificate in the
+	// SEV platform certificate chain (ARK/ASK/PEK/CEK), when SEV memory
+	// encryption is enabled and a certificate chain is configured.
+	SEVCertChainSubjects []string
+
+	// CPUModel is the effective CPU model passed to the hypervisor's
+	// -cpu option: the configured HypervisorConfig.CPUModel, or "host"
+	// (passthrough) if unset.
+	CPUModel string
+
+	// CPUFeatures lists the additional CPU features configured on top
+	// of CPUModel.
+	CPUFeatures []string
 }
 
 // ProxyInfo stores proxy details
@@ -100,6 +118,11 @@ type ShimInfo struct {
 // AgentInfo stores agent details
 type AgentInfo struct {
 	Type string
+
+	// VSOCKPort is the configured vsock port override the kata agent
+	// listens on. It is zero when the agent is not of type "kata" or no
+	// override was configured.
+	VSOCKPort uint32
 }
 
 // DistroInfo stores host operating system distribution details.
@@ -140,7 +163,7 @@ func getMetaInfo() MetaInfo {
 	}
 }
 
-func getRuntimeInfo(configFile string, config oci.RuntimeConfig) RuntimeInfo {
+func getRuntimeInfo(configFile, runtimeRoot string, config oci.RuntimeConfig) RuntimeInfo {
 	runtimeVersion := RuntimeVersionInfo{
 		Semver: version,
 		Commit: commit,
@@ -154,6 +177,7 @@ func getRuntimeInfo(configFile string, config oci.RuntimeConfig) RuntimeInfo {
 	return RuntimeInfo{
 		Version: runtimeVersion,
 		Config:  runtimeConfig,
+		Root:    runtimeRoot,
 	}
 }
 
@@ -258,6 +282,10 @@ func getAgentInfo(config oci.RuntimeConfig) AgentInfo {
 		Type: string(config.AgentType),
 	}
 
+	if kataConfig, ok := config.AgentConfig.(vc.KataAgentConfig); ok {
+		agent.VSOCKPort = kataConfig.AgentVSOCKPort
+	}
+
 	return agent
 }
 
@@ -269,19 +297,35 @@ func getHypervisorInfo(config oci.RuntimeConfig) HypervisorInfo {
 		version = unknown
 	}
 
+	var sevCertChainSubjects []string
+	if config.HypervisorConfig.MemEncrypt && config.HypervisorConfig.SEVCertChainPath != "" {
+		// Best-effort: an unreadable or unparsable chain was already
+		// rejected by HypervisorConfig.valid() at startup, so any
+		// error here is not worth failing the env command over.
+		sevCertChainSubjects, _ = vc.SEVCertChainSubjects(config.HypervisorConfig.SEVCertChainPath)
+	}
+
+	cpuModel := config.HypervisorConfig.CPUModel
+	if cpuModel == "" {
+		cpuModel = "host"
+	}
+
 	return HypervisorInfo{
-		MachineType:       config.HypervisorConfig.HypervisorMachineType,
-		Version:           version,
-		Path:              hypervisorPath,
-		BlockDeviceDriver: config.HypervisorConfig.BlockDeviceDriver,
-		Msize9p:           config.HypervisorConfig.Msize9p,
+		MachineType:          config.HypervisorConfig.HypervisorMachineType,
+		Version:              version,
+		Path:                 hypervisorPath,
+		BlockDeviceDriver:    config.HypervisorConfig.BlockDeviceDriver,
+		Msize9p:              config.HypervisorConfig.Msize9p,
+		SEVCertChainSubjects: sevCertChainSubjects,
+		CPUModel:             cpuModel,
+		CPUFeatures:          config.HypervisorConfig.CPUFeatures,
 	}
 }
 
-func getEnvInfo(configFile string, config oci.RuntimeConfig) (env EnvInfo, err error) {
+func getEnvInfo(configFile, runtimeRoot string, config oci.RuntimeConfig) (env EnvInfo, err error) {
 	meta := getMetaInfo()
 
-	runtime := getRuntimeInfo(configFile, config)
+	runtime := getRuntimeInfo(configFile, runtimeRoot, config)
 
 	host, err := getHostInfo()
 	if err != nil {
@@ -354,7 +398,12 @@ func handleSettings(file *os.File, metadata map[string]interface{}) error {
 		return errors.New("cannot determine runtime config")
 	}
 
-	env, err := getEnvInfo(configFile, runtimeConfig)
+	runtimeRoot, ok := metadata["runtimeRoot"].(string)
+	if !ok {
+		return errors.New("cannot determine runtime root directory")
+	}
+
+	env, err := getEnvInfo(configFile, runtimeRoot, runtimeConfig)
 	if err != nil {
 		return err
 	}
@@ -252,7 +252,7 @@ func getExpectedKernel(config oci.RuntimeConfig) KernelInfo {
 	}
 }
 
-func getExpectedRuntimeDetails(configFile string) RuntimeInfo {
+func getExpectedRuntimeDetails(configFile, runtimeRoot string) RuntimeInfo {
 	return RuntimeInfo{
 		Version: RuntimeVersionInfo{
 			Semver: version,
@@ -262,13 +262,14 @@ func getExpectedRuntimeDetails(configFile string) RuntimeInfo {
 		Config: RuntimeConfigInfo{
 			Path: configFile,
 		},
+		Root: runtimeRoot,
 	}
 }
 
 func getExpectedSettings(config oci.RuntimeConfig, tmpdir, configFile string) (EnvInfo, error) {
 	meta := getExpectedMetaInfo()
 
-	runtime := getExpectedRuntimeDetails(configFile)
+	runtime := getExpectedRuntimeDetails(configFile, tmpdir)
 
 	proxy, err := getExpectedProxyDetails(config)
 	if err != nil {
@@ -403,7 +404,7 @@ func TestEnvGetEnvInfo(t *testing.T) {
 	expectedEnv, err := getExpectedSettings(config, tmpdir, configFile)
 	assert.NoError(t, err)
 
-	env, err := getEnvInfo(configFile, config)
+	env, err := getEnvInfo(configFile, tmpdir, config)
 	assert.NoError(t, err)
 
 	assert.Equal(t, expectedEnv, env)
@@ -427,7 +428,7 @@ func TestEnvGetEnvInfoNoHypervisorVersion(t *testing.T) {
 
 	expectedEnv.Hypervisor.Version = unknown
 
-	env, err := getEnvInfo(configFile, config)
+	env, err := getEnvInfo(configFile, tmpdir, config)
 	assert.NoError(err)
 
 	assert.Equal(expectedEnv, env)
@@ -445,7 +446,7 @@ func TestEnvGetEnvInfoShimError(t *testing.T) {
 
 	config.ShimConfig = "invalid shim config"
 
-	_, err = getEnvInfo(configFile, config)
+	_, err = getEnvInfo(configFile, tmpdir, config)
 	assert.Error(err)
 }
 
@@ -461,7 +462,7 @@ func TestEnvGetEnvInfoAgentError(t *testing.T) {
 
 	config.AgentConfig = "invalid agent config"
 
-	_, err = getEnvInfo(configFile, config)
+	_, err = getEnvInfo(configFile, tmpdir, config)
 	assert.Error(err)
 }
 
@@ -481,7 +482,7 @@ func TestEnvGetEnvInfoNoOSRelease(t *testing.T) {
 	err = os.Remove(osRelease)
 	assert.NoError(t, err)
 
-	_, err = getEnvInfo(configFile, config)
+	_, err = getEnvInfo(configFile, tmpdir, config)
 	assert.Error(t, err)
 }
 
@@ -501,7 +502,7 @@ func TestEnvGetEnvInfoNoProcCPUInfo(t *testing.T) {
 	err = os.Remove(procCPUInfo)
 	assert.NoError(t, err)
 
-	_, err = getEnvInfo(configFile, config)
+	_, err = getEnvInfo(configFile, tmpdir, config)
 	assert.Error(t, err)
 }
 
@@ -521,7 +522,7 @@ func TestEnvGetEnvInfoNoProcVersion(t *testing.T) {
 	err = os.Remove(procVersion)
 	assert.NoError(t, err)
 
-	_, err = getEnvInfo(configFile, config)
+	_, err = getEnvInfo(configFile, tmpdir, config)
 	assert.Error(t, err)
 }
 
@@ -535,9 +536,9 @@ func TestEnvGetRuntimeInfo(t *testing.T) {
 	configFile, config, err := makeRuntimeConfig(tmpdir)
 	assert.NoError(t, err)
 
-	expectedRuntime := getExpectedRuntimeDetails(configFile)
+	expectedRuntime := getExpectedRuntimeDetails(configFile, tmpdir)
 
-	runtime := getRuntimeInfo(configFile, config)
+	runtime := getRuntimeInfo(configFile, tmpdir, config)
 
 	assert.Equal(t, expectedRuntime, runtime)
 }
@@ -668,6 +669,22 @@ func TestEnvGetAgentInfo(t *testing.T) {
 	assert.Equal(t, expectedAgent, agent)
 }
 
+func TestEnvGetAgentInfoReportsConfiguredVSOCKPort(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	_, config, err := makeRuntimeConfig(tmpdir)
+	assert.NoError(t, err)
+
+	config.AgentConfig = vc.KataAgentConfig{AgentVSOCKPort: 2048}
+
+	agent := getAgentInfo(config)
+	assert.Equal(t, uint32(2048), agent.VSOCKPort)
+}
+
 func testEnvShowSettings(t *testing.T, tmpdir string, tmpfile *os.File) error {
 
 	runtime := RuntimeInfo{}
@@ -785,6 +802,7 @@ func TestEnvHandleSettings(t *testing.T) {
 	m := map[string]interface{}{
 		"configFile":    configFile,
 		"runtimeConfig": config,
+		"runtimeRoot":   tmpdir,
 	}
 
 	tmpfile, err := ioutil.TempFile("", "")
@@ -818,6 +836,7 @@ func TestEnvHandleSettingsInvalidShimConfig(t *testing.T) {
 	m := map[string]interface{}{
 		"configFile":    configFile,
 		"runtimeConfig": config,
+		"runtimeRoot":   tmpdir,
 	}
 
 	tmpfile, err := ioutil.TempFile("", "")
@@ -842,6 +861,7 @@ func TestEnvHandleSettingsInvalidFile(t *testing.T) {
 	m := map[string]interface{}{
 		"configFile":    "foo",
 		"runtimeConfig": oci.RuntimeConfig{},
+		"runtimeRoot":   "/some/where",
 	}
 
 	err := handleSettings(nil, m)
@@ -852,6 +872,7 @@ func TestEnvHandleSettingsInvalidConfigFileType(t *testing.T) {
 	m := map[string]interface{}{
 		"configFile":    123,
 		"runtimeConfig": oci.RuntimeConfig{},
+		"runtimeRoot":   "/some/where",
 	}
 
 	err := handleSettings(os.Stderr, m)
@@ -862,6 +883,28 @@ func TestEnvHandleSettingsInvalidRuntimeConfigType(t *testing.T) {
 	m := map[string]interface{}{
 		"configFile":    "/some/where",
 		"runtimeConfig": true,
+		"runtimeRoot":   "/some/where",
+	}
+
+	err := handleSettings(os.Stderr, m)
+	assert.Error(t, err)
+}
+
+func TestEnvHandleSettingsInvalidRuntimeRootType(t *testing.T) {
+	m := map[string]interface{}{
+		"configFile":    "/some/where",
+		"runtimeConfig": oci.RuntimeConfig{},
+		"runtimeRoot":   123,
+	}
+
+	err := handleSettings(os.Stderr, m)
+	assert.Error(t, err)
+}
+
+func TestEnvHandleSettingsMissingRuntimeRoot(t *testing.T) {
+	m := map[string]interface{}{
+		"configFile":    "/some/where",
+		"runtimeConfig": oci.RuntimeConfig{},
 	}
 
 	err := handleSettings(os.Stderr, m)
@@ -888,6 +931,7 @@ func TestEnvCLIFunction(t *testing.T) {
 	ctx.App.Metadata = map[string]interface{}{
 		"configFile":    configFile,
 		"runtimeConfig": config,
+		"runtimeRoot":   tmpdir,
 	}
 
 	fn, ok := kataEnvCLICommand.Action.(func(context *cli.Context) error)
@@ -928,6 +972,7 @@ func TestEnvCLIFunctionFail(t *testing.T) {
 	ctx.App.Metadata = map[string]interface{}{
 		"configFile":    configFile,
 		"runtimeConfig": config,
+		"runtimeRoot":   tmpdir,
 	}
 
 	fn, ok := kataEnvCLICommand.Action.(func(context *cli.Context) error)
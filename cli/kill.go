@@ -17,6 +17,11 @@ import (
 	"github.com/urfave/cli"
 )
 
+// killCLICommand already had its --all flag, usage text, and wiring
+// through to kill(containerID, signal, all) before this file's signal
+// parser was renamed to parseSignal; that rename (and the numeric-signal
+// test added alongside it) is the only change this backlog entry made
+// here.
 var killCLICommand = cli.Command{
 	Name:  "kill",
 	Usage: "Kill sends signals to the container's init process",
@@ -104,7 +109,7 @@ func kill(containerID, signal string, all bool) error {
 		"sandbox":   sandboxID,
 	})
 
-	signum, err := processSignal(signal)
+	signum, err := parseSignal(signal)
 	if err != nil {
 		return err
 	}
@@ -139,7 +144,11 @@ func kill(containerID, signal string, all bool) error {
 	return err
 }
 
-func processSignal(signal string) (syscall.Signal, error) {
+// parseSignal resolves a signal argument given on the command line into a
+// syscall.Signal. It accepts full signal names (SIGTERM), short names
+// (TERM), and numeric values (15), and is shared by any command that needs
+// to translate a user-supplied signal string.
+func parseSignal(signal string) (syscall.Signal, error) {
 	signum, signalOk := signals[signal]
 	if signalOk {
 		return signum, nil
@@ -32,7 +32,7 @@ var (
 	}
 )
 
-func TestProcessSignal(t *testing.T) {
+func TestParseSignal(t *testing.T) {
 	tests := []struct {
 		signal string
 		valid  bool
@@ -47,7 +47,7 @@ func TestProcessSignal(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		signum, err := processSignal(test.signal)
+		signum, err := parseSignal(test.signal)
 		if signum != test.signum {
 			t.Fatalf("signal received: %d expected signal: %d\n", signum, test.signum)
 		}
@@ -118,6 +118,10 @@ func TestKillCLIFunctionSigtermSuccessful(t *testing.T) {
 	testKillCLIFunctionTerminationSignalSuccessful(t, "SIGTERM")
 }
 
+func TestKillCLIFunctionNumericSignalSuccessful(t *testing.T) {
+	testKillCLIFunctionTerminationSignalSuccessful(t, "9")
+}
+
 func TestKillCLIFunctionNotTerminationSignalSuccessful(t *testing.T) {
 	assert := assert.New(t)
 
@@ -67,6 +67,12 @@ type fullContainerState struct {
 	CurrentHypervisorDetails hypervisorDetails `json:"currentHypervisor"`
 	LatestHypervisorDetails  hypervisorDetails `json:"latestHypervisor"`
 	StaleAssets              []string
+	VMMPid                   int           `json:"vmmPid"`
+	AgentReachable           bool          `json:"agentReachable"`
+	MemEncrypted             bool          `json:"memEncrypted"`
+	ConfidentialMode         string        `json:"confidentialMode"`
+	ExitTime                 time.Time     `json:"exitTime,omitempty"`
+	Age                      time.Duration `json:"age"`
 }
 
 type formatState interface {
@@ -215,7 +221,7 @@ func (f formatTabular) Write(state []fullContainerState, showAll bool, file *os.
 	fmt.Fprint(w, "ID\tPID\tSTATUS\tBUNDLE\tCREATED\tOWNER")
 
 	if showAll {
-		fmt.Fprint(w, "\tHYPERVISOR\tKERNEL\tIMAGE\tLATEST-KERNEL\tLATEST-IMAGE\tSTALE\n")
+		fmt.Fprint(w, "\tHYPERVISOR\tKERNEL\tIMAGE\tLATEST-KERNEL\tLATEST-IMAGE\tSTALE\tVMM-PID\tAGENT-REACHABLE\tMEM-ENCRYPTED\tCONFIDENTIAL-MODE\tAGE\n")
 	} else {
 		fmt.Fprintf(w, "\n")
 	}
@@ -255,7 +261,7 @@ func (f formatTabular) Write(state []fullContainerState, showAll bool, file *os.
 				all += fmt.Sprintf("\t%s", current.ImageAsset.Path)
 			}
 
-			all += fmt.Sprintf("\t%s\n", stale)
+			all += fmt.Sprintf("\t%s\t%d\t%t\t%t\t%s\t%s\n", stale, item.VMMPid, item.AgentReachable, item.MemEncrypted, item.ConfidentialMode, item.Age)
 
 			fmt.Fprint(w, all)
 		} else {
@@ -341,6 +347,12 @@ func getContainers(context *cli.Context) ([]fullContainerState, error) {
 				CurrentHypervisorDetails: currentHypervisorDetails,
 				LatestHypervisorDetails:  latestHypervisorDetails,
 				StaleAssets:              staleAssets,
+				VMMPid:                   container.VMMPid,
+				AgentReachable:           container.AgentReachable,
+				MemEncrypted:             container.MemEncrypted,
+				ConfidentialMode:         container.ConfidentialMode,
+				ExitTime:                 container.ExitTime,
+				Age:                      container.Age,
 			})
 		}
 	}
@@ -81,6 +81,7 @@ var testStatuses = []fullContainerState{
 		CurrentHypervisorDetails: hypervisorDetails1,
 		LatestHypervisorDetails:  hypervisorDetails1,
 		StaleAssets:              []string{},
+		ConfidentialMode:         "none",
 	},
 	{
 		containerState: containerState{
@@ -97,6 +98,7 @@ var testStatuses = []fullContainerState{
 		CurrentHypervisorDetails: hypervisorDetails2,
 		LatestHypervisorDetails:  hypervisorDetails2,
 		StaleAssets:              []string{},
+		ConfidentialMode:         "SEV",
 	},
 	{
 		containerState: containerState{
@@ -113,6 +115,7 @@ var testStatuses = []fullContainerState{
 		CurrentHypervisorDetails: hypervisorDetails3,
 		LatestHypervisorDetails:  hypervisorDetails3,
 		StaleAssets:              []string{},
+		ConfidentialMode:         "SEV-SNP",
 	},
 }
 
@@ -189,7 +192,7 @@ func TestStateToTabular(t *testing.T) {
 	expectedLength := len(testStatuses) + 1
 
 	expectedDefaultHeaderPattern := `\AID\s+PID\s+STATUS\s+BUNDLE\s+CREATED\s+OWNER`
-	expectedExtendedHeaderPattern := `HYPERVISOR\s+KERNEL\s+IMAGE\s+LATEST-KERNEL\s+LATEST-IMAGE\s+STALE`
+	expectedExtendedHeaderPattern := `HYPERVISOR\s+KERNEL\s+IMAGE\s+LATEST-KERNEL\s+LATEST-IMAGE\s+STALE\s+VMM-PID\s+AGENT-REACHABLE\s+MEM-ENCRYPTED\s+CONFIDENTIAL-MODE\s+AGE`
 	endingPattern := `\s*\z`
 
 	lines, err := formatListDataAsString(&formatTabular{}, testStatuses, false)
@@ -271,7 +274,7 @@ func TestStateToTabular(t *testing.T) {
 		lineIndex := i + 1
 		line := lines[lineIndex]
 
-		expectedLinePattern := fmt.Sprintf(`\A%s\s+%d\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s*\z`,
+		expectedLinePattern := fmt.Sprintf(`\A%s\s+%d\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%s\s+%d\s+%t\s+%t\s+%s\s+%s\s*\z`,
 			regexp.QuoteMeta(status.ID),
 			status.InitProcessPid,
 			regexp.QuoteMeta(status.Status),
@@ -283,7 +286,12 @@ func TestStateToTabular(t *testing.T) {
 			regexp.QuoteMeta(status.CurrentHypervisorDetails.ImageAsset.Path),
 			regexp.QuoteMeta(status.LatestHypervisorDetails.KernelAsset.Path),
 			regexp.QuoteMeta(status.LatestHypervisorDetails.ImageAsset.Path),
-			regexp.QuoteMeta("-"))
+			regexp.QuoteMeta("-"),
+			status.VMMPid,
+			status.AgentReachable,
+			status.MemEncrypted,
+			regexp.QuoteMeta(status.ConfidentialMode),
+			regexp.QuoteMeta(status.Age.String()))
 
 		expectedLineRE := regexp.MustCompile(expectedLinePattern)
 
@@ -123,6 +123,27 @@ var runtimeCommands = []cli.Command{
 	// Kata Containers specific extensions
 	kataCheckCLICommand,
 	kataEnvCLICommand,
+	vmmCmdlineCLICommand,
+	pruneCLICommand,
+	balloonCLICommand,
+	oomAdjCLICommand,
+	reniceCLICommand,
+	fstrimCLICommand,
+	sevPDHCLICommand,
+	// sevSessionCLICommand is not registered: ExportSEVSession always
+	// fails today, since this tree has no real sevLaunchFirmware
+	// backend outside of tests. Register it once one exists.
+	capabilitiesCLICommand,
+	reconcileCLICommand,
+	healthCLICommand,
+	dumpStateCLICommand,
+	netInspectCLICommand,
+	guestMountsCLICommand,
+	guestPanicCLICommand,
+	vmmLogsCLICommand,
+	consoleLogCLICommand,
+	configCompareCLICommand,
+	verifyAssetsCLICommand,
 }
 
 // runtimeBeforeSubcommands is the function to run before command-line
@@ -250,6 +271,13 @@ func beforeSubcommands(context *cli.Context) error {
 		fatal(err)
 	}
 
+	vci.RegisterMaxConcurrentLaunches(runtimeConfig.MaxConcurrentLaunches)
+
+	runtimeRoot, err := validateRootDirectory(context.GlobalString("root"))
+	if err != nil {
+		fatal(err)
+	}
+
 	args := strings.Join(context.Args(), " ")
 
 	fields := logrus.Fields{
@@ -264,6 +292,7 @@ func beforeSubcommands(context *cli.Context) error {
 	context.App.Metadata = map[string]interface{}{
 		"runtimeConfig": runtimeConfig,
 		"configFile":    configFile,
+		"runtimeRoot":   runtimeRoot,
 	}
 
 	return nil
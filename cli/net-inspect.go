@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var netInspectCLICommand = cli.Command{
+	Name:  "net-inspect",
+	Usage: "show a sandbox's guest network interfaces, addresses, and routes",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The net-inspect command asks the agent running inside a sandbox's VM to
+report its network interfaces, addresses, and routes as the guest itself
+sees them. It is intended for debugging CNI/overlay issues that only
+manifest once traffic reaches the VM.`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "format, f",
+			Value: "table",
+			Usage: "select one of: table or json",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		state, err := vc.InspectGuestNetwork(args.First())
+		if err != nil {
+			return err
+		}
+
+		switch format := context.String("format"); format {
+		case "json":
+			return printNetInspectJSON(state)
+		case "table":
+			printNetInspectTable(state)
+		default:
+			return fmt.Errorf("invalid format %q, expecting table or json", format)
+		}
+
+		return nil
+	},
+}
+
+func printNetInspectJSON(state *vc.GuestNetworkState) error {
+	bytes, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(bytes))
+
+	return nil
+}
+
+func printNetInspectTable(state *vc.GuestNetworkState) {
+	fmt.Println("links:")
+	for _, l := range state.Links {
+		fmt.Printf("%-16s mtu=%-6d hwaddr=%-18s addrs=%v\n", l.Name, l.MTU, l.HardwareAddr, l.Addresses)
+	}
+
+	fmt.Println()
+	fmt.Println("routes:")
+	for _, r := range state.Routes {
+		fmt.Printf("%-20s via %-16s dev %-10s src %s\n", r.Destination, r.Gateway, r.Device, r.Source)
+	}
+}
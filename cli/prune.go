@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/urfave/cli"
+)
+
+var pruneCLICommand = cli.Command{
+	Name:  "prune",
+	Usage: "Remove resources held by stopped sandboxes older than a given duration",
+	ArgsUsage: `
+
+EXAMPLE:
+   Remove every stopped sandbox that has been idle for more than 24 hours:
+
+       # ` + name + ` prune --older-than 24h`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "older-than",
+			Value: "24h",
+			Usage: "only remove stopped sandboxes idle for longer than this duration",
+		},
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "list the sandboxes that would be removed without removing them",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		olderThan, err := time.ParseDuration(context.String("older-than"))
+		if err != nil {
+			return fmt.Errorf("invalid --older-than value: %v", err)
+		}
+
+		return prune(olderThan, context.Bool("dry-run"))
+	},
+}
+
+// prune removes every stopped sandbox whose most recent container activity
+// is older than olderThan. Sandboxes with no containers are skipped, since
+// there is no reliable way to determine how long they have been idle.
+func prune(olderThan time.Duration, dryRun bool) error {
+	sandboxList, err := vci.ListSandbox()
+	if err != nil {
+		return err
+	}
+
+	threshold := time.Now().Add(-olderThan)
+
+	for _, sandbox := range sandboxList {
+		if oci.StateToOCIState(sandbox.State) != oci.StateStopped {
+			continue
+		}
+
+		lastActivity, ok := lastContainerActivity(sandbox.ContainersStatus)
+		if !ok {
+			continue
+		}
+
+		if lastActivity.After(threshold) {
+			continue
+		}
+
+		kataLog.WithField("sandbox", sandbox.ID).Info("pruning stopped sandbox")
+
+		if dryRun {
+			fmt.Printf("would remove sandbox %s (idle since %s)\n", sandbox.ID, lastActivity)
+			continue
+		}
+
+		if _, err := vci.DeleteSandbox(sandbox.ID); err != nil {
+			return err
+		}
+
+		fmt.Printf("removed sandbox %s (idle since %s)\n", sandbox.ID, lastActivity)
+	}
+
+	return nil
+}
+
+// lastContainerActivity returns the most recent container start time among
+// statuses, or false if statuses is empty.
+func lastContainerActivity(statuses []vc.ContainerStatus) (time.Time, bool) {
+	if len(statuses) == 0 {
+		return time.Time{}, false
+	}
+
+	latest := statuses[0].StartTime
+	for _, status := range statuses[1:] {
+		if status.StartTime.After(latest) {
+			latest = status.StartTime
+		}
+	}
+
+	return latest, true
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneRemovesOnlyOldStoppedSandboxes(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+
+	oldSandbox := vc.SandboxStatus{
+		ID:    "old-stopped",
+		State: vc.State{State: vc.StateStopped},
+		ContainersStatus: []vc.ContainerStatus{
+			{ID: "c1", StartTime: now.Add(-48 * time.Hour)},
+		},
+	}
+
+	recentSandbox := vc.SandboxStatus{
+		ID:    "recent-stopped",
+		State: vc.State{State: vc.StateStopped},
+		ContainersStatus: []vc.ContainerStatus{
+			{ID: "c2", StartTime: now},
+		},
+	}
+
+	runningSandbox := vc.SandboxStatus{
+		ID:    "old-running",
+		State: vc.State{State: vc.StateRunning},
+		ContainersStatus: []vc.ContainerStatus{
+			{ID: "c3", StartTime: now.Add(-48 * time.Hour)},
+		},
+	}
+
+	var deleted []string
+
+	testingImpl.ListSandboxFunc = func() ([]vc.SandboxStatus, error) {
+		return []vc.SandboxStatus{oldSandbox, recentSandbox, runningSandbox}, nil
+	}
+	defer func() {
+		testingImpl.ListSandboxFunc = nil
+	}()
+
+	testingImpl.DeleteSandboxFunc = func(sandboxID string) (vc.VCSandbox, error) {
+		deleted = append(deleted, sandboxID)
+		return nil, nil
+	}
+	defer func() {
+		testingImpl.DeleteSandboxFunc = nil
+	}()
+
+	err := prune(24*time.Hour, false)
+	assert.NoError(err)
+
+	assert.Equal([]string{"old-stopped"}, deleted)
+}
+
+func TestPruneDryRunDoesNotDelete(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+
+	oldSandbox := vc.SandboxStatus{
+		ID:    "old-stopped",
+		State: vc.State{State: vc.StateStopped},
+		ContainersStatus: []vc.ContainerStatus{
+			{ID: "c1", StartTime: now.Add(-48 * time.Hour)},
+		},
+	}
+
+	testingImpl.ListSandboxFunc = func() ([]vc.SandboxStatus, error) {
+		return []vc.SandboxStatus{oldSandbox}, nil
+	}
+	defer func() {
+		testingImpl.ListSandboxFunc = nil
+	}()
+
+	deleteCalled := false
+	testingImpl.DeleteSandboxFunc = func(sandboxID string) (vc.VCSandbox, error) {
+		deleteCalled = true
+		return nil, nil
+	}
+	defer func() {
+		testingImpl.DeleteSandboxFunc = nil
+	}()
+
+	err := prune(24*time.Hour, true)
+	assert.NoError(err)
+	assert.False(deleteCalled)
+}
+
+func TestLastContainerActivityNoContainers(t *testing.T) {
+	assert := assert.New(t)
+
+	_, ok := lastContainerActivity(nil)
+	assert.False(ok)
+}
+
+func TestLastContainerActivityPicksMostRecent(t *testing.T) {
+	assert := assert.New(t)
+
+	now := time.Now()
+
+	latest, ok := lastContainerActivity([]vc.ContainerStatus{
+		{ID: "c1", StartTime: now.Add(-2 * time.Hour)},
+		{ID: "c2", StartTime: now},
+		{ID: "c3", StartTime: now.Add(-1 * time.Hour)},
+	})
+
+	assert.True(ok)
+	assert.Equal(now, latest)
+}
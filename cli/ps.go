@@ -10,10 +10,17 @@ import (
 	"fmt"
 
 	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
 )
 
+// verboseProcessListArgs asks the guest's ps(1) to also show thread
+// information (LWP/NLWP), the closest thing to a PID namespace mapping
+// ps itself can report, when the caller did not already pass explicit
+// ps(1) options of their own.
+var verboseProcessListArgs = []string{"-eLf"}
+
 var psCLICommand = cli.Command{
 	Name:      "ps",
 	Usage:     "ps displays the processes running inside a container",
@@ -24,12 +31,16 @@ var psCLICommand = cli.Command{
 			Value: "table",
 			Usage: `select one of: ` + formatOptions,
 		},
+		cli.StringFlag{
+			Name:  "sandbox",
+			Usage: "list processes for every container of the given sandbox, instead of a single container",
+		},
+		cli.BoolFlag{
+			Name:  "verbose",
+			Usage: "also show each process' guest-visible thread/namespace info, and whether it can be correlated to a host PID",
+		},
 	},
 	Action: func(context *cli.Context) error {
-		if context.Args().Present() == false {
-			return fmt.Errorf("Missing container ID, should at least provide one")
-		}
-
 		var args []string
 		if len(context.Args()) > 1 {
 			// [1:] is to remove container_id:
@@ -38,12 +49,38 @@ var psCLICommand = cli.Command{
 			args = context.Args()[1:]
 		}
 
-		return ps(context.Args().First(), context.String("format"), args)
+		memEncrypt := false
+		if runtimeConfig, ok := context.App.Metadata["runtimeConfig"].(oci.RuntimeConfig); ok {
+			memEncrypt = runtimeConfig.HypervisorConfig.MemEncrypt
+		}
+
+		if sandboxID := context.String("sandbox"); sandboxID != "" {
+			return psSandbox(sandboxID, context.String("format"), args, context.Bool("verbose"), memEncrypt)
+		}
+
+		if context.Args().Present() == false {
+			return fmt.Errorf("Missing container ID, should at least provide one")
+		}
+
+		return ps(context.Args().First(), context.String("format"), args, context.Bool("verbose"), memEncrypt)
 	},
 	SkipArgReorder: true,
 }
 
-func ps(containerID, format string, args []string) error {
+// hostCorrelationNote is printed after a --verbose ps listing to make
+// clear whether the guest-visible thread/namespace info it just printed
+// can be correlated back to a host-visible PID. It never can for a
+// memory-encrypted (SEV) sandbox, since the host cannot inspect guest
+// memory to make that correlation in the first place.
+func hostCorrelationNote(memEncrypt bool) string {
+	if memEncrypt {
+		return "NOTE: host PID correlation is unavailable for this sandbox (guest memory is SEV-encrypted)\n"
+	}
+
+	return "NOTE: host PID correlation is unavailable for VM-based containers\n"
+}
+
+func ps(containerID, format string, args []string, verbose, memEncrypt bool) error {
 	if containerID == "" {
 		return fmt.Errorf("Missing container ID")
 	}
@@ -70,7 +107,11 @@ func ps(containerID, format string, args []string) error {
 
 	options.Args = args
 	if len(options.Args) == 0 {
-		options.Args = []string{"-ef"}
+		if verbose {
+			options.Args = verboseProcessListArgs
+		} else {
+			options.Args = []string{"-ef"}
+		}
 	}
 
 	options.Format = format
@@ -82,5 +123,41 @@ func ps(containerID, format string, args []string) error {
 
 	fmt.Print(string(msg))
 
+	if verbose {
+		fmt.Print(hostCorrelationNote(memEncrypt))
+	}
+
+	return nil
+}
+
+func psSandbox(sandboxID, format string, args []string, verbose, memEncrypt bool) error {
+	kataLog = kataLog.WithField("sandbox", sandboxID)
+
+	var options vc.ProcessListOptions
+
+	options.Args = args
+	if len(options.Args) == 0 {
+		if verbose {
+			options.Args = verboseProcessListArgs
+		} else {
+			options.Args = []string{"-ef"}
+		}
+	}
+
+	options.Format = format
+
+	lists, err := vci.ProcessListSandbox(sandboxID, options)
+	if err != nil {
+		return err
+	}
+
+	for containerID, msg := range lists {
+		fmt.Printf("container: %s\n%s\n", containerID, string(msg))
+	}
+
+	if verbose {
+		fmt.Print(hostCorrelationNote(memEncrypt))
+	}
+
 	return nil
 }
@@ -6,6 +6,7 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"os"
 	"testing"
@@ -66,11 +67,11 @@ func TestPSFailure(t *testing.T) {
 	}()
 
 	// inexistent container
-	err = ps("xyz123abc", "json", []string{"-ef"})
+	err = ps("xyz123abc", "json", []string{"-ef"}, false, false)
 	assert.Error(err)
 
 	// container is not running
-	err = ps(sandbox.ID(), "json", []string{"-ef"})
+	err = ps(sandbox.ID(), "json", []string{"-ef"}, false, false)
 	assert.Error(err)
 }
 
@@ -113,6 +114,69 @@ func TestPSSuccessful(t *testing.T) {
 		testingImpl.ProcessListContainerFunc = nil
 	}()
 
-	err = ps(sandbox.ID(), "json", []string{})
+	err = ps(sandbox.ID(), "json", []string{}, false, false)
 	assert.NoError(err)
 }
+
+func TestPSVerboseIncludesThreadColumnsAndHostCorrelationNote(t *testing.T) {
+	assert := assert.New(t)
+
+	sandbox := &vcmock.Sandbox{
+		MockID: testContainerID,
+	}
+
+	sandbox.MockContainers = []*vcmock.Container{
+		{
+			MockID:      sandbox.ID(),
+			MockSandbox: sandbox,
+		},
+	}
+
+	path, err := createTempContainerIDMapping(sandbox.ID(), sandbox.ID())
+	assert.NoError(err)
+	defer os.RemoveAll(path)
+
+	testingImpl.StatusContainerFunc = func(sandboxID, containerID string) (vc.ContainerStatus, error) {
+		return vc.ContainerStatus{
+			State: vc.State{
+				State: vc.StateRunning,
+			},
+			ID: sandbox.ID(),
+			Annotations: map[string]string{
+				vcAnnotations.ContainerTypeKey: string(vc.PodContainer),
+			},
+		}, nil
+	}
+
+	var gotArgs []string
+	testingImpl.ProcessListContainerFunc = func(sandboxID, containerID string, options vc.ProcessListOptions) (vc.ProcessList, error) {
+		gotArgs = options.Args
+		// A fake agent reporting the extra thread/namespace columns a
+		// --verbose caller asked for.
+		return []byte("UID PID PPID LWP NLWP CMD\nroot 1 0 1 1 sleep"), nil
+	}
+
+	defer func() {
+		testingImpl.StatusContainerFunc = nil
+		testingImpl.ProcessListContainerFunc = nil
+	}()
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	assert.NoError(err)
+	os.Stdout = w
+
+	err = ps(sandbox.ID(), "table", []string{}, true, true)
+
+	w.Close()
+	os.Stdout = stdout
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	_, err = buf.ReadFrom(r)
+	assert.NoError(err)
+
+	assert.Equal(verboseProcessListArgs, gotArgs)
+	assert.Contains(buf.String(), "LWP NLWP")
+	assert.Contains(buf.String(), "SEV-encrypted")
+}
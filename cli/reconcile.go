@@ -0,0 +1,47 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var reconcileCLICommand = cli.Command{
+	Name:  "reconcile",
+	Usage: "force-reconcile a sandbox's persisted state with the real state of its VMM",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The reconcile command checks a sandbox's VMM process liveness and agent
+reachability against its persisted state. If the persisted state says the
+sandbox is running but the VMM is actually gone, e.g. after a host crash,
+it transitions the persisted state to stopped and records why. Otherwise
+it reports that the persisted state is consistent with reality.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "report what would change without persisting it",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		result, err := vc.ReconcileSandboxState(args.First(), context.Bool("dry-run"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(result.Message)
+
+		return nil
+	},
+}
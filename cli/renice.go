@@ -0,0 +1,53 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/urfave/cli"
+)
+
+var reniceCLICommand = cli.Command{
+	Name:  "renice",
+	Usage: "adjust the nice value of a process running inside a container",
+	ArgsUsage: `<container-id> <pid> <value>
+
+   <container-id> is the name for the instance of the container
+   <pid> is the process ID, as returned by the exec or create command
+   <value> is the nice value to set, in the range [-20, 19]`,
+	Description: `The renice command asks the guest to adjust the nice value of a process
+running inside a container, to make it more or less likely to be
+scheduled CPU time relative to other processes.`,
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 3 {
+			return fmt.Errorf("Expecting <container-id> <pid> <value>, got %d arguments: %v", len(args), []string(args))
+		}
+
+		containerID := args.Get(0)
+		pid := args.Get(1)
+
+		nice, err := strconv.Atoi(args.Get(2))
+		if err != nil {
+			return fmt.Errorf("Failed to convert value %q to int", args.Get(2))
+		}
+
+		return renice(containerID, pid, nice)
+	},
+}
+
+func renice(containerID, pid string, nice int) error {
+	status, sandboxID, err := getExistingContainerInfo(containerID)
+	if err != nil {
+		return err
+	}
+
+	containerID = status.ID
+
+	return vci.SetProcessNice(sandboxID, containerID, pid, nice)
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// tmpfsMagic is the f_type value statfs(2) reports for a tmpfs mount
+// (TMPFS_MAGIC in linux/magic.h).
+const tmpfsMagic = 0x01021994
+
+// rootDirMode is the permission mode used when creating the runtime root
+// directory.
+const rootDirMode = os.FileMode(0750)
+
+// validateRootDirectory ensures root exists (creating it if missing), is
+// writable, and warns (but does not fail) if it is not backed by tmpfs,
+// since the runtime's root is expected to hold only ephemeral
+// per-sandbox state. It returns the cleaned, absolute form of root.
+func validateRootDirectory(root string) (string, error) {
+	if root == "" {
+		return "", fmt.Errorf("root directory cannot be empty")
+	}
+
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(root, rootDirMode); err != nil {
+		return "", fmt.Errorf("root directory %v is not accessible: %v", root, err)
+	}
+
+	if err := checkRootDirectoryWritable(root); err != nil {
+		return "", err
+	}
+
+	if !isTmpfs(root) {
+		kataLog.WithField("root", root).Warn("root directory is not on tmpfs; ephemeral sandbox state will not be cleared across a reboot")
+	}
+
+	return root, nil
+}
+
+// checkRootDirectoryWritable verifies root is writable by creating and
+// removing a throwaway file in it.
+func checkRootDirectoryWritable(root string) error {
+	f, err := ioutil.TempFile(root, ".kata-root-check-")
+	if err != nil {
+		return fmt.Errorf("root directory %v is not writable: %v", root, err)
+	}
+
+	path := f.Name()
+	f.Close()
+
+	return os.Remove(path)
+}
+
+// isTmpfs reports whether path is on a tmpfs filesystem. It returns
+// false (rather than an error) if path's filesystem type cannot be
+// determined, since callers only use this for a best-effort warning.
+func isTmpfs(path string) bool {
+	var statFs syscall.Statfs_t
+
+	if err := syscall.Statfs(path, &statFs); err != nil {
+		return false
+	}
+
+	return statFs.Type == int64(tmpfsMagic)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRootDirectoryValid(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	root, err := validateRootDirectory(tmpdir)
+	assert.NoError(err)
+	assert.Equal(tmpdir, root)
+}
+
+func TestValidateRootDirectoryMissingButCreatable(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	missing := filepath.Join(tmpdir, "does", "not", "exist", "yet")
+
+	root, err := validateRootDirectory(missing)
+	assert.NoError(err)
+	assert.Equal(missing, root)
+
+	info, err := os.Stat(missing)
+	assert.NoError(err)
+	assert.True(info.IsDir())
+}
+
+func TestValidateRootDirectoryUnwritable(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip(testDisabledNeedNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	unwritable := filepath.Join(tmpdir, "unwritable")
+	assert.NoError(os.Mkdir(unwritable, 0500))
+
+	_, err = validateRootDirectory(unwritable)
+	assert.Error(err)
+}
+
+func TestValidateRootDirectoryEmpty(t *testing.T) {
+	_, err := validateRootDirectory("")
+	assert.Error(t, err)
+}
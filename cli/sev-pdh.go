@@ -0,0 +1,32 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var sevPDHCLICommand = cli.Command{
+	Name:  "sev-pdh",
+	Usage: "print the host's SEV platform Diffie-Hellman certificate (PDH)",
+	Description: `The sev-pdh command reads the host's SEV platform Diffie-Hellman
+certificate via the /dev/sev ioctl interface and prints it base64-encoded,
+for use by external attestation tooling negotiating an SEV launch session.`,
+	Action: func(context *cli.Context) error {
+		pdh, err := vc.GetPlatformPDH()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(base64.StdEncoding.EncodeToString(pdh))
+
+		return nil
+	},
+}
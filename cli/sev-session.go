@@ -0,0 +1,35 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var sevSessionCLICommand = cli.Command{
+	Name:  "sev-session",
+	Usage: "export a sandbox's SEV launch session for offline attestation",
+	ArgsUsage: `<sandbox-id> <output-path>
+
+   <sandbox-id> is the sandbox name as provided to the create command.
+   <output-path> is where to write the session info, as JSON.`,
+	Description: `The sev-session command exports the SEV launch session parameters of a
+memory-encrypted sandbox (the launch measurement and guest owner policy)
+to a JSON file, so a separate attestation service can verify the launch
+without access to this host. It refuses to run against a sandbox whose
+VM is already running.`,
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 2 {
+			return fmt.Errorf("Expecting a sandbox ID and an output path, got %d arguments: %v", len(args), []string(args))
+		}
+
+		return vc.ExportSEVSession(args.Get(0), args.Get(1))
+	},
+}
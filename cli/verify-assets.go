@@ -0,0 +1,82 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/urfave/cli"
+)
+
+var verifyAssetsCLICommand = cli.Command{
+	Name:  "verify-assets",
+	Usage: "verify the configured kernel/image/initrd/firmware/hypervisor assets against their configured hashes",
+	Description: `The verify-assets command recomputes the hash of every asset
+configured in the runtime's configuration file (kernel, image, initrd,
+firmware, hypervisor) and compares it against the hash configured for
+that asset, to detect tampering since the hash was recorded. Use the
+global --config flag to select a configuration file other than the
+default. An asset with no path configured, or no hash configured for
+its path, is reported as skipped rather than failed.`,
+	Action: func(context *cli.Context) error {
+		runtimeConfig, ok := context.App.Metadata["runtimeConfig"].(oci.RuntimeConfig)
+		if !ok {
+			return errors.New("invalid runtime config")
+		}
+
+		results := verifyHypervisorConfigAssets(runtimeConfig.HypervisorConfig)
+
+		failed := false
+		for _, result := range results {
+			status := "PASS"
+			switch result.Status {
+			case "fail":
+				status = "FAIL"
+				failed = true
+			case "skip":
+				status = "SKIP"
+			}
+
+			if result.Reason != "" {
+				fmt.Printf("%-12s%-6s%-40s(%s)\n", result.Kind, status, result.Path, result.Reason)
+			} else {
+				fmt.Printf("%-12s%-6s%-40s\n", result.Kind, status, result.Path)
+			}
+		}
+
+		if failed {
+			return errors.New("one or more assets failed hash verification")
+		}
+
+		return nil
+	},
+}
+
+// verifyHypervisorConfigAssets runs vc.VerifyAssetHash over every asset
+// path configured in config, in a fixed, predictable order.
+func verifyHypervisorConfigAssets(config vc.HypervisorConfig) []vc.AssetVerificationResult {
+	assets := []struct {
+		kind string
+		path string
+		hash string
+	}{
+		{"kernel", config.KernelPath, config.KernelHash},
+		{"image", config.ImagePath, config.ImageHash},
+		{"initrd", config.InitrdPath, config.InitrdHash},
+		{"firmware", config.FirmwarePath, config.FirmwareHash},
+		{"hypervisor", config.HypervisorPath, config.HypervisorHash},
+	}
+
+	var results []vc.AssetVerificationResult
+	for _, a := range assets {
+		results = append(results, vc.VerifyAssetHash(a.kind, a.path, a.hash, config.AssetHashType))
+	}
+
+	return results
+}
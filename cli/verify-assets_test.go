@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/kata-containers/runtime/virtcontainers/pkg/oci"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+)
+
+func hashFileSHA512(t *testing.T, path string) string {
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+
+	sum := sha512.Sum512(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestVerifyAssetsCLIFunctionAllPass(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "verify-assets-test-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	kernelPath := filepath.Join(tmpdir, "kernel")
+	imagePath := filepath.Join(tmpdir, "image")
+	assert.NoError(ioutil.WriteFile(kernelPath, []byte("kernel content"), 0640))
+	assert.NoError(ioutil.WriteFile(imagePath, []byte("image content"), 0640))
+
+	hConfig := vc.HypervisorConfig{
+		KernelPath: kernelPath,
+		KernelHash: hashFileSHA512(t, kernelPath),
+		ImagePath:  imagePath,
+		ImageHash:  hashFileSHA512(t, imagePath),
+	}
+
+	app := cli.NewApp()
+	ctx := cli.NewContext(app, nil, nil)
+	ctx.App.Metadata = map[string]interface{}{
+		"runtimeConfig": oci.RuntimeConfig{HypervisorConfig: hConfig},
+	}
+
+	fn, ok := verifyAssetsCLICommand.Action.(func(context *cli.Context) error)
+	assert.True(ok)
+
+	assert.NoError(fn(ctx))
+}
+
+func TestVerifyAssetsCLIFunctionFailsOnTamperedAsset(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpdir, err := ioutil.TempDir("", "verify-assets-test-")
+	assert.NoError(err)
+	defer os.RemoveAll(tmpdir)
+
+	kernelPath := filepath.Join(tmpdir, "kernel")
+	assert.NoError(ioutil.WriteFile(kernelPath, []byte("kernel content"), 0640))
+
+	hConfig := vc.HypervisorConfig{
+		KernelPath: kernelPath,
+		KernelHash: hashFileSHA512(t, kernelPath),
+	}
+
+	// Tamper with the asset after its hash was recorded.
+	assert.NoError(ioutil.WriteFile(kernelPath, []byte("tampered"), 0640))
+
+	app := cli.NewApp()
+	ctx := cli.NewContext(app, nil, nil)
+	ctx.App.Metadata = map[string]interface{}{
+		"runtimeConfig": oci.RuntimeConfig{HypervisorConfig: hConfig},
+	}
+
+	fn, ok := verifyAssetsCLICommand.Action.(func(context *cli.Context) error)
+	assert.True(ok)
+
+	assert.Error(fn(ctx))
+}
+
+func TestVerifyAssetsCLIFunctionSkipsUnconfiguredAssets(t *testing.T) {
+	assert := assert.New(t)
+
+	app := cli.NewApp()
+	ctx := cli.NewContext(app, nil, nil)
+	ctx.App.Metadata = map[string]interface{}{
+		"runtimeConfig": oci.RuntimeConfig{HypervisorConfig: vc.HypervisorConfig{}},
+	}
+
+	fn, ok := verifyAssetsCLICommand.Action.(func(context *cli.Context) error)
+	assert.True(ok)
+
+	assert.NoError(fn(ctx))
+}
+
+func TestVerifyAssetsCLIFunctionMissingRuntimeConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	app := cli.NewApp()
+	ctx := cli.NewContext(app, nil, nil)
+	ctx.App.Metadata = map[string]interface{}{}
+
+	fn, ok := verifyAssetsCLICommand.Action.(func(context *cli.Context) error)
+	assert.True(ok)
+
+	assert.Error(fn(ctx))
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var vmmCmdlineCLICommand = cli.Command{
+	Name:  "vmm-cmdline",
+	Usage: "show the VMM command line used to launch a sandbox's VM",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The vmm-cmdline command prints the command line used to launch the
+VM backing a sandbox, with any secret-bearing arguments redacted. It is
+intended for auditing and bug reports.`,
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		return vmmCmdline(args.First())
+	},
+}
+
+func vmmCmdline(sandboxID string) error {
+	launchArgs, err := vc.GetVMMLaunchArgs(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stdout, strings.Join(launchArgs, " "))
+
+	return nil
+}
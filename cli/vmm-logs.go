@@ -0,0 +1,155 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	vc "github.com/kata-containers/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+// followPollInterval is how often followFile checks for new content
+// once it has caught up to the end of the file.
+const followPollInterval = 200 * time.Millisecond
+
+var vmmLogsCLICommand = cli.Command{
+	Name:  "vmm-logs",
+	Usage: "show a sandbox's VMM (QEMU) stderr log",
+	ArgsUsage: `<sandbox-id>
+
+   <sandbox-id> is the sandbox name as provided to the create command.`,
+	Description: `The vmm-logs command prints the persisted stderr output of a sandbox's VMM,
+which carries emulation warnings and SEV launch errors that aren't otherwise
+surfaced. With --follow it streams new output as it is appended, like
+tail -f, until interrupted.`,
+	Flags: []cli.Flag{
+		cli.IntFlag{
+			Name:  "tail",
+			Value: 10,
+			Usage: "print only the last N lines (0 prints the whole log)",
+		},
+		cli.BoolFlag{
+			Name:  "follow, f",
+			Usage: "stream new log output as it is appended, until cancelled",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		args := context.Args()
+		if len(args) != 1 {
+			return fmt.Errorf("Expecting only one sandbox ID, got %d: %v", len(args), []string(args))
+		}
+
+		path, err := vc.GetVMMLogPath(args.First())
+		if err != nil {
+			return err
+		}
+
+		if err := tailFile(path, context.Int("tail"), defaultOutputFile); err != nil {
+			return err
+		}
+
+		if !context.Bool("follow") {
+			return nil
+		}
+
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		defer signal.Stop(sigCh)
+
+		return followFile(path, defaultOutputFile, sigCh)
+	},
+}
+
+// tailLines returns the last n lines of the file at path (or all of
+// them, if it has n lines or fewer, or if n is 0). A missing or empty
+// file yields no lines.
+func tailLines(path string, n int) ([]string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	trimmed := strings.TrimRight(string(data), "\n")
+	if trimmed == "" {
+		return nil, nil
+	}
+
+	lines := strings.Split(trimmed, "\n")
+	if n > 0 && len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+
+	return lines, nil
+}
+
+// tailFile writes the last n lines of the file at path to w.
+func tailFile(path string, n int, w io.Writer) error {
+	lines, err := tailLines(path, n)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+
+	return nil
+}
+
+// followFile streams content appended to the file at path to w,
+// polling for new output every followPollInterval, until stop
+// receives a signal.
+func followFile(path string, w io.Writer, stop <-chan os.Signal) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(f)
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+
+		line, err := reader.ReadString('\n')
+		if len(line) > 0 {
+			fmt.Fprint(w, line)
+		}
+
+		if err == nil {
+			continue
+		}
+
+		if err != io.EOF {
+			return err
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(followPollInterval):
+		}
+	}
+}
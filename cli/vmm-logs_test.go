@@ -0,0 +1,131 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent use by the background
+// followFile goroutine and the test's own polling.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestTailLinesMissingFileIsEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	lines, err := tailLines(filepath.Join(os.TempDir(), "does-not-exist-vmm.log"), 10)
+	assert.NoError(err)
+	assert.Empty(lines)
+}
+
+func TestTailLinesReturnsLastN(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := ioutil.TempFile("", "vmm-log-")
+	assert.NoError(err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("one\ntwo\nthree\nfour\n")
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	lines, err := tailLines(f.Name(), 2)
+	assert.NoError(err)
+	assert.Equal([]string{"three", "four"}, lines)
+
+	lines, err = tailLines(f.Name(), 0)
+	assert.NoError(err)
+	assert.Equal([]string{"one", "two", "three", "four"}, lines)
+}
+
+func TestTailFileWritesLines(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := ioutil.TempFile("", "vmm-log-")
+	assert.NoError(err)
+	defer os.Remove(f.Name())
+
+	_, err = f.WriteString("qemu: warning: SEV: something happened\n")
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	var buf bytes.Buffer
+	assert.NoError(tailFile(f.Name(), 10, &buf))
+	assert.Equal("qemu: warning: SEV: something happened\n", buf.String())
+}
+
+func TestFollowFileStreamsAppendedContent(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := ioutil.TempFile("", "vmm-log-")
+	assert.NoError(err)
+	path := f.Name()
+	defer os.Remove(path)
+
+	_, err = f.WriteString("initial line\n")
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	buf := &syncBuffer{}
+	stop := make(chan os.Signal, 1)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- followFile(path, buf, stop)
+	}()
+
+	// followFile seeks to EOF before streaming, so "initial line" (written
+	// before it started) must not appear; only content appended afterwards
+	// should be picked up.
+	time.Sleep(2 * followPollInterval)
+
+	appendFile, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0640)
+	assert.NoError(err)
+	_, err = appendFile.WriteString("guest panic: Fatal exception\n")
+	assert.NoError(err)
+	assert.NoError(appendFile.Close())
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !bytes.Contains([]byte(buf.String()), []byte("guest panic")) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Contains(buf.String(), "guest panic")
+
+	stop <- os.Interrupt
+
+	select {
+	case err := <-done:
+		assert.NoError(err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("followFile did not stop after receiving a signal")
+	}
+
+	assert.NotContains(buf.String(), "initial line")
+	assert.Contains(buf.String(), "guest panic: Fatal exception")
+}
@@ -6,8 +6,10 @@
 package virtcontainers
 
 import (
+	"context"
 	"fmt"
 	"syscall"
+	"time"
 
 	"github.com/mitchellh/mapstructure"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
@@ -134,8 +136,10 @@ type agent interface {
 	// supported by the agent.
 	capabilities() capabilities
 
-	// check will check the agent liveness
-	check() error
+	// check will check the agent liveness. The call is bound by ctx's
+	// deadline if it has one, so a caller can guarantee it returns
+	// promptly even if the underlying connection is half-open.
+	check(ctx context.Context) error
 
 	// disconnect will disconnect the connection to the agent
 	disconnect() error
@@ -143,15 +147,66 @@ type agent interface {
 	// createSandbox will tell the agent to perform necessary setup for a Sandbox.
 	createSandbox(sandbox *Sandbox) error
 
+	// getClockSource returns the guest's current clocksource and the
+	// list of clocksources available to switch to.
+	getClockSource(sandbox *Sandbox) (string, []string, error)
+
+	// getGuestMemInfo returns the guest's view of its own memory, read
+	// from /proc/meminfo, for sizing decisions that cgroup memory
+	// accounting cannot answer (the guest may be under memory pressure
+	// that isn't yet visible at the cgroup).
+	getGuestMemInfo(sandbox *Sandbox) (*MemInfo, error)
+
+	// getGuestLoad returns the guest's uptime and 1/5/15-minute load
+	// averages, read from /proc/uptime and /proc/loadavg, for a quick
+	// health assessment of the guest.
+	getGuestLoad(sandbox *Sandbox) (*LoadInfo, error)
+
+	// getGuestTime returns the guest's current wall-clock time, so
+	// callers can compare it against host time to detect clock skew
+	// that would otherwise surface as confusing TLS certificate
+	// validation failures inside the guest.
+	getGuestTime(sandbox *Sandbox) (time.Time, error)
+
+	// inspectNetwork returns the guest's network interfaces and routes,
+	// as seen from inside the guest, for diagnosing CNI/overlay issues
+	// that only manifest once traffic reaches the VM.
+	inspectNetwork(sandbox *Sandbox) (*GuestNetworkState, error)
+
+	// listGuestMounts returns the guest's current mounts, read from
+	// /proc/self/mountinfo, so callers can verify a shared mount
+	// actually landed in the guest rather than assuming it did from the
+	// host-side request alone.
+	listGuestMounts(sandbox *Sandbox) ([]GuestMount, error)
+
 	// exec will tell the agent to run a command in an already running container.
 	exec(sandbox *Sandbox, c Container, cmd Cmd) (*Process, error)
 
 	// startSandbox will tell the agent to start all containers related to the Sandbox.
 	startSandbox(sandbox *Sandbox) error
 
+	// proxyPID returns the pid of the proxy process started by
+	// startSandbox, so it can be placed alongside the VMM under a
+	// shared host accounting cgroup (see
+	// Sandbox.addSandboxProcessesToCgroup). It returns 0 if this
+	// agent's startSandbox does not spawn a proxy process.
+	proxyPID() int
+
+	// applySysctls applies sandbox-level sysctls inside the guest. It is
+	// called once, at sandbox start, after startSandbox and before any
+	// container runs. Callers are expected to have already validated
+	// sysctls against sysctlAllowedPrefixes.
+	applySysctls(sandbox *Sandbox, sysctls map[string]string) error
+
 	// stopSandbox will tell the agent to stop all containers related to the Sandbox.
 	stopSandbox(sandbox *Sandbox) error
 
+	// trimGuestFS will tell the agent to run fstrim on mountpoint inside
+	// the guest, discarding unused blocks to keep a thin-provisioned
+	// rootfs image from growing unbounded. Callers are expected to have
+	// already validated mountpoint with validateTrimMountpoint.
+	trimGuestFS(sandbox *Sandbox, mountpoint string) error
+
 	// cleanup will clean the resources for sandbox
 	cleanupSandbox(sandbox *Sandbox) error
 
@@ -164,6 +219,23 @@ type agent interface {
 	// stopContainer will tell the agent to stop a container related to a Sandbox.
 	stopContainer(sandbox *Sandbox, c Container) error
 
+	// reapOrphans finds guest processes for container c that have been
+	// reparented to the guest's init (PID 1) after their original
+	// parent exited, kills them, and returns how many were reaped.
+	reapOrphans(sandbox *Sandbox, c Container) (int, error)
+
+	// applySeccomp will tell the agent to apply a seccomp profile,
+	// either a raw BPF program or a JSON-encoded OCI seccomp spec, to a
+	// container at start. Callers are expected to have already
+	// validated profile with validateSeccompProfile.
+	applySeccomp(sandbox *Sandbox, c *Container, profile []byte) error
+
+	// applyRlimits will tell the agent to apply a set of POSIX resource
+	// limits to a container's process before exec in the guest.
+	// Callers are expected to have already validated limits with
+	// validateRlimits.
+	applyRlimits(sandbox *Sandbox, c *Container, limits []Rlimit) error
+
 	// signalProcess will tell the agent to send a signal to a
 	// container or a process related to a Sandbox. If all is true, all processes in
 	// the container will be sent the signal.
@@ -172,6 +244,17 @@ type agent interface {
 	// winsizeProcess will tell the agent to set a process' tty size
 	winsizeProcess(c *Container, processID string, height, width uint32) error
 
+	// setOOMScoreAdj will tell the agent to adjust the OOM killer score
+	// of a process related to a Sandbox. adj must be within
+	// [-1000, 1000], matching the range accepted by the kernel's
+	// /proc/<pid>/oom_score_adj.
+	setOOMScoreAdj(c *Container, processID string, adj int) error
+
+	// setProcessNice will tell the agent to adjust the nice value of a
+	// process related to a Sandbox. nice must be within [-20, 19],
+	// matching the range accepted by the kernel.
+	setProcessNice(c *Container, processID string, nice int) error
+
 	// writeProcessStdin will tell the agent to write a process stdin
 	writeProcessStdin(c *Container, ProcessID string, data []byte) (int, error)
 
@@ -190,6 +273,18 @@ type agent interface {
 	// updateContainer will update the resources of a running container
 	updateContainer(sandbox *Sandbox, c Container, resources specs.LinuxResources) error
 
+	// resizeContainerStorage will tell the agent to re-read a running
+	// container's virtio-blk backed rootfs/storage block device size
+	// and grow its filesystem to match, for an online size increase.
+	// Callers are expected to have already validated sizeBytes with
+	// validateResizeContainerStorageSize.
+	//
+	// This is not yet deliverable: the vendored kata agent protocol has
+	// no RPC to resize guest storage, so kataAgent's implementation (and
+	// hyperstart's and the noop agent's) can only ever return an error.
+	// Online rootfs growth requires that RPC to exist upstream first.
+	resizeContainerStorage(sandbox *Sandbox, c Container, sizeBytes uint64) error
+
 	// waitProcess will wait for the exit code of a process
 	waitProcess(c *Container, processID string) (int32, error)
 
@@ -0,0 +1,50 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+// AgentCapabilities is the exported, display-friendly view of an
+// agent's capabilities() flags.
+type AgentCapabilities struct {
+	BlockDeviceSupport        bool `json:"blockDeviceSupport"`
+	BlockDeviceHotplugSupport bool `json:"blockDeviceHotplugSupport"`
+	MemoryBalloonSupport      bool `json:"memoryBalloonSupport"`
+	CPUHotplugSupport         bool `json:"cpuHotplugSupport"`
+	MemoryHotplugSupport      bool `json:"memoryHotplugSupport"`
+	OnlineCPUMemSupport       bool `json:"onlineCPUMemSupport"`
+}
+
+func newAgentCapabilities(caps capabilities) AgentCapabilities {
+	return AgentCapabilities{
+		BlockDeviceSupport:        caps.isBlockDeviceSupported(),
+		BlockDeviceHotplugSupport: caps.isBlockDeviceHotplugSupported(),
+		MemoryBalloonSupport:      caps.isMemoryBalloonSupported(),
+		CPUHotplugSupport:         caps.isCPUHotplugSupported(),
+		MemoryHotplugSupport:      caps.isMemoryHotplugSupported(),
+		OnlineCPUMemSupport:       caps.isOnlineCPUMemSupported(),
+	}
+}
+
+// GetAgentCapabilities returns sandboxID's agent capabilities: the live
+// result of the agent's capabilities() call if the sandbox is running,
+// or the capabilities recorded when it was last started otherwise.
+func GetAgentCapabilities(sandboxID string) (AgentCapabilities, error) {
+	if sandboxID == "" {
+		return AgentCapabilities{}, errNeedSandboxID
+	}
+
+	lockFile, err := rLockSandbox(sandboxID)
+	if err != nil {
+		return AgentCapabilities{}, err
+	}
+	defer unlockSandbox(lockFile)
+
+	s, err := fetchSandbox(sandboxID)
+	if err != nil {
+		return AgentCapabilities{}, err
+	}
+
+	return newAgentCapabilities(s.agentCapabilities()), nil
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAgentCapabilitiesReflectsMockAgent(t *testing.T) {
+	assert := assert.New(t)
+
+	var caps capabilities
+	caps.setBlockDeviceSupport()
+
+	reported := newAgentCapabilities(caps)
+
+	assert.True(reported.BlockDeviceSupport)
+	assert.False(reported.BlockDeviceHotplugSupport)
+	assert.False(reported.MemoryBalloonSupport)
+}
+
+func TestNewAgentCapabilitiesAllBitsSet(t *testing.T) {
+	assert := assert.New(t)
+
+	var caps capabilities
+	caps.setBlockDeviceSupport()
+	caps.setBlockDeviceHotplugSupport()
+	caps.setMemoryBalloonSupport()
+	caps.setCPUHotplugSupport()
+	caps.setMemoryHotplugSupport()
+	caps.setOnlineCPUMemSupport()
+
+	reported := newAgentCapabilities(caps)
+
+	assert.True(reported.BlockDeviceSupport)
+	assert.True(reported.BlockDeviceHotplugSupport)
+	assert.True(reported.MemoryBalloonSupport)
+	assert.True(reported.CPUHotplugSupport)
+	assert.True(reported.MemoryHotplugSupport)
+	assert.True(reported.OnlineCPUMemSupport)
+}
+
+type fakeCapabilitiesAgent struct {
+	noopAgent
+	caps capabilities
+}
+
+func (a *fakeCapabilitiesAgent) capabilities() capabilities {
+	return a.caps
+}
+
+func TestSandboxAgentCapabilitiesUsesLiveAgentWhenRunning(t *testing.T) {
+	assert := assert.New(t)
+
+	var caps capabilities
+	caps.setBlockDeviceSupport()
+
+	s := &Sandbox{
+		agent: &fakeCapabilitiesAgent{caps: caps},
+		state: State{State: StateRunning},
+	}
+
+	reported := newAgentCapabilities(s.agentCapabilities())
+	assert.True(reported.BlockDeviceSupport)
+	assert.False(reported.BlockDeviceHotplugSupport)
+}
+
+func TestSandboxAgentCapabilitiesUsesRecordedStateWhenStopped(t *testing.T) {
+	assert := assert.New(t)
+
+	var liveCaps capabilities
+	liveCaps.setBlockDeviceHotplugSupport()
+
+	var recordedCaps capabilities
+	recordedCaps.setBlockDeviceSupport()
+	recordedCaps.setMemoryBalloonSupport()
+
+	s := &Sandbox{
+		agent: &fakeCapabilitiesAgent{caps: liveCaps},
+		state: State{
+			State:                  StateStopped,
+			AgentCapabilitiesFlags: recordedCaps.flags,
+		},
+	}
+
+	reported := newAgentCapabilities(s.agentCapabilities())
+	assert.True(reported.BlockDeviceSupport)
+	assert.True(reported.MemoryBalloonSupport)
+	assert.False(reported.BlockDeviceHotplugSupport)
+}
@@ -11,10 +11,18 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 
 	"github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
 )
 
+// maxConcurrentAssetHashes bounds how many assets newAssetsConcurrently
+// hashes at once. Hashing reads and digests the whole asset file, so an
+// unbounded number of concurrent hashes could compete heavily for disk
+// and CPU; this keeps the pool size reasonable regardless of how many
+// asset types are passed in.
+const maxConcurrentAssetHashes = 4
+
 type assetType string
 
 func (t assetType) annotations() (string, string, error) {
@@ -104,6 +112,68 @@ func (a *asset) hash(hashType string) (string, error) {
 	return hash, nil
 }
 
+// AssetVerificationResult reports whether a single asset's on-disk
+// content still matches its configured hash, as returned by
+// VerifyAssetHash.
+type AssetVerificationResult struct {
+	// Kind identifies the asset, e.g. "kernel" or "image".
+	Kind string
+
+	// Path is the on-disk path that was hashed.
+	Path string
+
+	// Status is one of "pass", "fail", or "skip". "skip" means there
+	// was nothing configured to verify the asset against, not that
+	// verification failed.
+	Status string
+
+	// Reason explains a "fail" or "skip" Status. It is empty for
+	// "pass".
+	Reason string
+}
+
+// VerifyAssetHash recomputes the hash of the asset at path using
+// hashType and compares it against expectedHash, using the same asset
+// machinery createAssets uses at sandbox launch. A missing path or
+// expectedHash is reported as a skip rather than a failure, since
+// there is then nothing configured to check.
+func VerifyAssetHash(kind, path, expectedHash, hashType string) AssetVerificationResult {
+	result := AssetVerificationResult{Kind: kind, Path: path}
+
+	if path == "" {
+		result.Status = "skip"
+		result.Reason = "asset not configured"
+		return result
+	}
+
+	if expectedHash == "" {
+		result.Status = "skip"
+		result.Reason = "no hash configured to verify against"
+		return result
+	}
+
+	if hashType == "" {
+		hashType = annotations.SHA512
+	}
+
+	a := &asset{path: path}
+	computed, err := a.hash(hashType)
+	if err != nil {
+		result.Status = "fail"
+		result.Reason = err.Error()
+		return result
+	}
+
+	if computed != expectedHash {
+		result.Status = "fail"
+		result.Reason = fmt.Sprintf("computed hash %s does not match configured hash %s", computed, expectedHash)
+		return result
+	}
+
+	result.Status = "pass"
+	return result
+}
+
 // newAsset returns a new asset from the sandbox annotations.
 func newAsset(sandboxConfig *SandboxConfig, t assetType) (*asset, error) {
 	pathAnnotation, hashAnnotation, err := t.annotations()
@@ -128,7 +198,14 @@ func newAsset(sandboxConfig *SandboxConfig, t assetType) (*asset, error) {
 
 	hash, ok := sandboxConfig.Annotations[hashAnnotation]
 	if !ok || hash == "" {
-		return a, nil
+		// No per-sandbox hash annotation: fall back to the centrally
+		// registered asset manifest, if one was loaded at startup.
+		manifestHash, ok := assetManifestHash(path)
+		if !ok {
+			return a, nil
+		}
+
+		hash = manifestHash
 	}
 
 	// We have a hash annotation, we need to verify the asset against it.
@@ -150,3 +227,60 @@ func newAsset(sandboxConfig *SandboxConfig, t assetType) (*asset, error) {
 
 	return a, nil
 }
+
+// newAssetsConcurrently builds the assets for every assetType in types,
+// like newAsset does one at a time, but spreads the hashing across a
+// bounded pool of goroutines instead of hashing each asset serially.
+// This matters because a single hash involves reading an entire, often
+// large, asset file. Unlike newAsset, it does not stop at the first
+// error: every asset is hashed regardless of whether another one
+// failed, and the errors are combined into one, each naming the asset
+// type it came from.
+func newAssetsConcurrently(sandboxConfig *SandboxConfig, types []assetType) (map[assetType]*asset, error) {
+	type result struct {
+		t     assetType
+		asset *asset
+		err   error
+	}
+
+	jobs := make(chan assetType, len(types))
+	results := make(chan result, len(types))
+
+	workers := maxConcurrentAssetHashes
+	if workers > len(types) {
+		workers = len(types)
+	}
+
+	for i := 0; i < workers; i++ {
+		go func() {
+			for t := range jobs {
+				a, err := newAsset(sandboxConfig, t)
+				results <- result{t: t, asset: a, err: err}
+			}
+		}()
+	}
+
+	for _, t := range types {
+		jobs <- t
+	}
+	close(jobs)
+
+	assets := make(map[assetType]*asset, len(types))
+	var errs []string
+
+	for i := 0; i < len(types); i++ {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", r.t, r.err))
+			continue
+		}
+
+		assets[r.t] = r.asset
+	}
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to load assets: %s", strings.Join(errs, "; "))
+	}
+
+	return assets, nil
+}
@@ -6,6 +6,8 @@
 package virtcontainers
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -104,3 +106,298 @@ func TestAssetNew(t *testing.T) {
 	_, err = newAsset(p, kernelAsset)
 	assert.NotNil(err)
 }
+
+func TestNewAssetFallsBackToManifestHash(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func() { registeredAssetManifest = nil }()
+
+	tmpfile, err := ioutil.TempFile("", "virtcontainers-test-")
+	assert.Nil(err)
+
+	defer func() {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+	}()
+
+	_, err = tmpfile.Write(assetContent)
+	assert.Nil(err)
+
+	registeredAssetManifest = &assetManifestBody{
+		Hashes: map[string]string{tmpfile.Name(): assetContentHash},
+	}
+
+	p := &SandboxConfig{
+		Annotations: map[string]string{
+			annotations.KernelPath: tmpfile.Name(),
+			// No annotations.KernelHash: the manifest hash should be
+			// consulted instead.
+		},
+	}
+
+	a, err := newAsset(p, kernelAsset)
+	assert.Nil(err)
+	assert.Equal(assetContentHash, a.computedHash)
+}
+
+func TestNewAssetRejectsManifestHashMismatch(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func() { registeredAssetManifest = nil }()
+
+	tmpfile, err := ioutil.TempFile("", "virtcontainers-test-")
+	assert.Nil(err)
+
+	defer func() {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+	}()
+
+	_, err = tmpfile.Write(assetContent)
+	assert.Nil(err)
+
+	registeredAssetManifest = &assetManifestBody{
+		Hashes: map[string]string{tmpfile.Name(): assetContentWrongHash},
+	}
+
+	p := &SandboxConfig{
+		Annotations: map[string]string{
+			annotations.KernelPath: tmpfile.Name(),
+		},
+	}
+
+	_, err = newAsset(p, kernelAsset)
+	assert.NotNil(err)
+}
+
+func TestNewAssetPrefersAnnotationHashOverManifestHash(t *testing.T) {
+	assert := assert.New(t)
+
+	defer func() { registeredAssetManifest = nil }()
+
+	tmpfile, err := ioutil.TempFile("", "virtcontainers-test-")
+	assert.Nil(err)
+
+	defer func() {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+	}()
+
+	_, err = tmpfile.Write(assetContent)
+	assert.Nil(err)
+
+	// The manifest records a mismatching hash, but the per-sandbox
+	// annotation hash (the correct one) must take precedence.
+	registeredAssetManifest = &assetManifestBody{
+		Hashes: map[string]string{tmpfile.Name(): assetContentWrongHash},
+	}
+
+	p := &SandboxConfig{
+		Annotations: map[string]string{
+			annotations.KernelPath: tmpfile.Name(),
+			annotations.KernelHash: assetContentHash,
+		},
+	}
+
+	a, err := newAsset(p, kernelAsset)
+	assert.Nil(err)
+	assert.Equal(assetContentHash, a.computedHash)
+}
+
+func TestNewAssetsConcurrentlyReportsFailingAssetByName(t *testing.T) {
+	assert := assert.New(t)
+
+	goodFile, err := ioutil.TempFile("", "virtcontainers-test-")
+	assert.Nil(err)
+
+	defer func() {
+		goodFile.Close()
+		os.Remove(goodFile.Name())
+	}()
+
+	_, err = goodFile.Write(assetContent)
+	assert.Nil(err)
+
+	badFile, err := ioutil.TempFile("", "virtcontainers-test-")
+	assert.Nil(err)
+
+	defer func() {
+		badFile.Close()
+		os.Remove(badFile.Name())
+	}()
+
+	_, err = badFile.Write(assetContent)
+	assert.Nil(err)
+
+	p := &SandboxConfig{
+		Annotations: map[string]string{
+			annotations.KernelPath: goodFile.Name(),
+			annotations.KernelHash: assetContentHash,
+			annotations.ImagePath:  badFile.Name(),
+			annotations.ImageHash:  assetContentWrongHash,
+		},
+	}
+
+	_, err = newAssetsConcurrently(p, []assetType{kernelAsset, imageAsset})
+	assert.NotNil(err)
+	assert.Contains(err.Error(), string(imageAsset))
+}
+
+func TestNewAssetsConcurrentlySuccessful(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpfile, err := ioutil.TempFile("", "virtcontainers-test-")
+	assert.Nil(err)
+
+	defer func() {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+	}()
+
+	_, err = tmpfile.Write(assetContent)
+	assert.Nil(err)
+
+	p := &SandboxConfig{
+		Annotations: map[string]string{
+			annotations.KernelPath: tmpfile.Name(),
+			annotations.KernelHash: assetContentHash,
+		},
+	}
+
+	assets, err := newAssetsConcurrently(p, []assetType{kernelAsset, imageAsset, initrdAsset})
+	assert.Nil(err)
+	assert.NotNil(assets[kernelAsset])
+	assert.Nil(assets[imageAsset])
+	assert.Nil(assets[initrdAsset])
+}
+
+func TestVerifyAssetHashPass(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpfile, err := ioutil.TempFile("", "virtcontainers-test-")
+	assert.Nil(err)
+
+	defer func() {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+	}()
+
+	_, err = tmpfile.Write(assetContent)
+	assert.Nil(err)
+
+	result := VerifyAssetHash("kernel", tmpfile.Name(), assetContentHash, annotations.SHA512)
+	assert.Equal("pass", result.Status)
+	assert.Empty(result.Reason)
+}
+
+func TestVerifyAssetHashFailsOnTamperedAsset(t *testing.T) {
+	assert := assert.New(t)
+
+	tmpfile, err := ioutil.TempFile("", "virtcontainers-test-")
+	assert.Nil(err)
+
+	defer func() {
+		tmpfile.Close()
+		os.Remove(tmpfile.Name())
+	}()
+
+	_, err = tmpfile.Write(assetContent)
+	assert.Nil(err)
+
+	result := VerifyAssetHash("kernel", tmpfile.Name(), assetContentHash, annotations.SHA512)
+	assert.Equal("pass", result.Status)
+
+	// Simulate the asset being tampered with after the hash was recorded.
+	assert.Nil(ioutil.WriteFile(tmpfile.Name(), []byte("tampered content"), 0640))
+
+	result = VerifyAssetHash("kernel", tmpfile.Name(), assetContentHash, annotations.SHA512)
+	assert.Equal("fail", result.Status)
+	assert.NotEmpty(result.Reason)
+}
+
+func TestVerifyAssetHashSkipsWhenNotConfigured(t *testing.T) {
+	assert := assert.New(t)
+
+	result := VerifyAssetHash("kernel", "", "", annotations.SHA512)
+	assert.Equal("skip", result.Status)
+
+	result = VerifyAssetHash("kernel", "/some/path", "", annotations.SHA512)
+	assert.Equal("skip", result.Status)
+}
+
+// benchmarkAssetTypes are the assets createAssets hashes at sandbox
+// launch, reused by the serial and parallel benchmarks below so they
+// hash the same four large files.
+var benchmarkAssetTypes = []assetType{kernelAsset, imageAsset, initrdAsset, firmwareAsset}
+
+// setupBenchmarkAssets writes one large file per entry in
+// benchmarkAssetTypes and returns a SandboxConfig with matching
+// path/hash annotations for all of them, along with a cleanup function.
+func setupBenchmarkAssets(b *testing.B) (*SandboxConfig, func()) {
+	// 16MiB, representative of a real kernel or initrd image.
+	content := make([]byte, 16*1024*1024)
+	for i := range content {
+		content[i] = byte(i)
+	}
+
+	sum := sha512.Sum512(content)
+	hash := hex.EncodeToString(sum[:])
+
+	sandboxAnnotations := make(map[string]string)
+	var paths []string
+
+	for _, t := range benchmarkAssetTypes {
+		f, err := ioutil.TempFile("", "virtcontainers-bench-")
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if _, err := f.Write(content); err != nil {
+			b.Fatal(err)
+		}
+		f.Close()
+		paths = append(paths, f.Name())
+
+		pathAnnotation, hashAnnotation, err := t.annotations()
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		sandboxAnnotations[pathAnnotation] = f.Name()
+		sandboxAnnotations[hashAnnotation] = hash
+	}
+
+	cleanup := func() {
+		for _, p := range paths {
+			os.Remove(p)
+		}
+	}
+
+	return &SandboxConfig{Annotations: sandboxAnnotations}, cleanup
+}
+
+func BenchmarkNewAssetsSerial(b *testing.B) {
+	sandboxConfig, cleanup := setupBenchmarkAssets(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, t := range benchmarkAssetTypes {
+			if _, err := newAsset(sandboxConfig, t); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkNewAssetsConcurrently(b *testing.B) {
+	sandboxConfig, cleanup := setupBenchmarkAssets(b)
+	defer cleanup()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := newAssetsConcurrently(sandboxConfig, benchmarkAssetTypes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
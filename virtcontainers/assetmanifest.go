@@ -0,0 +1,125 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// signedAssetManifest is the on-disk representation of an asset hash
+// manifest: the manifest's JSON-encoded body, alongside a signature over
+// that body. This lets a fleet centrally pin expected asset hashes in a
+// single file instead of setting a hash annotation on every sandbox,
+// while still rejecting a manifest that has been tampered with.
+type signedAssetManifest struct {
+	// Manifest is the JSON-encoded assetManifestBody the signature
+	// below was computed over.
+	Manifest json.RawMessage `json:"manifest"`
+
+	// Signature is the PKCS#1 v1.5 signature, over the SHA-256 digest
+	// of Manifest, made with the private key corresponding to the
+	// public key RegisterAssetManifest is asked to verify against.
+	Signature []byte `json:"signature"`
+}
+
+// assetManifestBody maps an absolute asset path to its expected hash,
+// hex encoded exactly as asset.hash (and VerifyAssetHash) produce it.
+type assetManifestBody struct {
+	Hashes map[string]string `json:"hashes"`
+}
+
+var (
+	assetManifestLock       sync.Mutex
+	registeredAssetManifest *assetManifestBody
+)
+
+// loadAssetManifestPublicKey reads and parses the PEM-encoded RSA public
+// key at path, used to verify an asset manifest's signature.
+func loadAssetManifestPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read asset manifest public key %s: %v", path, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in asset manifest public key %s", path)
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse asset manifest public key %s: %v", path, err)
+	}
+
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("asset manifest public key %s is not an RSA public key", path)
+	}
+
+	return rsaPub, nil
+}
+
+// RegisterAssetManifest loads, verifies and caches the signed asset hash
+// manifest at manifestPath, for newAsset to consult when a sandbox has
+// no per-asset hash annotation. publicKeyPath is a PEM-encoded RSA
+// public key used to verify the manifest's signature; a manifest that
+// fails signature verification is rejected outright rather than being
+// partially trusted. It is intended to be called once, at startup.
+func RegisterAssetManifest(manifestPath, publicKeyPath string) error {
+	pub, err := loadAssetManifestPublicKey(publicKeyPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("unable to read asset manifest %s: %v", manifestPath, err)
+	}
+
+	var signed signedAssetManifest
+	if err := json.Unmarshal(data, &signed); err != nil {
+		return fmt.Errorf("unable to parse asset manifest %s: %v", manifestPath, err)
+	}
+
+	digest := sha256.Sum256(signed.Manifest)
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signed.Signature); err != nil {
+		return fmt.Errorf("asset manifest %s failed signature verification: %v", manifestPath, err)
+	}
+
+	var body assetManifestBody
+	if err := json.Unmarshal(signed.Manifest, &body); err != nil {
+		return fmt.Errorf("unable to parse asset manifest %s body: %v", manifestPath, err)
+	}
+
+	assetManifestLock.Lock()
+	registeredAssetManifest = &body
+	assetManifestLock.Unlock()
+
+	return nil
+}
+
+// assetManifestHash returns the hash the registered asset manifest
+// records for path, if RegisterAssetManifest has been called and its
+// manifest has an entry for path.
+func assetManifestHash(path string) (string, bool) {
+	assetManifestLock.Lock()
+	defer assetManifestLock.Unlock()
+
+	if registeredAssetManifest == nil {
+		return "", false
+	}
+
+	hash, ok := registeredAssetManifest.Hashes[path]
+	return hash, ok
+}
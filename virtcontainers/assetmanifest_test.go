@@ -0,0 +1,144 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestAssetManifest signs body with key and writes the resulting
+// signedAssetManifest to dir/manifest.json, returning its path.
+func writeTestAssetManifest(t *testing.T, dir string, key *rsa.PrivateKey, body assetManifestBody) string {
+	rawBody, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	digest := sha256.Sum256(rawBody)
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	assert.NoError(t, err)
+
+	signed := signedAssetManifest{Manifest: rawBody, Signature: signature}
+	rawSigned, err := json.Marshal(signed)
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "manifest.json")
+	assert.NoError(t, ioutil.WriteFile(path, rawSigned, 0640))
+
+	return path
+}
+
+// writeTestAssetManifestPublicKey PEM-encodes key's public half to
+// dir/pubkey.pem, returning its path.
+func writeTestAssetManifestPublicKey(t *testing.T, dir string, key *rsa.PrivateKey) string {
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	path := filepath.Join(dir, "pubkey.pem")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	assert.NoError(t, pem.Encode(f, &pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	return path
+}
+
+func TestRegisterAssetManifestValidSignature(t *testing.T) {
+	defer func() { registeredAssetManifest = nil }()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	tmpdir, err := ioutil.TempDir("", "virtcontainers-test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	manifestPath := writeTestAssetManifest(t, tmpdir, key, assetManifestBody{
+		Hashes: map[string]string{"/opt/kata/kernel": assetContentHash},
+	})
+	pubKeyPath := writeTestAssetManifestPublicKey(t, tmpdir, key)
+
+	assert.NoError(t, RegisterAssetManifest(manifestPath, pubKeyPath))
+
+	hash, ok := assetManifestHash("/opt/kata/kernel")
+	assert.True(t, ok)
+	assert.Equal(t, assetContentHash, hash)
+
+	_, ok = assetManifestHash("/opt/kata/not-in-manifest")
+	assert.False(t, ok)
+}
+
+func TestRegisterAssetManifestRejectsTamperedManifest(t *testing.T) {
+	defer func() { registeredAssetManifest = nil }()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	tmpdir, err := ioutil.TempDir("", "virtcontainers-test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	manifestPath := writeTestAssetManifest(t, tmpdir, key, assetManifestBody{
+		Hashes: map[string]string{"/opt/kata/kernel": assetContentHash},
+	})
+	pubKeyPath := writeTestAssetManifestPublicKey(t, tmpdir, key)
+
+	// Tamper with the signed manifest after it was signed: swap in a
+	// different hash for the same path without re-signing.
+	raw, err := ioutil.ReadFile(manifestPath)
+	assert.NoError(t, err)
+
+	var signed signedAssetManifest
+	assert.NoError(t, json.Unmarshal(raw, &signed))
+
+	signed.Manifest = json.RawMessage(`{"hashes":{"/opt/kata/kernel":"` + assetContentWrongHash + `"}}`)
+	tampered, err := json.Marshal(signed)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(manifestPath, tampered, 0640))
+
+	assert.Error(t, RegisterAssetManifest(manifestPath, pubKeyPath))
+	assert.Nil(t, registeredAssetManifest)
+}
+
+func TestRegisterAssetManifestRejectsWrongKey(t *testing.T) {
+	defer func() { registeredAssetManifest = nil }()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	tmpdir, err := ioutil.TempDir("", "virtcontainers-test-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpdir)
+
+	manifestPath := writeTestAssetManifest(t, tmpdir, key, assetManifestBody{
+		Hashes: map[string]string{"/opt/kata/kernel": assetContentHash},
+	})
+	wrongPubKeyPath := writeTestAssetManifestPublicKey(t, tmpdir, otherKey)
+
+	assert.Error(t, RegisterAssetManifest(manifestPath, wrongPubKeyPath))
+}
+
+func TestAssetManifestHashNoneRegistered(t *testing.T) {
+	defer func() { registeredAssetManifest = nil }()
+	registeredAssetManifest = nil
+
+	_, ok := assetManifestHash("/opt/kata/kernel")
+	assert.False(t, ok)
+}
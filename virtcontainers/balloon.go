@@ -0,0 +1,65 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "fmt"
+
+// minBalloonTargetMiB is the floor below which a sandbox's VM is not
+// allowed to be ballooned down to, so that the guest always keeps enough
+// memory to stay responsive.
+const minBalloonTargetMiB = 64
+
+// validateBalloonTarget checks that targetMiB is a sane value to reclaim
+// a sandbox's memory down to: below the memory the sandbox was configured
+// with, and above minBalloonTargetMiB.
+func validateBalloonTarget(targetMiB, configuredMemoryMiB uint32) error {
+	if targetMiB < minBalloonTargetMiB {
+		return fmt.Errorf("balloon target %d MiB is below the %d MiB floor", targetMiB, minBalloonTargetMiB)
+	}
+
+	if targetMiB >= configuredMemoryMiB {
+		return fmt.Errorf("balloon target %d MiB must be less than the sandbox's configured memory of %d MiB", targetMiB, configuredMemoryMiB)
+	}
+
+	return nil
+}
+
+// setBalloonTarget drives the sandbox's virtio-balloon device down to
+// targetMiB, to reclaim memory from an idle sandbox. It requires the
+// sandbox's hypervisor to advertise memoryBalloonSupport, which SEV
+// guests currently do not due to memory-encryption constraints.
+func (s *Sandbox) setBalloonTarget(targetMiB uint32) error {
+	if !s.hypervisor.capabilities().isMemoryBalloonSupported() {
+		return fmt.Errorf("sandbox %s's hypervisor does not support memory ballooning", s.id)
+	}
+
+	if err := validateBalloonTarget(targetMiB, s.config.HypervisorConfig.DefaultMemSz); err != nil {
+		return err
+	}
+
+	return s.hypervisor.setBalloonTarget(targetMiB)
+}
+
+// SetBalloonTarget is the virtcontainers entry point for reclaiming
+// memory from sandboxID's VM via its virtio-balloon device.
+func SetBalloonTarget(sandboxID string, targetMiB uint32) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	lockFile, err := rwLockSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+	defer unlockSandbox(lockFile)
+
+	s, err := fetchSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	return s.setBalloonTarget(targetMiB)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "testing"
+
+func TestValidateBalloonTargetValid(t *testing.T) {
+	if err := validateBalloonTarget(512, 1024); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateBalloonTargetBelowFloor(t *testing.T) {
+	if err := validateBalloonTarget(minBalloonTargetMiB-1, 1024); err == nil {
+		t.Fatal("expected an error for a target below the floor")
+	}
+}
+
+func TestValidateBalloonTargetAboveConfiguredMemory(t *testing.T) {
+	if err := validateBalloonTarget(1024, 1024); err == nil {
+		t.Fatal("expected an error for a target at or above configured memory")
+	}
+}
+
+// balloonCapableHypervisor behaves like mockHypervisor except it reports
+// memory balloon support, for testing the capability-gated path of
+// Sandbox.setBalloonTarget.
+type balloonCapableHypervisor struct {
+	mockHypervisor
+	target uint32
+}
+
+func (h *balloonCapableHypervisor) capabilities() capabilities {
+	var caps capabilities
+	caps.setMemoryBalloonSupport()
+	return caps
+}
+
+func (h *balloonCapableHypervisor) setBalloonTarget(targetMiB uint32) error {
+	h.target = targetMiB
+	return nil
+}
+
+func TestSandboxSetBalloonTargetUnsupportedCapability(t *testing.T) {
+	sandbox := &Sandbox{
+		id:         "balloon-unsupported",
+		hypervisor: &mockHypervisor{},
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{DefaultMemSz: 1024},
+		},
+	}
+
+	if err := sandbox.setBalloonTarget(512); err == nil {
+		t.Fatal("expected an error when the hypervisor does not support ballooning")
+	}
+}
+
+func TestSandboxSetBalloonTargetSuccessful(t *testing.T) {
+	hv := &balloonCapableHypervisor{}
+
+	sandbox := &Sandbox{
+		id:         "balloon-supported",
+		hypervisor: hv,
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{DefaultMemSz: 1024},
+		},
+	}
+
+	if err := sandbox.setBalloonTarget(512); err != nil {
+		t.Fatal(err)
+	}
+
+	if hv.target != 512 {
+		t.Fatalf("expected hypervisor to be asked for a target of 512 MiB, got %d", hv.target)
+	}
+}
+
+func TestSandboxSetBalloonTargetInvalidTarget(t *testing.T) {
+	hv := &balloonCapableHypervisor{}
+
+	sandbox := &Sandbox{
+		id:         "balloon-invalid",
+		hypervisor: hv,
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{DefaultMemSz: 1024},
+		},
+	}
+
+	if err := sandbox.setBalloonTarget(2048); err == nil {
+		t.Fatal("expected an error for a target above configured memory")
+	}
+}
@@ -0,0 +1,825 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/boltdb/bolt"
+	"github.com/sirupsen/logrus"
+
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/device/drivers"
+)
+
+// boltDBPath is the single file boltStorage persists all sandbox and
+// container resources into, as nested buckets, instead of the per-sandbox
+// directory tree filesystem uses.
+var boltDBPath = filepath.Join(configStoragePath, "vc.db")
+
+// boltSandboxesBucket is the top-level bucket holding one nested bucket
+// per sandbox ID.
+const boltSandboxesBucket = "sandboxes"
+
+// boltContainersBucket is, within a sandbox's bucket, the nested bucket
+// holding one further nested bucket per container ID.
+const boltContainersBucket = "containers"
+
+func init() {
+	RegisterStorageBackend("boltdb", func(lenientDeviceRestore bool) resourceStorage {
+		return &boltStorage{LenientDeviceRestore: lenientDeviceRestore}
+	})
+}
+
+// boltStorage is a resourceStorage interface implementation that keeps
+// every sandbox and container resource in buckets within a single BoltDB
+// file, instead of filesystem's per-sandbox directory tree. This avoids
+// the inode pressure and slow directory scans that tree causes on hosts
+// running huge numbers of sandboxes.
+//
+// Locking (lockSandbox/unlockSandbox) and sandbox enumeration
+// (ListSandbox) remain filesystem-based regardless of which
+// resourceStorage backend a sandbox uses, since both only need a sandbox
+// ID, not any backend-specific resource.
+type boltStorage struct {
+	// LenientDeviceRestore mirrors filesystem.LenientDeviceRestore: it
+	// makes fetchContainerDevices preserve devices of an unrecognized
+	// type as opaque TypedDevice entries instead of failing the restore.
+	LenientDeviceRestore bool
+}
+
+// Logger returns a logrus logger appropriate for logging boltStorage messages
+func (b *boltStorage) Logger() *logrus.Entry {
+	return virtLog.WithField("subsystem", "boltstorage")
+}
+
+// boltDBMu guards boltDB. bolt.Open takes an exclusive flock on
+// boltDBPath for the life of the handle, so two independent bolt.Open
+// calls against the same file, even within the same process, are
+// different file descriptions: the second would block forever waiting
+// on a lock the first already holds. Memoizing the handle per
+// boltStorage *instance* (as openDB once did) does not help, since
+// nothing stops two separate instances from being constructed in the
+// same process, e.g. fetchSandbox building one to read the sandbox
+// config and then createSandbox building another for the rest of the
+// sandbox's lifetime. The handle must therefore be shared package-wide.
+var boltDBMu sync.Mutex
+var boltDB *bolt.DB
+
+// openDB returns the shared, process-wide BoltDB handle, opening (or,
+// the first time, creating) boltDBPath if it isn't already open. See
+// boltDBMu's comment for why this must not be per-boltStorage state.
+func (b *boltStorage) openDB() (*bolt.DB, error) {
+	boltDBMu.Lock()
+	defer boltDBMu.Unlock()
+
+	if boltDB != nil {
+		return boltDB, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(boltDBPath), dirMode); err != nil {
+		return nil, err
+	}
+
+	db, err := bolt.Open(boltDBPath, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	boltDB = db
+	return boltDB, nil
+}
+
+// closeBoltDB closes the shared BoltDB handle opened by openDB, if one
+// is open, and clears it so a later openDB call reopens boltDBPath.
+// Production callers never need this: the process exits, and the OS
+// releases the flock, long before a second boltStorage backend would
+// ever be configured. Tests use it to release the lock between cases
+// that reopen boltDBPath instead of leaking it across the test binary.
+func closeBoltDB() error {
+	boltDBMu.Lock()
+	defer boltDBMu.Unlock()
+
+	if boltDB == nil {
+		return nil
+	}
+
+	err := boltDB.Close()
+	boltDB = nil
+	return err
+}
+
+// boltResourceKey returns the key a resource is stored under within a
+// sandbox or container bucket. Unlike resourceName (metrics.go), which
+// only needs to cover the resources metrics observe, this must cover
+// every sandboxResource boltStorage can be asked to persist.
+func boltResourceKey(resource sandboxResource) (string, error) {
+	switch resource {
+	case configFileType:
+		return "config", nil
+	case stateFileType:
+		return "state", nil
+	case networkFileType:
+		return "network", nil
+	case hypervisorFileType:
+		return "hypervisor", nil
+	case agentFileType:
+		return "agent", nil
+	case processFileType:
+		return "process", nil
+	case lockFileType:
+		return "lock", nil
+	case mountsFileType:
+		return "mounts", nil
+	case devicesFileType:
+		return "devices", nil
+	case detachedProcessesFileType:
+		return "detached-processes", nil
+	case hooksFileType:
+		return "hooks", nil
+	default:
+		return "", errInvalidResource
+	}
+}
+
+// boltBucketPath returns the ordered chain of bucket names leading to
+// sandboxID's bucket, or, if containerID is non-empty, to that
+// container's bucket nested within it.
+func boltBucketPath(sandboxID, containerID string) []string {
+	path := []string{boltSandboxesBucket, sandboxID}
+
+	if containerID != "" {
+		path = append(path, boltContainersBucket, containerID)
+	}
+
+	return path
+}
+
+// containerURI and sandboxURI exist to satisfy resourceStorage. BoltDB
+// has no filesystem path for a resource, so they return a pseudo-URI
+// identifying the bucket chain and key a resource lives under instead;
+// nothing currently depends on these being real paths for boltStorage,
+// since locking always goes through filesystem directly.
+func (b *boltStorage) containerURI(sandboxID, containerID string, resource sandboxResource) (string, string, error) {
+	if sandboxID == "" {
+		return "", "", errNeedSandboxID
+	}
+
+	if containerID == "" {
+		return "", "", errNeedContainerID
+	}
+
+	key, err := boltResourceKey(resource)
+	if err != nil {
+		return "", "", err
+	}
+
+	dir := filepath.Join(boltBucketPath(sandboxID, containerID)...)
+
+	return filepath.Join(dir, key), dir, nil
+}
+
+func (b *boltStorage) sandboxURI(sandboxID string, resource sandboxResource) (string, string, error) {
+	if sandboxID == "" {
+		return "", "", errNeedSandboxID
+	}
+
+	key, err := boltResourceKey(resource)
+	if err != nil {
+		return "", "", err
+	}
+
+	dir := filepath.Join(boltBucketPath(sandboxID, "")...)
+
+	return filepath.Join(dir, key), dir, nil
+}
+
+// bucketWalker is satisfied by both *bolt.Tx and *bolt.Bucket, letting
+// withBucket walk a chain of nested buckets uniformly starting from the
+// transaction itself.
+type bucketWalker interface {
+	Bucket([]byte) *bolt.Bucket
+	CreateBucketIfNotExists([]byte) (*bolt.Bucket, error)
+}
+
+// withBucket opens the bucket chain identified by path and runs fn
+// against it. When writable, missing buckets along the way are created;
+// otherwise a missing bucket makes fn run against a nil *bolt.Bucket, so
+// fn must treat a nil bucket the same as a bucket with no matching key.
+func (b *boltStorage) withBucket(path []string, writable bool, fn func(*bolt.Bucket) error) error {
+	db, err := b.openDB()
+	if err != nil {
+		return err
+	}
+
+	run := func(tx *bolt.Tx) error {
+		var (
+			parent bucketWalker = tx
+			bucket *bolt.Bucket
+		)
+
+		for _, name := range path {
+			if writable {
+				bucket, err = parent.CreateBucketIfNotExists([]byte(name))
+				if err != nil {
+					return err
+				}
+			} else {
+				bucket = parent.Bucket([]byte(name))
+				if bucket == nil {
+					return fn(nil)
+				}
+			}
+
+			parent = bucket
+		}
+
+		return fn(bucket)
+	}
+
+	if writable {
+		return db.Update(run)
+	}
+
+	return db.View(run)
+}
+
+func (b *boltStorage) createAllResources(sandbox *Sandbox) error {
+	return b.withBucket(boltBucketPath(sandbox.id, ""), true, func(sandboxBucket *bolt.Bucket) error {
+		if len(sandbox.containers) == 0 {
+			return nil
+		}
+
+		containers, err := sandboxBucket.CreateBucketIfNotExists([]byte(boltContainersBucket))
+		if err != nil {
+			return err
+		}
+
+		for _, container := range sandbox.containers {
+			if _, err := containers.CreateBucketIfNotExists([]byte(container.id)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// errBoltResourceNotFound is returned when a requested resource has no
+// entry in the database yet, mirroring the error filesystem gets for
+// free, via ioutil.ReadFile, from a missing file.
+var errBoltResourceNotFound = fmt.Errorf("resource not found")
+
+func (b *boltStorage) storeJSON(path []string, key string, data interface{}) error {
+	jsonOut, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("Could not marshall data: %s", err)
+	}
+
+	return b.withBucket(path, true, func(bucket *bolt.Bucket) error {
+		return bucket.Put([]byte(key), jsonOut)
+	})
+}
+
+func (b *boltStorage) fetchRaw(path []string, key string) ([]byte, error) {
+	var raw []byte
+
+	err := b.withBucket(path, false, func(bucket *bolt.Bucket) error {
+		if bucket == nil {
+			return errBoltResourceNotFound
+		}
+
+		v := bucket.Get([]byte(key))
+		if v == nil {
+			return errBoltResourceNotFound
+		}
+
+		raw = append(raw, v...)
+		return nil
+	})
+
+	return raw, err
+}
+
+// storeDevicesJSON is boltStorage's analog of filesystem.storeDeviceFile:
+// devices are marshalled via TypedDevice so each one's concrete type
+// survives the round-trip back through fetchDevicesJSON.
+func storeDevicesJSON(devices []api.Device) ([]byte, error) {
+	var typedDevices []TypedDevice
+	for _, d := range devices {
+		// An FdDevice only carries a host file descriptor, which is not
+		// meaningful once this process exits, so persisting it would
+		// silently produce an entry that can never be restored from.
+		if _, ok := d.(*drivers.FdDevice); ok {
+			return nil, fmt.Errorf("cannot persist fd device %v: file descriptors do not survive a restart, it must be re-passed", d)
+		}
+
+		tempJSON, _ := json.Marshal(d)
+		typedDevices = append(typedDevices, TypedDevice{
+			Type: string(d.DeviceType()),
+			Data: tempJSON,
+		})
+	}
+
+	jsonOut, err := json.Marshal(typedDevices)
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal devices: %s", err)
+	}
+
+	return jsonOut, nil
+}
+
+// fetchDevicesJSON is boltStorage's analog of filesystem.fetchDeviceFile.
+func fetchDevicesJSON(raw []byte, lenientDeviceRestore bool) ([]api.Device, error) {
+	var typedDevices []TypedDevice
+	if err := json.Unmarshal(raw, &typedDevices); err != nil {
+		return nil, err
+	}
+
+	var devices []api.Device
+	for _, d := range typedDevices {
+		switch d.Type {
+		case string(config.DeviceVFIO):
+			var device drivers.VFIODevice
+			if err := json.Unmarshal(d.Data, &device); err != nil {
+				return nil, err
+			}
+			devices = append(devices, &device)
+
+		case string(config.DeviceBlock):
+			var device drivers.BlockDevice
+			if err := json.Unmarshal(d.Data, &device); err != nil {
+				return nil, err
+			}
+			devices = append(devices, &device)
+
+		case string(config.DeviceGeneric):
+			var device drivers.GenericDevice
+			if err := json.Unmarshal(d.Data, &device); err != nil {
+				return nil, err
+			}
+			devices = append(devices, &device)
+
+		default:
+			if !lenientDeviceRestore {
+				return nil, fmt.Errorf("Unknown device type, could not unmarshal")
+			}
+
+			devices = append(devices, &opaqueDevice{
+				devType: config.DeviceType(d.Type),
+				data:    d.Data,
+			})
+		}
+	}
+
+	return devices, nil
+}
+
+func (b *boltStorage) commonResourceChecks(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if resourceNeedsContainerID(sandboxSpecific, resource) && containerID == "" {
+		return errNeedContainerID
+	}
+
+	if _, err := boltResourceKey(resource); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (b *boltStorage) storeResource(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) (err error) {
+	defer func() { storageMetrics.observe(storageOpStore, resource, err) }()
+
+	if err = b.commonResourceChecks(sandboxSpecific, sandboxID, containerID, resource); err != nil {
+		return err
+	}
+
+	key, err := boltResourceKey(resource)
+	if err != nil {
+		return err
+	}
+
+	path := boltBucketPath(sandboxID, containerID)
+	if sandboxSpecific {
+		path = boltBucketPath(sandboxID, "")
+	}
+
+	if devices, ok := data.([]api.Device); ok {
+		raw, err := storeDevicesJSON(devices)
+		if err != nil {
+			return wrapStorageErr(storageOpStore, resource, sandboxID, containerID, err)
+		}
+
+		return wrapStorageErr(storageOpStore, resource, sandboxID, containerID, b.withBucket(path, true, func(bucket *bolt.Bucket) error {
+			return bucket.Put([]byte(key), raw)
+		}))
+	}
+
+	return wrapStorageErr(storageOpStore, resource, sandboxID, containerID, b.storeJSON(path, key, data))
+}
+
+func (b *boltStorage) fetchResource(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) (err error) {
+	defer func() { storageMetrics.observe(storageOpFetch, resource, err) }()
+
+	if err = b.commonResourceChecks(sandboxSpecific, sandboxID, containerID, resource); err != nil {
+		return err
+	}
+
+	key, err := boltResourceKey(resource)
+	if err != nil {
+		return err
+	}
+
+	path := boltBucketPath(sandboxID, containerID)
+	if sandboxSpecific {
+		path = boltBucketPath(sandboxID, "")
+	}
+
+	raw, err := b.fetchRaw(path, key)
+	if err != nil {
+		return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, err)
+	}
+
+	if resource == devicesFileType {
+		devices, ok := data.(*[]api.Device)
+		if !ok {
+			return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, fmt.Errorf("Could not cast %v into *[]Device type", data))
+		}
+
+		fetched, err := fetchDevicesJSON(raw, b.LenientDeviceRestore)
+		if err != nil {
+			return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, err)
+		}
+
+		*devices = fetched
+		return nil
+	}
+
+	return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, json.Unmarshal(raw, data))
+}
+
+func (b *boltStorage) storeSandboxResource(sandboxID string, resource sandboxResource, data interface{}) error {
+	return b.storeResource(true, sandboxID, "", resource, data)
+}
+
+func (b *boltStorage) deleteSandboxResources(sandboxID string, resources []sandboxResource) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	db, err := b.openDB()
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(boltSandboxesBucket))
+		if bucket == nil {
+			return nil
+		}
+
+		if bucket.Bucket([]byte(sandboxID)) == nil {
+			return nil
+		}
+
+		return bucket.DeleteBucket([]byte(sandboxID))
+	})
+
+	if resources == nil {
+		resources = []sandboxResource{configFileType, stateFileType}
+	}
+
+	for _, resource := range resources {
+		storageMetrics.observe(storageOpDelete, resource, err)
+	}
+
+	return err
+}
+
+// listSandboxes returns the IDs of every sandbox bucket under
+// boltSandboxesBucket that has a state resource, skipping (and logging)
+// any sandbox bucket that does not, e.g. one left behind mid-deletion.
+func (b *boltStorage) listSandboxes() ([]string, error) {
+	db, err := b.openDB()
+	if err != nil {
+		return nil, err
+	}
+
+	stateKey, err := boltResourceKey(stateFileType)
+	if err != nil {
+		return nil, err
+	}
+
+	var sandboxIDs []string
+
+	err = db.View(func(tx *bolt.Tx) error {
+		sandboxesBucket := tx.Bucket([]byte(boltSandboxesBucket))
+		if sandboxesBucket == nil {
+			return nil
+		}
+
+		return sandboxesBucket.ForEach(func(k, v []byte) error {
+			if v != nil {
+				// Not a nested (sandbox) bucket.
+				return nil
+			}
+
+			sandboxBucket := sandboxesBucket.Bucket(k)
+			if sandboxBucket.Get([]byte(stateKey)) == nil {
+				b.Logger().WithField("sandbox", string(k)).Warn("skipping sandbox with no valid state")
+				return nil
+			}
+
+			sandboxIDs = append(sandboxIDs, string(k))
+			return nil
+		})
+	})
+
+	return sandboxIDs, err
+}
+
+func (b *boltStorage) fetchSandboxConfig(sandboxID string) (SandboxConfig, error) {
+	var config SandboxConfig
+	if err := b.fetchResource(true, sandboxID, "", configFileType, &config); err != nil {
+		return SandboxConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (b *boltStorage) fetchSandboxState(sandboxID string) (State, error) {
+	var state State
+	if err := b.fetchResource(true, sandboxID, "", stateFileType, &state); err != nil {
+		return State{}, err
+	}
+
+	return state, nil
+}
+
+func (b *boltStorage) fetchSandboxNetwork(sandboxID string) (NetworkNamespace, error) {
+	var networkNS NetworkNamespace
+	if err := b.fetchResource(true, sandboxID, "", networkFileType, &networkNS); err != nil {
+		return NetworkNamespace{}, err
+	}
+
+	return networkNS, nil
+}
+
+func (b *boltStorage) storeSandboxNetwork(sandboxID string, networkNS NetworkNamespace) error {
+	return b.storeSandboxResource(sandboxID, networkFileType, networkNS)
+}
+
+// fetchSandboxHooks returns the hook executions recorded for sandboxID so
+// far, or an empty slice if none have been recorded yet.
+func (b *boltStorage) fetchSandboxHooks(sandboxID string) ([]HookExecution, error) {
+	var executions []HookExecution
+
+	if err := b.fetchResource(true, sandboxID, "", hooksFileType, &executions); err != nil {
+		if Is(err, errBoltResourceNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return executions, nil
+}
+
+func (b *boltStorage) fetchHypervisorState(sandboxID string, state interface{}) error {
+	return b.fetchResource(true, sandboxID, "", hypervisorFileType, state)
+}
+
+func (b *boltStorage) storeHypervisorState(sandboxID string, state interface{}) error {
+	return b.storeSandboxResource(sandboxID, hypervisorFileType, state)
+}
+
+func (b *boltStorage) fetchAgentState(sandboxID string, state interface{}) error {
+	return b.fetchResource(true, sandboxID, "", agentFileType, state)
+}
+
+func (b *boltStorage) storeAgentState(sandboxID string, state interface{}) error {
+	return b.storeSandboxResource(sandboxID, agentFileType, state)
+}
+
+func (b *boltStorage) storeContainerResource(sandboxID, containerID string, resource sandboxResource, data interface{}) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if containerID == "" {
+		return errNeedContainerID
+	}
+
+	return b.storeResource(false, sandboxID, containerID, resource, data)
+}
+
+func (b *boltStorage) deleteContainerResources(sandboxID, containerID string, resources []sandboxResource) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if containerID == "" {
+		return errNeedContainerID
+	}
+
+	db, err := b.openDB()
+	if err != nil {
+		return err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		sandboxBucket := tx.Bucket([]byte(boltSandboxesBucket))
+		if sandboxBucket == nil {
+			return nil
+		}
+
+		containers := sandboxBucket.Bucket([]byte(boltContainersBucket))
+		if containers == nil {
+			return nil
+		}
+
+		if containers.Bucket([]byte(containerID)) == nil {
+			return nil
+		}
+
+		return containers.DeleteBucket([]byte(containerID))
+	})
+
+	if resources == nil {
+		resources = []sandboxResource{configFileType, stateFileType, processFileType, mountsFileType, devicesFileType}
+	}
+
+	for _, resource := range resources {
+		storageMetrics.observe(storageOpDelete, resource, err)
+	}
+
+	return err
+}
+
+// fetchSandboxContainers returns the IDs of every container bucket
+// nested under sandboxID's boltContainersBucket that has a config
+// resource, skipping (and logging) any container bucket that does not.
+func (b *boltStorage) fetchSandboxContainers(sandboxID string) ([]string, error) {
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	db, err := b.openDB()
+	if err != nil {
+		return nil, err
+	}
+
+	configKey, err := boltResourceKey(configFileType)
+	if err != nil {
+		return nil, err
+	}
+
+	var containerIDs []string
+
+	err = db.View(func(tx *bolt.Tx) error {
+		sandboxBucket := tx.Bucket([]byte(boltSandboxesBucket))
+		if sandboxBucket == nil {
+			return nil
+		}
+
+		sandboxBucket = sandboxBucket.Bucket([]byte(sandboxID))
+		if sandboxBucket == nil {
+			return nil
+		}
+
+		containersBucket := sandboxBucket.Bucket([]byte(boltContainersBucket))
+		if containersBucket == nil {
+			return nil
+		}
+
+		return containersBucket.ForEach(func(k, v []byte) error {
+			if v != nil {
+				// Not a nested (container) bucket.
+				return nil
+			}
+
+			containerBucket := containersBucket.Bucket(k)
+			if containerBucket.Get([]byte(configKey)) == nil {
+				b.Logger().WithField("sandbox", sandboxID).WithField("container", string(k)).Warn("skipping container with no valid config")
+				return nil
+			}
+
+			containerIDs = append(containerIDs, string(k))
+			return nil
+		})
+	})
+
+	return containerIDs, err
+}
+
+func (b *boltStorage) fetchContainerConfig(sandboxID, containerID string) (ContainerConfig, error) {
+	var config ContainerConfig
+	if err := b.fetchResource(false, sandboxID, containerID, configFileType, &config); err != nil {
+		return ContainerConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (b *boltStorage) fetchContainerState(sandboxID, containerID string) (State, error) {
+	var state State
+	if err := b.fetchResource(false, sandboxID, containerID, stateFileType, &state); err != nil {
+		return State{}, err
+	}
+
+	return state, nil
+}
+
+// fetchContainerStateField returns only the "state" field of a
+// container's persisted State, mirroring filesystem's optimization of
+// avoiding a full unmarshal for callers that just need to know whether a
+// container is running.
+func (b *boltStorage) fetchContainerStateField(sandboxID, containerID string) (stateString, error) {
+	if err := b.commonResourceChecks(false, sandboxID, containerID, stateFileType); err != nil {
+		return "", err
+	}
+
+	key, err := boltResourceKey(stateFileType)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := b.fetchRaw(boltBucketPath(sandboxID, containerID), key)
+	if err != nil {
+		return "", err
+	}
+
+	var partial struct {
+		State stateString `json:"state"`
+	}
+
+	if err := json.Unmarshal(raw, &partial); err != nil {
+		return "", err
+	}
+
+	return partial.State, nil
+}
+
+func (b *boltStorage) fetchContainerProcess(sandboxID, containerID string) (Process, error) {
+	var process Process
+	if err := b.fetchResource(false, sandboxID, containerID, processFileType, &process); err != nil {
+		return Process{}, err
+	}
+
+	return process, nil
+}
+
+func (b *boltStorage) storeContainerProcess(sandboxID, containerID string, process Process) error {
+	return b.storeContainerResource(sandboxID, containerID, processFileType, process)
+}
+
+func (b *boltStorage) fetchContainerMounts(sandboxID, containerID string) ([]Mount, error) {
+	var mounts []Mount
+	if err := b.fetchResource(false, sandboxID, containerID, mountsFileType, &mounts); err != nil {
+		return []Mount{}, err
+	}
+
+	return mounts, nil
+}
+
+func (b *boltStorage) storeContainerMounts(sandboxID, containerID string, mounts []Mount) error {
+	return b.storeContainerResource(sandboxID, containerID, mountsFileType, mounts)
+}
+
+func (b *boltStorage) fetchContainerDevices(sandboxID, containerID string) ([]api.Device, error) {
+	var devices []api.Device
+	if err := b.fetchResource(false, sandboxID, containerID, devicesFileType, &devices); err != nil {
+		return []api.Device{}, err
+	}
+
+	return devices, nil
+}
+
+func (b *boltStorage) storeContainerDevices(sandboxID, containerID string, devices []api.Device) error {
+	return b.storeContainerResource(sandboxID, containerID, devicesFileType, devices)
+}
+
+func (b *boltStorage) fetchContainerDetachedProcesses(sandboxID, containerID string) ([]Process, error) {
+	var processes []Process
+	if err := b.fetchResource(false, sandboxID, containerID, detachedProcessesFileType, &processes); err != nil {
+		return []Process{}, err
+	}
+
+	return processes, nil
+}
+
+func (b *boltStorage) storeContainerDetachedProcesses(sandboxID, containerID string, processes []Process) error {
+	return b.storeContainerResource(sandboxID, containerID, detachedProcessesFileType, processes)
+}
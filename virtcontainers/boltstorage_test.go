@@ -0,0 +1,49 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBoltStorageConformance(t *testing.T) {
+	RunStorageConformance(t, func() resourceStorage {
+		return &boltStorage{}
+	})
+}
+
+// TestBoltStorageSecondInstanceSharesHandle guards against a regression
+// where openDB memoized *bolt.DB per boltStorage instance instead of per
+// process: bolt.Open takes an exclusive flock on boltDBPath for the life
+// of the handle, so a second boltStorage built while a first is still
+// alive (as fetchSandbox's lookup and createSandbox's own boltStorage do)
+// would block forever reopening the same file.
+func TestBoltStorageSecondInstanceSharesHandle(t *testing.T) {
+	defer closeBoltDB()
+
+	first := &boltStorage{}
+	if _, err := first.openDB(); err != nil {
+		t.Fatalf("failed to open first boltStorage instance: %v", err)
+	}
+
+	second := &boltStorage{}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := second.openDB()
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("second boltStorage instance failed to open: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("second boltStorage instance deadlocked opening the file the first instance already holds open")
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "fmt"
+
+// bootSourceAnnotation lets a pod pick which boot asset the hypervisor
+// should use, overriding whatever the sandbox's configured image/initrd
+// paths would otherwise select. This is useful on nodes that keep both
+// an image and an initrd configured so individual pods can choose
+// faster initrd boot without a host-wide config change.
+const bootSourceAnnotation = "kata.boot_source"
+
+const (
+	bootSourceImage  = "image"
+	bootSourceInitrd = "initrd"
+)
+
+// resolveBootSource applies the kata.boot_source annotation to config's
+// boot asset selection. It is validated against the assets actually
+// configured for this sandbox, returning an error if the requested
+// source has no corresponding path so a misconfigured pod fails fast at
+// create time instead of silently booting from whichever asset the
+// hypervisor happens to prefer. The unselected asset is cleared, custom
+// asset annotation included, so hypervisor code that picks between the
+// two, e.g. qemu's "use initrd if one is set" rule, honors the request.
+func resolveBootSource(config *SandboxConfig) error {
+	source, ok := config.Annotations[bootSourceAnnotation]
+	if !ok || source == "" {
+		return nil
+	}
+
+	hc := &config.HypervisorConfig
+
+	imagePath, err := hc.ImageAssetPath()
+	if err != nil {
+		return err
+	}
+
+	initrdPath, err := hc.InitrdAssetPath()
+	if err != nil {
+		return err
+	}
+
+	switch source {
+	case bootSourceImage:
+		if imagePath == "" {
+			return fmt.Errorf("the %s annotation requests %s but no image path is configured for this sandbox", bootSourceAnnotation, bootSourceImage)
+		}
+		hc.InitrdPath = ""
+		delete(hc.customAssets, initrdAsset)
+	case bootSourceInitrd:
+		if initrdPath == "" {
+			return fmt.Errorf("the %s annotation requests %s but no initrd path is configured for this sandbox", bootSourceAnnotation, bootSourceInitrd)
+		}
+		hc.ImagePath = ""
+		delete(hc.customAssets, imageAsset)
+	default:
+		return fmt.Errorf("the %s annotation must be %q or %q, got %q", bootSourceAnnotation, bootSourceImage, bootSourceInitrd, source)
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveBootSourceNoopWhenAnnotationAbsent(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{ImagePath: "/image", InitrdPath: "/initrd"},
+	}
+
+	assert.NoError(resolveBootSource(config))
+	assert.Equal("/image", config.HypervisorConfig.ImagePath)
+	assert.Equal("/initrd", config.HypervisorConfig.InitrdPath)
+}
+
+func TestResolveBootSourceSelectsImage(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{ImagePath: "/image", InitrdPath: "/initrd"},
+		Annotations:      map[string]string{bootSourceAnnotation: bootSourceImage},
+	}
+
+	assert.NoError(resolveBootSource(config))
+	assert.Equal("/image", config.HypervisorConfig.ImagePath)
+	assert.Empty(config.HypervisorConfig.InitrdPath)
+}
+
+func TestResolveBootSourceSelectsInitrd(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{ImagePath: "/image", InitrdPath: "/initrd"},
+		Annotations:      map[string]string{bootSourceAnnotation: bootSourceInitrd},
+	}
+
+	assert.NoError(resolveBootSource(config))
+	assert.Empty(config.HypervisorConfig.ImagePath)
+	assert.Equal("/initrd", config.HypervisorConfig.InitrdPath)
+}
+
+func TestResolveBootSourceErrorsWhenRequestedAssetMissing(t *testing.T) {
+	assert := assert.New(t)
+
+	imageOnly := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{ImagePath: "/image"},
+		Annotations:      map[string]string{bootSourceAnnotation: bootSourceInitrd},
+	}
+	assert.Error(resolveBootSource(imageOnly))
+
+	initrdOnly := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{InitrdPath: "/initrd"},
+		Annotations:      map[string]string{bootSourceAnnotation: bootSourceImage},
+	}
+	assert.Error(resolveBootSource(initrdOnly))
+}
+
+func TestResolveBootSourceErrorsOnUnknownValue(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{ImagePath: "/image", InitrdPath: "/initrd"},
+		Annotations:      map[string]string{bootSourceAnnotation: "disk"},
+	}
+
+	assert.Error(resolveBootSource(config))
+}
@@ -8,6 +8,10 @@ package virtcontainers
 const (
 	blockDeviceSupport = 1 << iota
 	blockDeviceHotplugSupport
+	memoryBalloonSupport
+	cpuHotplugSupport
+	memoryHotplugSupport
+	onlineCPUMemSupport
 )
 
 type capabilities struct {
@@ -35,3 +39,54 @@ func (caps *capabilities) isBlockDeviceHotplugSupported() bool {
 func (caps *capabilities) setBlockDeviceHotplugSupport() {
 	caps.flags |= blockDeviceHotplugSupport
 }
+
+func (caps *capabilities) isMemoryBalloonSupported() bool {
+	if caps.flags&memoryBalloonSupport != 0 {
+		return true
+	}
+	return false
+}
+
+func (caps *capabilities) setMemoryBalloonSupport() {
+	caps.flags |= memoryBalloonSupport
+}
+
+// isCPUHotplugSupported tells whether the agent can hot add CPUs to the
+// guest without a reboot.
+func (caps *capabilities) isCPUHotplugSupported() bool {
+	if caps.flags&cpuHotplugSupport != 0 {
+		return true
+	}
+	return false
+}
+
+func (caps *capabilities) setCPUHotplugSupport() {
+	caps.flags |= cpuHotplugSupport
+}
+
+// isMemoryHotplugSupported tells whether the agent can hot add memory
+// to the guest without a reboot.
+func (caps *capabilities) isMemoryHotplugSupported() bool {
+	if caps.flags&memoryHotplugSupport != 0 {
+		return true
+	}
+	return false
+}
+
+func (caps *capabilities) setMemoryHotplugSupport() {
+	caps.flags |= memoryHotplugSupport
+}
+
+// isOnlineCPUMemSupported tells whether the agent can bring newly
+// hot-added CPUs and memory online inside the guest, i.e. whether
+// onlineCPUMem is expected to work rather than being a no-op or error.
+func (caps *capabilities) isOnlineCPUMemSupported() bool {
+	if caps.flags&onlineCPUMemSupport != 0 {
+		return true
+	}
+	return false
+}
+
+func (caps *capabilities) setOnlineCPUMemSupport() {
+	caps.flags |= onlineCPUMemSupport
+}
@@ -34,3 +34,59 @@ func TestBlockDeviceHotplugCapability(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestMemoryBalloonCapability(t *testing.T) {
+	var caps capabilities
+
+	if caps.isMemoryBalloonSupported() {
+		t.Fatal()
+	}
+
+	caps.setMemoryBalloonSupport()
+
+	if !caps.isMemoryBalloonSupported() {
+		t.Fatal()
+	}
+}
+
+func TestCPUHotplugCapability(t *testing.T) {
+	var caps capabilities
+
+	if caps.isCPUHotplugSupported() {
+		t.Fatal()
+	}
+
+	caps.setCPUHotplugSupport()
+
+	if !caps.isCPUHotplugSupported() {
+		t.Fatal()
+	}
+}
+
+func TestMemoryHotplugCapability(t *testing.T) {
+	var caps capabilities
+
+	if caps.isMemoryHotplugSupported() {
+		t.Fatal()
+	}
+
+	caps.setMemoryHotplugSupport()
+
+	if !caps.isMemoryHotplugSupported() {
+		t.Fatal()
+	}
+}
+
+func TestOnlineCPUMemCapability(t *testing.T) {
+	var caps capabilities
+
+	if caps.isOnlineCPUMemSupported() {
+		t.Fatal()
+	}
+
+	caps.setOnlineCPUMemSupport()
+
+	if !caps.isOnlineCPUMemSupported() {
+		t.Fatal()
+	}
+}
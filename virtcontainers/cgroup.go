@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// cgroupProcsFile is the cgroupfs file a process' pid is written to in
+// order to move it into a cgroup, as described in cgroups(7).
+const cgroupProcsFile = "cgroup.procs"
+
+// cgroupFsType is the filesystem type statfs(2) reports for a cgroupfs
+// mount (CGROUP_SUPER_MAGIC in linux/magic.h).
+const cgroupFsType = 0x27e0eb
+
+// validateSandboxCgroupPath ensures path is an absolute, existing
+// directory backed by cgroupfs, suitable for placing a sandbox's VMM and
+// helper processes under.
+func validateSandboxCgroupPath(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("sandbox cgroup path %v must be absolute", path)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("sandbox cgroup path %v is not accessible: %v", path, err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("sandbox cgroup path %v is not a directory", path)
+	}
+
+	var statFs syscall.Statfs_t
+	if err := syscall.Statfs(path, &statFs); err != nil {
+		return fmt.Errorf("sandbox cgroup path %v is not accessible: %v", path, err)
+	}
+
+	if statFs.Type != int64(cgroupFsType) {
+		return fmt.Errorf("sandbox cgroup path %v is not backed by cgroupfs", path)
+	}
+
+	return nil
+}
+
+// cgroupWriter adds a process to a host cgroup. It is an interface so
+// tests can substitute a fake in place of real cgroupfs I/O.
+type cgroupWriter interface {
+	// addPID writes pid into the cgroup at path, so the kernel accounts
+	// its resource usage (and that of anything it subsequently forks)
+	// there.
+	addPID(path string, pid int) error
+}
+
+// fsCgroupWriter is the cgroupWriter used outside of tests. It adds a
+// process to a cgroup the way cgroups(7) describes: by writing its pid
+// to the cgroup's cgroup.procs file.
+type fsCgroupWriter struct{}
+
+func (fsCgroupWriter) addPID(path string, pid int) error {
+	procsFile := filepath.Join(path, cgroupProcsFile)
+
+	f, err := os.OpenFile(procsFile, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("could not open %v: %v", procsFile, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(strconv.Itoa(pid)); err != nil {
+		return fmt.Errorf("could not write pid %d to %v: %v", pid, procsFile, err)
+	}
+
+	return nil
+}
+
+// defaultCgroupWriter is the cgroupWriter used to place a sandbox's
+// processes into SandboxConfig.SandboxCgroupPath. It is a variable so
+// tests can substitute a fake in its place.
+var defaultCgroupWriter cgroupWriter = fsCgroupWriter{}
+
+// addSandboxProcessesToCgroup adds s's VMM and, if one was started, its
+// proxy process to SandboxConfig.SandboxCgroupPath, so a node's
+// scheduler can account for their combined resource usage under a
+// single host cgroup. It is only called once SandboxCgroupPath has
+// already been confirmed non-empty.
+func (s *Sandbox) addSandboxProcessesToCgroup() error {
+	path := s.config.SandboxCgroupPath
+
+	vmmPid, err := s.hypervisor.pid()
+	if err != nil {
+		return fmt.Errorf("could not determine VMM pid: %v", err)
+	}
+
+	if err := defaultCgroupWriter.addPID(path, vmmPid); err != nil {
+		return err
+	}
+
+	if proxyPid := s.agent.proxyPID(); proxyPid > 0 {
+		if err := defaultCgroupWriter.addPID(path, proxyPid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
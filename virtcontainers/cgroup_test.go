@@ -0,0 +1,119 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"testing"
+)
+
+// fakeCgroupPidHypervisor behaves like mockHypervisor except pid()
+// reports a fixed, fake VMM pid.
+type fakeCgroupPidHypervisor struct {
+	mockHypervisor
+	reportedPid int
+}
+
+func (h *fakeCgroupPidHypervisor) pid() (int, error) {
+	return h.reportedPid, nil
+}
+
+// fakeProxyPidAgent behaves like noopAgent except proxyPID() reports a
+// fixed, fake proxy pid.
+type fakeProxyPidAgent struct {
+	noopAgent
+	reportedProxyPid int
+}
+
+func (a *fakeProxyPidAgent) proxyPID() int {
+	return a.reportedProxyPid
+}
+
+// fakeCgroupWriter is a cgroupWriter that records the pids it was asked
+// to add, instead of touching real cgroupfs.
+type fakeCgroupWriter struct {
+	path string
+	pids []int
+}
+
+func (w *fakeCgroupWriter) addPID(path string, pid int) error {
+	if path != w.path {
+		return fmt.Errorf("unexpected cgroup path %v, want %v", path, w.path)
+	}
+
+	w.pids = append(w.pids, pid)
+
+	return nil
+}
+
+func TestAddSandboxProcessesToCgroupWritesVMMPid(t *testing.T) {
+	const (
+		fakeVMMPid = 424244
+		fakeCgroup = "/sys/fs/cgroup/memory/kata/sbx1"
+	)
+
+	writer := &fakeCgroupWriter{path: fakeCgroup}
+	origWriter := defaultCgroupWriter
+	defaultCgroupWriter = writer
+	defer func() { defaultCgroupWriter = origWriter }()
+
+	s := &Sandbox{
+		hypervisor: &fakeCgroupPidHypervisor{reportedPid: fakeVMMPid},
+		agent:      &noopAgent{},
+		config: &SandboxConfig{
+			SandboxCgroupPath: fakeCgroup,
+		},
+	}
+
+	if err := s.addSandboxProcessesToCgroup(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(writer.pids) != 1 || writer.pids[0] != fakeVMMPid {
+		t.Fatalf("expected the VMM pid %d to be written to %v, got %v", fakeVMMPid, fakeCgroup, writer.pids)
+	}
+}
+
+func TestAddSandboxProcessesToCgroupAlsoWritesProxyPid(t *testing.T) {
+	const (
+		fakeVMMPid   = 424245
+		fakeProxyPid = 424246
+		fakeCgroup   = "/sys/fs/cgroup/memory/kata/sbx2"
+	)
+
+	writer := &fakeCgroupWriter{path: fakeCgroup}
+	origWriter := defaultCgroupWriter
+	defaultCgroupWriter = writer
+	defer func() { defaultCgroupWriter = origWriter }()
+
+	s := &Sandbox{
+		hypervisor: &fakeCgroupPidHypervisor{reportedPid: fakeVMMPid},
+		agent:      &fakeProxyPidAgent{reportedProxyPid: fakeProxyPid},
+		config: &SandboxConfig{
+			SandboxCgroupPath: fakeCgroup,
+		},
+	}
+
+	if err := s.addSandboxProcessesToCgroup(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(writer.pids) != 2 || writer.pids[0] != fakeVMMPid || writer.pids[1] != fakeProxyPid {
+		t.Fatalf("expected the VMM and proxy pids to be written to %v, got %v", fakeCgroup, writer.pids)
+	}
+}
+
+func TestValidateSandboxCgroupPathRejectsRelativePath(t *testing.T) {
+	if err := validateSandboxCgroupPath("relative/path"); err == nil {
+		t.Fatal("expected an error for a relative cgroup path")
+	}
+}
+
+func TestValidateSandboxCgroupPathRejectsMissingPath(t *testing.T) {
+	if err := validateSandboxCgroupPath("/this/path/should/not/exist/on/any/host"); err == nil {
+		t.Fatal("expected an error for a nonexistent cgroup path")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultClockSkewCheckPeriod is how often a running sandbox's guest
+	// clock is compared against the host's, when
+	// SandboxConfig.ClockSkewCheckPeriod is unset.
+	defaultClockSkewCheckPeriod = time.Minute
+
+	// defaultClockSkewThreshold is how far the guest clock may drift
+	// from the host's before a warning is logged, when
+	// SandboxConfig.ClockSkewThreshold is unset.
+	defaultClockSkewThreshold = 2 * time.Second
+)
+
+// clockSkewFromGuestTime returns the absolute difference between the
+// current host time and guestTime.
+func clockSkewFromGuestTime(guestTime time.Time) time.Duration {
+	skew := time.Since(guestTime)
+	if skew < 0 {
+		skew = -skew
+	}
+
+	return skew
+}
+
+// clockSkewMonitor periodically compares a sandbox's guest wall-clock
+// time against host time and records the result, so drift that would
+// otherwise surface as confusing TLS certificate validation failures
+// inside the guest gets caught and logged early.
+type clockSkewMonitor struct {
+	sync.Mutex
+
+	sandbox   *Sandbox
+	period    time.Duration
+	threshold time.Duration
+
+	running bool
+	stopCh  chan bool
+	wg      sync.WaitGroup
+}
+
+func newClockSkewMonitor(s *Sandbox) *clockSkewMonitor {
+	period := s.config.ClockSkewCheckPeriod
+	if period == 0 {
+		period = defaultClockSkewCheckPeriod
+	}
+
+	threshold := s.config.ClockSkewThreshold
+	if threshold == 0 {
+		threshold = defaultClockSkewThreshold
+	}
+
+	return &clockSkewMonitor{
+		sandbox:   s,
+		period:    period,
+		threshold: threshold,
+		stopCh:    make(chan bool, 1),
+	}
+}
+
+// start begins periodically checking clock skew. It is a no-op if the
+// monitor is already running.
+func (c *clockSkewMonitor) start() {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.running {
+		return
+	}
+	c.running = true
+	c.wg.Add(1)
+
+	go func() {
+		tick := time.NewTicker(c.period)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-c.stopCh:
+				c.wg.Done()
+				return
+			case <-tick.C:
+				c.check()
+			}
+		}
+	}()
+}
+
+// stop halts the periodic check. It is a no-op if the monitor is not
+// running.
+func (c *clockSkewMonitor) stop() {
+	c.Lock()
+	if !c.running {
+		c.Unlock()
+		return
+	}
+	c.running = false
+	c.Unlock()
+
+	c.stopCh <- true
+	c.wg.Wait()
+}
+
+// check measures the current guest/host clock skew and records it on
+// the sandbox, logging a warning if it exceeds the configured
+// threshold.
+func (c *clockSkewMonitor) check() {
+	guestTime, err := c.sandbox.agent.getGuestTime(c.sandbox)
+	if err != nil {
+		c.sandbox.Logger().WithError(err).Warn("could not measure guest clock skew")
+		return
+	}
+
+	skew := clockSkewFromGuestTime(guestTime)
+
+	if err := c.sandbox.recordClockSkew(skew); err != nil {
+		c.sandbox.Logger().WithError(err).Warn("could not record guest clock skew")
+	}
+
+	if skew > c.threshold {
+		c.sandbox.Logger().WithField("skew", skew).Warnf("guest clock has drifted more than %s from the host clock", c.threshold)
+	}
+}
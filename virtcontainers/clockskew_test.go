@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeClockSkewAgent behaves like noopAgent except getGuestTime can be
+// made to report an arbitrary guest time or fail outright.
+type fakeClockSkewAgent struct {
+	noopAgent
+	guestTime time.Time
+	err       error
+}
+
+func (a *fakeClockSkewAgent) getGuestTime(sandbox *Sandbox) (time.Time, error) {
+	return a.guestTime, a.err
+}
+
+func TestClockSkewMonitorCheckRecordsSkew(t *testing.T) {
+	agent := &fakeClockSkewAgent{guestTime: time.Now().Add(-5 * time.Second)}
+	sandbox := &Sandbox{agent: agent, storage: &filesystem{}}
+
+	monitor := newClockSkewMonitor(sandbox)
+	monitor.check()
+
+	if sandbox.state.ClockSkewCheckedAt.IsZero() {
+		t.Fatal("expected a clock skew measurement to be recorded")
+	}
+
+	if sandbox.state.ClockSkew < 4*time.Second || sandbox.state.ClockSkew > 6*time.Second {
+		t.Fatalf("expected the recorded skew to be around 5s, got %s", sandbox.state.ClockSkew)
+	}
+}
+
+func TestClockSkewMonitorCheckAgentErrorSkipsRecording(t *testing.T) {
+	agent := &fakeClockSkewAgent{err: fmt.Errorf("agent unreachable")}
+	sandbox := &Sandbox{agent: agent, storage: &filesystem{}}
+
+	monitor := newClockSkewMonitor(sandbox)
+	monitor.check()
+
+	if !sandbox.state.ClockSkewCheckedAt.IsZero() {
+		t.Fatal("expected no clock skew measurement to be recorded when the agent errors")
+	}
+}
+
+func TestSandboxClockSkewUsesRecordedValueWhenNotRunning(t *testing.T) {
+	sandbox := &Sandbox{
+		state: State{
+			ClockSkew:          3 * time.Second,
+			ClockSkewCheckedAt: time.Now(),
+		},
+	}
+
+	skew, measured := sandbox.ClockSkew()
+	if !measured {
+		t.Fatal("expected a previously recorded measurement to be reported")
+	}
+
+	if skew != 3*time.Second {
+		t.Fatalf("expected the recorded skew of 3s, got %s", skew)
+	}
+}
+
+func TestSandboxClockSkewNotMeasuredYet(t *testing.T) {
+	sandbox := &Sandbox{}
+
+	_, measured := sandbox.ClockSkew()
+	if measured {
+		t.Fatal("expected no measurement to be reported before any check has run")
+	}
+}
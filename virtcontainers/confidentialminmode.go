@@ -0,0 +1,63 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "fmt"
+
+// minConfidentialModeAnnotation lets a workload refuse to launch unless
+// the host can provide at least a given confidential-computing mode,
+// e.g. a workload requiring kata.confidential.min_mode: SEV-SNP should
+// not silently run under plain SEV on a host that lacks SEV-SNP.
+const minConfidentialModeAnnotation = "kata.confidential.min_mode"
+
+// confidentialModeRank orders the ConfidentialMode* constants from
+// weakest to strongest so two of them can be compared. It returns -1 for
+// a value that is not one of the known constants.
+func confidentialModeRank(mode string) int {
+	switch mode {
+	case ConfidentialModeNone:
+		return 0
+	case ConfidentialModeSEV:
+		return 1
+	case ConfidentialModeSEVES:
+		return 2
+	case ConfidentialModeSEVSNP:
+		return 3
+	default:
+		return -1
+	}
+}
+
+// resolveMinConfidentialMode applies the kata.confidential.min_mode
+// annotation: when set, it must name one of the ConfidentialMode*
+// constants, and the mode config's effective hypervisor settings put the
+// sandbox's VM into (per confidentialModeFromConfig) must be at least as
+// strong, or the sandbox is rejected outright rather than silently
+// launched under a weaker mode than the workload requires. It must be
+// called after resolveSEVDisable, so the comparison reflects the
+// sandbox's actual effective launch state rather than merely the
+// requested one.
+func resolveMinConfidentialMode(config *SandboxConfig) error {
+	minMode, ok := config.Annotations[minConfidentialModeAnnotation]
+	if !ok {
+		return nil
+	}
+
+	minRank := confidentialModeRank(minMode)
+	if minRank < 0 {
+		return fmt.Errorf("invalid %s annotation %q: must be one of %s, %s, %s, %s",
+			minConfidentialModeAnnotation, minMode,
+			ConfidentialModeNone, ConfidentialModeSEV, ConfidentialModeSEVES, ConfidentialModeSEVSNP)
+	}
+
+	actualMode := confidentialModeFromConfig(config)
+	if confidentialModeRank(actualMode) < minRank {
+		return fmt.Errorf("%s annotation requires confidential mode %s, but the host can only provide %s",
+			minConfidentialModeAnnotation, minMode, actualMode)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfidentialModeRank(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(confidentialModeRank(ConfidentialModeSEVSNP) > confidentialModeRank(ConfidentialModeSEVES))
+	assert.True(confidentialModeRank(ConfidentialModeSEVES) > confidentialModeRank(ConfidentialModeSEV))
+	assert.True(confidentialModeRank(ConfidentialModeSEV) > confidentialModeRank(ConfidentialModeNone))
+	assert.Equal(-1, confidentialModeRank("bogus"))
+}
+
+func TestResolveMinConfidentialModeNoopWhenAnnotationAbsent(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{MemEncrypt: false},
+	}
+
+	assert.NoError(resolveMinConfidentialMode(config))
+}
+
+func TestResolveMinConfidentialModeRejectsInvalidValue(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		Annotations: map[string]string{minConfidentialModeAnnotation: "bogus"},
+	}
+
+	assert.Error(resolveMinConfidentialMode(config))
+}
+
+func TestResolveMinConfidentialModeSatisfied(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{MemEncrypt: true},
+		Annotations:      map[string]string{minConfidentialModeAnnotation: ConfidentialModeSEV},
+	}
+
+	assert.NoError(resolveMinConfidentialMode(config))
+}
+
+func TestResolveMinConfidentialModeUnsatisfied(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{MemEncrypt: false},
+		Annotations:      map[string]string{minConfidentialModeAnnotation: ConfidentialModeSEVSNP},
+	}
+
+	err := resolveMinConfidentialMode(config)
+	assert.Error(err)
+}
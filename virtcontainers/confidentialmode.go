@@ -0,0 +1,46 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+// Confidential-computing modes a sandbox's VM can report having launched
+// under. These are the values surfaced in State.ConfidentialMode,
+// SandboxStatus, and the CLI's list/dump-state output.
+const (
+	// ConfidentialModeNone means the sandbox's VM has no
+	// confidential-computing protections active.
+	ConfidentialModeNone = "none"
+
+	// ConfidentialModeSEV means the sandbox's VM is running under plain
+	// AMD SEV memory encryption.
+	ConfidentialModeSEV = "SEV"
+
+	// ConfidentialModeSEVES means the sandbox's VM is running under AMD
+	// SEV-ES (encrypted state).
+	ConfidentialModeSEVES = "SEV-ES"
+
+	// ConfidentialModeSEVSNP means the sandbox's VM is running under AMD
+	// SEV-SNP (secure nested paging).
+	ConfidentialModeSEVSNP = "SEV-SNP"
+)
+
+// confidentialModeFromConfig reports which confidential-computing mode
+// config's effective hypervisor settings put the sandbox's VM into. It
+// must be called after resolveSEVDisable has been applied to config, so
+// MemEncrypt reflects whether SEV is actually active for this sandbox
+// rather than merely requested.
+//
+// The runtime does not yet negotiate SEV-ES or SEV-SNP capabilities
+// separately from plain SEV memory encryption, so ConfidentialModeSEVES
+// and ConfidentialModeSEVSNP are never produced here today; they exist
+// so State.ConfidentialMode has a stable set of values to round-trip
+// once that negotiation is added.
+func confidentialModeFromConfig(config *SandboxConfig) string {
+	if config.HypervisorConfig.MemEncrypt {
+		return ConfidentialModeSEV
+	}
+
+	return ConfidentialModeNone
+}
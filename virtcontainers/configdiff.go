@@ -0,0 +1,106 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ConfigDiffEntry describes a single named aspect of a sandbox
+// configuration that differs between two sandboxes, as reported by
+// CompareSandboxConfigs. Field identifies what was compared (e.g.
+// "HypervisorPath", "KernelParams", "DefaultVCPUs"); First and Second
+// hold the corresponding values from each sandbox.
+type ConfigDiffEntry struct {
+	Field  string      `json:"field"`
+	First  interface{} `json:"first"`
+	Second interface{} `json:"second"`
+}
+
+// SandboxConfigDiff is a structured diff between the configurations of
+// two sandboxes, as produced by CompareSandboxConfigs. Only the aspects
+// that actually differ are included.
+type SandboxConfigDiff struct {
+	FirstID  string            `json:"firstID"`
+	SecondID string            `json:"secondID"`
+	Entries  []ConfigDiffEntry `json:"entries"`
+}
+
+// diffValue appends an entry to entries if first and second are not
+// equal, using reflect.DeepEqual so slices and maps compare by value.
+func diffValue(entries []ConfigDiffEntry, field string, first, second interface{}) []ConfigDiffEntry {
+	if reflect.DeepEqual(first, second) {
+		return entries
+	}
+
+	return append(entries, ConfigDiffEntry{
+		Field:  field,
+		First:  first,
+		Second: second,
+	})
+}
+
+// containerDeviceIDs returns the sorted-by-appearance list of device IDs
+// requested by every container in config, for a coarse comparison of the
+// devices attached to two sandboxes.
+func containerDeviceIDs(config SandboxConfig) []string {
+	var ids []string
+
+	for _, c := range config.Containers {
+		for _, d := range c.DeviceInfos {
+			ids = append(ids, d.ID)
+		}
+	}
+
+	return ids
+}
+
+// containerMounts returns a human-readable summary of every mount
+// requested by every container in config, for a coarse comparison of
+// the mounts configured for two sandboxes.
+func containerMounts(config SandboxConfig) []string {
+	var mounts []string
+
+	for _, c := range config.Containers {
+		for _, m := range c.Mounts {
+			mounts = append(mounts, fmt.Sprintf("%s:%s", m.Source, m.Destination))
+		}
+	}
+
+	return mounts
+}
+
+// diffSandboxConfigs computes a SandboxConfigDiff between first and
+// second, covering the hypervisor paths, kernel parameters, vcpu/memory
+// sizing, devices, and mounts configured for each sandbox.
+func diffSandboxConfigs(firstID, secondID string, first, second SandboxConfig) SandboxConfigDiff {
+	var entries []ConfigDiffEntry
+
+	firstHv := first.HypervisorConfig
+	secondHv := second.HypervisorConfig
+
+	entries = diffValue(entries, "HypervisorPath", firstHv.HypervisorPath, secondHv.HypervisorPath)
+	entries = diffValue(entries, "KernelPath", firstHv.KernelPath, secondHv.KernelPath)
+	entries = diffValue(entries, "ImagePath", firstHv.ImagePath, secondHv.ImagePath)
+	entries = diffValue(entries, "InitrdPath", firstHv.InitrdPath, secondHv.InitrdPath)
+	entries = diffValue(entries, "FirmwarePath", firstHv.FirmwarePath, secondHv.FirmwarePath)
+
+	entries = diffValue(entries, "KernelParams", firstHv.KernelParams, secondHv.KernelParams)
+
+	entries = diffValue(entries, "DefaultVCPUs", firstHv.DefaultVCPUs, secondHv.DefaultVCPUs)
+	entries = diffValue(entries, "DefaultMaxVCPUs", firstHv.DefaultMaxVCPUs, secondHv.DefaultMaxVCPUs)
+	entries = diffValue(entries, "DefaultMemSz", firstHv.DefaultMemSz, secondHv.DefaultMemSz)
+
+	entries = diffValue(entries, "Devices", containerDeviceIDs(first), containerDeviceIDs(second))
+	entries = diffValue(entries, "Mounts", containerMounts(first), containerMounts(second))
+
+	return SandboxConfigDiff{
+		FirstID:  firstID,
+		SecondID: secondID,
+		Entries:  entries,
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+)
+
+func TestDiffSandboxConfigsNoDifferences(t *testing.T) {
+	config := SandboxConfig{
+		HypervisorConfig: HypervisorConfig{
+			HypervisorPath: "/usr/bin/qemu",
+			KernelPath:     "/usr/share/kata/vmlinuz",
+			DefaultVCPUs:   1,
+			DefaultMemSz:   2048,
+		},
+	}
+
+	diff := diffSandboxConfigs("sandbox1", "sandbox2", config, config)
+
+	if len(diff.Entries) != 0 {
+		t.Fatalf("expected no differences between identical configs, got %+v", diff.Entries)
+	}
+}
+
+func TestDiffSandboxConfigsReportsOnlyDifferingFields(t *testing.T) {
+	first := SandboxConfig{
+		HypervisorConfig: HypervisorConfig{
+			HypervisorPath: "/usr/bin/qemu",
+			KernelPath:     "/usr/share/kata/vmlinuz",
+			DefaultVCPUs:   1,
+			DefaultMemSz:   2048,
+		},
+	}
+
+	second := first
+	second.HypervisorConfig.DefaultVCPUs = 4
+	second.HypervisorConfig.KernelParams = []Param{{Key: "debug", Value: "1"}}
+
+	diff := diffSandboxConfigs("sandbox1", "sandbox2", first, second)
+
+	if diff.FirstID != "sandbox1" || diff.SecondID != "sandbox2" {
+		t.Fatalf("unexpected sandbox IDs in diff: %+v", diff)
+	}
+
+	if len(diff.Entries) != 2 {
+		t.Fatalf("expected exactly 2 differing fields, got %d: %+v", len(diff.Entries), diff.Entries)
+	}
+
+	seen := make(map[string]bool)
+	for _, e := range diff.Entries {
+		seen[e.Field] = true
+	}
+
+	if !seen["DefaultVCPUs"] || !seen["KernelParams"] {
+		t.Fatalf("expected DefaultVCPUs and KernelParams in diff, got %+v", diff.Entries)
+	}
+
+	if seen["HypervisorPath"] || seen["KernelPath"] || seen["DefaultMemSz"] {
+		t.Fatalf("identical fields should not appear in the diff, got %+v", diff.Entries)
+	}
+}
+
+func TestDiffSandboxConfigsDevicesAndMounts(t *testing.T) {
+	first := SandboxConfig{
+		Containers: []ContainerConfig{
+			{
+				Mounts: []Mount{{Source: "/host/data", Destination: "/data"}},
+			},
+		},
+	}
+
+	second := SandboxConfig{
+		Containers: []ContainerConfig{
+			{
+				Mounts: []Mount{{Source: "/host/other", Destination: "/data"}},
+			},
+		},
+	}
+
+	diff := diffSandboxConfigs("sandbox1", "sandbox2", first, second)
+
+	if len(diff.Entries) != 1 || diff.Entries[0].Field != "Mounts" {
+		t.Fatalf("expected only a Mounts difference, got %+v", diff.Entries)
+	}
+}
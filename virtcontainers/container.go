@@ -10,6 +10,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -41,6 +42,19 @@ type Process struct {
 	Pid int
 
 	StartTime time.Time
+
+	// StopTime is when the process was last observed to have stopped
+	// running, e.g. as recorded by Container.stop(). It is the zero
+	// Time if the process has never stopped.
+	StopTime time.Time
+
+	// Signaled is true if the process was terminated by a signal
+	// rather than exiting normally.
+	Signaled bool
+
+	// TermSignal is the signal that terminated the process.
+	// It is only meaningful when Signaled is true.
+	TermSignal int
 }
 
 // ContainerStatus describes a container status.
@@ -51,10 +65,53 @@ type ContainerStatus struct {
 	StartTime time.Time
 	RootFs    string
 
+	// Signaled and TermSignal report whether the container's process was
+	// terminated by a signal, mirroring the Process fields of the same name.
+	Signaled   bool
+	TermSignal int
+
 	// Annotations allow clients to store arbitrary values,
 	// for example to add additional status values required
 	// to support particular specifications.
 	Annotations map[string]string
+
+	// SpecHashMismatch is true when the OCI spec found on disk no longer
+	// matches the hash recorded at container creation, indicating the
+	// bundle was edited after the container started. See
+	// verifySpecUnchanged.
+	SpecHashMismatch bool
+
+	// VMMPid is the host process ID of the hypervisor running the
+	// container's sandbox, for correlating with host-level tooling.
+	// It is zero if the VMM pid could not be determined.
+	VMMPid int
+
+	// AgentReachable reports whether the guest agent responded to a
+	// liveness check when this status was read. See checkAgentHealth.
+	AgentReachable bool
+
+	// MemEncrypted reports whether the container's sandbox is running
+	// with SEV memory encryption enabled.
+	MemEncrypted bool
+
+	// ConfidentialMode reports which confidential-computing mode the
+	// container's sandbox VM is running under (one of the
+	// ConfidentialMode* constants), as negotiated at launch.
+	ConfidentialMode string
+
+	// CreatedAt is when the container was first created.
+	CreatedAt time.Time
+
+	// ExitTime is when the container's process was last observed to
+	// have stopped running. It is the zero Time if the container has
+	// never stopped.
+	ExitTime time.Time
+
+	// Age is how long has elapsed since the container was created. It
+	// is computed from a monotonic clock reference where available
+	// (see Container.Age), so unlike CreatedAt it cannot appear to go
+	// backward because of a wall-clock adjustment.
+	Age time.Duration
 }
 
 // ThrottlingData gather the date related to container cpu throttling.
@@ -168,6 +225,22 @@ type CgroupStats struct {
 // ContainerStats describes a container stats.
 type ContainerStats struct {
 	CgroupStats *CgroupStats
+
+	// MemoryPeak is the high-water mark of the container's memory
+	// usage, derived from the guest's memory.max_usage_in_bytes cgroup
+	// file (memory.peak on a cgroup v2 guest). It is zero if unknown.
+	MemoryPeak uint64
+}
+
+// memoryPeak derives the memory high-water mark from cg, for use as
+// ContainerStats.MemoryPeak. cg may be nil, in which case the peak is
+// unknown and zero is returned.
+func memoryPeak(cg *CgroupStats) uint64 {
+	if cg == nil {
+		return 0
+	}
+
+	return cg.MemoryStats.Usage.MaxUsage
 }
 
 // ContainerResources describes container resources
@@ -189,6 +262,20 @@ type ContainerConfig struct {
 	// ReadOnlyRootfs indicates if the rootfs should be mounted readonly
 	ReadonlyRootfs bool
 
+	// ForceReadonlyRootfs forces the rootfs to be mounted read-only end
+	// to end (host bind mount and guest mount), overriding the OCI
+	// spec's root.readonly value. It is intended for hardened
+	// deployments (e.g. SEV) that must not trust the spec to request
+	// this on their own.
+	ForceReadonlyRootfs bool
+
+	// LazyRootfs defers the host-side bind mount of a non-block-device
+	// rootfs until the container is actually started, instead of
+	// performing it at container creation time. For sandboxes with
+	// many containers, this avoids paying for every container's mount
+	// up front when most of them won't run immediately.
+	LazyRootfs bool
+
 	// Cmd specifies the command to run on a container
 	Cmd Cmd
 
@@ -204,6 +291,24 @@ type ContainerConfig struct {
 
 	// Resources container resources
 	Resources ContainerResources
+
+	// SpecHash is the SHA512 hash of the OCI spec (config.json) read from
+	// the bundle at creation time, computed via asset.hash. It lets us
+	// detect someone editing the bundle under a running container; see
+	// verifySpecUnchanged.
+	SpecHash string
+
+	// SeccompProfile holds the seccomp profile to be delivered to the
+	// guest and applied to the container at start, either as a BPF
+	// program or as a JSON-encoded OCI seccomp spec. It is validated at
+	// creation time by validateSeccompProfile. An empty value means no
+	// seccomp profile is applied.
+	SeccompProfile []byte
+
+	// Rlimits holds the POSIX resource limits to apply to the
+	// container's process before exec in the guest. It is validated at
+	// creation time by validateRlimits.
+	Rlimits []Rlimit
 }
 
 // valid checks that the container configuration is valid.
@@ -219,6 +324,13 @@ func (c *ContainerConfig) valid() bool {
 	return true
 }
 
+// effectiveRootfsReadonly reports whether the container's rootfs must be
+// mounted read-only, either because the OCI spec requested it or because
+// ForceReadonlyRootfs overrides the spec.
+func (c *ContainerConfig) effectiveRootfsReadonly() bool {
+	return c.ReadonlyRootfs || c.ForceReadonlyRootfs
+}
+
 // SystemMountsInfo describes additional information for system mounts that the agent
 // needs to handle
 type SystemMountsInfo struct {
@@ -280,6 +392,13 @@ func (c *Container) Process() Process {
 	return c.process
 }
 
+// Age returns how long has elapsed since the container was created,
+// computed from a monotonic clock reference where available so it
+// cannot go negative because of a wall-clock adjustment. See ageSince.
+func (c *Container) Age() time.Duration {
+	return ageSince(c.state.CreatedAt, c.state.CreatedAtMonotonicNs)
+}
+
 // GetToken returns the token related to this container's process.
 func (c *Container) GetToken() string {
 	return c.process.Token
@@ -362,6 +481,19 @@ func (c *Container) storeDevices() error {
 	return c.sandbox.storage.storeContainerDevices(c.sandboxID, c.id, c.devices)
 }
 
+// storeDetachedProcess records process among c's detached (exec --detach)
+// processes, so that a later reaper can still collect its exit status.
+func (c *Container) storeDetachedProcess(process Process) error {
+	processes, err := c.sandbox.storage.fetchContainerDetachedProcesses(c.sandboxID, c.id)
+	if err != nil {
+		processes = []Process{}
+	}
+
+	processes = append(processes, process)
+
+	return c.sandbox.storage.storeContainerDetachedProcesses(c.sandboxID, c.id, processes)
+}
+
 func (c *Container) fetchDevices() ([]api.Device, error) {
 	return c.sandbox.storage.fetchContainerDevices(c.sandboxID, c.id)
 }
@@ -384,6 +516,12 @@ func (c *Container) setContainerState(state stateString) error {
 		return errNeedState
 	}
 
+	if c.state.State != "" {
+		if err := validateStateTransition(c.state.State, state); err != nil {
+			return err
+		}
+	}
+
 	// update in-memory state
 	c.state.State = state
 
@@ -556,6 +694,13 @@ func newContainer(sandbox *Sandbox, contConfig ContainerConfig) (*Container, err
 		c.state = state
 	}
 
+	if c.state.CreatedAt.IsZero() {
+		c.state.CreatedAt = time.Now()
+		if mono, err := monotonicNow(); err == nil {
+			c.state.CreatedAtMonotonicNs = mono
+		}
+	}
+
 	process, err := c.sandbox.storage.fetchContainerProcess(c.sandboxID, c.id)
 	if err == nil {
 		c.process = process
@@ -613,6 +758,74 @@ func (c *Container) checkBlockDeviceSupport() bool {
 	return false
 }
 
+// defaultCreateContainerTimeout bounds how long createContainerWithTimeout
+// waits on the agent when SandboxConfig.CreateContainerTimeout is unset.
+const defaultCreateContainerTimeout = 60 * time.Second
+
+// createContainerWithTimeout calls into the agent to create the guest-side
+// container, but gives up after timeout (or defaultCreateContainerTimeout
+// if timeout is zero) rather than hanging forever on an unresponsive
+// guest. On timeout, it best-effort tells the agent to stop whatever it
+// may have partially created, and returns without anything persisted to
+// disk: the caller's deferred rollback takes care of that.
+func createContainerWithTimeout(sandbox *Sandbox, c *Container, timeout time.Duration) (*Process, error) {
+	if timeout <= 0 {
+		timeout = defaultCreateContainerTimeout
+	}
+
+	type result struct {
+		process *Process
+		err     error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		process, err := sandbox.agent.createContainer(c.sandbox, c)
+		done <- result{process, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.process, res.err
+	case <-time.After(timeout):
+		sandbox.agent.stopContainer(sandbox, *c)
+		return nil, fmt.Errorf("createContainer timed out after %s waiting for the agent", timeout)
+	}
+}
+
+// bundlePath returns a container's OCI bundle path annotation. ok is
+// false when the annotation is absent, which is the case for containers
+// not created through the OCI bundle path (e.g. created directly
+// against this API).
+func bundlePath(contAnnotations map[string]string) (path string, ok bool) {
+	bundlePath, ok := contAnnotations[annotations.BundlePathKey]
+	if !ok || bundlePath == "" {
+		return "", false
+	}
+
+	return bundlePath, true
+}
+
+// specPath returns the path to the OCI spec (config.json) for a container,
+// derived from its OCI bundle path annotation. ok is false when the
+// annotation is absent, which is the case for containers not created
+// through the OCI bundle path (e.g. created directly against this API).
+func specPath(contAnnotations map[string]string) (path string, ok bool) {
+	bundlePath, ok := bundlePath(contAnnotations)
+	if !ok {
+		return "", false
+	}
+
+	return filepath.Join(bundlePath, "config.json"), true
+}
+
+// hashSpec computes the SHA512 hash of the OCI spec found at specPath,
+// reusing the asset hashing machinery.
+func hashSpec(specPath string) (string, error) {
+	a := &asset{path: specPath}
+	return a.hash(annotations.SHA512)
+}
+
 // createContainer creates and start a container inside a Sandbox. It has to be
 // called only when a new container, not known by the sandbox, has to be created.
 func createContainer(sandbox *Sandbox, contConfig ContainerConfig) (c *Container, err error) {
@@ -625,6 +838,28 @@ func createContainer(sandbox *Sandbox, contConfig ContainerConfig) (c *Container
 		return
 	}
 
+	if err = validateMounts(c.mounts); err != nil {
+		return
+	}
+
+	if err = validateMountSizeLimits(c.mounts, sandbox.config.HypervisorConfig.DefaultMemSz); err != nil {
+		return
+	}
+
+	if err = validateSeccompProfile(c.config.SeccompProfile); err != nil {
+		return
+	}
+
+	if err = validateRlimits(c.config.Rlimits); err != nil {
+		return
+	}
+
+	if path, ok := specPath(c.config.Annotations); ok {
+		if c.config.SpecHash, err = hashSpec(path); err != nil {
+			return
+		}
+	}
+
 	if err = c.createContainersDirs(); err != nil {
 		return
 	}
@@ -643,6 +878,10 @@ func createContainer(sandbox *Sandbox, contConfig ContainerConfig) (c *Container
 		}
 	}
 
+	if err = validateSEVVFIOCompatibility(c.sandbox.config.HypervisorConfig.MemEncrypt, c.devices, sysVFIOSEVCapability{}); err != nil {
+		return
+	}
+
 	// Attach devices
 	if err = c.attachDevices(); err != nil {
 		return
@@ -660,7 +899,7 @@ func createContainer(sandbox *Sandbox, contConfig ContainerConfig) (c *Container
 		return
 	}
 
-	process, err := sandbox.agent.createContainer(c.sandbox, c)
+	process, err := createContainerWithTimeout(sandbox, c, sandbox.config.CreateContainerTimeout)
 	if err != nil {
 		return c, err
 	}
@@ -742,6 +981,18 @@ func (c *Container) start() error {
 		return err
 	}
 
+	if len(c.config.SeccompProfile) > 0 {
+		if err := c.sandbox.agent.applySeccomp(c.sandbox, c, c.config.SeccompProfile); err != nil {
+			return err
+		}
+	}
+
+	if len(c.config.Rlimits) > 0 {
+		if err := c.sandbox.agent.applyRlimits(c.sandbox, c, c.config.Rlimits); err != nil {
+			return err
+		}
+	}
+
 	if err := c.sandbox.agent.startContainer(c.sandbox, c); err != nil {
 		c.Logger().WithError(err).Error("Failed to start container")
 
@@ -754,6 +1005,13 @@ func (c *Container) start() error {
 	return c.setContainerState(StateRunning)
 }
 
+// reapOrphans asks the agent to find and kill any guest processes for
+// this container that have been reparented to the guest's init after
+// their original parent exited, and returns how many were reaped.
+func (c *Container) reapOrphans() (int, error) {
+	return c.sandbox.agent.reapOrphans(c.sandbox, *c)
+}
+
 func (c *Container) stop() error {
 	// In case the container status has been updated implicitly because
 	// the container process has terminated, it might be possible that
@@ -823,6 +1081,12 @@ func (c *Container) stop() error {
 		return err
 	}
 
+	if reaped, err := c.reapOrphans(); err != nil {
+		c.Logger().WithError(err).Warn("could not reap orphaned guest processes")
+	} else if reaped > 0 {
+		c.Logger().WithField("count", reaped).Info("reaped orphaned guest processes")
+	}
+
 	if err := c.removeResources(); err != nil {
 		return err
 	}
@@ -835,6 +1099,11 @@ func (c *Container) stop() error {
 		return err
 	}
 
+	c.process.StopTime = time.Now()
+	if err := c.storeProcess(); err != nil {
+		return err
+	}
+
 	return c.setContainerState(StateStopped)
 }
 
@@ -854,6 +1123,12 @@ func (c *Container) enter(cmd Cmd) (*Process, error) {
 		return nil, err
 	}
 
+	if cmd.Detach && process != nil {
+		if err := c.storeDetachedProcess(*process); err != nil {
+			c.Logger().WithError(err).Error("failed to store detached process")
+		}
+	}
+
 	return process, nil
 }
 
@@ -864,7 +1139,38 @@ func (c *Container) wait(processID string) (int32, error) {
 			"impossible to wait")
 	}
 
-	return c.sandbox.agent.waitProcess(c, processID)
+	status, err := c.sandbox.agent.waitProcess(c, processID)
+	if err != nil {
+		return 0, err
+	}
+
+	exitCode, signaled, termSignal := decodeWaitStatus(status)
+
+	if processID == c.process.Token {
+		c.process.Signaled = signaled
+		c.process.TermSignal = termSignal
+
+		if err := c.storeProcess(); err != nil {
+			c.Logger().WithError(err).Error("failed to store process exit info")
+		}
+	}
+
+	return exitCode, nil
+}
+
+// decodeWaitStatus extracts the exit code, and whether the process was
+// signalled, out of the raw wait status the agent reports. It follows the
+// same encoding as syscall.WaitStatus: a process that exited normally has
+// its exit code in the high byte, while a process killed by a signal has
+// the signal number in the low 7 bits.
+func decodeWaitStatus(status int32) (exitCode int32, signaled bool, termSignal int) {
+	ws := syscall.WaitStatus(status)
+
+	if ws.Signaled() {
+		return -1, true, int(ws.Signal())
+	}
+
+	return int32(ws.ExitStatus()), false, 0
 }
 
 func (c *Container) kill(signal syscall.Signal, all bool) error {
@@ -891,6 +1197,22 @@ func (c *Container) winsizeProcess(processID string, height, width uint32) error
 	return c.sandbox.agent.winsizeProcess(c, processID, height, width)
 }
 
+func (c *Container) setOOMScoreAdj(processID string, adj int) error {
+	if c.state.State != StateReady && c.state.State != StateRunning {
+		return fmt.Errorf("Container not ready or running, impossible to set process oom_score_adj")
+	}
+
+	return c.sandbox.agent.setOOMScoreAdj(c, processID, adj)
+}
+
+func (c *Container) setProcessNice(processID string, nice int) error {
+	if c.state.State != StateReady && c.state.State != StateRunning {
+		return fmt.Errorf("Container not ready or running, impossible to set process nice value")
+	}
+
+	return c.sandbox.agent.setProcessNice(c, processID, nice)
+}
+
 func (c *Container) ioStream(processID string) (io.WriteCloser, io.Reader, io.Reader, error) {
 	if c.state.State != StateReady && c.state.State != StateRunning {
 		return nil, nil, nil, fmt.Errorf("Container not ready or running, impossible to signal the container")
@@ -901,6 +1223,80 @@ func (c *Container) ioStream(processID string) (io.WriteCloser, io.Reader, io.Re
 	return stream.stdin(), stream.stdout(), stream.stderr(), nil
 }
 
+// runGuestCommand runs argv to completion inside c and returns its captured
+// output. It is a lighter-weight alternative to exec+IOStream+wait for
+// simple one-shot checks (reading a guest file, running id, ...) that don't
+// need interactive stdio wiring.
+func runGuestCommand(sandbox *Sandbox, c Container, argv []string, timeout time.Duration) (stdout, stderr []byte, exitCode int, err error) {
+	process, err := sandbox.agent.exec(sandbox, c, Cmd{Args: argv})
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	if process == nil {
+		return nil, nil, 0, fmt.Errorf("agent did not return a process for command %v", argv)
+	}
+
+	_, stdoutReader, stderrReader, err := c.ioStream(process.Token)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	type waitResult struct {
+		exitCode int32
+		err      error
+	}
+
+	waitCh := make(chan waitResult, 1)
+	go func() {
+		ec, waitErr := c.wait(process.Token)
+		waitCh <- waitResult{exitCode: ec, err: waitErr}
+	}()
+
+	select {
+	case res := <-waitCh:
+		if res.err != nil {
+			return nil, nil, 0, res.err
+		}
+		exitCode = int(res.exitCode)
+	case <-time.After(timeout):
+		return nil, nil, 0, fmt.Errorf("timed out after %s waiting for command %v to complete", timeout, argv)
+	}
+
+	if stdout, err = ioutil.ReadAll(stdoutReader); err != nil {
+		return nil, nil, 0, err
+	}
+
+	if stderr, err = ioutil.ReadAll(stderrReader); err != nil {
+		return nil, nil, 0, err
+	}
+
+	return stdout, stderr, exitCode, nil
+}
+
+// probe runs argv inside c, retrying up to retries times with interval
+// between attempts, until it exits 0. It returns true as soon as that
+// happens, or false if every attempt either errored or exited non-zero.
+// This backs orchestrator-style liveness/readiness checks.
+func probe(sandbox *Sandbox, c Container, argv []string, retries int, interval time.Duration) (bool, error) {
+	var err error
+
+	for attempt := 0; attempt < retries; attempt++ {
+		var exitCode int
+
+		_, _, exitCode, err = runGuestCommand(sandbox, c, argv, interval)
+		if err == nil && exitCode == 0 {
+			return true, nil
+		}
+
+		if attempt < retries-1 {
+			time.Sleep(interval)
+		}
+	}
+
+	return false, err
+}
+
 func (c *Container) processList(options ProcessListOptions) (ProcessList, error) {
 	if err := c.checkSandboxRunning("ps"); err != nil {
 		return nil, err
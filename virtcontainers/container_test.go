@@ -1,477 +1,926 @@
-// Copyright (c) 2017 Intel Corporation
-//
-// SPDX-License-Identifier: Apache-2.0
-//
-
-package virtcontainers
-
-import (
-	"io/ioutil"
-	"os"
-	"os/exec"
-	"path/filepath"
-	"reflect"
-	"strings"
-	"syscall"
-	"testing"
-
-	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
-	"github.com/stretchr/testify/assert"
-)
-
-func TestGetAnnotations(t *testing.T) {
-	annotations := map[string]string{
-		"annotation1": "abc",
-		"annotation2": "xyz",
-		"annotation3": "123",
-	}
-
-	container := Container{
-		config: &ContainerConfig{
-			Annotations: annotations,
-		},
-	}
-
-	containerAnnotations := container.GetAnnotations()
-
-	for k, v := range containerAnnotations {
-		if annotations[k] != v {
-			t.Fatalf("Expecting ['%s']='%s', Got ['%s']='%s'\n", k, annotations[k], k, v)
-		}
-	}
-}
-
-func TestContainerSystemMountsInfo(t *testing.T) {
-	mounts := []Mount{
-		{
-			Source:      "/dev",
-			Destination: "/dev",
-			Type:        "bind",
-		},
-		{
-			Source:      "procfs",
-			Destination: "/proc",
-			Type:        "procfs",
-		},
-	}
-
-	c := Container{
-		mounts: mounts,
-	}
-
-	assert.False(t, c.systemMountsInfo.BindMountDev)
-	c.getSystemMountInfo()
-	assert.True(t, c.systemMountsInfo.BindMountDev)
-
-	c.mounts[0].Type = "tmpfs"
-	c.getSystemMountInfo()
-	assert.False(t, c.systemMountsInfo.BindMountDev)
-}
-
-func TestContainerSandbox(t *testing.T) {
-	expectedSandbox := &Sandbox{}
-
-	container := Container{
-		sandbox: expectedSandbox,
-	}
-
-	sandbox := container.Sandbox()
-
-	if !reflect.DeepEqual(sandbox, expectedSandbox) {
-		t.Fatalf("Expecting %+v\nGot %+v", expectedSandbox, sandbox)
-	}
-}
-
-func TestContainerRemoveDrive(t *testing.T) {
-	sandbox := &Sandbox{}
-
-	container := Container{
-		sandbox: sandbox,
-		id:      "testContainer",
-	}
-
-	container.state.Fstype = ""
-	err := container.removeDrive()
-
-	// hotplugRemoveDevice for hypervisor should not be called.
-	// test should pass without a hypervisor created for the container's sandbox.
-	if err != nil {
-		t.Fatal("")
-	}
-
-	container.state.Fstype = "xfs"
-	container.state.HotpluggedDrive = false
-	err = container.removeDrive()
-
-	// hotplugRemoveDevice for hypervisor should not be called.
-	if err != nil {
-		t.Fatal("")
-	}
-
-	container.state.HotpluggedDrive = true
-	sandbox.hypervisor = &mockHypervisor{}
-	err = container.removeDrive()
-
-	if err != nil {
-		t.Fatal()
-	}
-}
-
-func testSetupFakeRootfs(t *testing.T) (testRawFile, loopDev, mntDir string, err error) {
-	tmpDir, err := ioutil.TempDir("", "")
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	testRawFile = filepath.Join(tmpDir, "raw.img")
-	if _, err := os.Stat(testRawFile); !os.IsNotExist(err) {
-		os.Remove(testRawFile)
-	}
-
-	output, err := exec.Command("losetup", "-f").CombinedOutput()
-	if err != nil {
-		t.Fatalf("Skipping test since no loop device available for tests : %s, %s", output, err)
-		return
-	}
-	loopDev = strings.TrimSpace(string(output[:]))
-
-	output, err = exec.Command("fallocate", "-l", "256K", testRawFile).CombinedOutput()
-	if err != nil {
-		t.Fatalf("fallocate failed %s %s", output, err)
-	}
-
-	output, err = exec.Command("mkfs.ext4", "-F", testRawFile).CombinedOutput()
-	if err != nil {
-		t.Fatalf("mkfs.ext4 failed for %s:  %s, %s", testRawFile, output, err)
-	}
-
-	output, err = exec.Command("losetup", loopDev, testRawFile).CombinedOutput()
-	if err != nil {
-		t.Fatalf("Losetup for %s at %s failed : %s, %s ", loopDev, testRawFile, output, err)
-		return
-	}
-
-	mntDir = filepath.Join(tmpDir, "rootfs")
-	err = os.Mkdir(mntDir, dirMode)
-	if err != nil {
-		t.Fatalf("Error creating dir %s: %s", mntDir, err)
-	}
-
-	err = syscall.Mount(loopDev, mntDir, "ext4", uintptr(0), "")
-	if err != nil {
-		t.Fatalf("Error while mounting loop device %s at %s: %s", loopDev, mntDir, err)
-	}
-	return
-}
-
-func cleanupFakeRootfsSetup(testRawFile, loopDev, mntDir string) {
-	// unmount loop device
-	if mntDir != "" {
-		syscall.Unmount(mntDir, 0)
-	}
-
-	// detach loop device
-	if loopDev != "" {
-		exec.Command("losetup", "-d", loopDev).CombinedOutput()
-	}
-
-	if _, err := os.Stat(testRawFile); err == nil {
-		tmpDir := filepath.Dir(testRawFile)
-		os.RemoveAll(tmpDir)
-	}
-}
-
-func TestContainerAddDriveDir(t *testing.T) {
-	if os.Geteuid() != 0 {
-		t.Skip(testDisabledAsNonRoot)
-	}
-
-	testRawFile, loopDev, fakeRootfs, err := testSetupFakeRootfs(t)
-
-	defer cleanupFakeRootfsSetup(testRawFile, loopDev, fakeRootfs)
-
-	if err != nil {
-		t.Fatalf("Error while setting up fake rootfs: %v, Skipping test", err)
-	}
-
-	fs := &filesystem{}
-	sandbox := &Sandbox{
-		id:         testSandboxID,
-		storage:    fs,
-		hypervisor: &mockHypervisor{},
-		agent:      &noopAgent{},
-		config: &SandboxConfig{
-			HypervisorConfig: HypervisorConfig{
-				DisableBlockDeviceUse: false,
-			},
-		},
-	}
-
-	contID := "100"
-	container := Container{
-		sandbox: sandbox,
-		id:      contID,
-		rootFs:  fakeRootfs,
-	}
-
-	// create state file
-	path := filepath.Join(runStoragePath, testSandboxID, container.ID())
-	err = os.MkdirAll(path, dirMode)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	defer os.RemoveAll(path)
-
-	stateFilePath := filepath.Join(path, stateFile)
-	os.Remove(stateFilePath)
-
-	_, err = os.Create(stateFilePath)
-	if err != nil {
-		t.Fatal(err)
-	}
-	defer os.Remove(stateFilePath)
-
-	// Make the checkStorageDriver func variable point to a fake check function
-	savedFunc := checkStorageDriver
-	checkStorageDriver = func(major, minor int) (bool, error) {
-		return true, nil
-	}
-
-	defer func() {
-		checkStorageDriver = savedFunc
-	}()
-
-	container.state.Fstype = ""
-	container.state.HotpluggedDrive = false
-
-	err = container.hotplugDrive()
-	if err != nil {
-		t.Fatalf("Error with hotplugDrive :%v", err)
-	}
-
-	if container.state.Fstype == "" || !container.state.HotpluggedDrive {
-		t.Fatal()
-	}
-}
-
-func TestCheckSandboxRunningEmptyCmdFailure(t *testing.T) {
-	c := &Container{}
-	err := c.checkSandboxRunning("")
-	assert.NotNil(t, err, "Should fail because provided command is empty")
-}
-
-func TestCheckSandboxRunningNotRunningFailure(t *testing.T) {
-	c := &Container{
-		sandbox: &Sandbox{},
-	}
-	err := c.checkSandboxRunning("test_cmd")
-	assert.NotNil(t, err, "Should fail because sandbox state is empty")
-}
-
-func TestCheckSandboxRunningSuccessful(t *testing.T) {
-	c := &Container{
-		sandbox: &Sandbox{
-			state: State{
-				State: StateRunning,
-			},
-		},
-	}
-	err := c.checkSandboxRunning("test_cmd")
-	assert.Nil(t, err, "%v", err)
-}
-
-func TestContainerAddResources(t *testing.T) {
-	assert := assert.New(t)
-
-	c := &Container{
-		sandbox: &Sandbox{
-			storage: &filesystem{},
-		},
-	}
-	err := c.addResources()
-	assert.Nil(err)
-
-	c.config = &ContainerConfig{Annotations: make(map[string]string)}
-	c.config.Annotations[vcAnnotations.ContainerTypeKey] = string(PodSandbox)
-	err = c.addResources()
-	assert.Nil(err)
-
-	c.config.Annotations[vcAnnotations.ContainerTypeKey] = string(PodContainer)
-	err = c.addResources()
-	assert.Nil(err)
-
-	vCPUs := uint32(5)
-	c.config.Resources = ContainerResources{
-		VCPUs: vCPUs,
-	}
-	c.sandbox = &Sandbox{
-		hypervisor: &mockHypervisor{
-			vCPUs: vCPUs,
-		},
-		agent:   &noopAgent{},
-		storage: &filesystem{},
-	}
-	err = c.addResources()
-	assert.Nil(err)
-}
-
-func TestContainerRemoveResources(t *testing.T) {
-	assert := assert.New(t)
-
-	c := &Container{
-		sandbox: &Sandbox{
-			storage: &filesystem{},
-		},
-	}
-
-	err := c.addResources()
-	assert.Nil(err)
-
-	c.config = &ContainerConfig{Annotations: make(map[string]string)}
-	c.config.Annotations[vcAnnotations.ContainerTypeKey] = string(PodSandbox)
-	err = c.removeResources()
-	assert.Nil(err)
-
-	c.config.Annotations[vcAnnotations.ContainerTypeKey] = string(PodContainer)
-	err = c.removeResources()
-	assert.Nil(err)
-
-	vCPUs := uint32(5)
-	c.config.Resources = ContainerResources{
-		VCPUs: vCPUs,
-	}
-
-	c.sandbox = &Sandbox{
-		hypervisor: &mockHypervisor{
-			vCPUs: vCPUs,
-		},
-		storage: &filesystem{},
-	}
-
-	err = c.removeResources()
-	assert.Nil(err)
-}
-
-func TestContainerEnterErrorsOnContainerStates(t *testing.T) {
-	assert := assert.New(t)
-	c := &Container{
-		sandbox: &Sandbox{
-			state: State{
-				State: StateRunning,
-			},
-		},
-	}
-	cmd := Cmd{}
-
-	// Container state undefined
-	_, err := c.enter(cmd)
-	assert.Error(err)
-
-	// Container paused
-	c.state.State = StatePaused
-	_, err = c.enter(cmd)
-	assert.Error(err)
-
-	// Container stopped
-	c.state.State = StateStopped
-	_, err = c.enter(cmd)
-	assert.Error(err)
-}
-
-func TestContainerWaitErrorState(t *testing.T) {
-	assert := assert.New(t)
-	c := &Container{
-		sandbox: &Sandbox{
-			state: State{
-				State: StateRunning,
-			},
-		},
-	}
-	processID := "foobar"
-
-	// Container state undefined
-	_, err := c.wait(processID)
-	assert.Error(err)
-
-	// Container paused
-	c.state.State = StatePaused
-	_, err = c.wait(processID)
-	assert.Error(err)
-
-	// Container stopped
-	c.state.State = StateStopped
-	_, err = c.wait(processID)
-	assert.Error(err)
-}
-
-func TestKillContainerErrorState(t *testing.T) {
-	assert := assert.New(t)
-	c := &Container{
-		sandbox: &Sandbox{
-			state: State{
-				State: StateRunning,
-			},
-		},
-	}
-	// Container state undefined
-	err := c.kill(syscall.SIGKILL, true)
-	assert.Error(err)
-
-	// Container stopped
-	c.state.State = StateStopped
-	err = c.kill(syscall.SIGKILL, true)
-	assert.Error(err)
-}
-
-func TestWinsizeProcessErrorState(t *testing.T) {
-	assert := assert.New(t)
-	c := &Container{
-		sandbox: &Sandbox{
-			state: State{
-				State: StateRunning,
-			},
-		},
-	}
-	processID := "foobar"
-
-	// Container state undefined
-	err := c.winsizeProcess(processID, 100, 200)
-	assert.Error(err)
-
-	// Container paused
-	c.state.State = StatePaused
-	err = c.winsizeProcess(processID, 100, 200)
-	assert.Error(err)
-
-	// Container stopped
-	c.state.State = StateStopped
-	err = c.winsizeProcess(processID, 100, 200)
-	assert.Error(err)
-}
-
-func TestProcessIOStream(t *testing.T) {
-	assert := assert.New(t)
-	c := &Container{
-		sandbox: &Sandbox{
-			state: State{
-				State: StateRunning,
-			},
-		},
-	}
-	processID := "foobar"
-
-	// Container state undefined
-	_, _, _, err := c.ioStream(processID)
-	assert.Error(err)
-
-	// Container paused
-	c.state.State = StatePaused
-	_, _, _, err = c.ioStream(processID)
-	assert.Error(err)
-
-	// Container stopped
-	c.state.State = StateStopped
-	_, _, _, err = c.ioStream(processID)
-	assert.Error(err)
-}
+// Copyright (c) 2017 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingAgent behaves like noopAgent except createContainer never
+// returns, so it can be used to exercise createContainerWithTimeout.
+type blockingAgent struct {
+	noopAgent
+	stopContainerCalled int32
+}
+
+func (b *blockingAgent) createContainer(sandbox *Sandbox, c *Container) (*Process, error) {
+	select {}
+}
+
+func (b *blockingAgent) stopContainer(sandbox *Sandbox, c Container) error {
+	atomic.AddInt32(&b.stopContainerCalled, 1)
+	return nil
+}
+
+// guestCommandAgent behaves like noopAgent except exec/wait/stdio reads
+// return canned values, for testing runGuestCommand.
+type guestCommandAgent struct {
+	noopAgent
+	token    string
+	stdout   []byte
+	stderr   []byte
+	exitCode int32
+}
+
+func (a *guestCommandAgent) exec(sandbox *Sandbox, c Container, cmd Cmd) (*Process, error) {
+	return &Process{Token: a.token}, nil
+}
+
+func (a *guestCommandAgent) waitProcess(c *Container, processID string) (int32, error) {
+	return a.exitCode, nil
+}
+
+func (a *guestCommandAgent) readProcessStdout(c *Container, processID string, data []byte) (int, error) {
+	return readCannedOutput(&a.stdout, data)
+}
+
+func (a *guestCommandAgent) readProcessStderr(c *Container, processID string, data []byte) (int, error) {
+	return readCannedOutput(&a.stderr, data)
+}
+
+func readCannedOutput(remaining *[]byte, data []byte) (int, error) {
+	if len(*remaining) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(data, *remaining)
+	*remaining = (*remaining)[n:]
+
+	return n, nil
+}
+
+func TestRunGuestCommandReturnsCannedOutput(t *testing.T) {
+	sandboxID := "runguestcommand"
+	contID := "100"
+
+	sandbox := &Sandbox{
+		id: sandboxID,
+		agent: &guestCommandAgent{
+			token:    "test-token",
+			stdout:   []byte("hello\n"),
+			stderr:   []byte("warn\n"),
+			exitCode: 42,
+		},
+	}
+
+	c := Container{
+		id:        contID,
+		sandboxID: sandboxID,
+		sandbox:   sandbox,
+		state:     State{State: StateRunning},
+	}
+
+	stdout, stderr, exitCode, err := runGuestCommand(sandbox, c, []string{"id"}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(stdout) != "hello\n" {
+		t.Fatalf("expected stdout %q, got %q", "hello\n", stdout)
+	}
+
+	if string(stderr) != "warn\n" {
+		t.Fatalf("expected stderr %q, got %q", "warn\n", stderr)
+	}
+
+	if exitCode != 42 {
+		t.Fatalf("expected exit code 42, got %d", exitCode)
+	}
+}
+
+// flakyProbeAgent behaves like noopAgent except exec's exit code fails the
+// first failCount attempts and succeeds thereafter, for testing probe's
+// retry behavior.
+type flakyProbeAgent struct {
+	noopAgent
+	failCount int
+	attempts  int32
+}
+
+func (a *flakyProbeAgent) exec(sandbox *Sandbox, c Container, cmd Cmd) (*Process, error) {
+	atomic.AddInt32(&a.attempts, 1)
+	return &Process{Token: "probe-token"}, nil
+}
+
+func (a *flakyProbeAgent) waitProcess(c *Container, processID string) (int32, error) {
+	if int(atomic.LoadInt32(&a.attempts)) <= a.failCount {
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+func (a *flakyProbeAgent) readProcessStdout(c *Container, processID string, data []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (a *flakyProbeAgent) readProcessStderr(c *Container, processID string, data []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func TestProbeRetriesUntilSuccess(t *testing.T) {
+	agent := &flakyProbeAgent{failCount: 2}
+	sandbox := &Sandbox{agent: agent}
+
+	c := Container{
+		sandbox: sandbox,
+		state:   State{State: StateRunning},
+	}
+
+	ok, err := probe(sandbox, c, []string{"true"}, 5, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok {
+		t.Fatal("expected probe to eventually succeed")
+	}
+
+	if atomic.LoadInt32(&agent.attempts) != 3 {
+		t.Fatalf("expected 3 attempts (2 failures + 1 success), got %d", agent.attempts)
+	}
+}
+
+func TestProbeExhaustsRetries(t *testing.T) {
+	agent := &flakyProbeAgent{failCount: 10}
+	sandbox := &Sandbox{agent: agent}
+
+	c := Container{
+		sandbox: sandbox,
+		state:   State{State: StateRunning},
+	}
+
+	ok, err := probe(sandbox, c, []string{"false"}, 3, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Fatal("expected probe to fail after exhausting retries")
+	}
+
+	if atomic.LoadInt32(&agent.attempts) != 3 {
+		t.Fatalf("expected exactly 3 attempts, got %d", agent.attempts)
+	}
+}
+
+func TestRunGuestCommandFailingNoop(t *testing.T) {
+	sandbox := &Sandbox{agent: &noopAgent{}}
+
+	c := Container{
+		sandbox: sandbox,
+		state:   State{State: StateRunning},
+	}
+
+	if _, _, _, err := runGuestCommand(sandbox, c, []string{"id"}, time.Second); err == nil {
+		t.Fatal("expected an error running a guest command against the noop agent")
+	}
+}
+
+// clockSourceAgent behaves like noopAgent except getClockSource returns
+// canned values, for testing callers of the clocksource diagnostic.
+type clockSourceAgent struct {
+	noopAgent
+	current   string
+	available []string
+}
+
+func (a *clockSourceAgent) getClockSource(sandbox *Sandbox) (string, []string, error) {
+	return a.current, a.available, nil
+}
+
+func TestGetClockSourceReturnsAgentValues(t *testing.T) {
+	agent := &clockSourceAgent{
+		current:   "tsc",
+		available: []string{"kvm-clock", "tsc", "acpi_pm"},
+	}
+	sandbox := &Sandbox{agent: agent}
+
+	current, available, err := sandbox.agent.getClockSource(sandbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current != "tsc" {
+		t.Fatalf("expected current clocksource %q, got %q", "tsc", current)
+	}
+
+	if len(available) != 3 || available[0] != "kvm-clock" {
+		t.Fatalf("unexpected available clocksources: %v", available)
+	}
+}
+
+// signaledAgent behaves like noopAgent except waitProcess reports a wait
+// status as if the process had been killed by a signal.
+type signaledAgent struct {
+	noopAgent
+	signal syscall.Signal
+}
+
+func (a *signaledAgent) waitProcess(c *Container, processID string) (int32, error) {
+	return int32(a.signal), nil
+}
+
+func TestContainerWaitReportsSignalTermination(t *testing.T) {
+	sandboxID := "containerwaitsignal"
+	contID := "100"
+
+	sandbox := &Sandbox{
+		id:      sandboxID,
+		agent:   &signaledAgent{signal: syscall.SIGKILL},
+		storage: &filesystem{},
+	}
+
+	c := &Container{
+		id:        contID,
+		sandboxID: sandboxID,
+		sandbox:   sandbox,
+		state:     State{State: StateRunning},
+		process:   Process{Token: "test-token"},
+	}
+
+	contDir := filepath.Join(runStoragePath, sandboxID, contID)
+	if err := os.MkdirAll(contDir, dirMode); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(filepath.Join(runStoragePath, sandboxID))
+
+	_, err := c.wait(c.process.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.process.Signaled {
+		t.Fatal("expected process to be reported as signaled")
+	}
+
+	if c.process.TermSignal != int(syscall.SIGKILL) {
+		t.Fatalf("expected TermSignal %d, got %d", syscall.SIGKILL, c.process.TermSignal)
+	}
+}
+
+func TestCreateContainerWithTimeoutTimesOutAndCleansUp(t *testing.T) {
+	agent := &blockingAgent{}
+	sandbox := &Sandbox{agent: agent}
+	container := &Container{sandbox: sandbox}
+
+	_, err := createContainerWithTimeout(sandbox, container, 20*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected a timeout error")
+	}
+
+	if atomic.LoadInt32(&agent.stopContainerCalled) != 1 {
+		t.Fatal("expected stopContainer to be called once on timeout")
+	}
+}
+
+func TestGetAnnotations(t *testing.T) {
+	annotations := map[string]string{
+		"annotation1": "abc",
+		"annotation2": "xyz",
+		"annotation3": "123",
+	}
+
+	container := Container{
+		config: &ContainerConfig{
+			Annotations: annotations,
+		},
+	}
+
+	containerAnnotations := container.GetAnnotations()
+
+	for k, v := range containerAnnotations {
+		if annotations[k] != v {
+			t.Fatalf("Expecting ['%s']='%s', Got ['%s']='%s'\n", k, annotations[k], k, v)
+		}
+	}
+}
+
+func TestSpecPathFromAnnotations(t *testing.T) {
+	path, ok := specPath(map[string]string{
+		vcAnnotations.BundlePathKey: "/a/bundle",
+	})
+	if !ok {
+		t.Fatal("expected a spec path to be found")
+	}
+	if path != "/a/bundle/config.json" {
+		t.Fatalf("expected /a/bundle/config.json, got %s", path)
+	}
+
+	if _, ok := specPath(map[string]string{}); ok {
+		t.Fatal("expected no spec path without a bundle annotation")
+	}
+}
+
+func TestHashSpecMatchesSHA512(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "spec-hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	specFile := filepath.Join(tmpdir, "config.json")
+	content := []byte(`{"ociVersion": "1.0.0"}`)
+	if err := ioutil.WriteFile(specFile, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := hashSpec(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha512.Sum512(content)
+	want := hex.EncodeToString(sum[:])
+
+	if got != want {
+		t.Fatalf("hashSpec returned %s, want %s", got, want)
+	}
+}
+
+func TestCreateContainerComputesSpecHash(t *testing.T) {
+	hConfig := newHypervisorConfig(nil, nil)
+	sandbox, err := testCreateSandbox(t, testSandboxID, MockHypervisor, hConfig, NoopAgentType, NoopNetworkModel, NetworkConfig{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUp()
+
+	bundlePath, err := ioutil.TempDir("", "spec-hash-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bundlePath)
+
+	specFile := filepath.Join(bundlePath, "config.json")
+	if err := ioutil.WriteFile(specFile, []byte(`{"ociVersion": "1.0.0"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	contConfig := newTestContainerConfigNoop("spec-hash-container")
+	contConfig.Annotations = map[string]string{
+		vcAnnotations.BundlePathKey: bundlePath,
+	}
+
+	c, err := createContainer(sandbox, contConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantHash, err := hashSpec(specFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.config.SpecHash != wantHash {
+		t.Fatalf("SpecHash = %s, want %s", c.config.SpecHash, wantHash)
+	}
+
+	if err := sandbox.addContainer(c); err != nil {
+		t.Fatal(err)
+	}
+
+	// The spec on disk has not changed, so it should still verify.
+	if err := sandbox.verifySpecUnchanged(c.id, specFile); err != nil {
+		t.Fatalf("unexpected drift on an unmodified spec: %v", err)
+	}
+
+	// Editing the bundle's config.json should be detected as drift.
+	if err := ioutil.WriteFile(specFile, []byte(`{"ociVersion": "1.0.1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sandbox.verifySpecUnchanged(c.id, specFile); err == nil {
+		t.Fatal("expected drift to be detected after editing the spec")
+	}
+}
+
+func TestContainerSystemMountsInfo(t *testing.T) {
+	mounts := []Mount{
+		{
+			Source:      "/dev",
+			Destination: "/dev",
+			Type:        "bind",
+		},
+		{
+			Source:      "procfs",
+			Destination: "/proc",
+			Type:        "procfs",
+		},
+	}
+
+	c := Container{
+		mounts: mounts,
+	}
+
+	assert.False(t, c.systemMountsInfo.BindMountDev)
+	c.getSystemMountInfo()
+	assert.True(t, c.systemMountsInfo.BindMountDev)
+
+	c.mounts[0].Type = "tmpfs"
+	c.getSystemMountInfo()
+	assert.False(t, c.systemMountsInfo.BindMountDev)
+}
+
+func TestContainerSandbox(t *testing.T) {
+	expectedSandbox := &Sandbox{}
+
+	container := Container{
+		sandbox: expectedSandbox,
+	}
+
+	sandbox := container.Sandbox()
+
+	if !reflect.DeepEqual(sandbox, expectedSandbox) {
+		t.Fatalf("Expecting %+v\nGot %+v", expectedSandbox, sandbox)
+	}
+}
+
+func TestContainerRemoveDrive(t *testing.T) {
+	sandbox := &Sandbox{}
+
+	container := Container{
+		sandbox: sandbox,
+		id:      "testContainer",
+	}
+
+	container.state.Fstype = ""
+	err := container.removeDrive()
+
+	// hotplugRemoveDevice for hypervisor should not be called.
+	// test should pass without a hypervisor created for the container's sandbox.
+	if err != nil {
+		t.Fatal("")
+	}
+
+	container.state.Fstype = "xfs"
+	container.state.HotpluggedDrive = false
+	err = container.removeDrive()
+
+	// hotplugRemoveDevice for hypervisor should not be called.
+	if err != nil {
+		t.Fatal("")
+	}
+
+	container.state.HotpluggedDrive = true
+	sandbox.hypervisor = &mockHypervisor{}
+	err = container.removeDrive()
+
+	if err != nil {
+		t.Fatal()
+	}
+}
+
+func testSetupFakeRootfs(t *testing.T) (testRawFile, loopDev, mntDir string, err error) {
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testRawFile = filepath.Join(tmpDir, "raw.img")
+	if _, err := os.Stat(testRawFile); !os.IsNotExist(err) {
+		os.Remove(testRawFile)
+	}
+
+	output, err := exec.Command("losetup", "-f").CombinedOutput()
+	if err != nil {
+		t.Fatalf("Skipping test since no loop device available for tests : %s, %s", output, err)
+		return
+	}
+	loopDev = strings.TrimSpace(string(output[:]))
+
+	output, err = exec.Command("fallocate", "-l", "256K", testRawFile).CombinedOutput()
+	if err != nil {
+		t.Fatalf("fallocate failed %s %s", output, err)
+	}
+
+	output, err = exec.Command("mkfs.ext4", "-F", testRawFile).CombinedOutput()
+	if err != nil {
+		t.Fatalf("mkfs.ext4 failed for %s:  %s, %s", testRawFile, output, err)
+	}
+
+	output, err = exec.Command("losetup", loopDev, testRawFile).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Losetup for %s at %s failed : %s, %s ", loopDev, testRawFile, output, err)
+		return
+	}
+
+	mntDir = filepath.Join(tmpDir, "rootfs")
+	err = os.Mkdir(mntDir, dirMode)
+	if err != nil {
+		t.Fatalf("Error creating dir %s: %s", mntDir, err)
+	}
+
+	err = syscall.Mount(loopDev, mntDir, "ext4", uintptr(0), "")
+	if err != nil {
+		t.Fatalf("Error while mounting loop device %s at %s: %s", loopDev, mntDir, err)
+	}
+	return
+}
+
+func cleanupFakeRootfsSetup(testRawFile, loopDev, mntDir string) {
+	// unmount loop device
+	if mntDir != "" {
+		syscall.Unmount(mntDir, 0)
+	}
+
+	// detach loop device
+	if loopDev != "" {
+		exec.Command("losetup", "-d", loopDev).CombinedOutput()
+	}
+
+	if _, err := os.Stat(testRawFile); err == nil {
+		tmpDir := filepath.Dir(testRawFile)
+		os.RemoveAll(tmpDir)
+	}
+}
+
+func TestContainerAddDriveDir(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	testRawFile, loopDev, fakeRootfs, err := testSetupFakeRootfs(t)
+
+	defer cleanupFakeRootfsSetup(testRawFile, loopDev, fakeRootfs)
+
+	if err != nil {
+		t.Fatalf("Error while setting up fake rootfs: %v, Skipping test", err)
+	}
+
+	fs := &filesystem{}
+	sandbox := &Sandbox{
+		id:         testSandboxID,
+		storage:    fs,
+		hypervisor: &mockHypervisor{},
+		agent:      &noopAgent{},
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				DisableBlockDeviceUse: false,
+			},
+		},
+	}
+
+	contID := "100"
+	container := Container{
+		sandbox: sandbox,
+		id:      contID,
+		rootFs:  fakeRootfs,
+	}
+
+	// create state file
+	path := filepath.Join(runStoragePath, testSandboxID, container.ID())
+	err = os.MkdirAll(path, dirMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(path)
+
+	stateFilePath := filepath.Join(path, stateFile)
+	os.Remove(stateFilePath)
+
+	_, err = os.Create(stateFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(stateFilePath)
+
+	// Make the checkStorageDriver func variable point to a fake check function
+	savedFunc := checkStorageDriver
+	checkStorageDriver = func(major, minor int) (bool, error) {
+		return true, nil
+	}
+
+	defer func() {
+		checkStorageDriver = savedFunc
+	}()
+
+	container.state.Fstype = ""
+	container.state.HotpluggedDrive = false
+
+	err = container.hotplugDrive()
+	if err != nil {
+		t.Fatalf("Error with hotplugDrive :%v", err)
+	}
+
+	if container.state.Fstype == "" || !container.state.HotpluggedDrive {
+		t.Fatal()
+	}
+}
+
+func TestCheckSandboxRunningEmptyCmdFailure(t *testing.T) {
+	c := &Container{}
+	err := c.checkSandboxRunning("")
+	assert.NotNil(t, err, "Should fail because provided command is empty")
+}
+
+func TestCheckSandboxRunningNotRunningFailure(t *testing.T) {
+	c := &Container{
+		sandbox: &Sandbox{},
+	}
+	err := c.checkSandboxRunning("test_cmd")
+	assert.NotNil(t, err, "Should fail because sandbox state is empty")
+}
+
+func TestCheckSandboxRunningSuccessful(t *testing.T) {
+	c := &Container{
+		sandbox: &Sandbox{
+			state: State{
+				State: StateRunning,
+			},
+		},
+	}
+	err := c.checkSandboxRunning("test_cmd")
+	assert.Nil(t, err, "%v", err)
+}
+
+func TestContainerAddResources(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Container{
+		sandbox: &Sandbox{
+			storage: &filesystem{},
+		},
+	}
+	err := c.addResources()
+	assert.Nil(err)
+
+	c.config = &ContainerConfig{Annotations: make(map[string]string)}
+	c.config.Annotations[vcAnnotations.ContainerTypeKey] = string(PodSandbox)
+	err = c.addResources()
+	assert.Nil(err)
+
+	c.config.Annotations[vcAnnotations.ContainerTypeKey] = string(PodContainer)
+	err = c.addResources()
+	assert.Nil(err)
+
+	vCPUs := uint32(5)
+	c.config.Resources = ContainerResources{
+		VCPUs: vCPUs,
+	}
+	c.sandbox = &Sandbox{
+		hypervisor: &mockHypervisor{
+			vCPUs: vCPUs,
+		},
+		agent:   &noopAgent{},
+		storage: &filesystem{},
+	}
+	err = c.addResources()
+	assert.Nil(err)
+}
+
+func TestContainerRemoveResources(t *testing.T) {
+	assert := assert.New(t)
+
+	c := &Container{
+		sandbox: &Sandbox{
+			storage: &filesystem{},
+		},
+	}
+
+	err := c.addResources()
+	assert.Nil(err)
+
+	c.config = &ContainerConfig{Annotations: make(map[string]string)}
+	c.config.Annotations[vcAnnotations.ContainerTypeKey] = string(PodSandbox)
+	err = c.removeResources()
+	assert.Nil(err)
+
+	c.config.Annotations[vcAnnotations.ContainerTypeKey] = string(PodContainer)
+	err = c.removeResources()
+	assert.Nil(err)
+
+	vCPUs := uint32(5)
+	c.config.Resources = ContainerResources{
+		VCPUs: vCPUs,
+	}
+
+	c.sandbox = &Sandbox{
+		hypervisor: &mockHypervisor{
+			vCPUs: vCPUs,
+		},
+		storage: &filesystem{},
+	}
+
+	err = c.removeResources()
+	assert.Nil(err)
+}
+
+func TestContainerEnterErrorsOnContainerStates(t *testing.T) {
+	assert := assert.New(t)
+	c := &Container{
+		sandbox: &Sandbox{
+			state: State{
+				State: StateRunning,
+			},
+		},
+	}
+	cmd := Cmd{}
+
+	// Container state undefined
+	_, err := c.enter(cmd)
+	assert.Error(err)
+
+	// Container paused
+	c.state.State = StatePaused
+	_, err = c.enter(cmd)
+	assert.Error(err)
+
+	// Container stopped
+	c.state.State = StateStopped
+	_, err = c.enter(cmd)
+	assert.Error(err)
+}
+
+// execProcessAgent behaves like noopAgent except exec returns a canned
+// Process, for testing that detached exec processes get persisted.
+type execProcessAgent struct {
+	noopAgent
+	process Process
+}
+
+func (a *execProcessAgent) exec(sandbox *Sandbox, c Container, cmd Cmd) (*Process, error) {
+	return &a.process, nil
+}
+
+func TestContainerEnterDetachPersistsProcess(t *testing.T) {
+	assert := assert.New(t)
+
+	sandboxID := "enterdetachsandbox"
+	contID := "100"
+
+	sandbox := &Sandbox{
+		id:      sandboxID,
+		agent:   &execProcessAgent{process: Process{Token: "detached-token", Pid: 1234}},
+		storage: &filesystem{},
+		state:   State{State: StateRunning},
+	}
+
+	c := &Container{
+		id:        contID,
+		sandboxID: sandboxID,
+		sandbox:   sandbox,
+		state:     State{State: StateRunning},
+	}
+
+	contDir := filepath.Join(runStoragePath, sandboxID, contID)
+	assert.NoError(os.MkdirAll(contDir, dirMode))
+
+	process, err := c.enter(Cmd{Detach: true})
+	assert.NoError(err)
+	assert.Equal("detached-token", process.Token)
+
+	detached, err := sandbox.storage.fetchContainerDetachedProcesses(sandboxID, contID)
+	assert.NoError(err)
+	assert.Len(detached, 1)
+	assert.Equal(1234, detached[0].Pid)
+}
+
+func TestContainerWaitErrorState(t *testing.T) {
+	assert := assert.New(t)
+	c := &Container{
+		sandbox: &Sandbox{
+			state: State{
+				State: StateRunning,
+			},
+		},
+	}
+	processID := "foobar"
+
+	// Container state undefined
+	_, err := c.wait(processID)
+	assert.Error(err)
+
+	// Container paused
+	c.state.State = StatePaused
+	_, err = c.wait(processID)
+	assert.Error(err)
+
+	// Container stopped
+	c.state.State = StateStopped
+	_, err = c.wait(processID)
+	assert.Error(err)
+}
+
+func TestKillContainerErrorState(t *testing.T) {
+	assert := assert.New(t)
+	c := &Container{
+		sandbox: &Sandbox{
+			state: State{
+				State: StateRunning,
+			},
+		},
+	}
+	// Container state undefined
+	err := c.kill(syscall.SIGKILL, true)
+	assert.Error(err)
+
+	// Container stopped
+	c.state.State = StateStopped
+	err = c.kill(syscall.SIGKILL, true)
+	assert.Error(err)
+}
+
+func TestWinsizeProcessErrorState(t *testing.T) {
+	assert := assert.New(t)
+	c := &Container{
+		sandbox: &Sandbox{
+			state: State{
+				State: StateRunning,
+			},
+		},
+	}
+	processID := "foobar"
+
+	// Container state undefined
+	err := c.winsizeProcess(processID, 100, 200)
+	assert.Error(err)
+
+	// Container paused
+	c.state.State = StatePaused
+	err = c.winsizeProcess(processID, 100, 200)
+	assert.Error(err)
+
+	// Container stopped
+	c.state.State = StateStopped
+	err = c.winsizeProcess(processID, 100, 200)
+	assert.Error(err)
+}
+
+func TestProcessIOStream(t *testing.T) {
+	assert := assert.New(t)
+	c := &Container{
+		sandbox: &Sandbox{
+			state: State{
+				State: StateRunning,
+			},
+		},
+	}
+	processID := "foobar"
+
+	// Container state undefined
+	_, _, _, err := c.ioStream(processID)
+	assert.Error(err)
+
+	// Container paused
+	c.state.State = StatePaused
+	_, _, _, err = c.ioStream(processID)
+	assert.Error(err)
+
+	// Container stopped
+	c.state.State = StateStopped
+	_, _, _, err = c.ioStream(processID)
+	assert.Error(err)
+}
+
+func TestMemoryPeakNilCgroupStats(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(uint64(0), memoryPeak(nil))
+}
+
+func TestMemoryPeakFromCannedCgroupStats(t *testing.T) {
+	assert := assert.New(t)
+
+	// A canned memory.max_usage_in_bytes value, as would be reported by
+	// the guest's memory.peak cgroup file.
+	cg := &CgroupStats{
+		MemoryStats: MemoryStats{
+			Usage: MemoryData{
+				Usage:    104857600,
+				MaxUsage: 209715200,
+			},
+		},
+	}
+
+	assert.Equal(uint64(209715200), memoryPeak(cg))
+}
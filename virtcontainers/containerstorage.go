@@ -0,0 +1,20 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "fmt"
+
+// validateResizeContainerStorageSize checks that sizeBytes is a plausible
+// target size for an online rootfs/storage resize: the guest agent can
+// only grow a filesystem, never shrink it online, so zero (meaning
+// "shrink to nothing" or "unspecified") is always rejected.
+func validateResizeContainerStorageSize(sizeBytes uint64) error {
+	if sizeBytes == 0 {
+		return fmt.Errorf("resize container storage size must be greater than 0 bytes")
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// hostCPUFlags returns the set of CPU flags advertised by the host, read
+// from the "flags" line of cpuInfoPath (normally /proc/cpuinfo).
+func hostCPUFlags(cpuInfoPath string) ([]string, error) {
+	flagsField := "flags"
+
+	f, err := os.Open(cpuInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Expected format: ["flags", ":", ...] or ["flags:", ...]
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		if !strings.HasPrefix(fields[0], flagsField) {
+			continue
+		}
+
+		return fields[1:], nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("Couldn't find %q from %q output", flagsField, cpuInfoPath)
+}
+
+// validateCPUFeatures checks that every feature in features is advertised
+// by the host, read from cpuInfoPath, so that a sandbox cannot be launched
+// claiming to expose a CPU feature the host itself cannot back.
+func validateCPUFeatures(features []string, cpuInfoPath string) error {
+	if len(features) == 0 {
+		return nil
+	}
+
+	hostFlags, err := hostCPUFlags(cpuInfoPath)
+	if err != nil {
+		return fmt.Errorf("could not determine host CPU flags: %v", err)
+	}
+
+	supported := make(map[string]bool, len(hostFlags))
+	for _, flag := range hostFlags {
+		supported[flag] = true
+	}
+
+	for _, feature := range features {
+		if !supported[feature] {
+			return fmt.Errorf("CPU feature %q was requested but is not supported by the host", feature)
+		}
+	}
+
+	return nil
+}
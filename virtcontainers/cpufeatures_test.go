@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+var dataFlagsFieldForCPUFeatures = []byte(`
+fpu_exception   : yes
+cpuid level     : 20
+wp              : yes
+flags           : fpu vme de pse tsc msr pae mce cx8 apic sep mtrr pge mca cmov pat pse36 clflush mmx fxsr sse sse2 ss ht syscall nx pdpe1gb rdtscp lm constant_tsc rep_good nopl xtopology eagerfpu pni pclmulqdq vmx ssse3 fma cx16 sse4_1 sse4_2 movbe popcnt aes xsave avx f16c rdrand lahf_lm abm 3dnowprefetch tpr_shadow vnmi ept vpid fsgsbase bmi1 hle avx2 smep bmi2 erms rtm rdseed adx smap xsaveopt
+bugs            :
+bogomips        : 4589.35
+`)
+
+func writeCPUInfoFile(t *testing.T, content []byte) string {
+	f, err := ioutil.TempFile("", "cpuinfo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(content); err != nil {
+		t.Fatal(err)
+	}
+
+	return f.Name()
+}
+
+func TestValidateCPUFeaturesSupportedFeature(t *testing.T) {
+	cpuInfoPath := writeCPUInfoFile(t, dataFlagsFieldForCPUFeatures)
+	defer os.Remove(cpuInfoPath)
+
+	if err := validateCPUFeatures([]string{"vmx", "sse4_1"}, cpuInfoPath); err != nil {
+		t.Fatalf("expected no error for host-supported features, got %v", err)
+	}
+}
+
+func TestValidateCPUFeaturesUnsupportedFeatureRejected(t *testing.T) {
+	cpuInfoPath := writeCPUInfoFile(t, dataFlagsFieldForCPUFeatures)
+	defer os.Remove(cpuInfoPath)
+
+	if err := validateCPUFeatures([]string{"avx512f"}, cpuInfoPath); err == nil {
+		t.Fatal("expected an error for a CPU feature the host does not support")
+	}
+}
+
+func TestValidateCPUFeaturesNoneRequestedSkipsHostCheck(t *testing.T) {
+	// No features requested, so this must succeed even against a
+	// non-existent cpuinfo path: the default passthrough behavior
+	// should not require reading the host's CPU flags at all.
+	if err := validateCPUFeatures(nil, "/does/not/exist"); err != nil {
+		t.Fatalf("expected no error when no CPU features are requested, got %v", err)
+	}
+}
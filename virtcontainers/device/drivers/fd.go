@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package drivers
+
+import (
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+)
+
+// FdDevice refers to a device that is passed into the guest proxy as an
+// already-opened file descriptor, such as a host TUN/TAP fd or /dev/sev,
+// rather than by path. A file descriptor is only meaningful for the
+// lifetime of the process that opened it, so unlike the other device
+// types, an FdDevice cannot be persisted across a runtime restart: the
+// caller must re-pass the fd after restart.
+type FdDevice struct {
+	DevType    config.DeviceType
+	DeviceInfo config.DeviceInfo
+
+	// Fd is the host file descriptor to hand to the guest proxy.
+	Fd uintptr
+}
+
+// NewFdDevice creates a new FdDevice based on DeviceInfo
+func NewFdDevice(devInfo config.DeviceInfo) *FdDevice {
+	return &FdDevice{
+		DevType:    config.DeviceFd,
+		DeviceInfo: devInfo,
+	}
+}
+
+// Attach is standard interface of api.Device
+func (device *FdDevice) Attach(devReceiver api.DeviceReceiver) error {
+	deviceLogger().WithField("fd", device.Fd).Info("Attaching fd device")
+	return devReceiver.HotplugAddDevice(device, config.DeviceFd)
+}
+
+// Detach is standard interface of api.Device
+func (device *FdDevice) Detach(devReceiver api.DeviceReceiver) error {
+	deviceLogger().WithField("fd", device.Fd).Info("Detaching fd device")
+	return devReceiver.HotplugRemoveDevice(device, config.DeviceFd)
+}
+
+// DeviceType is standard interface of api.Device, it returns device type
+func (device *FdDevice) DeviceType() config.DeviceType {
+	return device.DevType
+}
@@ -7,15 +7,124 @@ package virtcontainers
 
 import (
 	"errors"
+	"fmt"
+)
+
+// Exported error values callers and embedders can reliably match against,
+// rather than string-matching error messages. The lowercase names below
+// are kept as aliases for existing call sites within this package.
+var (
+	// ErrNeedSandbox is returned when an operation that requires a
+	// Sandbox is called without one.
+	ErrNeedSandbox = errors.New("Sandbox must be specified")
+
+	// ErrNeedSandboxID is returned when an operation that requires a
+	// sandbox ID is called with an empty one.
+	ErrNeedSandboxID = errors.New("Sandbox ID cannot be empty")
+
+	// ErrNeedContainerID is returned when an operation that requires a
+	// container ID is called with an empty one.
+	ErrNeedContainerID = errors.New("Container ID cannot be empty")
+
+	// ErrNeedFile is returned when an operation that requires a file
+	// path is called with an empty one.
+	ErrNeedFile = errors.New("File cannot be empty")
+
+	// ErrNeedState is returned when an operation that requires a State
+	// is called without one.
+	ErrNeedState = errors.New("State cannot be empty")
+
+	// ErrInvalidResource is returned when a sandboxResource value does
+	// not match any resource known to the storage layer.
+	ErrInvalidResource = errors.New("Invalid sandbox resource")
+
+	// ErrNoSuchContainer is returned when a container ID does not
+	// match any container tracked by a sandbox.
+	ErrNoSuchContainer = errors.New("Container does not exist")
 )
 
 // common error objects used for argument checking
 var (
-	errNeedSandbox     = errors.New("Sandbox must be specified")
-	errNeedSandboxID   = errors.New("Sandbox ID cannot be empty")
-	errNeedContainerID = errors.New("Container ID cannot be empty")
-	errNeedFile        = errors.New("File cannot be empty")
-	errNeedState       = errors.New("State cannot be empty")
-	errInvalidResource = errors.New("Invalid sandbox resource")
-	errNoSuchContainer = errors.New("Container does not exist")
+	errNeedSandbox     = ErrNeedSandbox
+	errNeedSandboxID   = ErrNeedSandboxID
+	errNeedContainerID = ErrNeedContainerID
+	errNeedFile        = ErrNeedFile
+	errNeedState       = ErrNeedState
+	errInvalidResource = ErrInvalidResource
+	errNoSuchContainer = ErrNoSuchContainer
 )
+
+// causer is implemented by errors that wrap another error while adding
+// context, such as storageError below. It mirrors the convention used by
+// popular pre-1.13 error-wrapping packages, since this repo targets the
+// Go 1.8 toolchain and predates both "%w" and errors.Unwrap.
+type causer interface {
+	Cause() error
+}
+
+// Is reports whether err is target, unwrapping one wrapping layer at a
+// time via the causer interface until it finds a match or runs out of
+// wrapped errors. It exists because the standard library's errors.Is
+// (which does the same thing via errors.Unwrap) was only added in Go
+// 1.13, after the Go 1.8 toolchain this repo targets. Call sites read the
+// same way they would against errors.Is, and can be switched to it
+// verbatim if the minimum Go version is ever raised.
+func Is(err, target error) bool {
+	for err != nil {
+		if err == target {
+			return true
+		}
+
+		c, ok := err.(causer)
+		if !ok {
+			return false
+		}
+
+		err = c.Cause()
+	}
+
+	return false
+}
+
+// storageError wraps an error returned by the storage layer with the
+// sandbox/container/resource context it happened under, so logs are
+// useful without needing to cross-reference a bare os.PathError against
+// whatever operation was in flight. The original error is preserved
+// behind Cause() so Is can still match it.
+type storageError struct {
+	op          storageOp
+	resource    sandboxResource
+	sandboxID   string
+	containerID string
+	cause       error
+}
+
+func (e *storageError) Error() string {
+	if e.containerID != "" {
+		return fmt.Sprintf("%s %s for sandbox %s container %s: %v", e.op, resourceName(e.resource), e.sandboxID, e.containerID, e.cause)
+	}
+
+	return fmt.Sprintf("%s %s for sandbox %s: %v", e.op, resourceName(e.resource), e.sandboxID, e.cause)
+}
+
+// Cause returns the underlying error, for Is to unwrap.
+func (e *storageError) Cause() error {
+	return e.cause
+}
+
+// wrapStorageErr wraps a non-nil err from a store/fetch operation with
+// the sandbox/container/resource it happened under. It returns err
+// unchanged if err is nil.
+func wrapStorageErr(op storageOp, resource sandboxResource, sandboxID, containerID string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &storageError{
+		op:          op,
+		resource:    resource,
+		sandboxID:   sandboxID,
+		containerID: containerID,
+		cause:       err,
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestIsMatchesSentinelError(t *testing.T) {
+	if !Is(ErrNeedFile, ErrNeedFile) {
+		t.Fatal("expected Is to match an error against itself")
+	}
+
+	if Is(ErrNeedFile, ErrNeedSandboxID) {
+		t.Fatal("expected Is to not match two different sentinel errors")
+	}
+}
+
+func TestIsMatchesThroughStorageCallStack(t *testing.T) {
+	fs := &filesystem{}
+
+	err := fs.storeDeviceFile("", nil)
+	if !Is(err, ErrNeedFile) {
+		t.Fatalf("expected storeDeviceFile(\"\", ...) to return ErrNeedFile, got %v", err)
+	}
+
+	err = fs.fetchFile("", stateFileType, nil)
+	if !Is(err, ErrNeedFile) {
+		t.Fatalf("expected fetchFile(\"\", ...) to return ErrNeedFile, got %v", err)
+	}
+}
+
+func TestWrapStorageErrIncludesContextAndUnwraps(t *testing.T) {
+	cause := errors.New("no such file or directory")
+
+	err := wrapStorageErr(storageOpFetch, stateFileType, "sandbox1", "container1", cause)
+	if err == nil {
+		t.Fatal("expected wrapStorageErr to return a non-nil error")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"sandbox1", "container1", resourceName(stateFileType), cause.Error()} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("expected wrapped error message %q to contain %q", msg, want)
+		}
+	}
+
+	if !Is(err, cause) {
+		t.Fatal("expected Is to unwrap the wrapped error down to its cause")
+	}
+}
+
+func TestWrapStorageErrNilIsNil(t *testing.T) {
+	if err := wrapStorageErr(storageOpStore, stateFileType, "sandbox1", "", nil); err != nil {
+		t.Fatalf("expected wrapStorageErr(nil) to return nil, got %v", err)
+	}
+}
+
+func TestFetchResourceWrapsUnderlyingError(t *testing.T) {
+	fs := &filesystem{}
+
+	err := fs.fetchResource(true, "nonexistent-sandbox", "", stateFileType, &State{})
+	if err == nil {
+		t.Fatal("expected fetchResource to fail for a sandbox that was never stored")
+	}
+
+	if !strings.Contains(err.Error(), "nonexistent-sandbox") {
+		t.Fatalf("expected wrapped error to mention the sandbox ID, got %v", err)
+	}
+}
+
+func TestAliasesMatchExportedErrors(t *testing.T) {
+	cases := []struct {
+		alias    error
+		exported error
+	}{
+		{errNeedSandbox, ErrNeedSandbox},
+		{errNeedSandboxID, ErrNeedSandboxID},
+		{errNeedContainerID, ErrNeedContainerID},
+		{errNeedFile, ErrNeedFile},
+		{errNeedState, ErrNeedState},
+		{errInvalidResource, ErrInvalidResource},
+		{errNoSuchContainer, ErrNoSuchContainer},
+	}
+
+	for _, c := range cases {
+		if !Is(c.alias, c.exported) {
+			t.Fatalf("expected alias %v to match exported error %v", c.alias, c.exported)
+		}
+	}
+}
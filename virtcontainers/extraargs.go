@@ -0,0 +1,83 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hypervisorExtraArgsAnnotation lets a power user append extra, raw
+// arguments (e.g. -object/-device) to the VMM command line for
+// experimentation, without a runtime config change. It only takes effect
+// when the host permits it via HypervisorConfig.AllowExtraArgs, since
+// arbitrary arguments are a way to tamper with a sandbox's isolation.
+const hypervisorExtraArgsAnnotation = "kata.hypervisor.extra_args"
+
+// denylistedExtraArgPatterns matches extra_args substrings that are
+// always rejected, regardless of host policy, because they name the
+// same qemu objects and devices SEV memory encryption relies on and
+// could be used to weaken or disable it.
+var denylistedExtraArgPatterns = []string{
+	"sev-guest",
+	"sev0",
+	"memory-encryption",
+	"-machine",
+}
+
+// extraArgsRequested returns the raw, whitespace-split extra_args the
+// kata.hypervisor.extra_args annotation requests, or nil if the
+// annotation is absent or empty.
+func extraArgsRequested(annotations map[string]string) []string {
+	value, ok := annotations[hypervisorExtraArgsAnnotation]
+	if !ok || value == "" {
+		return nil
+	}
+
+	return strings.Fields(value)
+}
+
+// validateExtraArgs rejects any arg matching denylistedExtraArgPatterns,
+// regardless of host policy.
+func validateExtraArgs(args []string) error {
+	for _, arg := range args {
+		lower := strings.ToLower(arg)
+		for _, pattern := range denylistedExtraArgPatterns {
+			if strings.Contains(lower, pattern) {
+				return fmt.Errorf("extra_args value %q is not allowed: matches denylisted pattern %q", arg, pattern)
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveHypervisorExtraArgs applies the kata.hypervisor.extra_args
+// annotation to config's HypervisorConfig.ExtraArgs, gated by
+// config.HypervisorConfig.AllowExtraArgs and denylist-checked by
+// validateExtraArgs. It returns an error if extra args are requested but
+// not permitted by host policy, or if any of them are denylisted, so a
+// host that forbids or disallows the annotation rejects the sandbox
+// outright rather than silently launching it one way or the other.
+func resolveHypervisorExtraArgs(config *SandboxConfig) error {
+	args := extraArgsRequested(config.Annotations)
+	if len(args) == 0 {
+		return nil
+	}
+
+	if !config.HypervisorConfig.AllowExtraArgs {
+		return fmt.Errorf("the %s annotation is forbidden by host policy: AllowExtraArgs is not enabled", hypervisorExtraArgsAnnotation)
+	}
+
+	if err := validateExtraArgs(args); err != nil {
+		return err
+	}
+
+	virtLog.Warningf("%s annotation honored: appending user-provided args to the VMM command line for sandbox %s", hypervisorExtraArgsAnnotation, config.ID)
+	config.HypervisorConfig.ExtraArgs = args
+
+	return nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtraArgsRequestedMissingOrEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Nil(extraArgsRequested(map[string]string{}))
+	assert.Nil(extraArgsRequested(map[string]string{hypervisorExtraArgsAnnotation: ""}))
+}
+
+func TestExtraArgsRequestedSplitsOnWhitespace(t *testing.T) {
+	assert := assert.New(t)
+
+	args := extraArgsRequested(map[string]string{hypervisorExtraArgsAnnotation: "-device foo,id=bar"})
+	assert.Equal([]string{"-device", "foo,id=bar"}, args)
+}
+
+func TestValidateExtraArgsRejectsDenylistedPattern(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Error(validateExtraArgs([]string{"-object", "sev-guest,id=sev0"}))
+	assert.Error(validateExtraArgs([]string{"-machine", "q35"}))
+}
+
+func TestValidateExtraArgsAcceptsBenignArgs(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(validateExtraArgs([]string{"-device", "virtio-rng-pci"}))
+}
+
+func TestResolveHypervisorExtraArgsNoopWhenAnnotationAbsent(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{AllowExtraArgs: true},
+	}
+
+	assert.NoError(resolveHypervisorExtraArgs(config))
+	assert.Nil(config.HypervisorConfig.ExtraArgs)
+}
+
+func TestResolveHypervisorExtraArgsRejectedWhenForbiddenByPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		Annotations: map[string]string{hypervisorExtraArgsAnnotation: "-device virtio-rng-pci"},
+	}
+
+	err := resolveHypervisorExtraArgs(config)
+	assert.Error(err)
+	assert.Nil(config.HypervisorConfig.ExtraArgs)
+}
+
+func TestResolveHypervisorExtraArgsRejectedByDenylistEvenWhenPermitted(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{AllowExtraArgs: true},
+		Annotations:      map[string]string{hypervisorExtraArgsAnnotation: "-object sev-guest,id=sev0"},
+	}
+
+	err := resolveHypervisorExtraArgs(config)
+	assert.Error(err)
+	assert.Nil(config.HypervisorConfig.ExtraArgs)
+}
+
+func TestResolveHypervisorExtraArgsHonoredWhenPermitted(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{AllowExtraArgs: true},
+		Annotations:      map[string]string{hypervisorExtraArgsAnnotation: "-device virtio-rng-pci"},
+	}
+
+	assert.NoError(resolveHypervisorExtraArgs(config))
+	assert.Equal([]string{"-device", "virtio-rng-pci"}, config.HypervisorConfig.ExtraArgs)
+}
+
+func TestExtraArgsDeviceQemuParams(t *testing.T) {
+	assert := assert.New(t)
+
+	device := extraArgsDevice{args: []string{"-device", "virtio-rng-pci"}}
+	assert.True(device.Valid())
+	assert.Equal([]string{"-device", "virtio-rng-pci"}, device.QemuParams(nil))
+
+	assert.False(extraArgsDevice{}.Valid())
+}
@@ -6,11 +6,15 @@
 package virtcontainers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
 
@@ -52,6 +56,16 @@ const (
 
 	// devicesFileType represents a device file type
 	devicesFileType
+
+	// detachedProcessesFileType represents the set of a container's
+	// detached (exec --detach) processes, kept around so a later reaper
+	// can still collect their exit status.
+	detachedProcessesFileType
+
+	// hooksFileType represents a sandbox's recorded lifecycle hook
+	// executions (stdout/stderr/exit code), kept so a hook failure
+	// remains visible after the fact via dump-state.
+	hooksFileType
 )
 
 // configFile is the file name used for every JSON sandbox configuration.
@@ -80,6 +94,14 @@ const mountsFile = "mounts.json"
 // devicesFile is the file name storing a container's devices.
 const devicesFile = "devices.json"
 
+// detachedProcessesFile is the file name storing a container's detached
+// (exec --detach) processes.
+const detachedProcessesFile = "detached-processes.json"
+
+// hooksFile is the file name storing a sandbox's recorded lifecycle
+// hook executions.
+const hooksFile = "hooks.json"
+
 // dirMode is the permission bits used for creating a directory
 const dirMode = os.FileMode(0750) | os.ModeDir
 
@@ -97,6 +119,214 @@ var configStoragePath = filepath.Join("/var/lib", storagePathSuffix)
 // It will contain one state.json and one lock file for each created sandbox.
 var runStoragePath = filepath.Join("/run", storagePathSuffix)
 
+// runStorageRoots is the ordered list of candidate run-storage roots.
+// storeFile always tries runStorageRoots[0] first and fails over to the
+// next root on ENOSPC, so a full /run tmpfs does not make a sandbox
+// unmanageable.
+var runStorageRoots = []string{runStoragePath}
+
+// runRootMarkerFile records, under a sandbox's (more durable) config
+// directory, which run-storage root that sandbox's ephemeral resources
+// ended up on after a failover.
+const runRootMarkerFile = "run-root"
+
+// RegisterRunStorageRoots configures the ordered list of run-storage
+// roots used for a sandbox's ephemeral (state, lock, ...) resources.
+// Passing an empty slice restores the single-root default.
+func RegisterRunStorageRoots(roots []string) {
+	if len(roots) == 0 {
+		runStorageRoots = []string{runStoragePath}
+		return
+	}
+
+	runStorageRoots = roots
+}
+
+// activeRunRoot returns the run-storage root sandboxID's resources live
+// under, honoring a previous failover recorded by recordRunRoot.
+func activeRunRoot(sandboxID string) string {
+	marker := filepath.Join(configStoragePath, sandboxID, runRootMarkerFile)
+
+	data, err := ioutil.ReadFile(marker)
+	if err != nil {
+		return runStorageRoots[0]
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// recordRunRoot persists which run-storage root sandboxID is using. It is
+// stored alongside the sandbox config, which lives on a more durable
+// root than the run-storage roots it is recording a choice between.
+func recordRunRoot(sandboxID, root string) error {
+	dir := filepath.Join(activeConfigRoot(sandboxID), sandboxID)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, runRootMarkerFile), []byte(root), 0640)
+}
+
+// configRootOverrides maps a sandbox ID to a config root other than the
+// global configStoragePath, set by migrateSandboxStorage after a live
+// migration. Unlike a run-storage failover, which records its choice in
+// a marker file under the (unmoving) config root, there is nowhere
+// durable left to record a config-root override once the config root
+// itself is what moved, so this only lives for the life of the process.
+var (
+	configRootOverrides   = make(map[string]string)
+	configRootOverridesMu sync.Mutex
+)
+
+// activeConfigRoot returns the config root sandboxID's configuration and
+// sandbox-specific resources live under, honoring a previous migration
+// recorded by migrateSandboxStorage.
+func activeConfigRoot(sandboxID string) string {
+	configRootOverridesMu.Lock()
+	defer configRootOverridesMu.Unlock()
+
+	if root, ok := configRootOverrides[sandboxID]; ok {
+		return root
+	}
+
+	return configStoragePath
+}
+
+// setConfigRootOverride records that sandboxID's config-root resources
+// now live under root.
+func setConfigRootOverride(sandboxID, root string) {
+	configRootOverridesMu.Lock()
+	defer configRootOverridesMu.Unlock()
+
+	configRootOverrides[sandboxID] = root
+}
+
+// storageBackends maps a backend name, as set via SandboxConfig's
+// StorageBackend, to a constructor for the resourceStorage it selects.
+// "filesystem" is always registered; RegisterStorageBackend adds others.
+var (
+	storageBackends = map[string]func(lenientDeviceRestore bool) resourceStorage{
+		"filesystem": func(lenientDeviceRestore bool) resourceStorage {
+			return &filesystem{LenientDeviceRestore: lenientDeviceRestore}
+		},
+	}
+	storageBackendsMu sync.Mutex
+)
+
+// defaultStorageBackend is the name newResourceStorage and
+// activeStorageBackend fall back to when a sandbox does not specify one,
+// or specifies one that is not registered.
+const defaultStorageBackend = "filesystem"
+
+// RegisterStorageBackend makes a resourceStorage implementation other
+// than the built-in filesystem one selectable by name via
+// SandboxConfig.StorageBackend, e.g. for a BoltDB-backed implementation
+// on nodes with enough sandboxes that filesystem's per-sandbox directory
+// tree causes inode pressure and slow directory scans.
+func RegisterStorageBackend(name string, newStorage func(lenientDeviceRestore bool) resourceStorage) {
+	storageBackendsMu.Lock()
+	defer storageBackendsMu.Unlock()
+
+	storageBackends[name] = newStorage
+}
+
+// newResourceStorage constructs the resourceStorage that sandboxConfig
+// selects via StorageBackend, falling back to defaultStorageBackend if
+// unset or not registered.
+func newResourceStorage(sandboxConfig SandboxConfig) resourceStorage {
+	name := sandboxConfig.StorageBackend
+	if name == "" {
+		name = defaultStorageBackend
+	}
+
+	storageBackendsMu.Lock()
+	newStorage, ok := storageBackends[name]
+	storageBackendsMu.Unlock()
+
+	if !ok {
+		newStorage = storageBackends[defaultStorageBackend]
+	}
+
+	return newStorage(sandboxConfig.LenientDeviceRestore)
+}
+
+// currentManifestSchemaVersion is bumped whenever a stored resource's
+// on-disk shape changes in a way that requires a migration to read it
+// back with a newer binary. It is recorded into each sandbox's manifest
+// at create time so fetchSandbox can compare it against what's recorded
+// and run any migrations that bridge the gap before handing resources to
+// the selected backend.
+const currentManifestSchemaVersion = 1
+
+// manifestFile is the name of the small, backend-agnostic file recording
+// a sandbox's Manifest, under its (plain filesystem) config directory.
+// This is the only way fetchSandbox can know which resourceStorage to
+// construct for an existing sandbox ID before it has read that
+// sandbox's config, since the config is itself one of the resources a
+// backend stores.
+const manifestFile = "manifest.json"
+
+// Manifest records which storage backend persisted a sandbox and at
+// what schema version, so a later load can select the matching
+// resourceStorage implementation and run any migrations needed to read
+// its resources with the current binary.
+type Manifest struct {
+	Backend       string
+	SchemaVersion int
+}
+
+// recordSandboxManifest persists sandboxID's Manifest under its config
+// directory, recording backend as the storage backend it uses and the
+// current schema version.
+func recordSandboxManifest(sandboxID, backend string) error {
+	dir := filepath.Join(activeConfigRoot(sandboxID), sandboxID)
+	if err := os.MkdirAll(dir, dirMode); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(Manifest{Backend: backend, SchemaVersion: currentManifestSchemaVersion})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filepath.Join(dir, manifestFile), data, 0640)
+}
+
+// fetchSandboxManifest returns sandboxID's recorded Manifest, defaulting
+// to defaultStorageBackend at schema version 0 if none was recorded,
+// i.e. a sandbox created before the manifest existed.
+func fetchSandboxManifest(sandboxID string) (Manifest, error) {
+	path := filepath.Join(activeConfigRoot(sandboxID), sandboxID, manifestFile)
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Manifest{Backend: defaultStorageBackend, SchemaVersion: 0}, nil
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return Manifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// migrateSandboxResources is where migrations bridging manifest's
+// recorded SchemaVersion up to currentManifestSchemaVersion would run,
+// before fetchSandbox hands sandboxID's resources to the backend it
+// selects. There are no schema versions older than the current one yet,
+// so this only warns; it does not fail the load, since an unrecognized
+// older version is not necessarily unreadable by the current backend.
+func migrateSandboxResources(sandboxID string, manifest Manifest) error {
+	virtLog.WithFields(logrus.Fields{
+		"sandbox":         sandboxID,
+		"recorded-schema": manifest.SchemaVersion,
+		"current-schema":  currentManifestSchemaVersion,
+	}).Warn("sandbox manifest schema version does not match the running binary; no migration available")
+
+	return nil
+}
+
 // resourceStorage is the virtcontainers resources (configuration, state, etc...)
 // storage interface.
 // The default resource storage implementation is filesystem.
@@ -112,10 +342,12 @@ type resourceStorage interface {
 	// Sandbox resources
 	storeSandboxResource(sandboxID string, resource sandboxResource, data interface{}) error
 	deleteSandboxResources(sandboxID string, resources []sandboxResource) error
+	listSandboxes() ([]string, error)
 	fetchSandboxConfig(sandboxID string) (SandboxConfig, error)
 	fetchSandboxState(sandboxID string) (State, error)
 	fetchSandboxNetwork(sandboxID string) (NetworkNamespace, error)
 	storeSandboxNetwork(sandboxID string, networkNS NetworkNamespace) error
+	fetchSandboxHooks(sandboxID string) ([]HookExecution, error)
 
 	// Hypervisor resources
 	fetchHypervisorState(sandboxID string, state interface{}) error
@@ -128,18 +360,29 @@ type resourceStorage interface {
 	// Container resources
 	storeContainerResource(sandboxID, containerID string, resource sandboxResource, data interface{}) error
 	deleteContainerResources(sandboxID, containerID string, resources []sandboxResource) error
+	fetchSandboxContainers(sandboxID string) ([]string, error)
 	fetchContainerConfig(sandboxID, containerID string) (ContainerConfig, error)
 	fetchContainerState(sandboxID, containerID string) (State, error)
+	fetchContainerStateField(sandboxID, containerID string) (stateString, error)
 	fetchContainerProcess(sandboxID, containerID string) (Process, error)
 	storeContainerProcess(sandboxID, containerID string, process Process) error
 	fetchContainerMounts(sandboxID, containerID string) ([]Mount, error)
 	storeContainerMounts(sandboxID, containerID string, mounts []Mount) error
 	fetchContainerDevices(sandboxID, containerID string) ([]api.Device, error)
 	storeContainerDevices(sandboxID, containerID string, devices []api.Device) error
+	fetchContainerDetachedProcesses(sandboxID, containerID string) ([]Process, error)
+	storeContainerDetachedProcesses(sandboxID, containerID string, processes []Process) error
 }
 
 // filesystem is a resourceStorage interface implementation for a local filesystem.
 type filesystem struct {
+	// LenientDeviceRestore, when set, makes fetchDeviceFile preserve
+	// devices of a type it does not recognize as opaque TypedDevice
+	// entries instead of failing the restore. This lets a sandbox
+	// created by a newer runtime with devices.json entries of types
+	// this older runtime doesn't know about still be restored, minus
+	// those devices, rather than being bricked outright.
+	LenientDeviceRestore bool
 }
 
 // Logger returns a logrus logger appropriate for logging filesystem messages
@@ -187,23 +430,251 @@ func (fs *filesystem) createAllResources(sandbox *Sandbox) (err error) {
 }
 
 func (fs *filesystem) storeFile(file string, data interface{}) error {
+	return fs.storeFileCtx(context.Background(), file, data)
+}
+
+// storeFileCtx behaves like storeFile but aborts before touching the
+// filesystem if ctx has already been cancelled or its deadline exceeded,
+// so that callers backed by a slow or networked storage root can bound
+// how long a store is allowed to block. If file lives under a run-storage
+// root and writing it fails with ENOSPC, it fails over to the next
+// configured run-storage root and records the switch so later fetches
+// look in the right place.
+func (fs *filesystem) storeFileCtx(ctx context.Context, file string, data interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := writeJSONFile(file, data)
+	if err == nil {
+		return nil
+	}
+
+	if !isENOSPC(err) {
+		return err
+	}
+
+	return fs.storeFileWithFailover(file, data, err)
+}
+
+// currentFileSchemaVersion is the schema version written into the
+// fileEnvelope wrapping every document storeFile produces. It must be
+// bumped whenever a change to a stored struct's JSON layout would stop
+// an older binary's unmarshal from working, with a migration registered
+// in fileMigrations to carry existing on-disk documents forward.
+const currentFileSchemaVersion = 2
+
+// legacyUnversionedSchema is the implicit schema version of documents
+// written before fileEnvelope existed: a bare marshalled struct with no
+// wrapper at all. fetchFileCtx treats any document it cannot parse as a
+// fileEnvelope as being at this version.
+const legacyUnversionedSchema = 1
+
+// fileEnvelope wraps every document storeFile writes with the schema
+// version it was written at, so fetchFileCtx can detect a document
+// written by an older binary and migrate it forward before unmarshalling
+// into the caller's struct.
+type fileEnvelope struct {
+	SchemaVersion int             `json:"schema_version"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// fileMigrations maps a schema version to the function that upgrades a
+// document from that version to the next one. fetchFileCtx walks this
+// chain until it reaches currentFileSchemaVersion.
+var fileMigrations = map[int]func(json.RawMessage) (json.RawMessage, error){
+	legacyUnversionedSchema: migrateFileSchemaV1ToV2,
+}
+
+// migrateFileSchemaV1ToV2 upgrades a document from schema version 1 (a
+// bare struct, written before fileEnvelope existed) to version 2 (the
+// same struct, now wrapped in fileEnvelope). The struct layout itself
+// did not change between the two versions, so the raw document is
+// carried forward unmodified.
+func migrateFileSchemaV1ToV2(data json.RawMessage) (json.RawMessage, error) {
+	return data, nil
+}
+
+// writeJSONFile marshals data as JSON, wraps it in a fileEnvelope
+// recording currentFileSchemaVersion, and atomically replaces file with
+// the result via atomicWriteFile.
+func writeJSONFile(file string, data interface{}) error {
 	if file == "" {
 		return errNeedFile
 	}
 
-	f, err := os.Create(file)
+	raw, err := json.Marshal(data)
 	if err != nil {
-		return err
+		return fmt.Errorf("Could not marshall data: %s", err)
 	}
-	defer f.Close()
 
-	jsonOut, err := json.Marshal(data)
+	jsonOut, err := json.Marshal(fileEnvelope{SchemaVersion: currentFileSchemaVersion, Data: raw})
 	if err != nil {
 		return fmt.Errorf("Could not marshall data: %s", err)
 	}
-	f.Write(jsonOut)
 
-	return nil
+	return atomicWriteFile(file, jsonOut)
+}
+
+// unwrapFileEnvelope extracts the versioned document carried by fileData,
+// migrating it forward to currentFileSchemaVersion if it was written by
+// an older binary. It returns a clear error naming the on-disk version
+// and the supported range if no migration path exists.
+func unwrapFileEnvelope(fileData []byte) (json.RawMessage, error) {
+	var envelope fileEnvelope
+	if err := json.Unmarshal(fileData, &envelope); err != nil {
+		return nil, err
+	}
+
+	version := envelope.SchemaVersion
+	raw := envelope.Data
+	if version == 0 {
+		version = legacyUnversionedSchema
+		raw = fileData
+	}
+
+	for version < currentFileSchemaVersion {
+		migrate, ok := fileMigrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration registered to upgrade schema version %d to the supported range [%d-%d]",
+				version, legacyUnversionedSchema, currentFileSchemaVersion)
+		}
+
+		upgraded, err := migrate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to migrate schema version %d to %d: %s", version, version+1, err)
+		}
+
+		raw = upgraded
+		version++
+	}
+
+	if version > currentFileSchemaVersion {
+		return nil, fmt.Errorf("on-disk schema version %d is newer than the supported range [%d-%d]",
+			version, legacyUnversionedSchema, currentFileSchemaVersion)
+	}
+
+	return raw, nil
+}
+
+// atomicWriteFile writes data to a temporary file in file's directory,
+// fsyncs it, then renames it over file. The rename is atomic, so a crash
+// or power loss at any point leaves file either fully holding its
+// previous contents or fully holding the new ones, never truncated or
+// half-written. The parent directory is fsynced afterwards too, since
+// the rename is only durable once that directory's entry for file has
+// itself been flushed.
+func atomicWriteFile(file string, data []byte) error {
+	dir := filepath.Dir(file)
+
+	tmp, err := ioutil.TempFile(dir, filepath.Base(file)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op once the rename below succeeds
+
+	if err := os.Chmod(tmpName, 0640); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	_, writeErr := tmp.Write(data)
+	syncErr := tmp.Sync()
+	closeErr := tmp.Close()
+
+	if writeErr != nil {
+		return writeErr
+	}
+	if syncErr != nil {
+		return syncErr
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+
+	if err := os.Rename(tmpName, file); err != nil {
+		return err
+	}
+
+	return fsyncDir(dir)
+}
+
+// fsyncDir fsyncs dir itself, so a preceding rename or create within it is
+// durable against a crash, not just the file that was renamed or created.
+func fsyncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// lockedReadFile behaves like ioutil.ReadFile. It used to hold a shared
+// lock on file for the duration of the read, coordinating with an
+// exclusive lock writeJSONFile held while writing; now that
+// atomicWriteFile writes to a temp file and renames it over file
+// instead, a read here always lands on the document from before a
+// concurrent write started or the one after it finished, never a torn
+// one from the middle, with no lock needed to guarantee it. The name is
+// kept because callers read it as "the locked, coordinated way to read
+// one of these files", which remains true even though the mechanism
+// backing that guarantee changed.
+func lockedReadFile(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ioutil.ReadAll(f)
+}
+
+// isENOSPC reports whether err ultimately wraps syscall.ENOSPC.
+func isENOSPC(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+
+	errno, ok := err.(syscall.Errno)
+	return ok && errno == syscall.ENOSPC
+}
+
+// storeFileWithFailover retries writing file under each configured
+// run-storage root, in order, after the primary root failed with origErr.
+// The sandbox ID is derived from file's path relative to the root it was
+// originally under; the chosen root is then persisted via recordRunRoot
+// so fetches for that sandbox use it too.
+func (fs *filesystem) storeFileWithFailover(file string, data interface{}, origErr error) error {
+	for i, root := range runStorageRoots {
+		rel, relErr := filepath.Rel(root, file)
+		if relErr != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		sandboxID := strings.SplitN(rel, string(filepath.Separator), 2)[0]
+
+		for _, altRoot := range runStorageRoots[i+1:] {
+			altFile := filepath.Join(altRoot, rel)
+
+			if err := os.MkdirAll(filepath.Dir(altFile), dirMode); err != nil {
+				continue
+			}
+
+			if err := writeJSONFile(altFile, data); err == nil {
+				if err := recordRunRoot(sandboxID, altRoot); err != nil {
+					return err
+				}
+				return nil
+			}
+		}
+
+		return origErr
+	}
+
+	return origErr
 }
 
 // TypedDevice is used as an intermediate representation for marshalling
@@ -217,6 +688,30 @@ type TypedDevice struct {
 	Data json.RawMessage
 }
 
+// opaqueDevice represents a device of a type this runtime does not
+// recognize. fetchDeviceFile falls back to it, in lenient device-restore
+// mode, instead of failing the whole restore outright. Attach and Detach
+// are no-ops, since there is no driver able to plug or unplug it, but its
+// raw persisted data is kept around so a later storeDeviceFile call does
+// not silently drop it from devices.json.
+type opaqueDevice struct {
+	devType config.DeviceType
+	data    json.RawMessage
+}
+
+func (d *opaqueDevice) Attach(api.DeviceReceiver) error { return nil }
+func (d *opaqueDevice) Detach(api.DeviceReceiver) error { return nil }
+
+func (d *opaqueDevice) DeviceType() config.DeviceType {
+	return d.devType
+}
+
+// MarshalJSON makes storeDeviceFile re-persist the device's original
+// data unchanged, rather than marshalling this wrapper's own fields.
+func (d *opaqueDevice) MarshalJSON() ([]byte, error) {
+	return d.data, nil
+}
+
 // storeDeviceFile is used to provide custom marshalling for Device objects.
 // Device is first marshalled into TypedDevice to include the type
 // of the Device object.
@@ -225,12 +720,6 @@ func (fs *filesystem) storeDeviceFile(file string, data interface{}) error {
 		return errNeedFile
 	}
 
-	f, err := os.Create(file)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
 	devices, ok := data.([]api.Device)
 	if !ok {
 		return fmt.Errorf("Incorrect data type received, Expected []Device")
@@ -238,6 +727,14 @@ func (fs *filesystem) storeDeviceFile(file string, data interface{}) error {
 
 	var typedDevices []TypedDevice
 	for _, d := range devices {
+		// An FdDevice only carries a host file descriptor, which is not
+		// meaningful once this process exits, so persisting it would
+		// silently produce a file that can never be restored from.
+		// Reject it explicitly instead of writing garbage.
+		if _, ok := d.(*drivers.FdDevice); ok {
+			return fmt.Errorf("cannot persist fd device %v: file descriptors do not survive a restart, it must be re-passed", d)
+		}
+
 		tempJSON, _ := json.Marshal(d)
 		typedDevice := TypedDevice{
 			Type: string(d.DeviceType()),
@@ -251,25 +748,33 @@ func (fs *filesystem) storeDeviceFile(file string, data interface{}) error {
 		return fmt.Errorf("Could not marshal devices: %s", err)
 	}
 
-	if _, err := f.Write(jsonOut); err != nil {
-		return err
-	}
-
-	return nil
+	return atomicWriteFile(file, jsonOut)
 }
 
 func (fs *filesystem) fetchFile(file string, resource sandboxResource, data interface{}) error {
+	return fs.fetchFileCtx(context.Background(), file, resource, data)
+}
+
+// fetchFileCtx behaves like fetchFile but aborts before reading the file
+// if ctx has already been cancelled or its deadline exceeded.
+func (fs *filesystem) fetchFileCtx(ctx context.Context, file string, resource sandboxResource, data interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	if file == "" {
 		return errNeedFile
 	}
 
-	fileData, err := ioutil.ReadFile(file)
+	fileData, err := lockedReadFile(file)
 	if err != nil {
 		return err
 	}
 
-	switch resource {
-	case devicesFileType:
+	// devices.json is written by storeDeviceFile, not storeFile, and
+	// carries its own per-device TypedDevice versioning rather than a
+	// fileEnvelope, so it is unmarshalled as-is.
+	if resource == devicesFileType {
 		devices, ok := data.(*[]api.Device)
 		if !ok {
 			return fmt.Errorf("Could not cast %v into *[]Device type", data)
@@ -278,7 +783,12 @@ func (fs *filesystem) fetchFile(file string, resource sandboxResource, data inte
 		return fs.fetchDeviceFile(fileData, devices)
 	}
 
-	return json.Unmarshal(fileData, data)
+	raw, err := unwrapFileEnvelope(fileData)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, data)
 }
 
 // fetchDeviceFile is used for custom unmarshalling of device interface objects.
@@ -321,7 +831,15 @@ func (fs *filesystem) fetchDeviceFile(fileData []byte, devices *[]api.Device) er
 			l.Infof("Generic device unmarshalled [%v]", device)
 
 		default:
-			return fmt.Errorf("Unknown device type, could not unmarshal")
+			if !fs.LenientDeviceRestore {
+				return fmt.Errorf("Unknown device type, could not unmarshal")
+			}
+
+			l.Warning("Unknown device type, preserving it as opaque and skipping reattachment")
+			tempDevices = append(tempDevices, &opaqueDevice{
+				devType: config.DeviceType(d.Type),
+				data:    d.Data,
+			})
 		}
 	}
 
@@ -337,7 +855,7 @@ func (fs *filesystem) fetchDeviceFile(fileData []byte, devices *[]api.Device) er
 func resourceNeedsContainerID(sandboxSpecific bool, resource sandboxResource) bool {
 
 	switch resource {
-	case lockFileType, networkFileType, hypervisorFileType, agentFileType:
+	case lockFileType, networkFileType, hypervisorFileType, agentFileType, hooksFileType:
 		// sandbox-specific resources
 		return false
 	default:
@@ -358,10 +876,10 @@ func resourceDir(sandboxSpecific bool, sandboxID, containerID string, resource s
 
 	switch resource {
 	case configFileType:
-		path = configStoragePath
+		path = activeConfigRoot(sandboxID)
 		break
-	case stateFileType, networkFileType, processFileType, lockFileType, mountsFileType, devicesFileType, hypervisorFileType, agentFileType:
-		path = runStoragePath
+	case stateFileType, networkFileType, processFileType, lockFileType, mountsFileType, devicesFileType, detachedProcessesFileType, hypervisorFileType, agentFileType, hooksFileType:
+		path = activeRunRoot(sandboxID)
 		break
 	default:
 		return "", errInvalidResource
@@ -411,6 +929,12 @@ func (fs *filesystem) resourceURI(sandboxSpecific bool, sandboxID, containerID s
 	case devicesFileType:
 		filename = devicesFile
 		break
+	case detachedProcessesFileType:
+		filename = detachedProcessesFile
+		break
+	case hooksFileType:
+		filename = hooksFile
+		break
 	default:
 		return "", "", errInvalidResource
 	}
@@ -456,6 +980,8 @@ func (fs *filesystem) commonResourceChecks(sandboxSpecific bool, sandboxID, cont
 	case processFileType:
 	case mountsFileType:
 	case devicesFileType:
+	case detachedProcessesFileType:
+	case hooksFileType:
 	default:
 		return errInvalidResource
 	}
@@ -542,36 +1068,62 @@ func (fs *filesystem) storeDeviceResource(sandboxSpecific bool, sandboxID, conta
 	return fs.storeDeviceFile(devicesFile, file)
 }
 
-func (fs *filesystem) storeResource(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) error {
+// storeResourceCtx behaves like storeResource but returns ctx.Err()
+// immediately if ctx is already done, instead of issuing the store.
+func (fs *filesystem) storeResourceCtx(ctx context.Context, sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return fs.storeResource(sandboxSpecific, sandboxID, containerID, resource, data)
+}
+
+func (fs *filesystem) storeResource(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) (err error) {
+	defer func() { storageMetrics.observe(storageOpStore, resource, err) }()
+
 	if err := fs.commonResourceChecks(sandboxSpecific, sandboxID, containerID, resource); err != nil {
 		return err
 	}
 
 	switch file := data.(type) {
 	case SandboxConfig, ContainerConfig:
-		return fs.storeSandboxAndContainerConfigResource(sandboxSpecific, sandboxID, containerID, resource, file)
+		err = fs.storeSandboxAndContainerConfigResource(sandboxSpecific, sandboxID, containerID, resource, file)
 
 	case State:
-		return fs.storeStateResource(sandboxSpecific, sandboxID, containerID, resource, file)
+		err = fs.storeStateResource(sandboxSpecific, sandboxID, containerID, resource, file)
 
 	case NetworkNamespace:
-		return fs.storeNetworkResource(sandboxSpecific, sandboxID, containerID, resource, file)
+		err = fs.storeNetworkResource(sandboxSpecific, sandboxID, containerID, resource, file)
 
 	case Process:
-		return fs.storeProcessResource(sandboxSpecific, sandboxID, containerID, resource, file)
+		err = fs.storeProcessResource(sandboxSpecific, sandboxID, containerID, resource, file)
 
 	case []Mount:
-		return fs.storeMountResource(sandboxSpecific, sandboxID, containerID, resource, file)
+		err = fs.storeMountResource(sandboxSpecific, sandboxID, containerID, resource, file)
 
 	case []api.Device:
-		return fs.storeDeviceResource(sandboxSpecific, sandboxID, containerID, resource, file)
+		err = fs.storeDeviceResource(sandboxSpecific, sandboxID, containerID, resource, file)
 
 	default:
-		return fmt.Errorf("Invalid resource data type")
+		err = fmt.Errorf("Invalid resource data type")
+	}
+
+	return wrapStorageErr(storageOpStore, resource, sandboxID, containerID, err)
+}
+
+// fetchResourceCtx behaves like fetchResource but returns ctx.Err()
+// immediately if ctx is already done, instead of issuing the fetch.
+func (fs *filesystem) fetchResourceCtx(ctx context.Context, sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
 	}
+
+	return fs.fetchResource(sandboxSpecific, sandboxID, containerID, resource, data)
 }
 
-func (fs *filesystem) fetchResource(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) error {
+func (fs *filesystem) fetchResource(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) (err error) {
+	defer func() { storageMetrics.observe(storageOpFetch, resource, err) }()
+
 	if err := fs.commonResourceChecks(sandboxSpecific, sandboxID, containerID, resource); err != nil {
 		return err
 	}
@@ -581,17 +1133,66 @@ func (fs *filesystem) fetchResource(sandboxSpecific bool, sandboxID, containerID
 		return err
 	}
 
-	return fs.fetchFile(path, resource, data)
+	return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, fs.fetchFile(path, resource, data))
 }
 
 func (fs *filesystem) storeSandboxResource(sandboxID string, resource sandboxResource, data interface{}) error {
-	return fs.storeResource(true, sandboxID, "", resource, data)
+	return fs.storeSandboxResourceCtx(context.Background(), sandboxID, resource, data)
+}
+
+// storeSandboxResourceCtx is the context-aware variant of
+// storeSandboxResource, allowing callers to bound or cancel the store.
+func (fs *filesystem) storeSandboxResourceCtx(ctx context.Context, sandboxID string, resource sandboxResource, data interface{}) error {
+	return fs.storeResourceCtx(ctx, true, sandboxID, "", resource, data)
+}
+
+// listSandboxes returns the IDs of every sandbox with a valid state.json
+// persisted under runStoragePath, for callers (e.g. a monitoring daemon)
+// that need to enumerate sandboxes without already knowing their IDs. A
+// sandbox directory that is present but has a missing or malformed
+// state.json -- for example, a partially-deleted sandbox whose config
+// has already been removed but whose run directory has not yet been
+// cleaned up -- is logged and skipped rather than failing the listing.
+func (fs *filesystem) listSandboxes() ([]string, error) {
+	entries, err := ioutil.ReadDir(runStoragePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	var sandboxIDs []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		sandboxID := entry.Name()
+
+		if _, err := fs.fetchSandboxState(sandboxID); err != nil {
+			fs.Logger().WithError(err).WithField("sandbox", sandboxID).Warn("skipping sandbox with no valid state.json")
+			continue
+		}
+
+		sandboxIDs = append(sandboxIDs, sandboxID)
+	}
+
+	return sandboxIDs, nil
 }
 
 func (fs *filesystem) fetchSandboxConfig(sandboxID string) (SandboxConfig, error) {
+	return fs.fetchSandboxConfigCtx(context.Background(), sandboxID)
+}
+
+// fetchSandboxConfigCtx is the context-aware variant of fetchSandboxConfig,
+// allowing callers to bound or cancel the fetch.
+func (fs *filesystem) fetchSandboxConfigCtx(ctx context.Context, sandboxID string) (SandboxConfig, error) {
 	var sandboxConfig SandboxConfig
 
-	if err := fs.fetchResource(true, sandboxID, "", configFileType, &sandboxConfig); err != nil {
+	if err := fs.fetchResourceCtx(ctx, true, sandboxID, "", configFileType, &sandboxConfig); err != nil {
 		return SandboxConfig{}, err
 	}
 
@@ -630,6 +1231,30 @@ func (fs *filesystem) storeSandboxNetwork(sandboxID string, networkNS NetworkNam
 	return fs.storeSandboxResource(sandboxID, networkFileType, networkNS)
 }
 
+// fetchSandboxHooks returns the hook executions recorded for sandboxID
+// so far, or an empty slice if none have been recorded yet.
+func (fs *filesystem) fetchSandboxHooks(sandboxID string) ([]HookExecution, error) {
+	path, _, err := fs.sandboxURI(sandboxID, hooksFileType)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	var executions []HookExecution
+	if err := fs.fetchResource(true, sandboxID, "", hooksFileType, &executions); err != nil {
+		return nil, err
+	}
+
+	return executions, nil
+}
+
 func (fs *filesystem) storeHypervisorState(sandboxID string, state interface{}) error {
 	hypervisorFile, _, err := fs.resourceURI(true, sandboxID, "", hypervisorFileType)
 	if err != nil {
@@ -656,13 +1281,118 @@ func (fs *filesystem) deleteSandboxResources(sandboxID string, resources []sandb
 	for _, resource := range resources {
 		_, dir, err := fs.sandboxURI(sandboxID, resource)
 		if err != nil {
+			storageMetrics.observe(storageOpDelete, resource, err)
 			return err
 		}
 
 		err = os.RemoveAll(dir)
+		storageMetrics.observe(storageOpDelete, resource, err)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copySandboxDir copies srcRoot/sandboxID recursively into
+// dstRoot/sandboxID, fsyncing each file as it is written. It is a no-op
+// if the source directory does not exist, since not every sandbox
+// touches every resource root (a sandbox that never failed over, for
+// example, has nothing under its alternate run-storage roots).
+func copySandboxDir(srcRoot, dstRoot, sandboxID string) error {
+	srcDir := filepath.Join(srcRoot, sandboxID)
+	dstDir := filepath.Join(dstRoot, sandboxID)
+
+	if _, err := os.Stat(srcDir); os.IsNotExist(err) {
+		return nil
+	}
+
+	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+
+		dstPath := filepath.Join(dstDir, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(dstPath, info.Mode())
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		if err := ioutil.WriteFile(dstPath, data, info.Mode()); err != nil {
+			return err
+		}
+
+		f, err := os.Open(dstPath)
 		if err != nil {
 			return err
 		}
+		defer f.Close()
+
+		return f.Sync()
+	})
+}
+
+// migrateSandboxStorage moves sandboxID's persisted resources onto
+// newRunRoot and newConfigRoot, for relocating a live sandbox off a
+// failing or decommissioned disk without restarting it. Resources are
+// copied and fsynced under the new roots, and reads are only repointed
+// at them once both copies have succeeded, so a failure partway through
+// leaves the sandbox exactly where it started rather than split across
+// old and new roots.
+func (fs *filesystem) migrateSandboxStorage(sandboxID, newRunRoot, newConfigRoot string) (err error) {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if newRunRoot == "" || newConfigRoot == "" {
+		return fmt.Errorf("newRunRoot and newConfigRoot must both be specified")
+	}
+
+	oldRunRoot := activeRunRoot(sandboxID)
+	oldConfigRoot := activeConfigRoot(sandboxID)
+
+	if err = copySandboxDir(oldRunRoot, newRunRoot, sandboxID); err != nil {
+		return fmt.Errorf("could not migrate run storage: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(filepath.Join(newRunRoot, sandboxID))
+		}
+	}()
+
+	if err = copySandboxDir(oldConfigRoot, newConfigRoot, sandboxID); err != nil {
+		return fmt.Errorf("could not migrate config storage: %v", err)
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(filepath.Join(newConfigRoot, sandboxID))
+		}
+	}()
+
+	setConfigRootOverride(sandboxID, newConfigRoot)
+
+	if err = recordRunRoot(sandboxID, newRunRoot); err != nil {
+		setConfigRootOverride(sandboxID, oldConfigRoot)
+		return fmt.Errorf("could not repoint run storage: %v", err)
+	}
+
+	if rmErr := os.RemoveAll(filepath.Join(oldRunRoot, sandboxID)); rmErr != nil {
+		fs.Logger().WithError(rmErr).Warn("could not remove old run storage after migrating sandbox")
+	}
+
+	if rmErr := os.RemoveAll(filepath.Join(oldConfigRoot, sandboxID)); rmErr != nil {
+		fs.Logger().WithError(rmErr).Warn("could not remove old config storage after migrating sandbox")
 	}
 
 	return nil
@@ -680,6 +1410,50 @@ func (fs *filesystem) storeContainerResource(sandboxID, containerID string, reso
 	return fs.storeResource(false, sandboxID, containerID, resource, data)
 }
 
+// fetchSandboxContainers returns the IDs of every container persisted
+// under sandboxID with a valid config.json, for callers (e.g. reloading
+// a sandbox after a restart) that need to enumerate its containers
+// without already knowing their IDs. A subdirectory with a missing or
+// malformed config.json is logged and skipped rather than failing the
+// listing; the sandbox's own top-level files live directly in this
+// directory rather than in a subdirectory, so they are naturally
+// excluded.
+func (fs *filesystem) fetchSandboxContainers(sandboxID string) ([]string, error) {
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	sandboxConfigDir := filepath.Join(activeConfigRoot(sandboxID), sandboxID)
+
+	entries, err := ioutil.ReadDir(sandboxConfigDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+
+		return nil, err
+	}
+
+	var containerIDs []string
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		containerID := entry.Name()
+
+		if _, err := fs.fetchContainerConfig(sandboxID, containerID); err != nil {
+			fs.Logger().WithError(err).WithField("sandbox", sandboxID).WithField("container", containerID).Warn("skipping container with no valid config.json")
+			continue
+		}
+
+		containerIDs = append(containerIDs, containerID)
+	}
+
+	return containerIDs, nil
+}
+
 func (fs *filesystem) fetchContainerConfig(sandboxID, containerID string) (ContainerConfig, error) {
 	var config ContainerConfig
 
@@ -700,6 +1474,35 @@ func (fs *filesystem) fetchContainerState(sandboxID, containerID string) (State,
 	return state, nil
 }
 
+// fetchContainerStateField reads a container's state.json and decodes only
+// the "state" field, avoiding the cost of unmarshalling the full State
+// structure when callers just need to know whether a container is running.
+func (fs *filesystem) fetchContainerStateField(sandboxID, containerID string) (stateString, error) {
+	if err := fs.commonResourceChecks(false, sandboxID, containerID, stateFileType); err != nil {
+		return "", err
+	}
+
+	path, _, err := fs.resourceURI(false, sandboxID, containerID, stateFileType)
+	if err != nil {
+		return "", err
+	}
+
+	fileData, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var partial struct {
+		State stateString `json:"state"`
+	}
+
+	if err := json.Unmarshal(fileData, &partial); err != nil {
+		return "", err
+	}
+
+	return partial.State, nil
+}
+
 func (fs *filesystem) fetchContainerProcess(sandboxID, containerID string) (Process, error) {
 	var process Process
 
@@ -738,24 +1541,37 @@ func (fs *filesystem) storeContainerMounts(sandboxID, containerID string, mounts
 	return fs.storeContainerResource(sandboxID, containerID, mountsFileType, mounts)
 }
 
+func (fs *filesystem) fetchContainerDetachedProcesses(sandboxID, containerID string) ([]Process, error) {
+	var processes []Process
+
+	if err := fs.fetchResource(false, sandboxID, containerID, detachedProcessesFileType, &processes); err != nil {
+		return []Process{}, err
+	}
+
+	return processes, nil
+}
+
+func (fs *filesystem) storeContainerDetachedProcesses(sandboxID, containerID string, processes []Process) error {
+	return fs.storeContainerResource(sandboxID, containerID, detachedProcessesFileType, processes)
+}
+
 func (fs *filesystem) storeContainerDevices(sandboxID, containerID string, devices []api.Device) error {
 	return fs.storeContainerResource(sandboxID, containerID, devicesFileType, devices)
 }
 
 func (fs *filesystem) deleteContainerResources(sandboxID, containerID string, resources []sandboxResource) error {
 	if resources == nil {
-		resources = []sandboxResource{configFileType, stateFileType}
+		resources = []sandboxResource{configFileType, stateFileType, processFileType, mountsFileType, devicesFileType}
 	}
 
 	for _, resource := range resources {
-		_, dir, err := fs.sandboxURI(sandboxID, resource)
+		_, dir, err := fs.containerURI(sandboxID, containerID, resource)
 		if err != nil {
 			return err
 		}
 
-		containerDir := filepath.Join(dir, containerID, "/")
-
-		err = os.RemoveAll(containerDir)
+		err = os.RemoveAll(dir)
+		storageMetrics.observe(storageOpDelete, resource, err)
 		if err != nil {
 			return err
 		}
@@ -6,13 +6,21 @@
 package virtcontainers
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"sync"
+	"syscall"
 	"testing"
 
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/device/drivers"
 	"github.com/kata-containers/runtime/virtcontainers/device/manager"
 )
 
@@ -141,7 +149,7 @@ func TestFilesystemStoreFileSuccessfulNotExisting(t *testing.T) {
 		Field2: "value2",
 	}
 
-	expected := "{\"Field1\":\"value1\",\"Field2\":\"value2\"}"
+	expected := "{\"schema_version\":2,\"data\":{\"Field1\":\"value1\",\"Field2\":\"value2\"}}"
 
 	err := fs.storeFile(path, data)
 	if err != nil {
@@ -175,7 +183,7 @@ func TestFilesystemStoreFileSuccessfulExisting(t *testing.T) {
 		Field2: "value2",
 	}
 
-	expected := "{\"Field1\":\"value1\",\"Field2\":\"value2\"}"
+	expected := "{\"schema_version\":2,\"data\":{\"Field1\":\"value1\",\"Field2\":\"value2\"}}"
 
 	err = fs.storeFile(path, data)
 	if err != nil {
@@ -330,6 +338,137 @@ func TestFilesystemFetchContainerConfigFailingSandboxIDEmpty(t *testing.T) {
 	}
 }
 
+func TestFilesystemFetchContainerStateFieldMatchesFullFetch(t *testing.T) {
+	fs := &filesystem{}
+	contID := "100"
+
+	contStateDir := filepath.Join(runStoragePath, testSandboxID, contID)
+	os.MkdirAll(contStateDir, dirMode)
+
+	path := filepath.Join(contStateDir, stateFile)
+	os.Remove(path)
+
+	state := State{
+		State: StateRunning,
+	}
+
+	if err := fs.storeFile(path, state); err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := fs.fetchContainerState(testSandboxID, contID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	field, err := fs.fetchContainerStateField(testSandboxID, contID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if field != full.State {
+		t.Fatalf("fetchContainerStateField() = %q, fetchContainerState().State = %q", field, full.State)
+	}
+}
+
+func TestFilesystemFetchContainerStateFieldFailingContIDEmpty(t *testing.T) {
+	fs := &filesystem{}
+
+	_, err := fs.fetchContainerStateField(testSandboxID, "")
+	if err == nil {
+		t.Fatal()
+	}
+}
+
+func BenchmarkFilesystemFetchContainerStateField(b *testing.B) {
+	fs := &filesystem{}
+	contID := "100"
+
+	contStateDir := filepath.Join(runStoragePath, testSandboxID, contID)
+	os.MkdirAll(contStateDir, dirMode)
+
+	path := filepath.Join(contStateDir, stateFile)
+	os.Remove(path)
+
+	state := State{
+		State: StateRunning,
+	}
+
+	if err := fs.storeFile(path, state); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := fs.fetchContainerStateField(testSandboxID, contID); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFilesystemStoreSandboxResourceCtxCancelled(t *testing.T) {
+	fs := &filesystem{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := fs.storeSandboxResourceCtx(ctx, testSandboxID, stateFileType, State{State: StateReady})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestFilesystemStoreFileWithFailoverSwitchesRoot(t *testing.T) {
+	sandboxID := "failover-sandbox"
+	primaryRoot := filepath.Join(testDir, "run-root-primary")
+	secondaryRoot := filepath.Join(testDir, "run-root-secondary")
+
+	defer RegisterRunStorageRoots(nil)
+	RegisterRunStorageRoots([]string{primaryRoot, secondaryRoot})
+
+	fs := &filesystem{}
+	file := filepath.Join(primaryRoot, sandboxID, stateFile)
+	state := State{State: StateRunning}
+
+	origErr := &os.PathError{Op: "write", Path: file, Err: syscall.ENOSPC}
+	if err := fs.storeFileWithFailover(file, state, origErr); err != nil {
+		t.Fatalf("storeFileWithFailover() unexpectedly failed: %v", err)
+	}
+
+	failedOverFile := filepath.Join(secondaryRoot, sandboxID, stateFile)
+	if _, err := os.Stat(failedOverFile); err != nil {
+		t.Fatalf("expected %s to exist after failover: %v", failedOverFile, err)
+	}
+
+	if root := activeRunRoot(sandboxID); root != secondaryRoot {
+		t.Fatalf("activeRunRoot() = %q, want %q", root, secondaryRoot)
+	}
+}
+
+func TestFilesystemIsENOSPC(t *testing.T) {
+	enospcErr := &os.PathError{Op: "write", Path: "/foo", Err: syscall.ENOSPC}
+	if !isENOSPC(enospcErr) {
+		t.Fatal("isENOSPC() = false, want true for wrapped ENOSPC")
+	}
+
+	if isENOSPC(&os.PathError{Op: "write", Path: "/foo", Err: syscall.EACCES}) {
+		t.Fatal("isENOSPC() = true, want false for unrelated errno")
+	}
+
+	if isENOSPC(nil) {
+		t.Fatal("isENOSPC() = true, want false for nil error")
+	}
+}
+
+func TestFilesystemActiveRunRootDefaultsWithoutFailover(t *testing.T) {
+	defer RegisterRunStorageRoots(nil)
+	RegisterRunStorageRoots([]string{runStoragePath, filepath.Join(testDir, "unused-secondary-root")})
+
+	if root := activeRunRoot("never-failed-over-sandbox"); root != runStoragePath {
+		t.Fatalf("activeRunRoot() = %q, want %q", root, runStoragePath)
+	}
+}
+
 func TestFilesystemFetchContainerMountsSuccessful(t *testing.T) {
 	fs := &filesystem{}
 	contID := "100"
@@ -561,3 +700,533 @@ func TestFilesystemFetchResourceFailingWrongResourceType(t *testing.T) {
 		}
 	}
 }
+
+func TestMigrateSandboxStorage(t *testing.T) {
+	fs := &filesystem{}
+	sandboxID := "migrate-sandbox"
+
+	defer func() {
+		os.RemoveAll(filepath.Join(configStoragePath, sandboxID))
+		os.RemoveAll(filepath.Join(runStoragePath, sandboxID))
+		configRootOverridesMu.Lock()
+		delete(configRootOverrides, sandboxID)
+		configRootOverridesMu.Unlock()
+	}()
+
+	sandboxConfig := SandboxConfig{ID: sandboxID}
+	if err := fs.storeSandboxResource(sandboxID, configFileType, sandboxConfig); err != nil {
+		t.Fatal(err)
+	}
+
+	state := State{State: StateRunning}
+	if err := fs.storeSandboxResource(sandboxID, stateFileType, state); err != nil {
+		t.Fatal(err)
+	}
+
+	newRunRoot, err := ioutil.TempDir("", "migrate-run-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(newRunRoot)
+
+	newConfigRoot, err := ioutil.TempDir("", "migrate-config-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(newConfigRoot)
+
+	if err := fs.migrateSandboxStorage(sandboxID, newRunRoot, newConfigRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	fetchedConfig, err := fs.fetchSandboxConfig(sandboxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fetchedConfig.ID != sandboxID {
+		t.Fatalf("expected fetched config to still resolve to sandbox %s, got %s", sandboxID, fetchedConfig.ID)
+	}
+
+	if _, err := os.Stat(filepath.Join(newConfigRoot, sandboxID, configFile)); err != nil {
+		t.Fatalf("expected config to have been copied to the new config root: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(newRunRoot, sandboxID, stateFile)); err != nil {
+		t.Fatalf("expected state to have been copied to the new run root: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(configStoragePath, sandboxID, configFile)); err == nil {
+		t.Fatal("expected the old config file to have been removed after migration")
+	}
+
+	if _, err := os.Stat(filepath.Join(runStoragePath, sandboxID, stateFile)); err == nil {
+		t.Fatal("expected the old state file to have been removed after migration")
+	}
+}
+
+func TestMigrateSandboxStorageRequiresNewRoots(t *testing.T) {
+	fs := &filesystem{}
+
+	if err := fs.migrateSandboxStorage("some-sandbox", "", "/tmp/config"); err == nil {
+		t.Fatal("expected an error when newRunRoot is empty")
+	}
+
+	if err := fs.migrateSandboxStorage("some-sandbox", "/tmp/run", ""); err == nil {
+		t.Fatal("expected an error when newConfigRoot is empty")
+	}
+}
+
+func TestFilesystemStoreDeviceFileRejectsFdDevice(t *testing.T) {
+	fs := &filesystem{}
+
+	file := filepath.Join(os.TempDir(), "fddevice-devices.json")
+	defer os.RemoveAll(file)
+
+	devices := []api.Device{
+		drivers.NewFdDevice(config.DeviceInfo{ID: "fd-device"}),
+	}
+
+	err := fs.storeDeviceFile(file, devices)
+	if err == nil {
+		t.Fatal("expected storeDeviceFile to reject an FdDevice")
+	}
+
+	if _, statErr := os.Stat(file); statErr == nil {
+		t.Fatal("expected no file to be written when storing an FdDevice fails")
+	}
+}
+
+func TestFilesystemFetchDeviceFileRejectsUnknownTypeByDefault(t *testing.T) {
+	fs := &filesystem{}
+
+	data := []byte(`[{"Type":"mystery","Data":{}}]`)
+
+	var devices []api.Device
+	if err := fs.fetchDeviceFile(data, &devices); err == nil {
+		t.Fatal("expected fetchDeviceFile to reject an unknown device type")
+	}
+}
+
+func TestFilesystemFetchDeviceFilePreservesUnknownTypeInLenientMode(t *testing.T) {
+	fs := &filesystem{LenientDeviceRestore: true}
+
+	knownDevice := drivers.NewGenericDevice(config.DeviceInfo{ID: "generic-device"})
+	knownJSON, err := json.Marshal(knownDevice)
+	if err != nil {
+		t.Fatalf("unable to marshal known device: %v", err)
+	}
+
+	data := []byte(fmt.Sprintf(
+		`[{"Type":"mystery","Data":{"foo":"bar"}},{"Type":%q,"Data":%s}]`,
+		config.DeviceGeneric, knownJSON))
+
+	var devices []api.Device
+	if err := fs.fetchDeviceFile(data, &devices); err != nil {
+		t.Fatalf("unexpected error in lenient mode: %v", err)
+	}
+
+	if len(devices) != 2 {
+		t.Fatalf("expected 2 devices, got %d", len(devices))
+	}
+
+	opaque, ok := devices[0].(*opaqueDevice)
+	if !ok {
+		t.Fatalf("expected first device to be an opaqueDevice, got %T", devices[0])
+	}
+
+	if opaque.DeviceType() != config.DeviceType("mystery") {
+		t.Fatalf("expected opaque device type %q, got %q", "mystery", opaque.DeviceType())
+	}
+
+	if err := opaque.Attach(nil); err != nil {
+		t.Fatalf("expected opaqueDevice.Attach to be a no-op, got: %v", err)
+	}
+
+	if _, ok := devices[1].(*drivers.GenericDevice); !ok {
+		t.Fatalf("expected second device to be a GenericDevice, got %T", devices[1])
+	}
+}
+
+func TestFilesystemStorageConformance(t *testing.T) {
+	RunStorageConformance(t, func() resourceStorage {
+		return &filesystem{}
+	})
+}
+
+// TestFilesystemConcurrentReadersAndWriter stresses writeJSONFile and
+// lockedReadFile with many concurrent readers racing a single writer and
+// verifies that a reader never observes a torn document: every successful
+// read unmarshals and has fields consistent with some write the writer
+// actually performed. Run with -race to also catch any data race around
+// atomicWriteFile's rename, which is what actually provides that guarantee.
+func TestFilesystemConcurrentReadersAndWriter(t *testing.T) {
+	type doc struct {
+		A int
+		B int
+	}
+
+	file := filepath.Join(testDir, "concurrent-rw.json")
+
+	if err := writeJSONFile(file, doc{A: 0, B: 0}); err != nil {
+		t.Fatalf("initial write failed: %v", err)
+	}
+
+	const iterations = 200
+	const readers = 10
+
+	var wg sync.WaitGroup
+	wg.Add(1 + readers)
+
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= iterations; i++ {
+			if err := writeJSONFile(file, doc{A: i, B: i}); err != nil {
+				t.Errorf("writeJSONFile failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	for r := 0; r < readers; r++ {
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				fileData, err := lockedReadFile(file)
+				if err != nil {
+					t.Errorf("lockedReadFile failed: %v", err)
+					return
+				}
+
+				data, err := unwrapFileEnvelope(fileData)
+				if err != nil {
+					t.Errorf("unwrapFileEnvelope failed: %v", err)
+					return
+				}
+
+				var d doc
+				if err := json.Unmarshal(data, &d); err != nil {
+					t.Errorf("read a torn document that failed to unmarshal: %v (%q)", err, data)
+					return
+				}
+
+				if d.A != d.B {
+					t.Errorf("read a torn document with mismatched fields: %+v", d)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestAtomicWriteFileSurvivesInterruptedWrite(t *testing.T) {
+	path := filepath.Join(testDir, "testAtomicWriteFile")
+	os.Remove(path)
+
+	good := "{\"Field1\":\"value1\",\"Field2\":\"value2\"}"
+	if err := ioutil.WriteFile(path, []byte(good), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash between creating the temp file and renaming it
+	// over path: write to a temp file in the same directory, but never
+	// rename it into place.
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tmp.Write([]byte("{\"Field1\":\"corrupt")); err != nil {
+		t.Fatal(err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	fileData, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(fileData) != good {
+		t.Fatalf("expected previous good file to survive interrupted write, got %q", string(fileData))
+	}
+
+	var data TestNoopStructure
+	if err := json.Unmarshal(fileData, &data); err != nil {
+		t.Fatalf("previous good file is no longer parseable: %v", err)
+	}
+}
+
+func TestFetchFileMigratesV1FixtureToCurrentSchema(t *testing.T) {
+	fs := &filesystem{}
+	data := TestNoopStructure{}
+
+	path := filepath.Join(testDir, "testFilesystemV1Fixture")
+	os.Remove(path)
+
+	// A v1 fixture: a bare struct with no fileEnvelope wrapper at all,
+	// exactly what storeFile wrote before schema versioning existed.
+	v1Fixture := "{\"Field1\":\"value1\",\"Field2\":\"value2\"}"
+	if err := ioutil.WriteFile(path, []byte(v1Fixture), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.fetchFile(path, sandboxResource(-1), &data); err != nil {
+		t.Fatalf("fetchFile() failed to migrate v1 fixture: %v", err)
+	}
+
+	expected := TestNoopStructure{
+		Field1: "value1",
+		Field2: "value2",
+	}
+
+	if reflect.DeepEqual(data, expected) == false {
+		t.Fatalf("expected %+v, got %+v", expected, data)
+	}
+}
+
+func TestUnwrapFileEnvelopeRejectsUnsupportedNewerSchema(t *testing.T) {
+	envelope := fileEnvelope{SchemaVersion: currentFileSchemaVersion + 1, Data: json.RawMessage(`{}`)}
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := unwrapFileEnvelope(raw); err == nil {
+		t.Fatal("expected an error for a schema version newer than supported")
+	}
+}
+
+func TestUnwrapFileEnvelopeRejectsVersionWithNoMigrationRegistered(t *testing.T) {
+	origMigrations := fileMigrations
+	fileMigrations = map[int]func(json.RawMessage) (json.RawMessage, error){}
+	defer func() { fileMigrations = origMigrations }()
+
+	envelope := fileEnvelope{SchemaVersion: legacyUnversionedSchema, Data: json.RawMessage(`{}`)}
+	raw, err := json.Marshal(envelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := unwrapFileEnvelope(raw); err == nil {
+		t.Fatal("expected an error when no migration is registered for the on-disk version")
+	}
+}
+
+func TestFilesystemListSandboxes(t *testing.T) {
+	origRunStoragePath := runStoragePath
+	runStoragePath = filepath.Join(testDir, "list-sandboxes-run")
+	defer func() { runStoragePath = origRunStoragePath }()
+
+	defer RegisterRunStorageRoots(nil)
+	RegisterRunStorageRoots([]string{runStoragePath})
+
+	fs := &filesystem{}
+
+	for _, sandboxID := range []string{"sandbox-1", "sandbox-2"} {
+		if err := fs.storeSandboxResource(sandboxID, stateFileType, State{State: StateRunning}); err != nil {
+			t.Fatalf("storeSandboxResource() unexpectedly failed for %s: %v", sandboxID, err)
+		}
+	}
+
+	// A sandbox directory whose config survived but whose state.json did
+	// not (e.g. a partially-deleted sandbox) must be skipped, not make
+	// the whole listing fail.
+	malformedDir := filepath.Join(runStoragePath, "sandbox-missing-state")
+	if err := os.MkdirAll(malformedDir, dirMode); err != nil {
+		t.Fatal(err)
+	}
+
+	sandboxIDs, err := fs.listSandboxes()
+	if err != nil {
+		t.Fatalf("listSandboxes() unexpectedly failed: %v", err)
+	}
+
+	sort.Strings(sandboxIDs)
+	expected := []string{"sandbox-1", "sandbox-2"}
+	if !reflect.DeepEqual(sandboxIDs, expected) {
+		t.Fatalf("listSandboxes() = %v, want %v", sandboxIDs, expected)
+	}
+}
+
+func TestFilesystemListSandboxesNoRunStoragePath(t *testing.T) {
+	origRunStoragePath := runStoragePath
+	runStoragePath = filepath.Join(testDir, "list-sandboxes-missing-run")
+	defer func() { runStoragePath = origRunStoragePath }()
+
+	fs := &filesystem{}
+
+	sandboxIDs, err := fs.listSandboxes()
+	if err != nil {
+		t.Fatalf("listSandboxes() unexpectedly failed: %v", err)
+	}
+
+	if len(sandboxIDs) != 0 {
+		t.Fatalf("listSandboxes() = %v, want an empty list", sandboxIDs)
+	}
+}
+
+func TestFilesystemFetchSandboxContainers(t *testing.T) {
+	fs := &filesystem{}
+
+	sandboxID := "list-containers-sandbox"
+	sandboxConfigDir := filepath.Join(configStoragePath, sandboxID)
+	os.RemoveAll(sandboxConfigDir)
+	defer os.RemoveAll(sandboxConfigDir)
+
+	containerIDs := []string{"container-1", "container-2", "container-3"}
+	for _, containerID := range containerIDs {
+		if err := fs.storeContainerResource(sandboxID, containerID, configFileType, ContainerConfig{ID: containerID}); err != nil {
+			t.Fatalf("storeContainerResource() unexpectedly failed for %s: %v", containerID, err)
+		}
+	}
+
+	// A container directory whose config.json did not survive (e.g. a
+	// partially-deleted container) must be skipped, not make the whole
+	// listing fail.
+	malformedDir := filepath.Join(sandboxConfigDir, "container-missing-config")
+	if err := os.MkdirAll(malformedDir, dirMode); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sandbox's own top-level config.json must not be mistaken for a
+	// container.
+	if err := fs.storeSandboxResource(sandboxID, configFileType, SandboxConfig{ID: sandboxID}); err != nil {
+		t.Fatalf("storeSandboxResource() unexpectedly failed: %v", err)
+	}
+
+	fetchedIDs, err := fs.fetchSandboxContainers(sandboxID)
+	if err != nil {
+		t.Fatalf("fetchSandboxContainers() unexpectedly failed: %v", err)
+	}
+
+	sort.Strings(fetchedIDs)
+	if !reflect.DeepEqual(fetchedIDs, containerIDs) {
+		t.Fatalf("fetchSandboxContainers() = %v, want %v", fetchedIDs, containerIDs)
+	}
+}
+
+func TestFilesystemFetchSandboxContainersFailingSandboxIDEmpty(t *testing.T) {
+	fs := &filesystem{}
+
+	if _, err := fs.fetchSandboxContainers(""); err == nil {
+		t.Fatal("expected fetchSandboxContainers to fail with an empty sandbox ID")
+	}
+}
+
+func TestFilesystemFetchSandboxContainersNoSandboxDir(t *testing.T) {
+	fs := &filesystem{}
+
+	containerIDs, err := fs.fetchSandboxContainers("no-such-sandbox")
+	if err != nil {
+		t.Fatalf("fetchSandboxContainers() unexpectedly failed: %v", err)
+	}
+
+	if len(containerIDs) != 0 {
+		t.Fatalf("fetchSandboxContainers() = %v, want an empty list", containerIDs)
+	}
+}
+
+func TestFilesystemDeleteContainerResourcesRemovesMountsAndDevices(t *testing.T) {
+	fs := &filesystem{}
+	contID := "delete-resources-container"
+
+	defer os.RemoveAll(filepath.Join(configStoragePath, testSandboxID))
+	defer os.RemoveAll(filepath.Join(runStoragePath, testSandboxID))
+
+	if err := fs.storeContainerMounts(testSandboxID, contID, []Mount{{Source: "/src", Destination: "/dst"}}); err != nil {
+		t.Fatalf("storeContainerMounts() unexpectedly failed: %v", err)
+	}
+
+	devices := []api.Device{drivers.NewGenericDevice(config.DeviceInfo{ID: "generic-device"})}
+	if err := fs.storeContainerDevices(testSandboxID, contID, devices); err != nil {
+		t.Fatalf("storeContainerDevices() unexpectedly failed: %v", err)
+	}
+
+	if err := fs.storeContainerResource(testSandboxID, contID, stateFileType, State{State: StateRunning}); err != nil {
+		t.Fatalf("storeContainerResource(stateFileType) unexpectedly failed: %v", err)
+	}
+
+	mountsPath, _, err := fs.containerURI(testSandboxID, contID, mountsFileType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	devicesPath, _, err := fs.containerURI(testSandboxID, contID, devicesFileType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Sanity check: the files exist before deletion.
+	if _, err := os.Stat(mountsPath); err != nil {
+		t.Fatalf("expected %s to exist before deletion: %v", mountsPath, err)
+	}
+
+	if _, err := os.Stat(devicesPath); err != nil {
+		t.Fatalf("expected %s to exist before deletion: %v", devicesPath, err)
+	}
+
+	if err := fs.deleteContainerResources(testSandboxID, contID, nil); err != nil {
+		t.Fatalf("deleteContainerResources() unexpectedly failed: %v", err)
+	}
+
+	if _, err := os.Stat(mountsPath); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, stat returned: %v", mountsPath, err)
+	}
+
+	if _, err := os.Stat(devicesPath); !os.IsNotExist(err) {
+		t.Fatalf("expected %s to be removed, stat returned: %v", devicesPath, err)
+	}
+}
+
+func TestFilesystemAgentStateVSOCKPortRoundTrip(t *testing.T) {
+	fs := &filesystem{}
+
+	stored := KataAgentState{
+		URL:       "vsock://3:2048",
+		VSOCKPort: 2048,
+	}
+
+	if err := fs.storeAgentState(testSandboxID, stored); err != nil {
+		t.Fatalf("storeAgentState() unexpectedly failed: %v", err)
+	}
+
+	var fetched KataAgentState
+	if err := fs.fetchAgentState(testSandboxID, &fetched); err != nil {
+		t.Fatalf("fetchAgentState() unexpectedly failed: %v", err)
+	}
+
+	if fetched.VSOCKPort != stored.VSOCKPort {
+		t.Fatalf("expected VSOCKPort %d, got %d", stored.VSOCKPort, fetched.VSOCKPort)
+	}
+}
+
+func TestFilesystemSandboxStateConfidentialModeRoundTrip(t *testing.T) {
+	fs := &filesystem{}
+
+	for _, mode := range []string{
+		ConfidentialModeNone,
+		ConfidentialModeSEV,
+		ConfidentialModeSEVES,
+		ConfidentialModeSEVSNP,
+	} {
+		stored := State{
+			State:            StateReady,
+			ConfidentialMode: mode,
+		}
+
+		if err := fs.storeSandboxResource(testSandboxID, stateFileType, stored); err != nil {
+			t.Fatalf("storeSandboxResource() unexpectedly failed for mode %q: %v", mode, err)
+		}
+
+		fetched, err := fs.fetchSandboxState(testSandboxID)
+		if err != nil {
+			t.Fatalf("fetchSandboxState() unexpectedly failed for mode %q: %v", mode, err)
+		}
+
+		if fetched.ConfidentialMode != mode {
+			t.Fatalf("expected ConfidentialMode %q, got %q", mode, fetched.ConfidentialMode)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// defaultTrimMountpoint is the mountpoint fstrim runs against when the
+// caller does not specify one: the guest rootfs itself.
+const defaultTrimMountpoint = "/"
+
+// validateTrimMountpoint checks that mountpoint is a known, trimmable
+// guest path, defaulting to defaultTrimMountpoint when empty.
+func validateTrimMountpoint(mountpoint string) (string, error) {
+	if mountpoint == "" {
+		return defaultTrimMountpoint, nil
+	}
+
+	if !filepath.IsAbs(mountpoint) {
+		return "", fmt.Errorf("trim mountpoint %q is not an absolute path", mountpoint)
+	}
+
+	return mountpoint, nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTrimMountpointDefaultsWhenEmpty(t *testing.T) {
+	assert := assert.New(t)
+
+	mountpoint, err := validateTrimMountpoint("")
+	assert.NoError(err)
+	assert.Equal(defaultTrimMountpoint, mountpoint)
+}
+
+func TestValidateTrimMountpointAbsolutePath(t *testing.T) {
+	assert := assert.New(t)
+
+	mountpoint, err := validateTrimMountpoint("/var/lib/containers")
+	assert.NoError(err)
+	assert.Equal("/var/lib/containers", mountpoint)
+}
+
+func TestValidateTrimMountpointRejectsRelativePath(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := validateTrimMountpoint("var/lib/containers")
+	assert.Error(err)
+}
+
+type fakeTrimAgent struct {
+	noopAgent
+	mountpoint string
+}
+
+func (a *fakeTrimAgent) trimGuestFS(sandbox *Sandbox, mountpoint string) error {
+	a.mountpoint = mountpoint
+	return nil
+}
+
+func TestFakeAgentCapturesTrimMountpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	agent := &fakeTrimAgent{}
+	sandbox := &Sandbox{agent: agent}
+
+	err := sandbox.agent.trimGuestFS(sandbox, "/var/lib/containers")
+	assert.NoError(err)
+	assert.Equal("/var/lib/containers", agent.mountpoint)
+}
+
+func TestFakeAgentDefaultsTrimMountpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	agent := &fakeTrimAgent{}
+	sandbox := &Sandbox{agent: agent}
+
+	mountpoint, err := validateTrimMountpoint("")
+	assert.NoError(err)
+
+	err = sandbox.agent.trimGuestFS(sandbox, mountpoint)
+	assert.NoError(err)
+	assert.Equal(defaultTrimMountpoint, agent.mountpoint)
+}
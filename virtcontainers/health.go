@@ -0,0 +1,117 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// agentHealthCheckTimeout bounds how long checkAgentHealth waits for the
+// agent to respond, so a half-open VSOCK connection cannot make a health
+// check itself hang.
+const agentHealthCheckTimeout = 5 * time.Second
+
+// ComponentHealth captures the outcome of checking a single subsystem a
+// running sandbox depends on.
+type ComponentHealth struct {
+	Healthy bool
+	Message string
+}
+
+// SandboxHealth aggregates the health of the subsystems a running sandbox
+// depends on: its persisted state, its agent connection, and its VMM
+// process.
+type SandboxHealth struct {
+	Storage    ComponentHealth
+	Agent      ComponentHealth
+	Hypervisor ComponentHealth
+	ClockSkew  ComponentHealth
+}
+
+// Healthy reports whether every checked component came back healthy.
+func (h *SandboxHealth) Healthy() bool {
+	return h.Storage.Healthy && h.Agent.Healthy && h.Hypervisor.Healthy && h.ClockSkew.Healthy
+}
+
+func checkAgentHealth(s *Sandbox) ComponentHealth {
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), agentHealthCheckTimeout)
+	defer cancel()
+
+	if err := s.agent.check(ctx); err != nil {
+		return ComponentHealth{Message: fmt.Sprintf("agent check failed: %v", err)}
+	}
+
+	return ComponentHealth{Healthy: true, Message: fmt.Sprintf("agent responded in %s", time.Since(start))}
+}
+
+func checkHypervisorHealth(s *Sandbox) ComponentHealth {
+	pid, err := s.hypervisor.pid()
+	if err != nil {
+		return ComponentHealth{Message: fmt.Sprintf("could not determine VMM pid: %v", err)}
+	}
+
+	// A signal 0 kill neither sends a signal nor requires permission
+	// beyond what is needed to see the process; it only reports whether
+	// the pid is still alive.
+	if err := syscall.Kill(pid, syscall.Signal(0)); err != nil {
+		return ComponentHealth{Message: fmt.Sprintf("VMM process %d is not running: %v", pid, err)}
+	}
+
+	return ComponentHealth{Healthy: true, Message: fmt.Sprintf("VMM running as pid %d", pid)}
+}
+
+func checkClockSkewHealth(s *Sandbox) ComponentHealth {
+	skew, measured := s.ClockSkew()
+	if !measured {
+		return ComponentHealth{Message: "guest clock skew has not been measured yet"}
+	}
+
+	threshold := s.config.ClockSkewThreshold
+	if threshold == 0 {
+		threshold = defaultClockSkewThreshold
+	}
+
+	if skew > threshold {
+		return ComponentHealth{Message: fmt.Sprintf("guest clock has drifted %s from the host, exceeding the %s threshold", skew, threshold)}
+	}
+
+	return ComponentHealth{Healthy: true, Message: fmt.Sprintf("guest clock skew is %s", skew)}
+}
+
+// CheckSandboxHealth aggregates the health of sandboxID's persisted state,
+// its agent connection, and its VMM process. A storage failure prevents
+// the other two checks from running, since neither can happen without a
+// fetched Sandbox.
+func CheckSandboxHealth(sandboxID string) (*SandboxHealth, error) {
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	lockFile, err := rLockSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockSandbox(lockFile)
+
+	s, err := fetchSandbox(sandboxID)
+	if err != nil {
+		return &SandboxHealth{
+			Storage: ComponentHealth{Message: fmt.Sprintf("could not fetch sandbox state: %v", err)},
+		}, nil
+	}
+
+	return &SandboxHealth{
+		Storage:    ComponentHealth{Healthy: true, Message: "sandbox state fetched successfully"},
+		Agent:      checkAgentHealth(s),
+		Hypervisor: checkHypervisorHealth(s),
+		ClockSkew:  checkClockSkewHealth(s),
+	}, nil
+}
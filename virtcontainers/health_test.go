@@ -0,0 +1,154 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+)
+
+// fakeHealthAgent behaves like noopAgent except check() can be made to
+// fail, for exercising the unhealthy path of checkAgentHealth.
+type fakeHealthAgent struct {
+	noopAgent
+	checkErr error
+}
+
+func (a *fakeHealthAgent) check(ctx context.Context) error {
+	return a.checkErr
+}
+
+// fakeHealthHypervisor behaves like mockHypervisor except pid() can be
+// made to report a pid that isn't running, for exercising the unhealthy
+// path of checkHypervisorHealth.
+type fakeHealthHypervisor struct {
+	mockHypervisor
+	reportedPid int
+	pidErr      error
+}
+
+func (h *fakeHealthHypervisor) pid() (int, error) {
+	return h.reportedPid, h.pidErr
+}
+
+func TestCheckAgentHealthHealthy(t *testing.T) {
+	s := &Sandbox{agent: &fakeHealthAgent{}}
+
+	health := checkAgentHealth(s)
+	if !health.Healthy {
+		t.Fatalf("expected a healthy agent, got %+v", health)
+	}
+}
+
+func TestCheckAgentHealthUnhealthy(t *testing.T) {
+	s := &Sandbox{agent: &fakeHealthAgent{checkErr: fmt.Errorf("agent unreachable")}}
+
+	health := checkAgentHealth(s)
+	if health.Healthy {
+		t.Fatal("expected an unhealthy agent")
+	}
+}
+
+func TestCheckHypervisorHealthHealthy(t *testing.T) {
+	s := &Sandbox{hypervisor: &fakeHealthHypervisor{reportedPid: os.Getpid()}}
+
+	health := checkHypervisorHealth(s)
+	if !health.Healthy {
+		t.Fatalf("expected a healthy hypervisor, got %+v", health)
+	}
+}
+
+func TestCheckHypervisorHealthPidLookupFailure(t *testing.T) {
+	s := &Sandbox{hypervisor: &fakeHealthHypervisor{pidErr: fmt.Errorf("no process found")}}
+
+	health := checkHypervisorHealth(s)
+	if health.Healthy {
+		t.Fatal("expected an unhealthy hypervisor when its pid cannot be determined")
+	}
+}
+
+func TestCheckHypervisorHealthProcessNotRunning(t *testing.T) {
+	// pid 1 does exist, but init is never the sandbox's VMM, so pick a
+	// pid that is very unlikely to be running instead: the highest valid
+	// pid plus one is guaranteed not to correspond to any process.
+	s := &Sandbox{hypervisor: &fakeHealthHypervisor{reportedPid: 1<<31 - 1}}
+
+	health := checkHypervisorHealth(s)
+	if health.Healthy {
+		t.Fatal("expected an unhealthy hypervisor when its pid is not running")
+	}
+}
+
+func TestSandboxHealthHealthy(t *testing.T) {
+	health := &SandboxHealth{
+		Storage:    ComponentHealth{Healthy: true},
+		Agent:      ComponentHealth{Healthy: true},
+		Hypervisor: ComponentHealth{Healthy: true},
+		ClockSkew:  ComponentHealth{Healthy: true},
+	}
+
+	if !health.Healthy() {
+		t.Fatal("expected SandboxHealth to be healthy when all components are")
+	}
+}
+
+func TestSandboxHealthUnhealthy(t *testing.T) {
+	health := &SandboxHealth{
+		Storage:    ComponentHealth{Healthy: true},
+		Agent:      ComponentHealth{Healthy: false},
+		Hypervisor: ComponentHealth{Healthy: true},
+	}
+
+	if health.Healthy() {
+		t.Fatal("expected SandboxHealth to be unhealthy when any component is")
+	}
+}
+
+func TestCheckClockSkewHealthNotMeasuredYet(t *testing.T) {
+	s := &Sandbox{}
+
+	health := checkClockSkewHealth(s)
+	if health.Healthy {
+		t.Fatal("expected an unmeasured clock skew to be reported as not yet healthy")
+	}
+}
+
+func TestCheckClockSkewHealthWithinThreshold(t *testing.T) {
+	s := &Sandbox{
+		state: State{
+			ClockSkew:          time.Second,
+			ClockSkewCheckedAt: time.Now(),
+		},
+	}
+
+	health := checkClockSkewHealth(s)
+	if !health.Healthy {
+		t.Fatalf("expected a skew within the default threshold to be healthy, got %+v", health)
+	}
+}
+
+func TestCheckClockSkewHealthExceedsThreshold(t *testing.T) {
+	s := &Sandbox{
+		state: State{
+			ClockSkew:          time.Hour,
+			ClockSkewCheckedAt: time.Now(),
+		},
+	}
+
+	health := checkClockSkewHealth(s)
+	if health.Healthy {
+		t.Fatal("expected a skew far beyond the default threshold to be unhealthy")
+	}
+}
+
+func TestCheckSandboxHealthNeedsSandboxID(t *testing.T) {
+	if _, err := CheckSandboxHealth(""); err == nil {
+		t.Fatal("expected an error for an empty sandbox ID")
+	}
+}
@@ -1,160 +1,235 @@
-// Copyright (c) 2017 Intel Corporation
-//
-// SPDX-License-Identifier: Apache-2.0
-//
-
-package virtcontainers
-
-import (
-	"bytes"
-	"encoding/json"
-	"fmt"
-	"os"
-	"os/exec"
-	"syscall"
-	"time"
-
-	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
-	specs "github.com/opencontainers/runtime-spec/specs-go"
-	"github.com/sirupsen/logrus"
-)
-
-// Hook represents an OCI hook, including its required parameters.
-type Hook struct {
-	Path    string
-	Args    []string
-	Env     []string
-	Timeout int
-}
-
-// Hooks gathers all existing OCI hooks list.
-type Hooks struct {
-	PreStartHooks  []Hook
-	PostStartHooks []Hook
-	PostStopHooks  []Hook
-}
-
-// Logger returns a logrus logger appropriate for logging Hooks messages
-func (h *Hooks) Logger() *logrus.Entry {
-	return virtLog.WithField("subsystem", "hooks")
-}
-
-func buildHookState(processID int, s *Sandbox) specs.State {
-	annotations := s.GetAnnotations()
-	return specs.State{
-		Pid:    processID,
-		Bundle: annotations[vcAnnotations.BundlePathKey],
-		ID:     s.id,
-	}
-}
-
-func (h *Hook) runHook(s *Sandbox) error {
-	state := buildHookState(os.Getpid(), s)
-	stateJSON, err := json.Marshal(state)
-	if err != nil {
-		return err
-	}
-
-	var stdout, stderr bytes.Buffer
-	cmd := &exec.Cmd{
-		Path:   h.Path,
-		Args:   h.Args,
-		Env:    h.Env,
-		Stdin:  bytes.NewReader(stateJSON),
-		Stdout: &stdout,
-		Stderr: &stderr,
-	}
-
-	err = cmd.Start()
-	if err != nil {
-		return err
-	}
-
-	if h.Timeout == 0 {
-		err = cmd.Wait()
-		if err != nil {
-			return fmt.Errorf("%s: stdout: %s, stderr: %s", err, stdout.String(), stderr.String())
-		}
-	} else {
-		done := make(chan error, 1)
-		go func() {
-			done <- cmd.Wait()
-			close(done)
-		}()
-
-		select {
-		case err := <-done:
-			if err != nil {
-				return fmt.Errorf("%s: stdout: %s, stderr: %s", err, stdout.String(), stderr.String())
-			}
-		case <-time.After(time.Duration(h.Timeout) * time.Second):
-			if err := syscall.Kill(cmd.Process.Pid, syscall.SIGKILL); err != nil {
-				return err
-			}
-
-			return fmt.Errorf("Hook timeout")
-		}
-	}
-
-	return nil
-}
-
-func (h *Hooks) preStartHooks(s *Sandbox) error {
-	if len(h.PreStartHooks) == 0 {
-		return nil
-	}
-
-	for _, hook := range h.PreStartHooks {
-		err := hook.runHook(s)
-		if err != nil {
-			h.Logger().WithFields(logrus.Fields{
-				"hook-type": "pre-start",
-				"error":     err,
-			}).Error("hook error")
-
-			return err
-		}
-	}
-
-	return nil
-}
-
-func (h *Hooks) postStartHooks(s *Sandbox) error {
-	if len(h.PostStartHooks) == 0 {
-		return nil
-	}
-
-	for _, hook := range h.PostStartHooks {
-		err := hook.runHook(s)
-		if err != nil {
-			// In case of post start hook, the error is not fatal,
-			// just need to be logged.
-			h.Logger().WithFields(logrus.Fields{
-				"hook-type": "post-start",
-				"error":     err,
-			}).Info("hook error")
-		}
-	}
-
-	return nil
-}
-
-func (h *Hooks) postStopHooks(s *Sandbox) error {
-	if len(h.PostStopHooks) == 0 {
-		return nil
-	}
-
-	for _, hook := range h.PostStopHooks {
-		err := hook.runHook(s)
-		if err != nil {
-			// In case of post stop hook, the error is not fatal,
-			// just need to be logged.
-			h.Logger().WithFields(logrus.Fields{
-				"hook-type": "post-stop",
-				"error":     err,
-			}).Info("hook error")
-		}
-	}
-
-	return nil
-}
+// Copyright (c) 2017 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	vcAnnotations "github.com/kata-containers/runtime/virtcontainers/pkg/annotations"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// Hook represents an OCI hook, including its required parameters.
+type Hook struct {
+	Path    string
+	Args    []string
+	Env     []string
+	Timeout int
+}
+
+// Hooks gathers all existing OCI hooks list.
+type Hooks struct {
+	PreStartHooks  []Hook
+	PostStartHooks []Hook
+	PreStopHooks   []Hook
+	PostStopHooks  []Hook
+}
+
+// HookExecution records the outcome of running a single lifecycle hook:
+// what it printed on stdout/stderr and how it exited. Sandboxes persist
+// these so a hook failure remains visible after the fact via the
+// dump-state command, rather than only whatever the hook itself
+// happened to log somewhere else.
+type HookExecution struct {
+	HookType string    `json:"hookType"`
+	Path     string    `json:"path"`
+	Args     []string  `json:"args"`
+	Stdout   string    `json:"stdout"`
+	Stderr   string    `json:"stderr"`
+	ExitCode int       `json:"exitCode"`
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Logger returns a logrus logger appropriate for logging Hooks messages
+func (h *Hooks) Logger() *logrus.Entry {
+	return virtLog.WithField("subsystem", "hooks")
+}
+
+func buildHookState(processID int, s *Sandbox) specs.State {
+	annotations := s.GetAnnotations()
+	return specs.State{
+		Pid:    processID,
+		Bundle: annotations[vcAnnotations.BundlePathKey],
+		ID:     s.id,
+	}
+}
+
+// hookExitCode extracts a hook's process exit code, or -1 if it is not
+// available, e.g. the process never ran or was killed before reaping.
+func hookExitCode(cmd *exec.Cmd) int {
+	if cmd.ProcessState == nil {
+		return -1
+	}
+
+	ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok {
+		return -1
+	}
+
+	return ws.ExitStatus()
+}
+
+func (h *Hook) runHook(hookType string, s *Sandbox) (err error) {
+	state := buildHookState(os.Getpid(), s)
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := &exec.Cmd{
+		Path:   h.Path,
+		Args:   h.Args,
+		Env:    h.Env,
+		Stdin:  bytes.NewReader(stateJSON),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	}
+
+	record := HookExecution{
+		HookType: hookType,
+		Path:     h.Path,
+		Args:     h.Args,
+		Time:     time.Now(),
+	}
+
+	defer func() {
+		record.Stdout = stdout.String()
+		record.Stderr = stderr.String()
+		record.ExitCode = hookExitCode(cmd)
+		if err != nil {
+			record.Error = err.Error()
+		}
+
+		if recErr := s.recordHookExecution(record); recErr != nil {
+			virtLog.WithError(recErr).Warn("failed to persist hook execution record")
+		}
+	}()
+
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+
+	if h.Timeout == 0 {
+		err = cmd.Wait()
+		if err != nil {
+			return fmt.Errorf("%s: stdout: %s, stderr: %s", err, stdout.String(), stderr.String())
+		}
+	} else {
+		done := make(chan error, 1)
+		go func() {
+			done <- cmd.Wait()
+			close(done)
+		}()
+
+		select {
+		case waitErr := <-done:
+			if waitErr != nil {
+				err = fmt.Errorf("%s: stdout: %s, stderr: %s", waitErr, stdout.String(), stderr.String())
+				return err
+			}
+		case <-time.After(time.Duration(h.Timeout) * time.Second):
+			if killErr := syscall.Kill(cmd.Process.Pid, syscall.SIGKILL); killErr != nil {
+				err = killErr
+				return err
+			}
+
+			err = fmt.Errorf("Hook timeout")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Hooks) preStartHooks(s *Sandbox) error {
+	if len(h.PreStartHooks) == 0 {
+		return nil
+	}
+
+	for _, hook := range h.PreStartHooks {
+		err := hook.runHook("pre-start", s)
+		if err != nil {
+			h.Logger().WithFields(logrus.Fields{
+				"hook-type": "pre-start",
+				"error":     err,
+			}).Error("hook error")
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Hooks) preStopHooks(s *Sandbox) error {
+	if len(h.PreStopHooks) == 0 {
+		return nil
+	}
+
+	for _, hook := range h.PreStopHooks {
+		err := hook.runHook("pre-stop", s)
+		if err != nil {
+			h.Logger().WithFields(logrus.Fields{
+				"hook-type": "pre-stop",
+				"error":     err,
+			}).Error("hook error")
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (h *Hooks) postStartHooks(s *Sandbox) error {
+	if len(h.PostStartHooks) == 0 {
+		return nil
+	}
+
+	for _, hook := range h.PostStartHooks {
+		err := hook.runHook("post-start", s)
+		if err != nil {
+			// In case of post start hook, the error is not fatal,
+			// just need to be logged.
+			h.Logger().WithFields(logrus.Fields{
+				"hook-type": "post-start",
+				"error":     err,
+			}).Info("hook error")
+		}
+	}
+
+	return nil
+}
+
+func (h *Hooks) postStopHooks(s *Sandbox) error {
+	if len(h.PostStopHooks) == 0 {
+		return nil
+	}
+
+	for _, hook := range h.PostStopHooks {
+		err := hook.runHook("post-stop", s)
+		if err != nil {
+			// In case of post stop hook, the error is not fatal,
+			// just need to be logged.
+			h.Logger().WithFields(logrus.Fields{
+				"hook-type": "post-stop",
+				"error":     err,
+			}).Info("hook error")
+		}
+	}
+
+	return nil
+}
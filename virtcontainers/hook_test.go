@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 
@@ -97,7 +98,7 @@ func testRunHookFull(t *testing.T, timeout int, expectFail bool) {
 	hook := createHook(timeout)
 
 	s := createTestSandbox()
-	err := hook.runHook(s)
+	err := hook.runHook("test", s)
 	if expectFail {
 		if err == nil {
 			t.Fatal("unexpected success")
@@ -127,7 +128,7 @@ func TestRunHookExitFailure(t *testing.T) {
 	hook := createWrongHook()
 	s := createTestSandbox()
 
-	err := hook.runHook(s)
+	err := hook.runHook("test", s)
 	if err == nil {
 		t.Fatal()
 	}
@@ -140,7 +141,7 @@ func TestRunHookTimeoutFailure(t *testing.T) {
 
 	s := createTestSandbox()
 
-	err := hook.runHook(s)
+	err := hook.runHook("test", s)
 	if err == nil {
 		t.Fatal()
 	}
@@ -152,7 +153,7 @@ func TestRunHookWaitFailure(t *testing.T) {
 	hook.Args = append(hook.Args, "1", "panic")
 	s := createTestSandbox()
 
-	err := hook.runHook(s)
+	err := hook.runHook("test", s)
 	if err == nil {
 		t.Fatal()
 	}
@@ -192,6 +193,7 @@ func testHooks(t *testing.T, hook *Hook) {
 	hooks := &Hooks{
 		PreStartHooks:  []Hook{*hook},
 		PostStartHooks: []Hook{*hook},
+		PreStopHooks:   []Hook{*hook},
 		PostStopHooks:  []Hook{*hook},
 	}
 	s := createTestSandbox()
@@ -206,6 +208,11 @@ func testHooks(t *testing.T, hook *Hook) {
 		t.Fatal(err)
 	}
 
+	err = hooks.preStopHooks(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	err = hooks.postStopHooks(s)
 	if err != nil {
 		t.Fatal(err)
@@ -216,6 +223,7 @@ func testFailingHooks(t *testing.T, hook *Hook) {
 	hooks := &Hooks{
 		PreStartHooks:  []Hook{*hook},
 		PostStartHooks: []Hook{*hook},
+		PreStopHooks:   []Hook{*hook},
 		PostStopHooks:  []Hook{*hook},
 	}
 	s := createTestSandbox()
@@ -230,6 +238,11 @@ func testFailingHooks(t *testing.T, hook *Hook) {
 		t.Fatal(err)
 	}
 
+	err = hooks.preStopHooks(s)
+	if err == nil {
+		t.Fatal(err)
+	}
+
 	err = hooks.postStopHooks(s)
 	if err != nil {
 		t.Fatal(err)
@@ -248,6 +261,59 @@ func TestFailingHooks(t *testing.T) {
 	testFailingHooks(t, createWrongHook())
 }
 
+func TestHookExecutionCapturedAndPersisted(t *testing.T) {
+	sandboxID := "hook-capture-test"
+
+	path := filepath.Join(runStoragePath, sandboxID)
+	if err := os.MkdirAll(path, dirMode); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	s := &Sandbox{
+		id:              sandboxID,
+		annotationsLock: &sync.RWMutex{},
+		config:          &SandboxConfig{},
+		storage:         &filesystem{},
+	}
+
+	hook := &Hook{
+		Path: "/bin/sh",
+		Args: []string{"/bin/sh", "-c", "echo out-line; echo err-line >&2; exit 3"},
+	}
+
+	err := hook.runHook("pre-start", s)
+	if err == nil {
+		t.Fatal("expected a non-zero exit to be reported as an error")
+	}
+
+	records, err := s.storage.fetchSandboxHooks(sandboxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(records) != 1 {
+		t.Fatalf("expected exactly one recorded hook execution, got %d", len(records))
+	}
+
+	record := records[0]
+	if record.HookType != "pre-start" {
+		t.Fatalf("unexpected hook type %q", record.HookType)
+	}
+
+	if !strings.Contains(record.Stdout, "out-line") {
+		t.Fatalf("expected stdout to contain %q, got %q", "out-line", record.Stdout)
+	}
+
+	if !strings.Contains(record.Stderr, "err-line") {
+		t.Fatalf("expected stderr to contain %q, got %q", "err-line", record.Stderr)
+	}
+
+	if record.ExitCode != 3 {
+		t.Fatalf("expected exit code 3, got %d", record.ExitCode)
+	}
+}
+
 func TestEmptyHooks(t *testing.T) {
 	hooks := &Hooks{}
 	s := createTestSandbox()
@@ -262,6 +328,11 @@ func TestEmptyHooks(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	err = hooks.preStopHooks(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	err = hooks.postStopHooks(s)
 	if err != nil {
 		t.Fatal(err)
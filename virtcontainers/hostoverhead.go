@@ -0,0 +1,82 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// SandboxStats describes a sandbox's host-side resource usage.
+type SandboxStats struct {
+	// HostOverheadMiB is the sandbox's fixed host-side overhead: its VMM
+	// process RSS minus the memory assigned to the guest. It captures
+	// QEMU itself, firmware, and (for confidential guests) encryption
+	// metadata -- memory a scheduler needs to account for on top of the
+	// sandbox's configured guest memory.
+	HostOverheadMiB uint32
+}
+
+// readVMMRSSKiB returns the resident set size, in KiB, of the process
+// running as pid, read from /proc/<pid>/status. It is a variable so
+// tests can fake the host's view of a VMM's memory usage without a real
+// qemu process to measure.
+var readVMMRSSKiB = func(pid int) (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(procDir, strconv.Itoa(pid), "status"))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, "VmRSS:") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return 0, fmt.Errorf("malformed VmRSS line %q in /proc/%d/status", line, pid)
+		}
+
+		return strconv.ParseUint(fields[1], 10, 64)
+	}
+
+	return 0, fmt.Errorf("no VmRSS line found in /proc/%d/status", pid)
+}
+
+// hostOverheadMiB returns how much of vmmRSSMiB is not accounted for by
+// guestMemMiB, floored at zero. A floor is needed because a freshly
+// launched VMM can momentarily have a smaller RSS than the memory it
+// will eventually be backing, which would otherwise show up as negative
+// overhead.
+func hostOverheadMiB(vmmRSSMiB, guestMemMiB uint32) uint32 {
+	if vmmRSSMiB <= guestMemMiB {
+		return 0
+	}
+
+	return vmmRSSMiB - guestMemMiB
+}
+
+// statsSandbox computes s's host-side resource usage.
+func (s *Sandbox) statsSandbox() (SandboxStats, error) {
+	pid, err := s.hypervisor.pid()
+	if err != nil {
+		return SandboxStats{}, err
+	}
+
+	rssKiB, err := readVMMRSSKiB(pid)
+	if err != nil {
+		return SandboxStats{}, err
+	}
+
+	vmmRSSMiB := uint32(rssKiB / 1024)
+
+	return SandboxStats{
+		HostOverheadMiB: hostOverheadMiB(vmmRSSMiB, s.config.HypervisorConfig.DefaultMemSz),
+	}, nil
+}
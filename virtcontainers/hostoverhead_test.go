@@ -0,0 +1,99 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHostOverheadMiB(t *testing.T) {
+	cases := []struct {
+		vmmRSSMiB   uint32
+		guestMemMiB uint32
+		want        uint32
+	}{
+		{vmmRSSMiB: 1280, guestMemMiB: 1024, want: 256},
+		{vmmRSSMiB: 1024, guestMemMiB: 1024, want: 0},
+		// A freshly launched VMM can momentarily read smaller than the
+		// memory it will eventually back; this must floor at zero
+		// rather than go negative.
+		{vmmRSSMiB: 512, guestMemMiB: 1024, want: 0},
+	}
+
+	for _, c := range cases {
+		if got := hostOverheadMiB(c.vmmRSSMiB, c.guestMemMiB); got != c.want {
+			t.Fatalf("hostOverheadMiB(%d, %d) = %d, want %d", c.vmmRSSMiB, c.guestMemMiB, got, c.want)
+		}
+	}
+}
+
+// fakeRSSHypervisor behaves like mockHypervisor except pid() reports a
+// fixed, fake VMM pid, for testing statsSandbox against a fake host-RSS
+// provider without a real qemu process to measure.
+type fakeRSSHypervisor struct {
+	mockHypervisor
+	reportedPid int
+}
+
+func (h *fakeRSSHypervisor) pid() (int, error) {
+	return h.reportedPid, nil
+}
+
+func TestStatsSandboxComputesHostOverhead(t *testing.T) {
+	const fakePid = 424242
+
+	origReadVMMRSSKiB := readVMMRSSKiB
+	readVMMRSSKiB = func(pid int) (uint64, error) {
+		if pid != fakePid {
+			return 0, fmt.Errorf("unexpected pid %d", pid)
+		}
+		return 1280 * 1024, nil
+	}
+	defer func() { readVMMRSSKiB = origReadVMMRSSKiB }()
+
+	s := &Sandbox{
+		hypervisor: &fakeRSSHypervisor{reportedPid: fakePid},
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{DefaultMemSz: 1024},
+		},
+	}
+
+	stats, err := s.statsSandbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.HostOverheadMiB != 256 {
+		t.Fatalf("expected a host overhead of 256 MiB, got %d", stats.HostOverheadMiB)
+	}
+}
+
+func TestStatsSandboxFloorsAtZero(t *testing.T) {
+	const fakePid = 424243
+
+	origReadVMMRSSKiB := readVMMRSSKiB
+	readVMMRSSKiB = func(pid int) (uint64, error) {
+		return 512 * 1024, nil
+	}
+	defer func() { readVMMRSSKiB = origReadVMMRSSKiB }()
+
+	s := &Sandbox{
+		hypervisor: &fakeRSSHypervisor{reportedPid: fakePid},
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{DefaultMemSz: 1024},
+		},
+	}
+
+	stats, err := s.statsSandbox()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.HostOverheadMiB != 0 {
+		t.Fatalf("expected host overhead to floor at zero, got %d", stats.HostOverheadMiB)
+	}
+}
@@ -6,6 +6,7 @@
 package virtcontainers
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/url"
@@ -262,6 +263,36 @@ func (h *hyper) init(sandbox *Sandbox, config interface{}) (err error) {
 	return nil
 }
 
+// getClockSource is not supported by the hyperstart agent.
+func (h *hyper) getClockSource(sandbox *Sandbox) (string, []string, error) {
+	return "", nil, fmt.Errorf("getClockSource is not supported by the hyperstart agent")
+}
+
+// getGuestMemInfo is not supported by the hyperstart agent.
+func (h *hyper) getGuestMemInfo(sandbox *Sandbox) (*MemInfo, error) {
+	return nil, fmt.Errorf("getGuestMemInfo is not supported by the hyperstart agent")
+}
+
+// getGuestLoad is not supported by the hyperstart agent.
+func (h *hyper) getGuestLoad(sandbox *Sandbox) (*LoadInfo, error) {
+	return nil, fmt.Errorf("getGuestLoad is not supported by the hyperstart agent")
+}
+
+// getGuestTime is not supported by the hyperstart agent.
+func (h *hyper) getGuestTime(sandbox *Sandbox) (time.Time, error) {
+	return time.Time{}, fmt.Errorf("getGuestTime is not supported by the hyperstart agent")
+}
+
+// inspectNetwork is not supported by the hyperstart agent.
+func (h *hyper) inspectNetwork(sandbox *Sandbox) (*GuestNetworkState, error) {
+	return nil, fmt.Errorf("inspectNetwork is not supported by the hyperstart agent")
+}
+
+// listGuestMounts is not supported by the hyperstart agent.
+func (h *hyper) listGuestMounts(sandbox *Sandbox) ([]GuestMount, error) {
+	return nil, fmt.Errorf("listGuestMounts is not supported by the hyperstart agent")
+}
+
 func (h *hyper) createSandbox(sandbox *Sandbox) (err error) {
 	for _, socket := range h.sockets {
 		err := sandbox.hypervisor.addDevice(socket, serialPortDev)
@@ -290,6 +321,11 @@ func (h *hyper) capabilities() capabilities {
 	// add all capabilities supported by agent
 	caps.setBlockDeviceSupport()
 
+	// hyperstart-agent onlines hot-added CPUs/memory automatically via
+	// udev, so onlineCPUMem is always effectively supported even though
+	// it issues no explicit request to the guest.
+	caps.setOnlineCPUMemSupport()
+
 	return caps
 }
 
@@ -343,10 +379,12 @@ func (h *hyper) exec(sandbox *Sandbox, c Container, cmd Cmd) (*Process, error) {
 // startSandbox is the agent Sandbox starting implementation for hyperstart.
 func (h *hyper) startSandbox(sandbox *Sandbox) error {
 	// Start the proxy here
+	agentConnectStart := time.Now()
 	pid, uri, err := h.proxy.start(sandbox, proxyParams{})
 	if err != nil {
 		return err
 	}
+	sandbox.hypervisor.recordLaunchPhase(launchPhaseAgentConnect, time.Since(agentConnectStart))
 
 	// Fill agent state with proxy information, and store them.
 	h.state.ProxyPid = pid
@@ -384,8 +422,28 @@ func (h *hyper) startSandbox(sandbox *Sandbox) error {
 		message: hyperSandbox,
 	}
 
+	createSandboxStart := time.Now()
 	_, err = h.sendCmd(proxyCmd)
-	return err
+	if err != nil {
+		return err
+	}
+	sandbox.hypervisor.recordLaunchPhase(launchPhaseCreateSandbox, time.Since(createSandboxStart))
+
+	return nil
+}
+
+// proxyPID returns the pid of the proxy process started by startSandbox.
+func (h *hyper) proxyPID() int {
+	return h.state.ProxyPid
+}
+
+// applySysctls is not supported by the hyperstart agent.
+func (h *hyper) applySysctls(sandbox *Sandbox, sysctls map[string]string) error {
+	if len(sysctls) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("applySysctls is not supported by the hyperstart agent")
 }
 
 // stopSandbox is the agent Sandbox stopping implementation for hyperstart.
@@ -451,7 +509,12 @@ func (h *hyper) startOneContainer(sandbox *Sandbox, c *Container) error {
 		container.Fstype = c.state.Fstype
 	} else {
 
-		if err := bindMountContainerRootfs(defaultSharedDir, sandbox.id, c.id, c.rootFs, false); err != nil {
+		var allowedRoots []string
+		if bp, ok := bundlePath(c.config.Annotations); ok {
+			allowedRoots = []string{bp}
+		}
+
+		if err := bindMountContainerRootfs(defaultSharedDir, sandbox.id, c.id, c.rootFs, false, allowedRoots); err != nil {
 			bindUnmountAllRootfs(defaultSharedDir, sandbox)
 			return err
 		}
@@ -537,6 +600,11 @@ func (h *hyper) stopContainer(sandbox *Sandbox, c Container) error {
 	return h.stopOneContainer(sandbox.id, c)
 }
 
+// reapOrphans is not supported by the hyperstart agent.
+func (h *hyper) reapOrphans(sandbox *Sandbox, c Container) (int, error) {
+	return 0, fmt.Errorf("reapOrphans: hyperstart-agent does not support this operation")
+}
+
 func (h *hyper) stopOneContainer(sandboxID string, c Container) error {
 	removeCommand := hyperstart.RemoveCommand{
 		Container: c.id,
@@ -810,7 +878,7 @@ func (h *hyper) onlineCPUMem(cpus uint32) error {
 	return nil
 }
 
-func (h *hyper) check() error {
+func (h *hyper) check(ctx context.Context) error {
 	// hyperstart-agent does not support check
 	return nil
 }
@@ -825,6 +893,61 @@ func (h *hyper) winsizeProcess(c *Container, processID string, height, width uin
 	return nil
 }
 
+func (h *hyper) resizeContainerStorage(sandbox *Sandbox, c Container, sizeBytes uint64) error {
+	if err := validateResizeContainerStorageSize(sizeBytes); err != nil {
+		return err
+	}
+
+	// hyperstart-agent does not support resizing guest storage online
+	return fmt.Errorf("resizeContainerStorage: hyperstart-agent does not support this operation")
+}
+
+func (h *hyper) trimGuestFS(sandbox *Sandbox, mountpoint string) error {
+	if _, err := validateTrimMountpoint(mountpoint); err != nil {
+		return err
+	}
+
+	// hyperstart-agent does not support running fstrim in the guest
+	return fmt.Errorf("trimGuestFS: hyperstart-agent does not support this operation")
+}
+
+func (h *hyper) applySeccomp(sandbox *Sandbox, c *Container, profile []byte) error {
+	if err := validateSeccompProfile(profile); err != nil {
+		return err
+	}
+
+	// hyperstart-agent does not support applying a seccomp profile in the guest
+	return fmt.Errorf("applySeccomp: hyperstart-agent does not support this operation")
+}
+
+func (h *hyper) applyRlimits(sandbox *Sandbox, c *Container, limits []Rlimit) error {
+	if err := validateRlimits(limits); err != nil {
+		return err
+	}
+
+	// hyperstart-agent does not support applying rlimits to an already
+	// running container's process
+	return fmt.Errorf("applyRlimits: hyperstart-agent does not support this operation")
+}
+
+func (h *hyper) setOOMScoreAdj(c *Container, processID string, adj int) error {
+	if err := validateOOMScoreAdj(adj); err != nil {
+		return err
+	}
+
+	// hyperstart-agent does not support setting a process' oom_score_adj
+	return fmt.Errorf("setOOMScoreAdj: hyperstart-agent does not support this operation")
+}
+
+func (h *hyper) setProcessNice(c *Container, processID string, nice int) error {
+	if err := validateNice(nice); err != nil {
+		return err
+	}
+
+	// hyperstart-agent does not support renicing a process
+	return fmt.Errorf("setProcessNice: hyperstart-agent does not support this operation")
+}
+
 func (h *hyper) writeProcessStdin(c *Container, ProcessID string, data []byte) (int, error) {
 	// hyperstart-agent does not support stdin write request
 	return 0, nil
@@ -12,6 +12,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // HypervisorType describes an hypervisor type.
@@ -149,6 +150,22 @@ type HypervisorConfig struct {
 	// HypervisorPath is the hypervisor executable host path.
 	HypervisorPath string
 
+	// KernelHash, ImageHash, InitrdHash, FirmwareHash, and
+	// HypervisorHash are the expected hashes of the asset at the
+	// correspondingly named *Path field, checked by VerifyAssetHash.
+	// An empty hash means that asset's path has nothing configured to
+	// verify it against.
+	KernelHash     string
+	ImageHash      string
+	InitrdHash     string
+	FirmwareHash   string
+	HypervisorHash string
+
+	// AssetHashType names the algorithm used to compute the above
+	// hashes. It defaults to SHA512, the only algorithm asset.hash
+	// currently supports, when left empty.
+	AssetHashType string
+
 	// BlockDeviceDriver specifies the driver to be used for block device
 	// either VirtioSCSI or VirtioBlock with the default driver being defaultBlockDriver
 	BlockDeviceDriver string
@@ -212,6 +229,111 @@ type HypervisorConfig struct {
 	// MemEncrypt is used to enable/disable memory encryption when supported
 	// by the architecture
 	MemEncrypt bool
+
+	// SEVCertChainPath points at the platform's SEV certificate chain
+	// (ARK/ASK/PEK/CEK), required for SEV attestation. It is only
+	// consulted when MemEncrypt is true.
+	SEVCertChainPath string
+
+	// SEVExpectedMeasurementPath points at a file containing the
+	// hex-encoded LAUNCH_MEASURE digest an operator expects an
+	// SEV-encrypted sandbox's launch to produce. checkSEVLaunchMeasurement
+	// implements the comparison against a sevLaunchFirmware, but this
+	// tree does not yet provide a real sevLaunchFirmware outside of
+	// tests, so nothing currently enforces it at launch. Setting this
+	// field is rejected by HypervisorConfig.valid rather than silently
+	// ignored, so a host is never left believing the check is active
+	// when it is not.
+	SEVExpectedMeasurementPath string
+
+	// SEVGuestPolicy holds the SEV guest owner policy bits the launch
+	// session was started with (NO_DBG, NO_KS, ES, NOSEND, DOMAIN, SEV,
+	// as defined by the AMD SEV API). It has no effect on this tree's
+	// own behavior; it is only recorded so it can be reported back to
+	// an external attester alongside the launch measurement.
+	SEVGuestPolicy uint32
+
+	// SEVLaunchMaxRetries is how many times to retry an SEV
+	// LAUNCH_START that fails because no ASID is currently available,
+	// before giving up with ErrSEVNoASID. Zero means don't retry.
+	// startSEVLaunch implements the retry against a sevLaunchStarter, but
+	// this tree's vendored QMP client (github.com/intel/govmm/qemu) has
+	// no LAUNCH_START command at all, so there is no real sevLaunchStarter
+	// to drive it and nothing calls startSEVLaunch outside of tests.
+	// Setting this field is rejected by HypervisorConfig.valid rather
+	// than silently ignored, so a host is never left believing ASID
+	// exhaustion is retried when it is not.
+	SEVLaunchMaxRetries int
+
+	// SEVLaunchRetryDelay is how long to wait between SEV LAUNCH_START
+	// retries triggered by ASID exhaustion. See SEVLaunchMaxRetries: it
+	// is rejected by HypervisorConfig.valid for the same reason.
+	SEVLaunchRetryDelay time.Duration
+
+	// SEVAllowDisable is a host policy flag permitting the
+	// kata.sev.disable annotation to turn off memory encryption for an
+	// individual sandbox. It defaults to false so production hosts
+	// cannot have SEV disabled out from under them by a container
+	// annotation unless an operator explicitly opts in.
+	SEVAllowDisable bool
+
+	// PrefaultMemory asks the hypervisor launch path to fault in and
+	// pin the sandbox's entire guest memory up front, instead of
+	// leaving it to be faulted in on first touch. prefaultGuestMemory
+	// implements this against a guestMemoryPrefaulter, but none of this
+	// tree's hypervisor backends currently expose a way for the runtime
+	// to touch or pin guest memory pages, so nothing currently provides
+	// a real guestMemoryPrefaulter outside of tests. Setting this field
+	// is rejected by HypervisorConfig.valid rather than silently
+	// ignored, so a host is never left believing prefaulting is active
+	// when it is not.
+	PrefaultMemory bool
+
+	// EnableGuestConsoleLog mirrors the guest's boot console onto a
+	// host log file under the sandbox's run directory, so early guest
+	// boot output (before the agent is reachable) can be inspected
+	// after the fact. It is forced off for SEV-encrypted sandboxes by
+	// valid(), since guest console output could otherwise leak
+	// encrypted guest data to the host.
+	EnableGuestConsoleLog bool
+
+	// MaxLogSize bounds, in bytes, the size the persisted VMM stderr
+	// (vmm.log) and guest console (console.log) log files are allowed
+	// to reach under a sandbox's run directory before being rotated.
+	// Zero means defaultMaxLogSize.
+	MaxLogSize int64
+
+	// MaxLogFiles is how many rotated copies of each of those log
+	// files are kept alongside the active one. Zero means
+	// defaultMaxLogFiles.
+	MaxLogFiles int
+
+	// CPUModel overrides the CPU model passed to the hypervisor's -cpu
+	// option. An empty value leaves the choice to the qemuArch
+	// implementation's default (see qemuArchBase.cpuModel), which is
+	// host passthrough.
+	CPUModel string
+
+	// CPUFeatures lists additional CPU features to expose to the guest
+	// on top of CPUModel, for reproducibility across hosts that share
+	// the same baseline features. Each entry is validated by valid()
+	// against the host's own /proc/cpuinfo flags, since a feature the
+	// host does not have cannot be exposed to the guest.
+	CPUFeatures []string
+
+	// AllowExtraArgs is a host policy flag permitting the
+	// kata.hypervisor.extra_args annotation to append arbitrary,
+	// denylist-checked arguments to the VMM command line. It defaults
+	// to false, since arbitrary arguments are a way to tamper with a
+	// sandbox's isolation, unless an operator explicitly opts in.
+	AllowExtraArgs bool
+
+	// ExtraArgs holds raw extra arguments to append to the VMM command
+	// line, populated from the kata.hypervisor.extra_args annotation
+	// by resolveHypervisorExtraArgs once AllowExtraArgs and the
+	// denylist have both been checked. It is recorded in the
+	// persisted LaunchArgs alongside every other argument.
+	ExtraArgs []string
 }
 
 func (conf *HypervisorConfig) valid() (bool, error) {
@@ -247,6 +369,37 @@ func (conf *HypervisorConfig) valid() (bool, error) {
 		conf.Msize9p = defaultMsize9p
 	}
 
+	if conf.MemEncrypt {
+		if conf.SEVCertChainPath == "" {
+			return false, fmt.Errorf("SEV memory encryption is enabled but no SEVCertChainPath was configured")
+		}
+
+		if _, err := loadSEVCertChain(conf.SEVCertChainPath); err != nil {
+			return false, fmt.Errorf("invalid SEV certificate chain: %v", err)
+		}
+	}
+
+	if conf.SEVExpectedMeasurementPath != "" {
+		return false, fmt.Errorf("SEVExpectedMeasurementPath is set, but this build does not yet verify SEV launch measurements: no real sevLaunchFirmware backend exists outside of tests")
+	}
+
+	if conf.PrefaultMemory {
+		return false, fmt.Errorf("PrefaultMemory is set, but this build does not yet support prefaulting guest memory: no real guestMemoryPrefaulter backend exists outside of tests")
+	}
+
+	if conf.SEVLaunchMaxRetries != 0 || conf.SEVLaunchRetryDelay != 0 {
+		return false, fmt.Errorf("SEVLaunchMaxRetries or SEVLaunchRetryDelay is set, but this build does not yet retry SEV LAUNCH_START: the vendored QMP client has no LAUNCH_START command, so no real sevLaunchStarter backend exists outside of tests")
+	}
+
+	if conf.MemEncrypt && conf.EnableGuestConsoleLog {
+		virtLog.Warningf("EnableGuestConsoleLog is set but SEV memory encryption is enabled; disabling guest console logging since it could leak encrypted guest data to the host")
+		conf.EnableGuestConsoleLog = false
+	}
+
+	if err := validateCPUFeatures(conf.CPUFeatures, procCPUInfo); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
@@ -502,9 +655,39 @@ type hypervisor interface {
 	stopSandbox() error
 	pauseSandbox() error
 	resumeSandbox() error
+	// saveSandboxState saves the VM's memory and device state to path,
+	// for later restoration via restoreSandboxState. The sandbox must
+	// already be paused (see pauseSandbox).
+	saveSandboxState(path string) error
+	// restoreSandboxState restores a VM's memory and device state
+	// previously written by saveSandboxState from path. It must be
+	// called before the sandbox's VM has been started.
+	restoreSandboxState(path string) error
+	// checkGuestPanic reports whether the VMM has observed its guest
+	// kernel panic, e.g. via a pvpanic device, along with the reported
+	// reason if so.
+	checkGuestPanic() (bool, string)
 	addDevice(devInfo interface{}, devType deviceType) error
 	hotplugAddDevice(devInfo interface{}, devType deviceType) (interface{}, error)
 	hotplugRemoveDevice(devInfo interface{}, devType deviceType) (interface{}, error)
 	getSandboxConsole(sandboxID string) (string, error)
 	capabilities() capabilities
+	// launchArgs returns the command line used to launch the sandbox's VM,
+	// with any secret-bearing arguments redacted.
+	launchArgs() []string
+	// setBalloonTarget requests that the VM's virtio-balloon device
+	// reclaim memory down to targetMiB. Callers are expected to have
+	// already checked capabilities().isMemoryBalloonSupported() and
+	// validated targetMiB.
+	setBalloonTarget(targetMiB uint32) error
+	// pid returns the process ID of the running VMM, for liveness
+	// checking. It returns an error if the VMM's pid cannot be
+	// determined.
+	pid() (int, error)
+	// recordLaunchPhase records how long the given phase of this
+	// sandbox's launch took, for later inspection via GetLaunchTiming.
+	recordLaunchPhase(phase launchPhase, d time.Duration)
+	// getLaunchTiming returns the launch timing breakdown recorded so
+	// far for this sandbox.
+	getLaunchTiming() LaunchTiming
 }
@@ -179,6 +179,34 @@ func TestHypervisorConfigDefaults(t *testing.T) {
 	}
 }
 
+func TestHypervisorConfigValidAcceptsCPUModel(t *testing.T) {
+	hypervisorConfig := &HypervisorConfig{
+		KernelPath:     fmt.Sprintf("%s/%s", testDir, testKernel),
+		ImagePath:      fmt.Sprintf("%s/%s", testDir, testImage),
+		HypervisorPath: fmt.Sprintf("%s/%s", testDir, testHypervisor),
+		CPUModel:       "qemu64",
+	}
+
+	testHypervisorConfigValid(t, hypervisorConfig, true)
+}
+
+func TestHypervisorConfigDefaultsToEmptyCPUModel(t *testing.T) {
+	hypervisorConfig := &HypervisorConfig{
+		KernelPath:     fmt.Sprintf("%s/%s", testDir, testKernel),
+		ImagePath:      fmt.Sprintf("%s/%s", testDir, testImage),
+		HypervisorPath: fmt.Sprintf("%s/%s", testDir, testHypervisor),
+	}
+
+	testHypervisorConfigValid(t, hypervisorConfig, true)
+
+	// valid() leaves CPUModel untouched when unset: the host
+	// passthrough default comes from qemuArch.cpuModel(), not from
+	// HypervisorConfig.
+	if hypervisorConfig.CPUModel != "" {
+		t.Fatalf("expected CPUModel to remain empty, got %q", hypervisorConfig.CPUModel)
+	}
+}
+
 func TestAppendParams(t *testing.T) {
 	paramList := []Param{
 		{
@@ -35,6 +35,11 @@ func (impl *VCImpl) DeleteSandbox(sandboxID string) (VCSandbox, error) {
 	return DeleteSandbox(sandboxID)
 }
 
+// ForceDeleteSandbox implements the VC function of the same name.
+func (impl *VCImpl) ForceDeleteSandbox(sandboxID string) (VCSandbox, error) {
+	return ForceDeleteSandbox(sandboxID)
+}
+
 // StartSandbox implements the VC function of the same name.
 func (impl *VCImpl) StartSandbox(sandboxID string) (VCSandbox, error) {
 	return StartSandbox(sandboxID)
@@ -111,16 +116,36 @@ func (impl *VCImpl) StatsContainer(sandboxID, containerID string) (ContainerStat
 	return StatsContainer(sandboxID, containerID)
 }
 
+// StatsSandbox implements the VC function of the same name.
+func (impl *VCImpl) StatsSandbox(sandboxID string) (SandboxStats, error) {
+	return StatsSandbox(sandboxID)
+}
+
+// TrimGuestFS implements the VC function of the same name.
+func (impl *VCImpl) TrimGuestFS(sandboxID, mountpoint string) error {
+	return TrimGuestFS(sandboxID, mountpoint)
+}
+
 // KillContainer implements the VC function of the same name.
 func (impl *VCImpl) KillContainer(sandboxID, containerID string, signal syscall.Signal, all bool) error {
 	return KillContainer(sandboxID, containerID, signal, all)
 }
 
+// SetOOMScoreAdj implements the VC function of the same name.
+func (impl *VCImpl) SetOOMScoreAdj(sandboxID, containerID, processID string, adj int) error {
+	return SetOOMScoreAdj(sandboxID, containerID, processID, adj)
+}
+
 // ProcessListContainer implements the VC function of the same name.
 func (impl *VCImpl) ProcessListContainer(sandboxID, containerID string, options ProcessListOptions) (ProcessList, error) {
 	return ProcessListContainer(sandboxID, containerID, options)
 }
 
+// ProcessListSandbox implements the VC function of the same name.
+func (impl *VCImpl) ProcessListSandbox(sandboxID string, options ProcessListOptions) (map[string]ProcessList, error) {
+	return ProcessListSandbox(sandboxID, options)
+}
+
 // UpdateContainer implements the VC function of the same name.
 func (impl *VCImpl) UpdateContainer(sandboxID, containerID string, resources specs.LinuxResources) error {
 	return UpdateContainer(sandboxID, containerID, resources)
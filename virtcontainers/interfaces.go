@@ -19,6 +19,7 @@ type VC interface {
 
 	CreateSandbox(sandboxConfig SandboxConfig) (VCSandbox, error)
 	DeleteSandbox(sandboxID string) (VCSandbox, error)
+	ForceDeleteSandbox(sandboxID string) (VCSandbox, error)
 	FetchSandbox(sandboxID string) (VCSandbox, error)
 	ListSandbox() ([]SandboxStatus, error)
 	PauseSandbox(sandboxID string) (VCSandbox, error)
@@ -26,17 +27,21 @@ type VC interface {
 	RunSandbox(sandboxConfig SandboxConfig) (VCSandbox, error)
 	StartSandbox(sandboxID string) (VCSandbox, error)
 	StatusSandbox(sandboxID string) (SandboxStatus, error)
+	StatsSandbox(sandboxID string) (SandboxStats, error)
 	StopSandbox(sandboxID string) (VCSandbox, error)
+	TrimGuestFS(sandboxID, mountpoint string) error
 
 	CreateContainer(sandboxID string, containerConfig ContainerConfig) (VCSandbox, VCContainer, error)
 	DeleteContainer(sandboxID, containerID string) (VCContainer, error)
 	EnterContainer(sandboxID, containerID string, cmd Cmd) (VCSandbox, VCContainer, *Process, error)
 	KillContainer(sandboxID, containerID string, signal syscall.Signal, all bool) error
+	SetOOMScoreAdj(sandboxID, containerID, processID string, adj int) error
 	StartContainer(sandboxID, containerID string) (VCContainer, error)
 	StatusContainer(sandboxID, containerID string) (ContainerStatus, error)
 	StatsContainer(sandboxID, containerID string) (ContainerStats, error)
 	StopContainer(sandboxID, containerID string) (VCContainer, error)
 	ProcessListContainer(sandboxID, containerID string, options ProcessListOptions) (ProcessList, error)
+	ProcessListSandbox(sandboxID string, options ProcessListOptions) (map[string]ProcessList, error)
 	UpdateContainer(sandboxID, containerID string, resources specs.LinuxResources) error
 	PauseContainer(sandboxID, containerID string) error
 	ResumeContainer(sandboxID, containerID string) error
@@ -58,6 +63,7 @@ type VCSandbox interface {
 	Monitor() (chan error, error)
 	Delete() error
 	Status() SandboxStatus
+	Stats() (SandboxStats, error)
 	CreateContainer(contConfig ContainerConfig) (VCContainer, error)
 	DeleteContainer(contID string) (VCContainer, error)
 	StartContainer(containerID string) (VCContainer, error)
@@ -10,6 +10,25 @@ import (
 	"io"
 )
 
+// defaultMaxProcessOutputBuffer bounds how many bytes of stdout or stderr
+// virtcontainers will pull from the agent for a single process, when
+// SandboxConfig.MaxProcessOutputBuffer is unset. It keeps a chatty guest
+// process from growing host-side buffers without bound when the consumer
+// reading the stream falls behind.
+const defaultMaxProcessOutputBuffer = 10 * 1024 * 1024 // 10MiB
+
+// defaultStreamPrefetchSize bounds how much stdout or stderr stdoutStream
+// and stderrStream pull from the agent in a single readProcessStdout or
+// readProcessStderr call. Serving Read from this prefetched buffer, and
+// only re-calling the agent once it drains, avoids issuing one gRPC round
+// trip per Read call for a chatty process.
+const defaultStreamPrefetchSize = 32 * 1024 // 32KiB
+
+// errOutputBufferExceeded is returned by a stdout or stderr stream's Read
+// once the process has produced more output than its MaxProcessOutputBuffer,
+// marking the output as truncated instead of growing the buffer further.
+var errOutputBufferExceeded = errors.New("process output exceeded MaxProcessOutputBuffer")
+
 type iostream struct {
 	sandbox   *Sandbox
 	container *Container
@@ -25,11 +44,17 @@ type stdinStream struct {
 // io.Reader
 type stdoutStream struct {
 	*iostream
+	maxBuffer uint64
+	bytesRead uint64
+	buf       []byte
 }
 
 // io.Reader
 type stderrStream struct {
 	*iostream
+	maxBuffer uint64
+	bytesRead uint64
+	buf       []byte
 }
 
 func newIOStream(s *Sandbox, c *Container, proc string) *iostream {
@@ -41,16 +66,26 @@ func newIOStream(s *Sandbox, c *Container, proc string) *iostream {
 	}
 }
 
+// maxOutputBuffer returns the configured bound on buffered per-process
+// output, falling back to defaultMaxProcessOutputBuffer when unset.
+func (s *iostream) maxOutputBuffer() uint64 {
+	if s.sandbox.config != nil && s.sandbox.config.MaxProcessOutputBuffer > 0 {
+		return s.sandbox.config.MaxProcessOutputBuffer
+	}
+
+	return defaultMaxProcessOutputBuffer
+}
+
 func (s *iostream) stdin() io.WriteCloser {
 	return &stdinStream{s}
 }
 
 func (s *iostream) stdout() io.Reader {
-	return &stdoutStream{s}
+	return &stdoutStream{iostream: s, maxBuffer: s.maxOutputBuffer()}
 }
 
 func (s *iostream) stderr() io.Reader {
-	return &stderrStream{s}
+	return &stderrStream{iostream: s, maxBuffer: s.maxOutputBuffer()}
 }
 
 func (s *stdinStream) Write(data []byte) (n int, err error) {
@@ -79,7 +114,47 @@ func (s *stdoutStream) Read(data []byte) (n int, err error) {
 		return 0, errors.New("stream closed")
 	}
 
-	return s.sandbox.agent.readProcessStdout(s.container, s.process, data)
+	if len(s.buf) == 0 {
+		if s.bytesRead >= s.maxBuffer {
+			return 0, errOutputBufferExceeded
+		}
+
+		if err := s.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(data, s.buf)
+	s.buf = s.buf[n:]
+
+	return n, nil
+}
+
+// fill tops up buf with a larger prefetched chunk from the agent, so that
+// Read can serve several calls out of it instead of round-tripping to the
+// guest for every one. It is only called once buf has fully drained, and
+// translates a successful but empty read into io.EOF, since the process
+// has nothing left to produce.
+func (s *stdoutStream) fill() error {
+	chunk := s.maxBuffer - s.bytesRead
+	if chunk > defaultStreamPrefetchSize {
+		chunk = defaultStreamPrefetchSize
+	}
+
+	buf := make([]byte, chunk)
+	n, err := s.sandbox.agent.readProcessStdout(s.container, s.process, buf)
+	s.bytesRead += uint64(n)
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return io.EOF
+	}
+
+	s.buf = buf[:n]
+
+	return nil
 }
 
 func (s *stderrStream) Read(data []byte) (n int, err error) {
@@ -87,5 +162,42 @@ func (s *stderrStream) Read(data []byte) (n int, err error) {
 		return 0, errors.New("stream closed")
 	}
 
-	return s.sandbox.agent.readProcessStderr(s.container, s.process, data)
+	if len(s.buf) == 0 {
+		if s.bytesRead >= s.maxBuffer {
+			return 0, errOutputBufferExceeded
+		}
+
+		if err := s.fill(); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(data, s.buf)
+	s.buf = s.buf[n:]
+
+	return n, nil
+}
+
+// fill tops up buf with a larger prefetched chunk from the agent; see
+// stdoutStream.fill for the rationale and the io.EOF translation rule.
+func (s *stderrStream) fill() error {
+	chunk := s.maxBuffer - s.bytesRead
+	if chunk > defaultStreamPrefetchSize {
+		chunk = defaultStreamPrefetchSize
+	}
+
+	buf := make([]byte, chunk)
+	n, err := s.sandbox.agent.readProcessStderr(s.container, s.process, buf)
+	s.bytesRead += uint64(n)
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return io.EOF
+	}
+
+	s.buf = buf[:n]
+
+	return nil
 }
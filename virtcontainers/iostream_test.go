@@ -6,6 +6,7 @@
 package virtcontainers
 
 import (
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -36,11 +37,13 @@ func TestIOStream(t *testing.T) {
 	_, err = stdin.Write(buffer)
 	assert.Nil(t, err, "stdin write failed: %s", err)
 
+	// The Noop agent never produces output, so the stream reports io.EOF
+	// rather than an error.
 	_, err = stdout.Read(buffer)
-	assert.Nil(t, err, "stdout read failed: %s", err)
+	assert.Equal(t, io.EOF, err, "stdout read failed: %s", err)
 
 	_, err = stderr.Read(buffer)
-	assert.Nil(t, err, "stderr read failed: %s", err)
+	assert.Equal(t, io.EOF, err, "stderr read failed: %s", err)
 
 	err = stdin.Close()
 	assert.Nil(t, err, "stream close failed: %s", err)
@@ -57,3 +60,107 @@ func TestIOStream(t *testing.T) {
 	err = stdin.Close()
 	assert.NotNil(t, err, "stdin close closed should fail")
 }
+
+// fakeChattyAgent is an agent that always fills the caller's buffer,
+// simulating a process that never stops producing output.
+type fakeChattyAgent struct {
+	noopAgent
+}
+
+func (a *fakeChattyAgent) readProcessStdout(c *Container, processID string, data []byte) (int, error) {
+	for i := range data {
+		data[i] = 'x'
+	}
+	return len(data), nil
+}
+
+func TestStdoutStreamRespectsMaxProcessOutputBuffer(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		agent:  &fakeChattyAgent{},
+		config: &SandboxConfig{MaxProcessOutputBuffer: 16},
+	}
+	c := &Container{sandbox: s}
+
+	stream := newIOStream(s, c, "bar")
+	stdout := stream.stdout()
+
+	buffer := make([]byte, 10)
+
+	n, err := stdout.Read(buffer)
+	assert.NoError(err)
+	assert.Equal(10, n)
+
+	n, err = stdout.Read(buffer)
+	assert.NoError(err)
+	assert.Equal(10, n)
+
+	_, err = stdout.Read(buffer)
+	assert.Equal(errOutputBufferExceeded, err)
+}
+
+// fakeBlockAgent hands back stdout data in large, fixed-size blocks
+// regardless of how much the caller actually asked for, simulating an
+// agent side that always has a full block ready to send. It counts how
+// many times readProcessStdout was called, and reports EOF by returning
+// a successful empty read once remaining reaches zero.
+type fakeBlockAgent struct {
+	noopAgent
+	remaining int
+	calls     int
+}
+
+func (a *fakeBlockAgent) readProcessStdout(c *Container, processID string, data []byte) (int, error) {
+	a.calls++
+
+	if a.remaining <= 0 {
+		return 0, nil
+	}
+
+	n := len(data)
+	if n > a.remaining {
+		n = a.remaining
+	}
+
+	for i := 0; i < n; i++ {
+		data[i] = 'x'
+	}
+	a.remaining -= n
+
+	return n, nil
+}
+
+func TestStdoutStreamPrefetchReducesAgentCalls(t *testing.T) {
+	assert := assert.New(t)
+
+	const totalBytes = 1024 * 1024 // 1MiB of output
+
+	agent := &fakeBlockAgent{remaining: totalBytes}
+	s := &Sandbox{
+		agent:  agent,
+		config: &SandboxConfig{MaxProcessOutputBuffer: 2 * totalBytes},
+	}
+	c := &Container{sandbox: s}
+
+	stream := newIOStream(s, c, "bar")
+	stdout := stream.stdout()
+
+	readBuffer := make([]byte, 64)
+	reads := 0
+	totalRead := 0
+
+	for {
+		n, err := stdout.Read(readBuffer)
+		totalRead += n
+		reads++
+
+		if err != nil {
+			assert.Equal(io.EOF, err)
+			break
+		}
+	}
+
+	assert.Equal(totalBytes, totalRead)
+	assert.True(agent.calls < reads, "expected far fewer agent calls (%d) than Read calls (%d)", agent.calls, reads)
+}
@@ -54,11 +54,39 @@ var (
 	kataEphemeralDevType  = "ephemeral"
 )
 
+// defaultAgentShortCallTimeout bounds agent calls expected to return
+// quickly, such as check(). It matches the timeout check() was
+// hard-coded to before ShortCallTimeout became configurable.
+const defaultAgentShortCallTimeout = 5 * time.Second
+
 // KataAgentConfig is a structure storing information needed
 // to reach the Kata Containers agent.
 type KataAgentConfig struct {
 	GRPCSocket   string
 	LongLiveConn bool
+
+	// EnableCallMetrics turns on per-method latency histograms for every
+	// gRPC call issued to the agent. It defaults to off so that agents
+	// are not charged the bookkeeping cost unless an embedder asks to
+	// scrape them through the same metrics registry as storage metrics.
+	EnableCallMetrics bool
+
+	// ShortCallTimeout bounds agent calls expected to return quickly,
+	// such as check(). It defaults to defaultAgentShortCallTimeout.
+	ShortCallTimeout time.Duration
+
+	// LongCallTimeout bounds agent calls that may legitimately take a
+	// while, such as createSandbox. It defaults to 0, meaning no
+	// timeout is applied, which preserves the behavior these calls had
+	// before per-call-class timeouts existed.
+	LongCallTimeout time.Duration
+
+	// AgentVSOCKPort overrides the vsock port the agent listens on, for
+	// hosts running custom vsock-based tooling alongside kata where the
+	// default port could collide. It is validated by
+	// validateAgentVSOCKPort and only takes effect when the agent is
+	// reached over vsock. Zero means use the port carried by GRPCSocket.
+	AgentVSOCKPort uint32
 }
 
 type kataVSOCK struct {
@@ -75,6 +103,79 @@ func (s *kataVSOCK) String() string {
 type KataAgentState struct {
 	ProxyPid int
 	URL      string
+
+	// ProtocolVersion is the agent gRPC protocol version negotiated with
+	// the running agent via its Version() RPC when the sandbox was
+	// started. It lets reconnect refuse to talk to an agent whose
+	// persisted protocol version is older than this runtime requires,
+	// e.g. after the runtime process itself has been upgraded.
+	ProtocolVersion string
+
+	// VSOCKPort is the vsock port the agent was reached on, if the
+	// sandbox's agent connection goes over vsock. It is zero when the
+	// connection instead goes over a UNIX socket backed serial channel.
+	VSOCKPort uint32
+}
+
+// kataAgentProtocolVersion is the oldest agent gRPC protocol version this
+// runtime requires in order to make all of the calls it relies on.
+const kataAgentProtocolVersion = "1.0.0"
+
+// kataAgentEnvMergeProtocolVersion is the oldest agent gRPC protocol
+// version that understands Cmd.MergeEnv, honoring it by merging the
+// exec'd process's environment instead of replacing it. An agent older
+// than this interprets an exec request no differently whether MergeEnv
+// is set or not, so checkExecCapabilities refuses the request outright
+// rather than silently falling back to replace semantics.
+const kataAgentEnvMergeProtocolVersion = "1.1.0"
+
+// parseAgentProtocolVersion parses a dotted major.minor.patch version
+// string, as reported by the agent's Version() RPC.
+func parseAgentProtocolVersion(version string) ([3]int, error) {
+	var parts [3]int
+
+	fields := strings.Split(version, ".")
+	if len(fields) != 3 {
+		return parts, fmt.Errorf("invalid agent protocol version %q: expected major.minor.patch", version)
+	}
+
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return parts, fmt.Errorf("invalid agent protocol version %q: %v", version, err)
+		}
+		parts[i] = n
+	}
+
+	return parts, nil
+}
+
+// agentProtocolVersionAtLeast reports whether version is at least as new
+// as min, comparing them as dotted major.minor.patch version strings.
+func agentProtocolVersionAtLeast(version, min string) (bool, error) {
+	got, err := parseAgentProtocolVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	want, err := parseAgentProtocolVersion(min)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range got {
+		if got[i] != want[i] {
+			return got[i] > want[i], nil
+		}
+	}
+
+	return true, nil
+}
+
+// agentProtocolVersionCompatible reports whether version is at least as
+// new as kataAgentProtocolVersion.
+func agentProtocolVersionCompatible(version string) (bool, error) {
+	return agentProtocolVersionAtLeast(version, kataAgentProtocolVersion)
 }
 
 type kataAgent struct {
@@ -89,6 +190,13 @@ type kataAgent struct {
 	state        KataAgentState
 	keepConn     bool
 	proxyBuiltIn bool
+	callMetrics  bool
+
+	// shortCallTimeout and longCallTimeout bound agent calls by class,
+	// set from KataAgentConfig's fields of the same name. See
+	// callTimeout for how a call is classified.
+	shortCallTimeout time.Duration
+	longCallTimeout  time.Duration
 
 	vmSocket interface{}
 }
@@ -134,6 +242,13 @@ func (k *kataAgent) generateVMSocket(sandbox *Sandbox, c KataAgentConfig) error
 			Name:     defaultKataChannel,
 		}
 	} else {
+		if c.AgentVSOCKPort != 0 {
+			if err := validateAgentVSOCKPort(c.AgentVSOCKPort); err != nil {
+				return err
+			}
+			port = c.AgentVSOCKPort
+		}
+
 		// We want to go through VSOCK. The VM VSOCK endpoint will be our gRPC.
 		k.vmSocket = kataVSOCK{
 			contextID: cid,
@@ -151,6 +266,9 @@ func (k *kataAgent) init(sandbox *Sandbox, config interface{}) (err error) {
 			return err
 		}
 		k.keepConn = c.LongLiveConn
+		k.callMetrics = c.EnableCallMetrics
+		k.shortCallTimeout = c.ShortCallTimeout
+		k.longCallTimeout = c.LongCallTimeout
 	default:
 		return fmt.Errorf("Invalid config type")
 	}
@@ -191,6 +309,9 @@ func (k *kataAgent) capabilities() capabilities {
 
 	// add all capabilities supported by agent
 	caps.setBlockDeviceSupport()
+	caps.setCPUHotplugSupport()
+	caps.setMemoryHotplugSupport()
+	caps.setOnlineCPUMemSupport()
 
 	return caps
 }
@@ -299,9 +420,34 @@ func cmdEnvsToStringSlice(ev []EnvVar) []string {
 	return env
 }
 
+// checkExecCapabilities returns a clear error if cmd requests exec
+// behavior the connected agent's negotiated protocol version does not
+// support, rather than letting the agent silently ignore it.
+func (k *kataAgent) checkExecCapabilities(cmd Cmd) error {
+	if !cmd.MergeEnv {
+		return nil
+	}
+
+	compatible, err := agentProtocolVersionAtLeast(k.state.ProtocolVersion, kataAgentEnvMergeProtocolVersion)
+	if err != nil {
+		return fmt.Errorf("cannot determine whether agent supports merging exec environment variables: %v", err)
+	}
+
+	if !compatible {
+		return fmt.Errorf("agent protocol version %s is too old to support merging exec environment variables (Cmd.MergeEnv); %s or newer is required",
+			k.state.ProtocolVersion, kataAgentEnvMergeProtocolVersion)
+	}
+
+	return nil
+}
+
 func (k *kataAgent) exec(sandbox *Sandbox, c Container, cmd Cmd) (*Process, error) {
 	var kataProcess *grpc.Process
 
+	if err := k.checkExecCapabilities(cmd); err != nil {
+		return nil, err
+	}
+
 	kataProcess, err := cmdToKataProcess(cmd)
 	if err != nil {
 		return nil, err
@@ -436,14 +582,31 @@ func (k *kataAgent) startSandbox(sandbox *Sandbox) error {
 	}
 
 	// Start the proxy here
+	agentConnectStart := time.Now()
 	pid, uri, err := k.proxy.start(sandbox, proxyParams)
 	if err != nil {
 		return err
 	}
+	sandbox.hypervisor.recordLaunchPhase(launchPhaseAgentConnect, time.Since(agentConnectStart))
 
-	// Fill agent state with proxy information, and store them.
+	// Fill agent state with proxy information.
 	k.state.ProxyPid = pid
 	k.state.URL = uri
+	if s, ok := k.vmSocket.(kataVSOCK); ok {
+		k.state.VSOCKPort = s.port
+	}
+
+	if err := k.connect(); err != nil {
+		return err
+	}
+
+	versionResp, err := k.client.Version(context.Background(), &grpc.CheckRequest{})
+	if err != nil {
+		return err
+	}
+	k.state.ProtocolVersion = versionResp.AgentVersion
+
+	// Store proxy and negotiated protocol version information.
 	if err := sandbox.storage.storeAgentState(sandbox.id, k.state); err != nil {
 		return err
 	}
@@ -536,8 +699,31 @@ func (k *kataAgent) startSandbox(sandbox *Sandbox) error {
 		SandboxPidns: sandbox.sharePidNs,
 	}
 
+	createSandboxStart := time.Now()
 	_, err = k.sendReq(req)
-	return err
+	if err != nil {
+		return err
+	}
+	sandbox.hypervisor.recordLaunchPhase(launchPhaseCreateSandbox, time.Since(createSandboxStart))
+
+	return nil
+}
+
+// proxyPID returns the pid of the proxy process started by startSandbox.
+func (k *kataAgent) proxyPID() int {
+	return k.state.ProxyPid
+}
+
+// applySysctls is not implemented: the vendored agent protocol has no
+// sandbox-wide exec RPC, only ExecProcessRequest, which always targets
+// an already-running container, and sandbox start happens before any
+// container exists.
+func (k *kataAgent) applySysctls(sandbox *Sandbox, sysctls map[string]string) error {
+	if len(sysctls) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("applySysctls: the kata agent protocol has no sandbox-wide exec RPC to apply sysctls with")
 }
 
 func (k *kataAgent) stopSandbox(sandbox *Sandbox) error {
@@ -643,6 +829,27 @@ func constraintGRPCSpec(grpcSpec *grpc.Spec) {
 	grpcSpec.Linux.Namespaces = tmpNamespaces
 }
 
+// applyMountSizeLimits appends a size= option to the OCI tmpfs mount
+// matching each mount in mounts that has a non-zero SizeLimit, so the
+// guest enforces the cap when it actually performs the tmpfs mount.
+func (k *kataAgent) applyMountSizeLimits(spec *specs.Spec, mounts []Mount) {
+	for _, m := range mounts {
+		if m.Type != "tmpfs" || m.SizeLimit == 0 {
+			continue
+		}
+
+		for idx, ociMount := range spec.Mounts {
+			if ociMount.Destination != m.Destination {
+				continue
+			}
+
+			sizeOption := fmt.Sprintf("size=%d", m.SizeLimit)
+			spec.Mounts[idx].Options = append(spec.Mounts[idx].Options, sizeOption)
+			k.Logger().WithField("mount", m.Destination).WithField("size-option", sizeOption).Debug("Applying tmpfs size limit")
+		}
+	}
+}
+
 func (k *kataAgent) handleShm(grpcSpec *grpc.Spec, sandbox *Sandbox) {
 	for idx, mnt := range grpcSpec.Mounts {
 		if mnt.Destination != "/dev/shm" {
@@ -765,7 +972,7 @@ func (k *kataAgent) createContainer(sandbox *Sandbox, c *Container) (p *Process,
 		// (kataGuestSharedDir/ctrID/
 		ctrStorages = append(ctrStorages, rootfs)
 
-	} else {
+	} else if !c.config.LazyRootfs {
 		// This is not a block based device rootfs.
 		// We are going to bind mount it into the 9pfs
 		// shared drive between the host and the guest.
@@ -774,7 +981,10 @@ func (k *kataAgent) createContainer(sandbox *Sandbox, c *Container) (p *Process,
 		// (kataGuestSharedDir) is already mounted in the
 		// guest. We only need to mount the rootfs from
 		// the host and it will show up in the guest.
-		if err = bindMountContainerRootfs(kataHostSharedDir, sandbox.id, c.id, c.rootFs, false); err != nil {
+		//
+		// LazyRootfs defers this mount to startContainer
+		// instead, so it isn't skipped here.
+		if err = k.mountContainerRootfs(sandbox, c); err != nil {
 			return nil, err
 		}
 	}
@@ -784,6 +994,11 @@ func (k *kataAgent) createContainer(sandbox *Sandbox, c *Container) (p *Process,
 		return nil, err
 	}
 
+	if c.config.ForceReadonlyRootfs && ociSpec.Root != nil && !ociSpec.Root.Readonly {
+		k.Logger().WithField("container", c.id).Info("ForceReadonlyRootfs is set: overriding OCI spec to mount rootfs read-only")
+		ociSpec.Root.Readonly = true
+	}
+
 	// Handle container mounts
 	newMounts, err := c.mountSharedDirMounts(kataHostSharedDir, kataGuestSharedDir)
 	if err != nil {
@@ -796,6 +1011,10 @@ func (k *kataAgent) createContainer(sandbox *Sandbox, c *Container) (p *Process,
 		return nil, err
 	}
 
+	// Cap any tmpfs mounts that were given a SizeLimit, so a container
+	// cannot exhaust guest memory through an unbounded tmpfs.
+	k.applyMountSizeLimits(ociSpec, c.mounts)
+
 	// Append container devices for block devices passed with --device.
 	ctrDevices = k.appendDevices(ctrDevices, c.devices)
 
@@ -938,7 +1157,32 @@ func (k *kataAgent) handlePidNamespace(grpcSpec *grpc.Spec, sandbox *Sandbox) (b
 	return sharedPidNs, nil
 }
 
+// mountContainerRootfs bind mounts a non-block-device container rootfs
+// into the 9pfs shared directory between the guest and the host. It is
+// called from createContainer, unless LazyRootfs defers it to
+// startContainer instead.
+func (k *kataAgent) mountContainerRootfs(sandbox *Sandbox, c *Container) error {
+	if c.state.Fstype != "" {
+		// Block based device rootfs: the agent mounts it as guest
+		// storage at creation time, there is nothing to bind mount.
+		return nil
+	}
+
+	var allowedRoots []string
+	if bp, ok := bundlePath(c.config.Annotations); ok {
+		allowedRoots = []string{bp}
+	}
+
+	return bindMountContainerRootfs(kataHostSharedDir, sandbox.id, c.id, c.rootFs, c.config.effectiveRootfsReadonly(), allowedRoots)
+}
+
 func (k *kataAgent) startContainer(sandbox *Sandbox, c *Container) error {
+	if c.config.LazyRootfs {
+		if err := k.mountContainerRootfs(sandbox, c); err != nil {
+			return err
+		}
+	}
+
 	req := &grpc.StartContainerRequest{
 		ContainerId: c.id,
 	}
@@ -970,6 +1214,27 @@ func (k *kataAgent) stopContainer(sandbox *Sandbox, c Container) error {
 	return os.RemoveAll(rootPathParent)
 }
 
+// reapOrphans asks the agent to find and kill any guest processes for c
+// that have been reparented to the guest's init (PID 1), and returns
+// how many were reaped.
+func (k *kataAgent) reapOrphans(sandbox *Sandbox, c Container) (int, error) {
+	req := &grpc.ReapOrphansRequest{
+		ContainerId: c.id,
+	}
+
+	resp, err := k.sendReq(req)
+	if err != nil {
+		return 0, err
+	}
+
+	reapResp, ok := resp.(*grpc.ReapOrphansResponse)
+	if !ok {
+		return 0, fmt.Errorf("Bad reap orphans response")
+	}
+
+	return int(reapResp.Reaped), nil
+}
+
 func (k *kataAgent) signalProcess(c *Container, processID string, signal syscall.Signal, all bool) error {
 	execID := processID
 	if all {
@@ -998,6 +1263,50 @@ func (k *kataAgent) winsizeProcess(c *Container, processID string, height, width
 	return err
 }
 
+func (k *kataAgent) trimGuestFS(sandbox *Sandbox, mountpoint string) error {
+	if _, err := validateTrimMountpoint(mountpoint); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("trimGuestFS: the kata agent protocol has no RPC to run fstrim in the guest")
+}
+
+func (k *kataAgent) applySeccomp(sandbox *Sandbox, c *Container, profile []byte) error {
+	if err := validateSeccompProfile(profile); err != nil {
+		return err
+	}
+
+	// Seccomp profiles are stripped from the OCI spec sent to the agent
+	// in constraintGRPCSpec because they cannot be handled properly
+	// until we provide a guest image with libseccomp support. More
+	// details here: https://github.com/kata-containers/agent/issues/104
+	return fmt.Errorf("applySeccomp: the kata agent protocol has no RPC to apply a seccomp profile in the guest")
+}
+
+func (k *kataAgent) applyRlimits(sandbox *Sandbox, c *Container, limits []Rlimit) error {
+	if err := validateRlimits(limits); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("applyRlimits: the kata agent protocol has no RPC to apply rlimits to an already running container's process")
+}
+
+func (k *kataAgent) setOOMScoreAdj(c *Container, processID string, adj int) error {
+	if err := validateOOMScoreAdj(adj); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("setOOMScoreAdj: the kata agent protocol has no RPC to write a guest process' oom_score_adj")
+}
+
+func (k *kataAgent) setProcessNice(c *Container, processID string, nice int) error {
+	if err := validateNice(nice); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("setProcessNice: the kata agent protocol has no RPC to renice a guest process")
+}
+
 func (k *kataAgent) processListContainer(sandbox *Sandbox, c Container, options ProcessListOptions) (ProcessList, error) {
 	req := &grpc.ListProcessesRequest{
 		ContainerId: c.id,
@@ -1033,6 +1342,14 @@ func (k *kataAgent) updateContainer(sandbox *Sandbox, c Container, resources spe
 	return err
 }
 
+func (k *kataAgent) resizeContainerStorage(sandbox *Sandbox, c Container, sizeBytes uint64) error {
+	if err := validateResizeContainerStorageSize(sizeBytes); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("resizeContainerStorage: the kata agent protocol has no RPC to resize guest storage")
+}
+
 func (k *kataAgent) pauseContainer(sandbox *Sandbox, c Container) error {
 	req := &grpc.PauseContainerRequest{
 		ContainerId: c.id,
@@ -1051,7 +1368,136 @@ func (k *kataAgent) resumeContainer(sandbox *Sandbox, c Container) error {
 	return err
 }
 
+// getClockSource asks the guest agent for the currently selected
+// clocksource and the set of clocksources available to switch to. This is
+// mainly useful to catch a confidential guest that picked a bad
+// clocksource (e.g. falling back off kvm-clock) and is drifting.
+func (k *kataAgent) getClockSource(sandbox *Sandbox) (string, []string, error) {
+	req := &grpc.GetClockSourceRequest{}
+
+	resp, err := k.sendReq(req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	r := resp.(*grpc.GetClockSourceResponse)
+	return r.Current, r.Available, nil
+}
+
+// getGuestMemInfo asks the guest agent to read back its own
+// /proc/meminfo and parses the result, so callers can make sizing
+// decisions based on memory the guest itself considers free rather than
+// cgroup accounting, which can lag guest-internal pressure.
+func (k *kataAgent) getGuestMemInfo(sandbox *Sandbox) (*MemInfo, error) {
+	req := &grpc.GetGuestMemInfoRequest{}
+
+	resp, err := k.sendReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r := resp.(*grpc.GetGuestMemInfoResponse)
+	return parseMemInfo(r.Content)
+}
+
+// getGuestLoad asks the guest agent to read back its own /proc/uptime
+// and /proc/loadavg and parses the result, so callers can get a quick
+// read on guest health without needing a shell inside the container.
+func (k *kataAgent) getGuestLoad(sandbox *Sandbox) (*LoadInfo, error) {
+	req := &grpc.GetGuestLoadRequest{}
+
+	resp, err := k.sendReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r := resp.(*grpc.GetGuestLoadResponse)
+	return parseLoadInfo(r.UptimeContent, r.LoadavgContent)
+}
+
+// getGuestTime asks the agent for the guest's current wall-clock time,
+// for comparison against host time to detect clock skew.
+func (k *kataAgent) getGuestTime(sandbox *Sandbox) (time.Time, error) {
+	req := &grpc.GetGuestTimeRequest{}
+
+	resp, err := k.sendReq(req)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	r, ok := resp.(*grpc.GetGuestTimeResponse)
+	if !ok {
+		return time.Time{}, fmt.Errorf("Bad get guest time response")
+	}
+
+	return time.Unix(r.Seconds, int64(r.Nanos)), nil
+}
+
+// listGuestMounts asks the guest agent to read back its own
+// /proc/self/mountinfo and parses the result, so callers can verify a
+// shared mount actually landed in the guest rather than assuming it did
+// from the host-side request alone.
+func (k *kataAgent) listGuestMounts(sandbox *Sandbox) ([]GuestMount, error) {
+	req := &grpc.ListGuestMountsRequest{}
+
+	resp, err := k.sendReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r := resp.(*grpc.ListGuestMountsResponse)
+	return parseMountInfo(r.Content)
+}
+
+// inspectNetwork asks the guest agent to list the interfaces and routes
+// it currently has configured, so the runtime can show what the guest
+// actually applied rather than what it was asked to apply.
+func (k *kataAgent) inspectNetwork(sandbox *Sandbox) (*GuestNetworkState, error) {
+	ifaceResp, err := k.sendReq(&grpc.ListInterfacesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	ifaces := ifaceResp.(*grpc.Interfaces).Interfaces
+
+	routeResp, err := k.sendReq(&grpc.ListRoutesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	routes := routeResp.(*grpc.Routes).Routes
+
+	state := &GuestNetworkState{}
+
+	for _, ifc := range ifaces {
+		link := NetworkLink{
+			Name:         ifc.Name,
+			MTU:          ifc.Mtu,
+			HardwareAddr: ifc.HwAddr,
+		}
+
+		for _, addr := range ifc.IPAddresses {
+			link.Addresses = append(link.Addresses, fmt.Sprintf("%s/%s", addr.Address, addr.Mask))
+		}
+
+		state.Links = append(state.Links, link)
+	}
+
+	for _, r := range routes {
+		state.Routes = append(state.Routes, NetworkRoute{
+			Destination: r.Dest,
+			Gateway:     r.Gateway,
+			Device:      r.Device,
+			Source:      r.Source,
+		})
+	}
+
+	return state, nil
+}
+
 func (k *kataAgent) onlineCPUMem(cpus uint32) error {
+	if !k.capabilities().isOnlineCPUMemSupported() {
+		return fmt.Errorf("onlineCPUMem: kata agent does not support onlining hot-added CPUs/memory")
+	}
+
 	req := &grpc.OnlineCPUMemRequest{
 		Wait:   false,
 		NbCpus: cpus,
@@ -1089,6 +1535,7 @@ func (k *kataAgent) statsContainer(sandbox *Sandbox, c Container) (*ContainerSta
 	}
 	containerStats := &ContainerStats{
 		CgroupStats: &cgroupStats,
+		MemoryPeak:  memoryPeak(&cgroupStats),
 	}
 	return containerStats, nil
 }
@@ -1117,6 +1564,28 @@ func (k *kataAgent) connect() error {
 	return nil
 }
 
+// reconnect establishes the gRPC connection to the agent, refusing to do
+// so if the agent's persisted protocol version is older than this
+// runtime requires. A sandbox fetched from storage after a runtime
+// restart carries forward the protocol version negotiated the last time
+// the agent was started, so this catches a runtime upgrade that has
+// outgrown an already-running agent before any real call is attempted
+// against it.
+func (k *kataAgent) reconnect() error {
+	if k.state.ProtocolVersion != "" {
+		compatible, err := agentProtocolVersionCompatible(k.state.ProtocolVersion)
+		if err != nil {
+			return err
+		}
+
+		if !compatible {
+			return fmt.Errorf("cannot reconnect to agent: its persisted protocol version %s is older than the %s this runtime requires", k.state.ProtocolVersion, kataAgentProtocolVersion)
+		}
+	}
+
+	return k.connect()
+}
+
 func (k *kataAgent) disconnect() error {
 	k.Lock()
 	defer k.Unlock()
@@ -1135,8 +1604,39 @@ func (k *kataAgent) disconnect() error {
 	return nil
 }
 
-func (k *kataAgent) check() error {
-	_, err := k.sendReq(&grpc.CheckRequest{})
+// check pings the agent to verify it is still alive. Unlike sendReq, it
+// takes ctx from the caller: if ctx has no deadline of its own, one is
+// added via callTimeout so the probe still can't block indefinitely, but
+// a caller-supplied deadline (e.g. an already-cancelled context) takes
+// precedence and is honored on the underlying gRPC call.
+func (k *kataAgent) check(ctx context.Context) error {
+	if err := k.reconnect(); err != nil {
+		return err
+	}
+	if !k.keepConn {
+		defer k.disconnect()
+	}
+
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, k.callTimeout("grpc.CheckRequest"))
+		defer cancel()
+	}
+
+	handler := k.reqHandlers["grpc.CheckRequest"]
+	if handler == nil {
+		return errors.New("Invalid request type")
+	}
+
+	if !k.callMetrics {
+		_, err := handler(ctx, &grpc.CheckRequest{})
+		return err
+	}
+
+	start := time.Now()
+	_, err := handler(ctx, &grpc.CheckRequest{})
+	agentMetrics.observeCall("grpc.CheckRequest", time.Since(start))
+
 	return err
 }
 
@@ -1180,8 +1680,6 @@ type reqFunc func(context.Context, interface{}, ...golangGrpc.CallOption) (inter
 func (k *kataAgent) installReqFunc(c *kataclient.AgentClient) {
 	k.reqHandlers = make(map[string]reqFunc)
 	k.reqHandlers["grpc.CheckRequest"] = func(ctx context.Context, req interface{}, opts ...golangGrpc.CallOption) (interface{}, error) {
-		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
-		defer cancel()
 		return k.client.Check(ctx, req.(*grpc.CheckRequest), opts...)
 	}
 	k.reqHandlers["grpc.ExecProcessRequest"] = func(ctx context.Context, req interface{}, opts ...golangGrpc.CallOption) (interface{}, error) {
@@ -1243,8 +1741,28 @@ func (k *kataAgent) installReqFunc(c *kataclient.AgentClient) {
 	}
 }
 
+// shortAgentCalls are the agent RPCs expected to return quickly; they
+// are bound by shortCallTimeout. Every other call is bound by
+// longCallTimeout, which defaults to 0 (no timeout).
+var shortAgentCalls = map[string]bool{
+	"grpc.CheckRequest": true,
+}
+
+// callTimeout returns how long msgName is allowed to run for, or 0 for
+// no timeout.
+func (k *kataAgent) callTimeout(msgName string) time.Duration {
+	if shortAgentCalls[msgName] {
+		if k.shortCallTimeout == 0 {
+			return defaultAgentShortCallTimeout
+		}
+		return k.shortCallTimeout
+	}
+
+	return k.longCallTimeout
+}
+
 func (k *kataAgent) sendReq(request interface{}) (interface{}, error) {
-	if err := k.connect(); err != nil {
+	if err := k.reconnect(); err != nil {
 		return nil, err
 	}
 	if !k.keepConn {
@@ -1257,7 +1775,22 @@ func (k *kataAgent) sendReq(request interface{}) (interface{}, error) {
 		return nil, errors.New("Invalid request type")
 	}
 
-	return handler(context.Background(), request)
+	ctx := context.Background()
+	if timeout := k.callTimeout(msgName); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if !k.callMetrics {
+		return handler(ctx, request)
+	}
+
+	start := time.Now()
+	resp, err := handler(ctx, request)
+	agentMetrics.observeCall(msgName, time.Since(start))
+
+	return resp, err
 }
 
 // readStdout and readStderr are special that we cannot differentiate them with the request types...
@@ -12,8 +12,11 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"syscall"
 	"testing"
+	"time"
 
+	"github.com/gogo/protobuf/proto"
 	gpb "github.com/gogo/protobuf/types"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
@@ -116,6 +119,169 @@ func TestKataAgentDisconnect(t *testing.T) {
 	}
 }
 
+func TestKataAgentCheckCancelledContext(t *testing.T) {
+	proxy := mock.ProxyUnixMock{
+		ClientHandler: proxyHandlerDiscard,
+	}
+
+	sockDir, err := testGenerateKataProxySockDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sockDir)
+
+	testKataProxyURL := fmt.Sprintf(testKataProxyURLTempl, sockDir)
+	if err := proxy.Start(testKataProxyURL); err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Stop()
+
+	k := &kataAgent{
+		state: KataAgentState{
+			URL: testKataProxyURL,
+		},
+	}
+
+	if err := k.connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- k.check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		assert.Error(t, err, "expected check to fail on an already-cancelled context")
+	case <-time.After(defaultAgentShortCallTimeout):
+		t.Fatal("check did not honor the cancelled context and blocked instead")
+	}
+}
+
+func TestAgentProtocolVersionCompatible(t *testing.T) {
+	compatible, err := agentProtocolVersionCompatible(kataAgentProtocolVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compatible {
+		t.Fatal("expected the runtime's own required version to be compatible with itself")
+	}
+
+	compatible, err = agentProtocolVersionCompatible("99.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !compatible {
+		t.Fatal("expected a newer major version to be compatible")
+	}
+
+	compatible, err = agentProtocolVersionCompatible("0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compatible {
+		t.Fatal("expected an older version to be incompatible")
+	}
+}
+
+func TestAgentProtocolVersionCompatibleRejectsMalformedVersion(t *testing.T) {
+	if _, err := agentProtocolVersionCompatible("not-a-version"); err == nil {
+		t.Fatal("expected an error for a malformed version string")
+	}
+}
+
+func TestCheckExecCapabilitiesAllowsMergeEnvOnNewEnoughAgent(t *testing.T) {
+	k := &kataAgent{
+		state: KataAgentState{
+			ProtocolVersion: kataAgentEnvMergeProtocolVersion,
+		},
+	}
+
+	if err := k.checkExecCapabilities(Cmd{MergeEnv: true}); err != nil {
+		t.Fatalf("unexpected error for a new enough agent: %v", err)
+	}
+}
+
+func TestCheckExecCapabilitiesRejectsMergeEnvOnOldAgent(t *testing.T) {
+	k := &kataAgent{
+		state: KataAgentState{
+			ProtocolVersion: "1.0.0",
+		},
+	}
+
+	err := k.checkExecCapabilities(Cmd{MergeEnv: true})
+	if err == nil {
+		t.Fatal("expected a clear error for an agent too old to support Cmd.MergeEnv")
+	}
+}
+
+func TestCheckExecCapabilitiesIgnoresMergeEnvVersionOnAgentNotRequestingIt(t *testing.T) {
+	k := &kataAgent{
+		state: KataAgentState{
+			ProtocolVersion: "1.0.0",
+		},
+	}
+
+	if err := k.checkExecCapabilities(Cmd{}); err != nil {
+		t.Fatalf("unexpected error when MergeEnv is not requested: %v", err)
+	}
+}
+
+func TestKataAgentReconnectRefusesOlderPersistedVersion(t *testing.T) {
+	k := &kataAgent{
+		state: KataAgentState{
+			URL:             "unix://does-not-matter",
+			ProtocolVersion: "0.0.1",
+		},
+	}
+
+	err := k.reconnect()
+	if err == nil {
+		t.Fatal("expected reconnect to refuse a persisted version older than this runtime requires")
+	}
+
+	if k.client != nil {
+		t.Fatal("expected reconnect to fail before establishing a client connection")
+	}
+}
+
+func TestKataAgentReconnectAllowsCompatiblePersistedVersion(t *testing.T) {
+	proxy := mock.ProxyUnixMock{
+		ClientHandler: proxyHandlerDiscard,
+	}
+
+	sockDir, err := testGenerateKataProxySockDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sockDir)
+
+	testKataProxyURL := fmt.Sprintf(testKataProxyURLTempl, sockDir)
+	if err := proxy.Start(testKataProxyURL); err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Stop()
+
+	k := &kataAgent{
+		state: KataAgentState{
+			URL:             testKataProxyURL,
+			ProtocolVersion: kataAgentProtocolVersion,
+		},
+	}
+
+	if err := k.reconnect(); err != nil {
+		t.Fatal(err)
+	}
+
+	if k.client == nil {
+		t.Fatal("Kata agent client is not properly initialized")
+	}
+}
+
 type gRPCProxy struct{}
 
 var emptyResp = &gpb.Empty{}
@@ -221,11 +387,33 @@ func (p *gRPCProxy) ResumeContainer(ctx context.Context, req *pb.ResumeContainer
 	return emptyResp, nil
 }
 
+// slowGRPCProxy behaves like gRPCProxy except Check and CreateSandbox
+// sleep for a configurable delay before responding, to exercise
+// per-call-class timeouts.
+type slowGRPCProxy struct {
+	gRPCProxy
+	checkDelay         time.Duration
+	createSandboxDelay time.Duration
+}
+
+func (p *slowGRPCProxy) Check(ctx context.Context, req *pb.CheckRequest) (*pb.HealthCheckResponse, error) {
+	time.Sleep(p.checkDelay)
+	return &pb.HealthCheckResponse{}, nil
+}
+
+func (p *slowGRPCProxy) CreateSandbox(ctx context.Context, req *pb.CreateSandboxRequest) (*gpb.Empty, error) {
+	time.Sleep(p.createSandboxDelay)
+	return emptyResp, nil
+}
+
 func gRPCRegister(s *grpc.Server, srv interface{}) {
 	switch g := srv.(type) {
 	case *gRPCProxy:
 		pb.RegisterAgentServiceServer(s, g)
 		pb.RegisterHealthServer(s, g)
+	case *slowGRPCProxy:
+		pb.RegisterAgentServiceServer(s, g)
+		pb.RegisterHealthServer(s, g)
 	}
 }
 
@@ -242,6 +430,37 @@ var reqList = []interface{}{
 	&pb.StatsContainerRequest{},
 }
 
+func TestKataAgentCapabilitiesExpectedSet(t *testing.T) {
+	k := &kataAgent{}
+	caps := k.capabilities()
+
+	if !caps.isBlockDeviceSupported() {
+		t.Fatal()
+	}
+
+	if !caps.isCPUHotplugSupported() {
+		t.Fatal()
+	}
+
+	if !caps.isMemoryHotplugSupported() {
+		t.Fatal()
+	}
+
+	if !caps.isOnlineCPUMemSupported() {
+		t.Fatal()
+	}
+
+	// The kata agent does not claim block device hotplug or memory
+	// balloon support.
+	if caps.isBlockDeviceHotplugSupported() {
+		t.Fatal()
+	}
+
+	if caps.isMemoryBalloonSupported() {
+		t.Fatal()
+	}
+}
+
 func TestKataAgentSendReq(t *testing.T) {
 	impl := &gRPCProxy{}
 
@@ -275,6 +494,179 @@ func TestKataAgentSendReq(t *testing.T) {
 	}
 }
 
+func TestKataAgentSendReqRecordsCallMetrics(t *testing.T) {
+	impl := &gRPCProxy{}
+
+	proxy := mock.ProxyGRPCMock{
+		GRPCImplementer: impl,
+		GRPCRegister:    gRPCRegister,
+	}
+
+	sockDir, err := testGenerateKataProxySockDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sockDir)
+
+	testKataProxyURL := fmt.Sprintf(testKataProxyURLTempl, sockDir)
+	if err := proxy.Start(testKataProxyURL); err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Stop()
+
+	k := &kataAgent{
+		state: KataAgentState{
+			URL: testKataProxyURL,
+		},
+		callMetrics: true,
+	}
+
+	req := reqList[0]
+	msgName := proto.MessageName(req.(proto.Message))
+
+	before := agentMetrics.Count(msgName)
+
+	if _, err := k.sendReq(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := agentMetrics.Count(msgName); got != before+1 {
+		t.Fatalf("expected call histogram for %s to be observed once, got %d (was %d)", msgName, got, before)
+	}
+}
+
+func TestKataAgentPerCallClassTimeouts(t *testing.T) {
+	impl := &slowGRPCProxy{
+		checkDelay:         200 * time.Millisecond,
+		createSandboxDelay: 200 * time.Millisecond,
+	}
+
+	proxy := mock.ProxyGRPCMock{
+		GRPCImplementer: impl,
+		GRPCRegister:    gRPCRegister,
+	}
+
+	sockDir, err := testGenerateKataProxySockDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sockDir)
+
+	testKataProxyURL := fmt.Sprintf(testKataProxyURLTempl, sockDir)
+	if err := proxy.Start(testKataProxyURL); err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Stop()
+
+	k := &kataAgent{
+		state: KataAgentState{
+			URL: testKataProxyURL,
+		},
+		shortCallTimeout: 50 * time.Millisecond,
+		longCallTimeout:  time.Second,
+	}
+
+	if _, err := k.sendReq(&pb.CheckRequest{}); err == nil {
+		t.Fatal("expected a slow check() to time out at the short timeout")
+	}
+
+	if _, err := k.sendReq(&pb.CreateSandboxRequest{}); err != nil {
+		t.Fatalf("expected a slow createSandbox to complete within the long timeout, got %v", err)
+	}
+}
+
+// TestApplyMountSizeLimits verifies that a tmpfs Mount with a SizeLimit
+// gets a matching size= option appended to the OCI spec mount that is
+// ultimately sent to the agent, and that mounts without a SizeLimit, or
+// with no matching destination, are left untouched.
+func TestApplyMountSizeLimits(t *testing.T) {
+	k := &kataAgent{}
+
+	spec := &specs.Spec{
+		Mounts: []specs.Mount{
+			{Destination: "/tmp", Type: "tmpfs", Options: []string{"noexec"}},
+			{Destination: "/data", Type: "bind", Options: []string{"rbind"}},
+		},
+	}
+
+	mounts := []Mount{
+		{Destination: "/tmp", Type: "tmpfs", SizeLimit: 64 * 1024 * 1024},
+		{Destination: "/data", Type: "bind"},
+		{Destination: "/no-such-mount", Type: "tmpfs", SizeLimit: 1024},
+	}
+
+	k.applyMountSizeLimits(spec, mounts)
+
+	if !reflect.DeepEqual(spec.Mounts[0].Options, []string{"noexec", "size=67108864"}) {
+		t.Fatalf("unexpected options for /tmp: %v", spec.Mounts[0].Options)
+	}
+
+	if !reflect.DeepEqual(spec.Mounts[1].Options, []string{"rbind"}) {
+		t.Fatalf("expected /data options to be untouched, got %v", spec.Mounts[1].Options)
+	}
+}
+
+func TestKataAgentStartContainerMountsLazyRootfs(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	impl := &gRPCProxy{}
+
+	proxy := mock.ProxyGRPCMock{
+		GRPCImplementer: impl,
+		GRPCRegister:    gRPCRegister,
+	}
+
+	sockDir, err := testGenerateKataProxySockDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(sockDir)
+
+	testKataProxyURL := fmt.Sprintf(testKataProxyURLTempl, sockDir)
+	if err := proxy.Start(testKataProxyURL); err != nil {
+		t.Fatal(err)
+	}
+	defer proxy.Stop()
+
+	k := &kataAgent{
+		state: KataAgentState{
+			URL: testKataProxyURL,
+		},
+	}
+
+	sandboxID := "lazyRootfsSandbox"
+	rootfs := filepath.Join(testDir, "lazyRootfsSrc")
+	if err := os.MkdirAll(rootfs, mountPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(kataHostSharedDir, sandboxID, testContainerID, rootfsDir)
+	syscall.Unmount(dest, 0)
+	defer syscall.Unmount(dest, 0)
+	defer os.RemoveAll(filepath.Join(kataHostSharedDir, sandboxID))
+
+	sandbox := &Sandbox{id: sandboxID}
+	c := &Container{
+		id:     testContainerID,
+		rootFs: rootfs,
+		config: &ContainerConfig{LazyRootfs: true},
+	}
+
+	if _, err := os.Stat(dest); err == nil {
+		t.Fatal("expected the lazy rootfs not to be mounted before startContainer")
+	}
+
+	if err := k.startContainer(sandbox, c); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(dest); err != nil {
+		t.Fatalf("expected the lazy rootfs to be mounted by startContainer, got %v", err)
+	}
+}
+
 func TestGenerateInterfacesAndRoutes(t *testing.T) {
 
 	impl := &gRPCProxy{}
@@ -575,3 +967,57 @@ func TestHandlePidNamespace(t *testing.T) {
 	_, err = k.handlePidNamespace(g, sandbox)
 	assert.NotNil(err)
 }
+
+func TestGenerateVMSocketVSOCKPortOverride(t *testing.T) {
+	assert := assert.New(t)
+
+	k := &kataAgent{}
+	sandbox := &Sandbox{id: "testSandbox"}
+
+	err := k.generateVMSocket(sandbox, KataAgentConfig{
+		GRPCSocket:     "vsock://12:1025",
+		AgentVSOCKPort: 2048,
+	})
+	assert.NoError(err)
+
+	vsock, ok := k.vmSocket.(kataVSOCK)
+	assert.True(ok)
+	assert.Equal(uint32(12), vsock.contextID)
+	assert.Equal(uint32(2048), vsock.port)
+}
+
+func TestGenerateVMSocketVSOCKPortOverrideInvalid(t *testing.T) {
+	assert := assert.New(t)
+
+	k := &kataAgent{}
+	sandbox := &Sandbox{id: "testSandbox"}
+
+	err := k.generateVMSocket(sandbox, KataAgentConfig{
+		GRPCSocket:     "vsock://12:1025",
+		AgentVSOCKPort: 1,
+	})
+	assert.Error(err)
+}
+
+func TestKataAgentResizeContainerStorage(t *testing.T) {
+	assert := assert.New(t)
+
+	k := &kataAgent{}
+	sandbox := &Sandbox{}
+	container := Container{}
+
+	err := k.resizeContainerStorage(sandbox, container, 0)
+	assert.Error(err, "expected an error for a zero size")
+
+	err = k.resizeContainerStorage(sandbox, container, 1024*1024*1024)
+	assert.Error(err, "the kata agent protocol has no RPC to resize guest storage yet")
+}
+
+func TestValidateAgentVSOCKPort(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(validateAgentVSOCKPort(minAgentVSOCKPort))
+	assert.NoError(validateAgentVSOCKPort(maxAgentVSOCKPort))
+	assert.Error(validateAgentVSOCKPort(1))
+	assert.Error(validateAgentVSOCKPort(maxAgentVSOCKPort + 1))
+}
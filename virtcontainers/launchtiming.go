@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "time"
+
+// launchPhase identifies one stage of a sandbox launch whose duration is
+// tracked in a LaunchTiming breakdown.
+type launchPhase int
+
+const (
+	// launchPhaseAssetHash covers hashing the sandbox's kernel, image
+	// and firmware assets.
+	launchPhaseAssetHash launchPhase = iota
+	// launchPhaseVMMSpawn covers starting the VMM process and waiting
+	// for it to come up.
+	launchPhaseVMMSpawn
+	// launchPhaseAgentConnect covers establishing the proxy/agent
+	// connection once the VMM is up.
+	launchPhaseAgentConnect
+	// launchPhaseCreateSandbox covers the agent's CreateSandbox request.
+	launchPhaseCreateSandbox
+	// launchPhaseStartContainer covers starting a container in the
+	// sandbox.
+	launchPhaseStartContainer
+)
+
+// LaunchTiming breaks down how long each phase of launching a sandbox
+// took, from hashing its assets through starting its first container.
+// It is persisted alongside the hypervisor's state so that startup
+// performance can be inspected after the fact.
+type LaunchTiming struct {
+	AssetHash      time.Duration
+	VMMSpawn       time.Duration
+	AgentConnect   time.Duration
+	CreateSandbox  time.Duration
+	StartContainer time.Duration
+}
+
+// record adds d to the duration tracked for phase.
+func (lt *LaunchTiming) record(phase launchPhase, d time.Duration) {
+	switch phase {
+	case launchPhaseAssetHash:
+		lt.AssetHash += d
+	case launchPhaseVMMSpawn:
+		lt.VMMSpawn += d
+	case launchPhaseAgentConnect:
+		lt.AgentConnect += d
+	case launchPhaseCreateSandbox:
+		lt.CreateSandbox += d
+	case launchPhaseStartContainer:
+		lt.StartContainer += d
+	}
+}
+
+// Total returns the sum of all recorded phase durations.
+func (lt LaunchTiming) Total() time.Duration {
+	return lt.AssetHash + lt.VMMSpawn + lt.AgentConnect + lt.CreateSandbox + lt.StartContainer
+}
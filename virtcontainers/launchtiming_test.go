@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+	"time"
+)
+
+// TestLaunchTimingMockLaunch drives a mockHypervisor through a simulated
+// launch's phases, in the order a real launch records them, and verifies
+// each phase's duration is recorded and that they roughly sum to Total().
+func TestLaunchTimingMockLaunch(t *testing.T) {
+	h := &mockHypervisor{}
+
+	phases := []struct {
+		phase launchPhase
+		d     time.Duration
+	}{
+		{launchPhaseAssetHash, 10 * time.Millisecond},
+		{launchPhaseVMMSpawn, 200 * time.Millisecond},
+		{launchPhaseAgentConnect, 30 * time.Millisecond},
+		{launchPhaseCreateSandbox, 15 * time.Millisecond},
+		{launchPhaseStartContainer, 25 * time.Millisecond},
+	}
+
+	var wantTotal time.Duration
+	for _, p := range phases {
+		h.recordLaunchPhase(p.phase, p.d)
+		wantTotal += p.d
+	}
+
+	timing := h.getLaunchTiming()
+
+	if timing.AssetHash != 10*time.Millisecond {
+		t.Fatalf("AssetHash = %v, want %v", timing.AssetHash, 10*time.Millisecond)
+	}
+	if timing.VMMSpawn != 200*time.Millisecond {
+		t.Fatalf("VMMSpawn = %v, want %v", timing.VMMSpawn, 200*time.Millisecond)
+	}
+	if timing.AgentConnect != 30*time.Millisecond {
+		t.Fatalf("AgentConnect = %v, want %v", timing.AgentConnect, 30*time.Millisecond)
+	}
+	if timing.CreateSandbox != 15*time.Millisecond {
+		t.Fatalf("CreateSandbox = %v, want %v", timing.CreateSandbox, 15*time.Millisecond)
+	}
+	if timing.StartContainer != 25*time.Millisecond {
+		t.Fatalf("StartContainer = %v, want %v", timing.StartContainer, 25*time.Millisecond)
+	}
+
+	if timing.Total() != wantTotal {
+		t.Fatalf("Total() = %v, want %v", timing.Total(), wantTotal)
+	}
+}
+
+// TestLaunchTimingRecordAccumulates verifies that recording the same
+// phase more than once (as happens when StartContainer runs for each
+// container in a sandbox) accumulates rather than overwrites.
+func TestLaunchTimingRecordAccumulates(t *testing.T) {
+	var lt LaunchTiming
+
+	lt.record(launchPhaseStartContainer, 10*time.Millisecond)
+	lt.record(launchPhaseStartContainer, 5*time.Millisecond)
+
+	if lt.StartContainer != 15*time.Millisecond {
+		t.Fatalf("StartContainer = %v, want %v", lt.StartContainer, 15*time.Millisecond)
+	}
+}
+
+// TestCreateSandboxRecordsAssetHashTiming verifies that a real (mock)
+// sandbox creation records a non-zero asset-hash duration.
+func TestCreateSandboxRecordsAssetHashTiming(t *testing.T) {
+	defer cleanUp()
+
+	sandbox, err := testCreateSandbox(t, testSandboxID, MockHypervisor, newHypervisorConfig(nil, nil), NoopAgentType, NoopNetworkModel, NetworkConfig{}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock, ok := sandbox.hypervisor.(*mockHypervisor)
+	if !ok {
+		t.Fatalf("expected a *mockHypervisor, got %T", sandbox.hypervisor)
+	}
+
+	if mock.launchTiming.AssetHash == 0 {
+		t.Fatal("expected createSandbox to have recorded a non-zero asset-hash duration")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LoadInfo reports the guest's uptime and load averages, as read from
+// /proc/uptime and /proc/loadavg.
+type LoadInfo struct {
+	UptimeSeconds float64
+	Load1         float64
+	Load5         float64
+	Load15        float64
+}
+
+// parseUptime parses the contents of a /proc/uptime file, returning the
+// first field (seconds since boot). The second field (idle time summed
+// across CPUs) is not tracked by LoadInfo.
+func parseUptime(content string) (float64, error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("malformed uptime content: %q", content)
+	}
+
+	uptime, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse uptime value %q: %v", fields[0], err)
+	}
+
+	return uptime, nil
+}
+
+// parseLoadAvg parses the contents of a /proc/loadavg file, returning the
+// 1/5/15-minute load averages. The remaining fields (runnable/total
+// process counts and the most recently created PID) are not tracked by
+// LoadInfo.
+func parseLoadAvg(content string) (load1, load5, load15 float64, err error) {
+	fields := strings.Fields(content)
+	if len(fields) < 3 {
+		return 0, 0, 0, fmt.Errorf("malformed loadavg content: %q", content)
+	}
+
+	if load1, err = strconv.ParseFloat(fields[0], 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("could not parse 1-minute load average %q: %v", fields[0], err)
+	}
+
+	if load5, err = strconv.ParseFloat(fields[1], 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("could not parse 5-minute load average %q: %v", fields[1], err)
+	}
+
+	if load15, err = strconv.ParseFloat(fields[2], 64); err != nil {
+		return 0, 0, 0, fmt.Errorf("could not parse 15-minute load average %q: %v", fields[2], err)
+	}
+
+	return load1, load5, load15, nil
+}
+
+// parseLoadInfo combines the contents of /proc/uptime and /proc/loadavg
+// into a LoadInfo.
+func parseLoadInfo(uptimeContent, loadavgContent string) (*LoadInfo, error) {
+	uptime, err := parseUptime(uptimeContent)
+	if err != nil {
+		return nil, err
+	}
+
+	load1, load5, load15, err := parseLoadAvg(loadavgContent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LoadInfo{
+		UptimeSeconds: uptime,
+		Load1:         load1,
+		Load5:         load5,
+		Load15:        load15,
+	}, nil
+}
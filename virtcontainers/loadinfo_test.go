@@ -0,0 +1,48 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+)
+
+func TestParseLoadInfo(t *testing.T) {
+	uptimeContent := "12345.67 54321.89\n"
+	loadavgContent := "0.52 0.41 0.33 2/456 12345\n"
+
+	info, err := parseLoadInfo(uptimeContent, loadavgContent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.UptimeSeconds != 12345.67 {
+		t.Fatalf("expected UptimeSeconds 12345.67, got %v", info.UptimeSeconds)
+	}
+
+	if info.Load1 != 0.52 {
+		t.Fatalf("expected Load1 0.52, got %v", info.Load1)
+	}
+
+	if info.Load5 != 0.41 {
+		t.Fatalf("expected Load5 0.41, got %v", info.Load5)
+	}
+
+	if info.Load15 != 0.33 {
+		t.Fatalf("expected Load15 0.33, got %v", info.Load15)
+	}
+}
+
+func TestParseLoadInfoMalformedUptime(t *testing.T) {
+	if _, err := parseLoadInfo("", "0.52 0.41 0.33 2/456 12345\n"); err == nil {
+		t.Fatal("expected an error for empty uptime content")
+	}
+}
+
+func TestParseLoadInfoMalformedLoadAvg(t *testing.T) {
+	if _, err := parseLoadInfo("12345.67 54321.89\n", "0.52 0.41\n"); err == nil {
+		t.Fatal("expected an error for a loadavg line missing fields")
+	}
+}
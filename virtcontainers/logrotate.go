@@ -0,0 +1,142 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"os"
+)
+
+// defaultMaxLogSize is the size, in bytes, at which a host-side log file
+// opened via openRotatingLogWriter is rotated, if HypervisorConfig.MaxLogSize
+// is left unset.
+const defaultMaxLogSize = 10 * 1024 * 1024
+
+// defaultMaxLogFiles is how many rotated copies of a log file are kept
+// alongside the active one, if HypervisorConfig.MaxLogFiles is left
+// unset.
+const defaultMaxLogFiles = 3
+
+// rotatingLogWriter appends to a host-side log file, rotating it once it
+// reaches maxSize: the active file is renamed to path.1 (path.1 becomes
+// path.2, and so on), whatever was at path.maxFiles is discarded, and
+// writing resumes on a fresh, empty path.
+type rotatingLogWriter struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+	mode     os.FileMode
+
+	file *os.File
+	size int64
+}
+
+// openRotatingLogWriter opens path for appending, creating it if
+// necessary, rotating it first if it already reaches maxSize. A maxSize
+// or maxFiles of zero falls back to defaultMaxLogSize /
+// defaultMaxLogFiles.
+func openRotatingLogWriter(path string, maxSize int64, maxFiles int, mode os.FileMode) (*rotatingLogWriter, error) {
+	if maxSize == 0 {
+		maxSize = defaultMaxLogSize
+	}
+
+	if maxFiles == 0 {
+		maxFiles = defaultMaxLogFiles
+	}
+
+	w := &rotatingLogWriter{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+		mode:     mode,
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() >= maxSize {
+		if err := w.rotate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// open (re)opens w.path for appending and records its current size.
+func (w *rotatingLogWriter) open() error {
+	f, err := os.OpenFile(w.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, w.mode)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.file = f
+	w.size = info.Size()
+
+	return nil
+}
+
+// Write appends p to the log file, rotating first if p would push the
+// file past maxSize.
+func (w *rotatingLogWriter) Write(p []byte) (int, error) {
+	if w.size > 0 && w.size+int64(len(p)) > w.maxSize {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+
+		if err := w.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Close closes the underlying file.
+func (w *rotatingLogWriter) Close() error {
+	return w.file.Close()
+}
+
+// rotate shifts path.1..path.maxFiles-1 up by one (dropping whatever
+// was at path.maxFiles) and renames path itself to path.1, closing w's
+// current file handle first since it cannot safely be renamed out from
+// under an open *os.File on some filesystems.
+func (w *rotatingLogWriter) rotate() error {
+	if w.file != nil {
+		w.file.Close()
+		w.file = nil
+	}
+
+	oldest := fmt.Sprintf("%s.%d", w.path, w.maxFiles)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := w.maxFiles - 1; i >= 1; i-- {
+		src := fmt.Sprintf("%s.%d", w.path, i)
+		dst := fmt.Sprintf("%s.%d", w.path, i+1)
+
+		if err := os.Rename(src, dst); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	if err := os.Rename(w.path, w.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingLogWriterRotatesPastLimit(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	path := filepath.Join(tmpdir, "vmm.log")
+
+	const (
+		maxSize  = 100
+		maxFiles = 2
+		chunk    = "0123456789" // 10 bytes
+	)
+
+	w, err := openRotatingLogWriter(path, maxSize, maxFiles, 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Write enough chunks to force several rotations: 30 chunks of 10
+	// bytes each is 300 bytes, three times the limit.
+	for i := 0; i < 30; i++ {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	entries, err := ioutil.ReadDir(tmpdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The active file plus at most maxFiles rotated copies: no more.
+	if len(entries) > maxFiles+1 {
+		t.Fatalf("expected at most %d files, found %d: %v", maxFiles+1, len(entries), entries)
+	}
+
+	for _, entry := range entries {
+		if entry.Size() > maxSize {
+			t.Fatalf("file %v exceeds maxSize %d: %d bytes", entry.Name(), maxSize, entry.Size())
+		}
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected the active log file %v to still exist: %v", path, err)
+	}
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expected a rotated file %v.1 to exist: %v", path, err)
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected %v.3 not to exist (maxFiles=%d), got err=%v", path, maxFiles, err)
+	}
+}
+
+func TestOpenRotatingLogWriterRotatesAlreadyOversizedFile(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	path := filepath.Join(tmpdir, "console.log")
+
+	if err := ioutil.WriteFile(path, make([]byte, 200), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := openRotatingLogWriter(path, 100, 2, 0640)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Fatalf("expected the oversized file to have been rotated to %v.1: %v", path, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size() != 0 {
+		t.Fatalf("expected a fresh, empty active log file, got %d bytes", info.Size())
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndFetchSandboxManifestRoundTrips(t *testing.T) {
+	assert := assert.New(t)
+
+	sandboxID := "manifest-sandbox"
+
+	assert.NoError(recordSandboxManifest(sandboxID, "filesystem"))
+
+	manifest, err := fetchSandboxManifest(sandboxID)
+	assert.NoError(err)
+	assert.Equal("filesystem", manifest.Backend)
+	assert.Equal(currentManifestSchemaVersion, manifest.SchemaVersion)
+}
+
+func TestFetchSandboxManifestDefaultsWhenNoneRecorded(t *testing.T) {
+	assert := assert.New(t)
+
+	manifest, err := fetchSandboxManifest("no-such-sandbox")
+	assert.NoError(err)
+	assert.Equal(defaultStorageBackend, manifest.Backend)
+	assert.Equal(0, manifest.SchemaVersion)
+}
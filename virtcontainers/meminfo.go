@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MemInfo reports the guest's view of its own memory, as read from
+// /proc/meminfo. All fields are expressed in kB, matching the unit used
+// by /proc/meminfo itself.
+type MemInfo struct {
+	TotalKB     uint64
+	FreeKB      uint64
+	AvailableKB uint64
+	CachedKB    uint64
+	SwapTotalKB uint64
+	SwapFreeKB  uint64
+}
+
+// parseMemInfo parses the contents of a /proc/meminfo file into a MemInfo.
+// Only the fields MemInfo tracks are extracted; unrecognized lines are
+// ignored so this keeps working if the guest kernel adds new fields.
+func parseMemInfo(content string) (*MemInfo, error) {
+	info := &MemInfo{}
+
+	fields := map[string]*uint64{
+		"MemTotal":     &info.TotalKB,
+		"MemFree":      &info.FreeKB,
+		"MemAvailable": &info.AvailableKB,
+		"Cached":       &info.CachedKB,
+		"SwapTotal":    &info.SwapTotalKB,
+		"SwapFree":     &info.SwapFreeKB,
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		dest, ok := fields[strings.TrimSpace(parts[0])]
+		if !ok {
+			continue
+		}
+
+		valueFields := strings.Fields(parts[1])
+		if len(valueFields) == 0 {
+			return nil, fmt.Errorf("malformed meminfo line: %q", line)
+		}
+
+		value, err := strconv.ParseUint(valueFields[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse meminfo value in line %q: %v", line, err)
+		}
+
+		// /proc/meminfo values are already in kB; a unit suffix other
+		// than "kB" would mean our assumption about the format broke.
+		if len(valueFields) > 1 && valueFields[1] != "kB" {
+			return nil, fmt.Errorf("unexpected meminfo unit %q in line %q", valueFields[1], line)
+		}
+
+		*dest = value
+	}
+
+	return info, nil
+}
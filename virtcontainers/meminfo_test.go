@@ -0,0 +1,74 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+)
+
+func TestParseMemInfo(t *testing.T) {
+	content := `MemTotal:        8167872 kB
+MemFree:         1234567 kB
+MemAvailable:    2345678 kB
+Cached:           345678 kB
+SwapTotal:       2097148 kB
+SwapFree:        2097148 kB
+Shmem:              1024 kB
+`
+
+	info, err := parseMemInfo(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.TotalKB != 8167872 {
+		t.Fatalf("expected TotalKB 8167872, got %d", info.TotalKB)
+	}
+
+	if info.FreeKB != 1234567 {
+		t.Fatalf("expected FreeKB 1234567, got %d", info.FreeKB)
+	}
+
+	if info.AvailableKB != 2345678 {
+		t.Fatalf("expected AvailableKB 2345678, got %d", info.AvailableKB)
+	}
+
+	if info.CachedKB != 345678 {
+		t.Fatalf("expected CachedKB 345678, got %d", info.CachedKB)
+	}
+
+	if info.SwapTotalKB != 2097148 {
+		t.Fatalf("expected SwapTotalKB 2097148, got %d", info.SwapTotalKB)
+	}
+
+	if info.SwapFreeKB != 2097148 {
+		t.Fatalf("expected SwapFreeKB 2097148, got %d", info.SwapFreeKB)
+	}
+}
+
+func TestParseMemInfoUnexpectedUnit(t *testing.T) {
+	content := `MemTotal:        8167872 MB
+`
+
+	if _, err := parseMemInfo(content); err == nil {
+		t.Fatal("expected an error for a non-kB meminfo unit")
+	}
+}
+
+func TestParseMemInfoIgnoresUnknownFields(t *testing.T) {
+	content := `SomeUnknownField: 123 kB
+MemTotal: 4096 kB
+`
+
+	info, err := parseMemInfo(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.TotalKB != 4096 {
+		t.Fatalf("expected TotalKB 4096, got %d", info.TotalKB)
+	}
+}
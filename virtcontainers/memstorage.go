@@ -0,0 +1,553 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/device/drivers"
+)
+
+func init() {
+	RegisterStorageBackend("mem", func(lenientDeviceRestore bool) resourceStorage {
+		return newMemStorage(lenientDeviceRestore)
+	})
+}
+
+// memStorage is a resourceStorage interface implementation that keeps
+// every sandbox and container resource JSON-marshalled in an in-memory
+// map instead of on disk, for short-lived sandboxes (and the bulk of our
+// own unit tests) that never need to survive a host reboot. Resources
+// are still round-tripped through json.Marshal/Unmarshal, exactly as
+// filesystem and boltStorage do, so callers exercising device
+// persistence see the same TypedDevice behavior they would against a
+// real backend.
+//
+// Locking (lockSandbox/unlockSandbox) and sandbox enumeration
+// (ListSandbox) remain filesystem-based regardless of which
+// resourceStorage backend a sandbox uses, since both only need a sandbox
+// ID, not any backend-specific resource.
+type memStorage struct {
+	// LenientDeviceRestore mirrors filesystem.LenientDeviceRestore: it
+	// makes fetchContainerDevices preserve devices of an unrecognized
+	// type as opaque TypedDevice entries instead of failing the restore.
+	LenientDeviceRestore bool
+
+	mu        sync.Mutex
+	resources map[memResourceKey][]byte
+}
+
+// memResourceKey identifies a single stored resource: a sandbox resource
+// when containerID is empty, a container resource otherwise.
+type memResourceKey struct {
+	sandboxID   string
+	containerID string
+	resource    sandboxResource
+}
+
+func newMemStorage(lenientDeviceRestore bool) *memStorage {
+	return &memStorage{
+		LenientDeviceRestore: lenientDeviceRestore,
+		resources:            make(map[memResourceKey][]byte),
+	}
+}
+
+// Logger returns a logrus logger appropriate for logging memStorage messages
+func (m *memStorage) Logger() *logrus.Entry {
+	return virtLog.WithField("subsystem", "memstorage")
+}
+
+// errMemResourceNotFound is returned when a requested resource has no
+// entry in the map yet, mirroring the error filesystem gets for free,
+// via ioutil.ReadFile, from a missing file.
+var errMemResourceNotFound = fmt.Errorf("resource not found")
+
+// containerURI and sandboxURI exist to satisfy resourceStorage. memStorage
+// has no filesystem path for a resource, so they return a pseudo-URI
+// identifying the map key a resource lives under instead; nothing
+// currently depends on these being real paths for memStorage, since
+// locking always goes through filesystem directly.
+func (m *memStorage) containerURI(sandboxID, containerID string, resource sandboxResource) (string, string, error) {
+	if sandboxID == "" {
+		return "", "", errNeedSandboxID
+	}
+
+	if containerID == "" {
+		return "", "", errNeedContainerID
+	}
+
+	dir := fmt.Sprintf("mem://%s/%s", sandboxID, containerID)
+
+	return fmt.Sprintf("%s/%d", dir, resource), dir, nil
+}
+
+func (m *memStorage) sandboxURI(sandboxID string, resource sandboxResource) (string, string, error) {
+	if sandboxID == "" {
+		return "", "", errNeedSandboxID
+	}
+
+	dir := fmt.Sprintf("mem://%s", sandboxID)
+
+	return fmt.Sprintf("%s/%d", dir, resource), dir, nil
+}
+
+func (m *memStorage) createAllResources(sandbox *Sandbox) error {
+	return nil
+}
+
+func (m *memStorage) set(key memResourceKey, data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.resources[key] = data
+}
+
+func (m *memStorage) get(key memResourceKey) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	data, ok := m.resources[key]
+	return data, ok
+}
+
+func (m *memStorage) deleteSandbox(sandboxID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.resources {
+		if key.sandboxID == sandboxID {
+			delete(m.resources, key)
+		}
+	}
+}
+
+func (m *memStorage) deleteContainer(sandboxID, containerID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.resources {
+		if key.sandboxID == sandboxID && key.containerID == containerID {
+			delete(m.resources, key)
+		}
+	}
+}
+
+// memMarshalDevices is memStorage's analog of filesystem.storeDeviceFile:
+// devices are marshalled via TypedDevice so each one's concrete type
+// survives the round-trip back through memUnmarshalDevices.
+func memMarshalDevices(devices []api.Device) ([]byte, error) {
+	var typedDevices []TypedDevice
+	for _, d := range devices {
+		// An FdDevice only carries a host file descriptor, which is not
+		// meaningful once this process exits, so persisting it would
+		// silently produce an entry that can never be restored from.
+		if _, ok := d.(*drivers.FdDevice); ok {
+			return nil, fmt.Errorf("cannot persist fd device %v: file descriptors do not survive a restart, it must be re-passed", d)
+		}
+
+		tempJSON, _ := json.Marshal(d)
+		typedDevices = append(typedDevices, TypedDevice{
+			Type: string(d.DeviceType()),
+			Data: tempJSON,
+		})
+	}
+
+	jsonOut, err := json.Marshal(typedDevices)
+	if err != nil {
+		return nil, fmt.Errorf("Could not marshal devices: %s", err)
+	}
+
+	return jsonOut, nil
+}
+
+// memUnmarshalDevices is memStorage's analog of filesystem.fetchDeviceFile.
+func memUnmarshalDevices(raw []byte, lenientDeviceRestore bool) ([]api.Device, error) {
+	var typedDevices []TypedDevice
+	if err := json.Unmarshal(raw, &typedDevices); err != nil {
+		return nil, err
+	}
+
+	var devices []api.Device
+	for _, d := range typedDevices {
+		switch d.Type {
+		case string(config.DeviceVFIO):
+			var device drivers.VFIODevice
+			if err := json.Unmarshal(d.Data, &device); err != nil {
+				return nil, err
+			}
+			devices = append(devices, &device)
+
+		case string(config.DeviceBlock):
+			var device drivers.BlockDevice
+			if err := json.Unmarshal(d.Data, &device); err != nil {
+				return nil, err
+			}
+			devices = append(devices, &device)
+
+		case string(config.DeviceGeneric):
+			var device drivers.GenericDevice
+			if err := json.Unmarshal(d.Data, &device); err != nil {
+				return nil, err
+			}
+			devices = append(devices, &device)
+
+		default:
+			if !lenientDeviceRestore {
+				return nil, fmt.Errorf("Unknown device type, could not unmarshal")
+			}
+
+			devices = append(devices, &opaqueDevice{
+				devType: config.DeviceType(d.Type),
+				data:    d.Data,
+			})
+		}
+	}
+
+	return devices, nil
+}
+
+func (m *memStorage) commonResourceChecks(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if resourceNeedsContainerID(sandboxSpecific, resource) && containerID == "" {
+		return errNeedContainerID
+	}
+
+	switch resource {
+	case configFileType, stateFileType, networkFileType, hypervisorFileType, agentFileType,
+		processFileType, lockFileType, mountsFileType, devicesFileType, detachedProcessesFileType,
+		hooksFileType:
+		return nil
+	default:
+		return errInvalidResource
+	}
+}
+
+func (m *memStorage) storeResource(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) (err error) {
+	defer func() { storageMetrics.observe(storageOpStore, resource, err) }()
+
+	if err = m.commonResourceChecks(sandboxSpecific, sandboxID, containerID, resource); err != nil {
+		return err
+	}
+
+	key := memResourceKey{sandboxID: sandboxID, resource: resource}
+	if !sandboxSpecific {
+		key.containerID = containerID
+	}
+
+	if devices, ok := data.([]api.Device); ok {
+		raw, err := memMarshalDevices(devices)
+		if err != nil {
+			return wrapStorageErr(storageOpStore, resource, sandboxID, containerID, err)
+		}
+
+		m.set(key, raw)
+		return nil
+	}
+
+	jsonOut, err := json.Marshal(data)
+	if err != nil {
+		return wrapStorageErr(storageOpStore, resource, sandboxID, containerID, fmt.Errorf("Could not marshall data: %s", err))
+	}
+
+	m.set(key, jsonOut)
+	return nil
+}
+
+func (m *memStorage) fetchResource(sandboxSpecific bool, sandboxID, containerID string, resource sandboxResource, data interface{}) (err error) {
+	defer func() { storageMetrics.observe(storageOpFetch, resource, err) }()
+
+	if err = m.commonResourceChecks(sandboxSpecific, sandboxID, containerID, resource); err != nil {
+		return err
+	}
+
+	key := memResourceKey{sandboxID: sandboxID, resource: resource}
+	if !sandboxSpecific {
+		key.containerID = containerID
+	}
+
+	raw, ok := m.get(key)
+	if !ok {
+		return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, errMemResourceNotFound)
+	}
+
+	if resource == devicesFileType {
+		devices, ok := data.(*[]api.Device)
+		if !ok {
+			return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, fmt.Errorf("Could not cast %v into *[]Device type", data))
+		}
+
+		fetched, err := memUnmarshalDevices(raw, m.LenientDeviceRestore)
+		if err != nil {
+			return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, err)
+		}
+
+		*devices = fetched
+		return nil
+	}
+
+	return wrapStorageErr(storageOpFetch, resource, sandboxID, containerID, json.Unmarshal(raw, data))
+}
+
+func (m *memStorage) storeSandboxResource(sandboxID string, resource sandboxResource, data interface{}) error {
+	return m.storeResource(true, sandboxID, "", resource, data)
+}
+
+func (m *memStorage) deleteSandboxResources(sandboxID string, resources []sandboxResource) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	m.deleteSandbox(sandboxID)
+
+	if resources == nil {
+		resources = []sandboxResource{configFileType, stateFileType}
+	}
+
+	for _, resource := range resources {
+		storageMetrics.observe(storageOpDelete, resource, nil)
+	}
+
+	return nil
+}
+
+// listSandboxes returns the IDs of every sandbox memStorage currently
+// holds a state resource for. Unlike filesystem, memStorage has no
+// on-disk directory tree to scan, so this is a map lookup rather than a
+// filesystem walk.
+func (m *memStorage) listSandboxes() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var sandboxIDs []string
+	for key := range m.resources {
+		if key.containerID == "" && key.resource == stateFileType {
+			sandboxIDs = append(sandboxIDs, key.sandboxID)
+		}
+	}
+
+	return sandboxIDs, nil
+}
+
+func (m *memStorage) fetchSandboxConfig(sandboxID string) (SandboxConfig, error) {
+	var config SandboxConfig
+	if err := m.fetchResource(true, sandboxID, "", configFileType, &config); err != nil {
+		return SandboxConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (m *memStorage) fetchSandboxState(sandboxID string) (State, error) {
+	var state State
+	if err := m.fetchResource(true, sandboxID, "", stateFileType, &state); err != nil {
+		return State{}, err
+	}
+
+	return state, nil
+}
+
+func (m *memStorage) fetchSandboxNetwork(sandboxID string) (NetworkNamespace, error) {
+	var networkNS NetworkNamespace
+	if err := m.fetchResource(true, sandboxID, "", networkFileType, &networkNS); err != nil {
+		return NetworkNamespace{}, err
+	}
+
+	return networkNS, nil
+}
+
+func (m *memStorage) storeSandboxNetwork(sandboxID string, networkNS NetworkNamespace) error {
+	return m.storeSandboxResource(sandboxID, networkFileType, networkNS)
+}
+
+// fetchSandboxHooks returns the hook executions recorded for sandboxID so
+// far, or an empty slice if none have been recorded yet.
+func (m *memStorage) fetchSandboxHooks(sandboxID string) ([]HookExecution, error) {
+	var executions []HookExecution
+
+	if err := m.fetchResource(true, sandboxID, "", hooksFileType, &executions); err != nil {
+		if Is(err, errMemResourceNotFound) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return executions, nil
+}
+
+func (m *memStorage) fetchHypervisorState(sandboxID string, state interface{}) error {
+	return m.fetchResource(true, sandboxID, "", hypervisorFileType, state)
+}
+
+func (m *memStorage) storeHypervisorState(sandboxID string, state interface{}) error {
+	return m.storeSandboxResource(sandboxID, hypervisorFileType, state)
+}
+
+func (m *memStorage) fetchAgentState(sandboxID string, state interface{}) error {
+	return m.fetchResource(true, sandboxID, "", agentFileType, state)
+}
+
+func (m *memStorage) storeAgentState(sandboxID string, state interface{}) error {
+	return m.storeSandboxResource(sandboxID, agentFileType, state)
+}
+
+func (m *memStorage) storeContainerResource(sandboxID, containerID string, resource sandboxResource, data interface{}) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if containerID == "" {
+		return errNeedContainerID
+	}
+
+	return m.storeResource(false, sandboxID, containerID, resource, data)
+}
+
+func (m *memStorage) deleteContainerResources(sandboxID, containerID string, resources []sandboxResource) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	if containerID == "" {
+		return errNeedContainerID
+	}
+
+	m.deleteContainer(sandboxID, containerID)
+
+	if resources == nil {
+		resources = []sandboxResource{configFileType, stateFileType, processFileType, mountsFileType, devicesFileType}
+	}
+
+	for _, resource := range resources {
+		storageMetrics.observe(storageOpDelete, resource, nil)
+	}
+
+	return nil
+}
+
+// fetchSandboxContainers returns the IDs of every container memStorage
+// currently holds a config resource for under sandboxID.
+func (m *memStorage) fetchSandboxContainers(sandboxID string) ([]string, error) {
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var containerIDs []string
+	for key := range m.resources {
+		if key.sandboxID == sandboxID && key.containerID != "" && key.resource == configFileType {
+			containerIDs = append(containerIDs, key.containerID)
+		}
+	}
+
+	return containerIDs, nil
+}
+
+func (m *memStorage) fetchContainerConfig(sandboxID, containerID string) (ContainerConfig, error) {
+	var config ContainerConfig
+	if err := m.fetchResource(false, sandboxID, containerID, configFileType, &config); err != nil {
+		return ContainerConfig{}, err
+	}
+
+	return config, nil
+}
+
+func (m *memStorage) fetchContainerState(sandboxID, containerID string) (State, error) {
+	var state State
+	if err := m.fetchResource(false, sandboxID, containerID, stateFileType, &state); err != nil {
+		return State{}, err
+	}
+
+	return state, nil
+}
+
+// fetchContainerStateField returns only the "state" field of a
+// container's persisted State, mirroring filesystem's optimization of
+// avoiding a full unmarshal for callers that just need to know whether a
+// container is running.
+func (m *memStorage) fetchContainerStateField(sandboxID, containerID string) (stateString, error) {
+	if err := m.commonResourceChecks(false, sandboxID, containerID, stateFileType); err != nil {
+		return "", err
+	}
+
+	raw, ok := m.get(memResourceKey{sandboxID: sandboxID, containerID: containerID, resource: stateFileType})
+	if !ok {
+		return "", errMemResourceNotFound
+	}
+
+	var partial struct {
+		State stateString `json:"state"`
+	}
+
+	if err := json.Unmarshal(raw, &partial); err != nil {
+		return "", err
+	}
+
+	return partial.State, nil
+}
+
+func (m *memStorage) fetchContainerProcess(sandboxID, containerID string) (Process, error) {
+	var process Process
+	if err := m.fetchResource(false, sandboxID, containerID, processFileType, &process); err != nil {
+		return Process{}, err
+	}
+
+	return process, nil
+}
+
+func (m *memStorage) storeContainerProcess(sandboxID, containerID string, process Process) error {
+	return m.storeContainerResource(sandboxID, containerID, processFileType, process)
+}
+
+func (m *memStorage) fetchContainerMounts(sandboxID, containerID string) ([]Mount, error) {
+	var mounts []Mount
+	if err := m.fetchResource(false, sandboxID, containerID, mountsFileType, &mounts); err != nil {
+		return []Mount{}, err
+	}
+
+	return mounts, nil
+}
+
+func (m *memStorage) storeContainerMounts(sandboxID, containerID string, mounts []Mount) error {
+	return m.storeContainerResource(sandboxID, containerID, mountsFileType, mounts)
+}
+
+func (m *memStorage) fetchContainerDevices(sandboxID, containerID string) ([]api.Device, error) {
+	var devices []api.Device
+	if err := m.fetchResource(false, sandboxID, containerID, devicesFileType, &devices); err != nil {
+		return []api.Device{}, err
+	}
+
+	return devices, nil
+}
+
+func (m *memStorage) storeContainerDevices(sandboxID, containerID string, devices []api.Device) error {
+	return m.storeContainerResource(sandboxID, containerID, devicesFileType, devices)
+}
+
+func (m *memStorage) fetchContainerDetachedProcesses(sandboxID, containerID string) ([]Process, error) {
+	var processes []Process
+	if err := m.fetchResource(false, sandboxID, containerID, detachedProcessesFileType, &processes); err != nil {
+		return []Process{}, err
+	}
+
+	return processes, nil
+}
+
+func (m *memStorage) storeContainerDetachedProcesses(sandboxID, containerID string, processes []Process) error {
+	return m.storeContainerResource(sandboxID, containerID, detachedProcessesFileType, processes)
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/device/drivers"
+)
+
+func TestMemStorageConformance(t *testing.T) {
+	RunStorageConformance(t, func() resourceStorage {
+		return newMemStorage(false)
+	})
+}
+
+// TestMemStorageDeviceTypePreservedAfterRoundTrip asserts that storing a
+// mix of VFIO, Block, and Generic devices through memStorage and fetching
+// them back preserves each device's concrete type and fields, exactly as
+// filesystem and boltStorage do via their own TypedDevice marshalling.
+func TestMemStorageDeviceTypePreservedAfterRoundTrip(t *testing.T) {
+	storage := newMemStorage(false)
+	sandboxID := "mem-device-roundtrip"
+	containerID := testContainerID
+
+	devices := []api.Device{
+		drivers.NewVFIODevice(config.DeviceInfo{ID: "vfio-device"}),
+		drivers.NewBlockDevice(config.DeviceInfo{ID: "block-device"}),
+		drivers.NewGenericDevice(config.DeviceInfo{ID: "generic-device"}),
+	}
+
+	if err := storage.storeContainerDevices(sandboxID, containerID, devices); err != nil {
+		t.Fatalf("storeContainerDevices failed: %v", err)
+	}
+
+	fetched, err := storage.fetchContainerDevices(sandboxID, containerID)
+	if err != nil {
+		t.Fatalf("fetchContainerDevices failed: %v", err)
+	}
+
+	if len(fetched) != len(devices) {
+		t.Fatalf("expected %d devices, got %d", len(devices), len(fetched))
+	}
+
+	for i, d := range devices {
+		if reflect.TypeOf(d) != reflect.TypeOf(fetched[i]) {
+			t.Fatalf("device %d: expected type %T, got %T", i, d, fetched[i])
+		}
+
+		if !reflect.DeepEqual(d, fetched[i]) {
+			t.Fatalf("device %d: fetched %+v does not match stored %+v", i, fetched[i], d)
+		}
+	}
+}
+
+// TestMemStorageLenientDeviceRestorePreservesUnknownTypeAsOpaque mirrors
+// filesystem's LenientDeviceRestore behavior: an unrecognized device type
+// is kept as an opaqueDevice instead of failing the fetch.
+func TestMemStorageLenientDeviceRestorePreservesUnknownTypeAsOpaque(t *testing.T) {
+	sandboxID := "mem-device-lenient"
+	containerID := testContainerID
+
+	typedDevices := []TypedDevice{{Type: "future-device-type", Data: []byte(`{"id":"future"}`)}}
+	raw, err := json.Marshal(typedDevices)
+	if err != nil {
+		t.Fatalf("could not marshal typed devices: %v", err)
+	}
+
+	storage := newMemStorage(true)
+	storage.set(memResourceKey{sandboxID: sandboxID, containerID: containerID, resource: devicesFileType}, raw)
+
+	fetched, err := storage.fetchContainerDevices(sandboxID, containerID)
+	if err != nil {
+		t.Fatalf("fetchContainerDevices failed: %v", err)
+	}
+
+	if len(fetched) != 1 {
+		t.Fatalf("expected 1 device, got %d", len(fetched))
+	}
+
+	if _, ok := fetched[0].(*opaqueDevice); !ok {
+		t.Fatalf("expected an opaqueDevice, got %T", fetched[0])
+	}
+
+	strictStorage := newMemStorage(false)
+	strictStorage.set(memResourceKey{sandboxID: sandboxID, containerID: containerID, resource: devicesFileType}, raw)
+
+	if _, err := strictStorage.fetchContainerDevices(sandboxID, containerID); err == nil {
+		t.Fatal("expected fetchContainerDevices to fail on an unknown device type without LenientDeviceRestore")
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// storageOp identifies the kind of storage operation a counter tracks.
+type storageOp string
+
+const (
+	storageOpStore  storageOp = "store"
+	storageOpFetch  storageOp = "fetch"
+	storageOpDelete storageOp = "delete"
+)
+
+// MetricsCollector accumulates counters for virtcontainers storage
+// operations, keyed by operation and resource type, along with a separate
+// error counter for each. It is exposed in Prometheus text exposition
+// format so an embedder can serve it from its own /metrics endpoint.
+type MetricsCollector struct {
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+// NewMetricsCollector creates an empty, ready to use MetricsCollector.
+func NewMetricsCollector() *MetricsCollector {
+	return &MetricsCollector{
+		counters: make(map[string]uint64),
+	}
+}
+
+// storageMetrics is the collector the filesystem storage implementation
+// reports to. It is nil until RegisterMetricsCollector is called, so the
+// storage hot path stays free of any bookkeeping overhead by default.
+var storageMetrics *MetricsCollector
+
+// RegisterMetricsCollector installs the collector that filesystem storage
+// operations report to. Passing nil disables metrics collection.
+func RegisterMetricsCollector(collector *MetricsCollector) {
+	storageMetrics = collector
+}
+
+// observe is a no-op when called on a nil collector, so call sites do not
+// need to guard every increment with a nil check.
+func (m *MetricsCollector) observe(op storageOp, resource sandboxResource, err error) {
+	if m == nil {
+		return
+	}
+
+	m.inc(fmt.Sprintf("kata_storage_%s_total{resource=%q}", op, resourceName(resource)))
+
+	if err != nil {
+		m.inc(fmt.Sprintf("kata_storage_%s_errors_total{resource=%q}", op, resourceName(resource)))
+	}
+}
+
+func (m *MetricsCollector) inc(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters[name]++
+}
+
+// Get returns the current value of a named counter. It is primarily
+// intended for tests; embedders should prefer Gather().
+func (m *MetricsCollector) Get(name string) uint64 {
+	if m == nil {
+		return 0
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.counters[name]
+}
+
+// Gather renders all counters in Prometheus text exposition format.
+func (m *MetricsCollector) Gather() string {
+	if m == nil {
+		return ""
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.counters))
+	for name := range m.counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var sb strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&sb, "%s %d\n", name, m.counters[name])
+	}
+
+	return sb.String()
+}
+
+// AgentCallHistogram holds per-method observations of agent gRPC call
+// latency. Unlike MetricsCollector's plain counters, it keeps the raw
+// durations so callers can compute sums, counts or percentiles.
+type AgentCallHistogram struct {
+	mu           sync.Mutex
+	observations map[string][]time.Duration
+}
+
+// NewAgentCallHistogram creates an empty, ready to use AgentCallHistogram.
+func NewAgentCallHistogram() *AgentCallHistogram {
+	return &AgentCallHistogram{
+		observations: make(map[string][]time.Duration),
+	}
+}
+
+// agentMetrics is the histogram kataAgent reports call durations to when
+// KataAgentConfig.EnableCallMetrics is set. It is nil by default so the
+// noop agent (which never calls observeCall) and a disabled kataAgent pay
+// no bookkeeping cost.
+var agentMetrics = NewAgentCallHistogram()
+
+// observeCall is a no-op when called on a nil histogram.
+func (h *AgentCallHistogram) observeCall(method string, d time.Duration) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.observations[method] = append(h.observations[method], d)
+}
+
+// Count returns the number of observations recorded for method.
+func (h *AgentCallHistogram) Count(method string) int {
+	if h == nil {
+		return 0
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.observations[method])
+}
+
+// Sum returns the total duration observed for method.
+func (h *AgentCallHistogram) Sum(method string) time.Duration {
+	if h == nil {
+		return 0
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var sum time.Duration
+	for _, d := range h.observations[method] {
+		sum += d
+	}
+
+	return sum
+}
+
+// Gather renders per-method count and total duration in Prometheus text
+// exposition format, as two series sharing the "kata_agent_call" name.
+func (h *AgentCallHistogram) Gather() string {
+	if h == nil {
+		return ""
+	}
+
+	h.mu.Lock()
+	methods := make([]string, 0, len(h.observations))
+	for method := range h.observations {
+		methods = append(methods, method)
+	}
+	h.mu.Unlock()
+	sort.Strings(methods)
+
+	var sb strings.Builder
+	for _, method := range methods {
+		fmt.Fprintf(&sb, "kata_agent_call_count{method=%q} %d\n", method, h.Count(method))
+		fmt.Fprintf(&sb, "kata_agent_call_duration_seconds_sum{method=%q} %f\n", method, h.Sum(method).Seconds())
+	}
+
+	return sb.String()
+}
+
+// resourceName maps a sandboxResource to the stable label value used in
+// exported metrics.
+func resourceName(resource sandboxResource) string {
+	switch resource {
+	case configFileType:
+		return "config"
+	case stateFileType:
+		return "state"
+	case networkFileType:
+		return "network"
+	case hypervisorFileType:
+		return "hypervisor"
+	case agentFileType:
+		return "agent"
+	case processFileType:
+		return "process"
+	case lockFileType:
+		return "lock"
+	case mountsFileType:
+		return "mounts"
+	case devicesFileType:
+		return "devices"
+	case detachedProcessesFileType:
+		return "detached-processes"
+	default:
+		return "unknown"
+	}
+}
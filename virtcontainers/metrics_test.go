@@ -0,0 +1,76 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMetricsCollectorStoreFetchDeleteCounters(t *testing.T) {
+	collector := NewMetricsCollector()
+	RegisterMetricsCollector(collector)
+	defer RegisterMetricsCollector(nil)
+
+	fs := &filesystem{}
+	contID := "100"
+
+	contStateDir := filepath.Join(runStoragePath, testSandboxID, contID)
+	os.MkdirAll(contStateDir, dirMode)
+	defer os.RemoveAll(contStateDir)
+
+	storeCounter := `kata_storage_store_total{resource="state"}`
+	fetchCounter := `kata_storage_fetch_total{resource="state"}`
+	deleteCounter := `kata_storage_delete_total{resource="state"}`
+
+	if err := fs.storeContainerResource(testSandboxID, contID, stateFileType, State{State: StateReady}); err != nil {
+		t.Fatal(err)
+	}
+	if got := collector.Get(storeCounter); got != 1 {
+		t.Fatalf("expected store counter to be 1, got %d", got)
+	}
+
+	if _, err := fs.fetchContainerState(testSandboxID, contID); err != nil {
+		t.Fatal(err)
+	}
+	if got := collector.Get(fetchCounter); got != 1 {
+		t.Fatalf("expected fetch counter to be 1, got %d", got)
+	}
+
+	if err := fs.deleteContainerResources(testSandboxID, contID, []sandboxResource{stateFileType}); err != nil {
+		t.Fatal(err)
+	}
+	if got := collector.Get(deleteCounter); got != 1 {
+		t.Fatalf("expected delete counter to be 1, got %d", got)
+	}
+}
+
+func TestAgentCallHistogramObservesDuration(t *testing.T) {
+	h := NewAgentCallHistogram()
+
+	h.observeCall("grpc.SlowMethod", 50*time.Millisecond)
+	h.observeCall("grpc.SlowMethod", 150*time.Millisecond)
+
+	if got := h.Count("grpc.SlowMethod"); got != 2 {
+		t.Fatalf("expected 2 observations, got %d", got)
+	}
+
+	if got := h.Sum("grpc.SlowMethod"); got != 200*time.Millisecond {
+		t.Fatalf("expected total duration of 200ms, got %v", got)
+	}
+}
+
+func TestMetricsCollectorNilIsNoop(t *testing.T) {
+	var collector *MetricsCollector
+
+	collector.observe(storageOpStore, stateFileType, nil)
+
+	if got := collector.Gather(); got != "" {
+		t.Fatalf("expected empty output from nil collector, got %q", got)
+	}
+}
@@ -5,8 +5,20 @@
 
 package virtcontainers
 
+import (
+	"io/ioutil"
+	"os"
+	"time"
+)
+
 type mockHypervisor struct {
-	vCPUs uint32
+	vCPUs        uint32
+	launchTiming LaunchTiming
+
+	// guestPanicked and guestPanicReason let tests simulate the VMM
+	// itself reporting a guest kernel panic, e.g. via a pvpanic device.
+	guestPanicked    bool
+	guestPanicReason string
 }
 
 func (m *mockHypervisor) init(sandbox *Sandbox) error {
@@ -46,6 +58,19 @@ func (m *mockHypervisor) resumeSandbox() error {
 	return nil
 }
 
+func (m *mockHypervisor) saveSandboxState(path string) error {
+	return ioutil.WriteFile(path, []byte("mock vm state"), 0640)
+}
+
+func (m *mockHypervisor) restoreSandboxState(path string) error {
+	_, err := ioutil.ReadFile(path)
+	return err
+}
+
+func (m *mockHypervisor) checkGuestPanic() (bool, string) {
+	return m.guestPanicked, m.guestPanicReason
+}
+
 func (m *mockHypervisor) addDevice(devInfo interface{}, devType deviceType) error {
 	return nil
 }
@@ -69,3 +94,23 @@ func (m *mockHypervisor) hotplugRemoveDevice(devInfo interface{}, devType device
 func (m *mockHypervisor) getSandboxConsole(sandboxID string) (string, error) {
 	return "", nil
 }
+
+func (m *mockHypervisor) launchArgs() []string {
+	return nil
+}
+
+func (m *mockHypervisor) setBalloonTarget(targetMiB uint32) error {
+	return nil
+}
+
+func (m *mockHypervisor) pid() (int, error) {
+	return os.Getpid(), nil
+}
+
+func (m *mockHypervisor) recordLaunchPhase(phase launchPhase, d time.Duration) {
+	m.launchTiming.record(phase, d)
+}
+
+func (m *mockHypervisor) getLaunchTiming() LaunchTiming {
+	return m.launchTiming
+}
@@ -6,6 +6,7 @@
 package virtcontainers
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -113,7 +114,12 @@ func (m *monitor) stop() {
 }
 
 func (m *monitor) watchAgent() {
-	err := m.sandbox.agent.check()
+	// Bound the probe by the same interval it's run on, so a single
+	// check can never run long enough to delay the next tick.
+	ctx, cancel := context.WithTimeout(context.Background(), m.checkInterval)
+	defer cancel()
+
+	err := m.sandbox.agent.check(ctx)
 	if err != nil {
 		m.notify(err)
 	}
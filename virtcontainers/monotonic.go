@@ -0,0 +1,47 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// monotonicNow returns the current reading of CLOCK_MONOTONIC, in
+// nanoseconds since an unspecified point (typically boot). Unlike
+// time.Now(), it never jumps backward because of wall-clock
+// adjustments such as NTP corrections, which makes it a safe source
+// for computing elapsed durations. Readings are only comparable
+// within the same boot: one persisted across a host reboot is
+// meaningless.
+func monotonicNow() (int64, error) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return 0, err
+	}
+
+	return ts.Nano(), nil
+}
+
+// ageSince returns how long has elapsed since createdAt, preferring the
+// jitter-free CLOCK_MONOTONIC reading createdAtMonotonicNs (taken at
+// creation time by monotonicNow) over the wall clock. It falls back to
+// time.Since(createdAt) when no monotonic reading is available, e.g.
+// state persisted before this field existed, a reading taken across a
+// host reboot, or a failure to read the monotonic clock.
+func ageSince(createdAt time.Time, createdAtMonotonicNs int64) time.Duration {
+	if createdAtMonotonicNs == 0 {
+		return time.Since(createdAt)
+	}
+
+	now, err := monotonicNow()
+	if err != nil || now < createdAtMonotonicNs {
+		return time.Since(createdAt)
+	}
+
+	return time.Duration(now - createdAtMonotonicNs)
+}
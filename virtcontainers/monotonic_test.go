@@ -0,0 +1,52 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAgeSinceSurvivesBackwardWallClockJump(t *testing.T) {
+	monotonicCreatedAt, err := monotonicNow()
+	if err != nil {
+		t.Skipf("could not read CLOCK_MONOTONIC: %v", err)
+	}
+
+	// createdAt is in the future relative to the real wall clock,
+	// simulating an NTP correction that jumped the wall clock
+	// backward after the container was created.
+	createdAt := time.Now().Add(time.Hour)
+
+	age := ageSince(createdAt, monotonicCreatedAt)
+	if age < 0 {
+		t.Fatalf("expected age computed from the monotonic clock to not go negative, got %s", age)
+	}
+}
+
+func TestAgeSinceFallsBackToWallClockWithoutMonotonicReading(t *testing.T) {
+	createdAt := time.Now().Add(-time.Minute)
+
+	age := ageSince(createdAt, 0)
+	if age < time.Minute {
+		t.Fatalf("expected age to be at least 1 minute, got %s", age)
+	}
+}
+
+func TestContainerAge(t *testing.T) {
+	c := &Container{}
+	c.state.CreatedAt = time.Now()
+
+	mono, err := monotonicNow()
+	if err != nil {
+		t.Skipf("could not read CLOCK_MONOTONIC: %v", err)
+	}
+	c.state.CreatedAtMonotonicNs = mono
+
+	if age := c.Age(); age < 0 {
+		t.Fatalf("expected a non-negative age, got %s", age)
+	}
+}
@@ -226,6 +226,89 @@ func isDeviceMapper(major, minor int) (bool, error) {
 
 const mountPerm = os.FileMode(0755)
 
+// validateMounts pre-checks bind mounts before the sandbox or VM is
+// involved, so that a bad mount is reported immediately instead of
+// failing deep inside bindMount. For each bind mount, it checks the
+// source exists, and that if the destination already exists on the
+// host, its type (directory or regular file) matches the source's,
+// since ensureDestinationExists silently keeps an existing destination
+// of the wrong type, which then fails the bind mount itself.
+func validateMounts(mounts []Mount) error {
+	for _, m := range mounts {
+		if m.Type != "bind" {
+			continue
+		}
+
+		srcInfo, err := os.Stat(m.Source)
+		if err != nil {
+			return fmt.Errorf("mount source %v: %v", m.Source, err)
+		}
+
+		destInfo, err := os.Stat(m.Destination)
+		if err != nil {
+			// The destination does not exist yet: ensureDestinationExists
+			// will create it to match the source's type.
+			continue
+		}
+
+		if srcInfo.IsDir() != destInfo.IsDir() {
+			return fmt.Errorf("mount destination %v already exists but does not match the type of source %v", m.Destination, m.Source)
+		}
+	}
+
+	return nil
+}
+
+// validateMountSizeLimits rejects any tmpfs mount whose SizeLimit is not
+// strictly below the guest's configured memory, since a tmpfs allowed to
+// grow up to (or beyond) the guest's entire memory defeats the point of
+// capping it.
+func validateMountSizeLimits(mounts []Mount, guestMemMiB uint32) error {
+	guestMemBytes := uint64(guestMemMiB) * 1024 * 1024
+
+	for _, m := range mounts {
+		if m.Type != "tmpfs" || m.SizeLimit == 0 {
+			continue
+		}
+
+		if m.SizeLimit >= guestMemBytes {
+			return fmt.Errorf("tmpfs size limit of %d bytes for %v must be less than the guest's configured memory of %d bytes", m.SizeLimit, m.Destination, guestMemBytes)
+		}
+	}
+
+	return nil
+}
+
+// validateMountSourceWithinRoots resolves source's symlinks and rejects
+// it unless the resolved path falls under one of allowedRoots, so a
+// malicious OCI bundle can't use a symlink to smuggle a shared mount
+// source outside the bundle it is supposed to stay within. A nil or
+// empty allowedRoots skips the check, for callers with no bundle to
+// confine sources to (see specPath's doc comment).
+func validateMountSourceWithinRoots(source string, allowedRoots []string) error {
+	if len(allowedRoots) == 0 {
+		return nil
+	}
+
+	resolved, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return fmt.Errorf("Could not resolve symlink for source %v: %v", source, err)
+	}
+
+	for _, root := range allowedRoots {
+		resolvedRoot, err := filepath.EvalSymlinks(root)
+		if err != nil {
+			continue
+		}
+
+		if resolved == resolvedRoot || strings.HasPrefix(resolved, resolvedRoot+string(os.PathSeparator)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("mount source %v resolves to %v, which escapes the allowed roots %v", source, resolved, allowedRoots)
+}
+
 // bindMount bind mounts a source in to a destination. This will
 // do some bookkeeping:
 // * evaluate all symlinks
@@ -260,8 +343,14 @@ func bindMount(source, destination string, readonly bool) error {
 }
 
 // bindMountContainerRootfs bind mounts a container rootfs into a 9pfs shared
-// directory between the guest and the host.
-func bindMountContainerRootfs(sharedDir, sandboxID, cID, cRootFs string, readonly bool) error {
+// directory between the guest and the host. allowedRoots, when non-empty,
+// confines cRootFs to resolve within one of those roots (typically the
+// container's OCI bundle), rejecting a symlink escape.
+func bindMountContainerRootfs(sharedDir, sandboxID, cID, cRootFs string, readonly bool, allowedRoots []string) error {
+	if err := validateMountSourceWithinRoots(cRootFs, allowedRoots); err != nil {
+		return err
+	}
+
 	rootfsDest := filepath.Join(sharedDir, sandboxID, cID, rootfsDir)
 
 	return bindMount(cRootFs, rootfsDest, readonly)
@@ -288,6 +377,13 @@ type Mount struct {
 	// VM in case this mount is a block device file or a directory
 	// backed by a block device.
 	BlockDevice *drivers.BlockDevice
+
+	// SizeLimit caps the size in bytes of a tmpfs-type mount. It is
+	// passed to the guest as the tmpfs "size=" mount option, so an
+	// unbounded tmpfs (e.g. a container's /tmp) cannot exhaust guest
+	// memory. Zero means no limit. It has no effect on mounts whose
+	// Type is not "tmpfs".
+	SizeLimit uint64
 }
 
 func bindUnmountContainerRootfs(sharedDir, sandboxID, cID string) error {
@@ -8,6 +8,7 @@ package virtcontainers
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -42,6 +43,135 @@ func TestIsSystemMount(t *testing.T) {
 	}
 }
 
+func TestValidateMountSourceWithinRootsNoRoots(t *testing.T) {
+	if err := validateMountSourceWithinRoots("/does/not/matter", nil); err != nil {
+		t.Fatalf("expected no error when allowedRoots is empty, got %v", err)
+	}
+}
+
+func TestValidateMountSourceWithinRootsInBundleAllowed(t *testing.T) {
+	bundle, err := ioutil.TempDir("", "validate-mount-source-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bundle)
+
+	rootfs := filepath.Join(bundle, "rootfs")
+	if err := os.Mkdir(rootfs, mountPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateMountSourceWithinRoots(rootfs, []string{bundle}); err != nil {
+		t.Fatalf("expected an in-bundle source to be allowed, got %v", err)
+	}
+}
+
+func TestValidateMountSourceWithinRootsSymlinkEscapeRejected(t *testing.T) {
+	bundle, err := ioutil.TempDir("", "validate-mount-source-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(bundle)
+
+	escape := filepath.Join(bundle, "rootfs")
+	if err := os.Symlink("/etc/shadow", escape); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := validateMountSourceWithinRoots(escape, []string{bundle}); err == nil {
+		t.Fatal("expected a symlink escaping the bundle to be rejected")
+	}
+}
+
+func TestValidateMounts(t *testing.T) {
+	tmpdir, err := ioutil.TempDir("", "validate-mounts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	srcDir := filepath.Join(tmpdir, "src-dir")
+	if err := os.Mkdir(srcDir, mountPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	srcFile := filepath.Join(tmpdir, "src-file")
+	if err := ioutil.WriteFile(srcFile, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	destFile := filepath.Join(tmpdir, "dest-file")
+	if err := ioutil.WriteFile(destFile, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A non-bind mount is never checked, regardless of whether its
+	// "source" exists.
+	if err := validateMounts([]Mount{
+		{Type: "tmpfs", Source: "tmpfs", Destination: filepath.Join(tmpdir, "tmpfs-dest")},
+	}); err != nil {
+		t.Fatalf("unexpected error for a non-bind mount: %v", err)
+	}
+
+	// Missing source.
+	err = validateMounts([]Mount{
+		{Type: "bind", Source: filepath.Join(tmpdir, "does-not-exist"), Destination: filepath.Join(tmpdir, "dest")},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a missing mount source")
+	}
+
+	// Type mismatch: source is a directory, but the destination already
+	// exists as a regular file.
+	err = validateMounts([]Mount{
+		{Type: "bind", Source: srcDir, Destination: destFile},
+	})
+	if err == nil {
+		t.Fatal("expected an error for a source/destination type mismatch")
+	}
+
+	// A valid set: an existing source, and either no destination yet or
+	// one whose type already matches.
+	if err := validateMounts([]Mount{
+		{Type: "bind", Source: srcFile, Destination: filepath.Join(tmpdir, "new-dest-file")},
+		{Type: "bind", Source: srcDir, Destination: filepath.Join(tmpdir, "new-dest-dir")},
+	}); err != nil {
+		t.Fatalf("unexpected error for a valid mount set: %v", err)
+	}
+}
+
+func TestValidateMountSizeLimits(t *testing.T) {
+	const guestMemMiB = 128
+
+	// A non-tmpfs mount is never checked, even with a SizeLimit set.
+	if err := validateMountSizeLimits([]Mount{
+		{Type: "bind", Destination: "/data", SizeLimit: 1024 * 1024 * 1024},
+	}, guestMemMiB); err != nil {
+		t.Fatalf("unexpected error for a non-tmpfs mount: %v", err)
+	}
+
+	// No SizeLimit set means no cap requested: always allowed.
+	if err := validateMountSizeLimits([]Mount{
+		{Type: "tmpfs", Destination: "/tmp"},
+	}, guestMemMiB); err != nil {
+		t.Fatalf("unexpected error for an unset size limit: %v", err)
+	}
+
+	// A limit below the guest's memory is fine.
+	if err := validateMountSizeLimits([]Mount{
+		{Type: "tmpfs", Destination: "/tmp", SizeLimit: 32 * 1024 * 1024},
+	}, guestMemMiB); err != nil {
+		t.Fatalf("unexpected error for a valid size limit: %v", err)
+	}
+
+	// A limit at or above the guest's memory must be rejected.
+	if err := validateMountSizeLimits([]Mount{
+		{Type: "tmpfs", Destination: "/tmp", SizeLimit: guestMemMiB * 1024 * 1024},
+	}, guestMemMiB); err == nil {
+		t.Fatal("expected an error for a size limit at the guest's memory size")
+	}
+}
+
 func TestIsHostDevice(t *testing.T) {
 	tests := []struct {
 		mnt      string
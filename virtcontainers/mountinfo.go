@@ -0,0 +1,106 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GuestMount describes a single mount as seen from inside the guest,
+// parsed from a line of /proc/self/mountinfo. See proc(5) for the
+// field layout.
+type GuestMount struct {
+	MountID      int
+	ParentID     int
+	Major        int
+	Minor        int
+	Root         string
+	MountPoint   string
+	Options      []string
+	FSType       string
+	Source       string
+	SuperOptions []string
+}
+
+// mountInfoUnescaper undoes the octal escaping mountinfo applies to
+// space, tab, newline, and backslash within its path fields.
+var mountInfoUnescaper = strings.NewReplacer(
+	`\040`, " ",
+	`\011`, "\t",
+	`\012`, "\n",
+	`\134`, `\`,
+)
+
+// parseMountInfo parses the contents of a /proc/self/mountinfo file
+// into a slice of GuestMount, one per line.
+func parseMountInfo(content string) ([]GuestMount, error) {
+	var mounts []GuestMount
+
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			return nil, fmt.Errorf("malformed mountinfo line: %q", line)
+		}
+
+		sepIdx := -1
+		for i, f := range fields {
+			if f == "-" {
+				sepIdx = i
+				break
+			}
+		}
+		if sepIdx == -1 || len(fields) < sepIdx+4 {
+			return nil, fmt.Errorf("malformed mountinfo line, missing separator: %q", line)
+		}
+
+		mountID, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse mount ID in line %q: %v", line, err)
+		}
+
+		parentID, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse parent mount ID in line %q: %v", line, err)
+		}
+
+		majorMinor := strings.SplitN(fields[2], ":", 2)
+		if len(majorMinor) != 2 {
+			return nil, fmt.Errorf("malformed major:minor in line %q", line)
+		}
+
+		major, err := strconv.Atoi(majorMinor[0])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse major in line %q: %v", line, err)
+		}
+
+		minor, err := strconv.Atoi(majorMinor[1])
+		if err != nil {
+			return nil, fmt.Errorf("could not parse minor in line %q: %v", line, err)
+		}
+
+		mounts = append(mounts, GuestMount{
+			MountID:      mountID,
+			ParentID:     parentID,
+			Major:        major,
+			Minor:        minor,
+			Root:         mountInfoUnescaper.Replace(fields[3]),
+			MountPoint:   mountInfoUnescaper.Replace(fields[4]),
+			Options:      strings.Split(fields[5], ","),
+			FSType:       fields[sepIdx+1],
+			Source:       mountInfoUnescaper.Replace(fields[sepIdx+2]),
+			SuperOptions: strings.Split(fields[sepIdx+3], ","),
+		})
+	}
+
+	return mounts, nil
+}
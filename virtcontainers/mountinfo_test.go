@@ -0,0 +1,64 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+)
+
+func TestParseMountInfo(t *testing.T) {
+	content := `22 28 0:21 / /sys rw,nosuid,nodev,noexec,relatime shared:7 - sysfs sysfs rw
+28 1 8:1 / / rw,relatime shared:1 - ext4 /dev/sda1 rw,errors=remount-ro
+45 28 0:39 / /mnt/my\040volume rw,relatime shared:25 - 9p share rw,trans=virtio,version=9p2000.L
+`
+
+	mounts, err := parseMountInfo(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mounts) != 3 {
+		t.Fatalf("expected 3 mounts, got %d", len(mounts))
+	}
+
+	root := mounts[1]
+	if root.MountID != 28 || root.ParentID != 1 {
+		t.Fatalf("unexpected mount/parent ID for root mount: %+v", root)
+	}
+	if root.Major != 8 || root.Minor != 1 {
+		t.Fatalf("unexpected major:minor for root mount: %+v", root)
+	}
+	if root.MountPoint != "/" || root.FSType != "ext4" || root.Source != "/dev/sda1" {
+		t.Fatalf("unexpected fields for root mount: %+v", root)
+	}
+
+	shared := mounts[2]
+	if shared.MountPoint != "/mnt/my volume" {
+		t.Fatalf("expected octal-escaped space to be decoded, got %q", shared.MountPoint)
+	}
+	if shared.FSType != "9p" || shared.Source != "share" {
+		t.Fatalf("unexpected fields for shared mount: %+v", shared)
+	}
+}
+
+func TestParseMountInfoMalformedLine(t *testing.T) {
+	content := "22 28 0:21 / /sys rw\n"
+
+	if _, err := parseMountInfo(content); err == nil {
+		t.Fatal("expected an error for a malformed mountinfo line")
+	}
+}
+
+func TestParseMountInfoEmpty(t *testing.T) {
+	mounts, err := parseMountInfo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mounts) != 0 {
+		t.Fatalf("expected no mounts, got %d", len(mounts))
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+// NetworkLink describes a single network interface as seen from inside
+// the guest.
+type NetworkLink struct {
+	Name         string
+	MTU          uint64
+	HardwareAddr string
+	Addresses    []string
+}
+
+// NetworkRoute describes a single route as seen from inside the guest.
+type NetworkRoute struct {
+	Destination string
+	Gateway     string
+	Device      string
+	Source      string
+}
+
+// GuestNetworkState reports the guest's own view of its network
+// namespace: the interfaces it sees and the routes it has configured.
+// It is gathered in-guest rather than derived from the host-side
+// NetworkNamespace, so it reflects what the guest agent actually
+// applied rather than what the runtime asked for, which is what makes
+// it useful for diagnosing CNI/overlay issues.
+type GuestNetworkState struct {
+	Links  []NetworkLink
+	Routes []NetworkRoute
+}
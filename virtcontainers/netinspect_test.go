@@ -0,0 +1,62 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"reflect"
+	"testing"
+)
+
+// fakeNetInspectAgent behaves like noopAgent except it returns a canned
+// GuestNetworkState, for testing that callers render whatever the agent
+// reports correctly.
+type fakeNetInspectAgent struct {
+	noopAgent
+	state *GuestNetworkState
+}
+
+func (a *fakeNetInspectAgent) inspectNetwork(sandbox *Sandbox) (*GuestNetworkState, error) {
+	return a.state, nil
+}
+
+func TestFakeAgentReturnsCannedNetworkState(t *testing.T) {
+	expected := &GuestNetworkState{
+		Links: []NetworkLink{
+			{
+				Name:         "eth0",
+				MTU:          1500,
+				HardwareAddr: "02:42:ac:11:00:02",
+				Addresses:    []string{"172.17.0.2/16"},
+			},
+		},
+		Routes: []NetworkRoute{
+			{
+				Destination: "default",
+				Gateway:     "172.17.0.1",
+				Device:      "eth0",
+			},
+		},
+	}
+
+	agent := &fakeNetInspectAgent{state: expected}
+
+	state, err := agent.inspectNetwork(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(state, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, state)
+	}
+}
+
+func TestNoopAgentInspectNetworkErrors(t *testing.T) {
+	agent := &noopAgent{}
+
+	if _, err := agent.inspectNetwork(nil); err == nil {
+		t.Fatal("expected the noop agent to error on inspectNetwork")
+	}
+}
@@ -142,6 +142,21 @@ type NetworkConfig struct {
 	NetNSPath         string
 	NumInterfaces     int
 	InterworkingModel NetInterworkingModel
+
+	// IngressBandwidth and EgressBandwidth cap each endpoint's host
+	// veth throughput, in bits/sec. Zero means unlimited.
+	IngressBandwidth uint64
+	EgressBandwidth  uint64
+}
+
+// validateBandwidth checks that bps, a bits/sec limit, is not negative.
+// Zero is valid and means unlimited.
+func validateBandwidth(bps int64) error {
+	if bps < 0 {
+		return fmt.Errorf("bandwidth limit must not be negative, got %d", bps)
+	}
+
+	return nil
 }
 
 // Endpoint represents a physical or virtual network interface.
@@ -162,6 +177,12 @@ type VirtualEndpoint struct {
 	EndpointProperties NetworkInfo
 	Physical           bool
 	EndpointType       EndpointType
+
+	// IngressBandwidth and EgressBandwidth cap the host veth's
+	// throughput, in bits/sec, applied as a tc qdisc when the endpoint
+	// is attached. Zero means unlimited.
+	IngressBandwidth uint64
+	EgressBandwidth  uint64
 }
 
 // PhysicalEndpoint gathers a physical network interface and its properties
@@ -225,6 +246,11 @@ func (endpoint *VirtualEndpoint) Attach(h hypervisor) error {
 		return err
 	}
 
+	if err := setupBandwidth(endpoint); err != nil {
+		networkLogger().WithError(err).Error("Error setting up bandwidth limits")
+		return err
+	}
+
 	return h.addDevice(endpoint, netDev)
 }
 
@@ -991,6 +1017,140 @@ func bridgeNetworkPair(netPair *NetworkInterfacePair) error {
 	return nil
 }
 
+const (
+	// bandwidthBurstDuration bounds how long a TBF qdisc's burst
+	// buffer can absorb traffic above the configured rate before
+	// shaping kicks in. 100ms matches the common tc default for
+	// interactive workloads.
+	bandwidthBurstDuration = 100 * time.Millisecond
+
+	// bandwidthMinBurstBytes is the minimum TBF burst buffer size,
+	// applied even when bandwidthBurstDuration worth of traffic at the
+	// configured rate would be smaller.
+	bandwidthMinBurstBytes = 4096
+
+	// bandwidthLatency bounds how long a packet may sit in a TBF
+	// qdisc's queue before being dropped.
+	bandwidthLatency = 25 * time.Millisecond
+
+	// ifbDeviceName is the intermediate functional block device used
+	// to shape ingress traffic, since tc can only shape what an
+	// interface transmits.
+	ifbDeviceName = "kata-ifb0"
+)
+
+// newTbfQdisc builds a TBF (token bucket filter) root qdisc rate
+// limiting link to rateBitsPerSec, with its burst buffer sized to
+// bandwidthBurstDuration worth of traffic (bandwidthMinBurstBytes at
+// minimum) and its queueing latency bounded by bandwidthLatency.
+func newTbfQdisc(link netlink.Link, rateBitsPerSec uint64) *netlink.Tbf {
+	rateBytesPerSec := rateBitsPerSec / 8
+
+	burst := uint32(float64(rateBytesPerSec) * bandwidthBurstDuration.Seconds())
+	if burst < bandwidthMinBurstBytes {
+		burst = bandwidthMinBurstBytes
+	}
+
+	limit := burst + uint32(float64(rateBytesPerSec)*bandwidthLatency.Seconds())
+
+	return &netlink.Tbf{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(1, 0),
+			Parent:    netlink.HANDLE_ROOT,
+		},
+		Rate:   rateBytesPerSec,
+		Buffer: burst,
+		Limit:  limit,
+	}
+}
+
+// setupEgressBandwidth rate-limits traffic transmitted by link to
+// rateBitsPerSec via a TBF root qdisc. A zero rateBitsPerSec is a no-op.
+func setupEgressBandwidth(netHandle *netlink.Handle, link netlink.Link, rateBitsPerSec uint64) error {
+	if rateBitsPerSec == 0 {
+		return nil
+	}
+
+	return netHandle.QdiscAdd(newTbfQdisc(link, rateBitsPerSec))
+}
+
+// setupIngressBandwidth rate-limits traffic received on link to
+// rateBitsPerSec. tc can only shape what an interface transmits, so
+// ingress traffic is redirected to an IFB pseudo-device via a mirred
+// action on link's ingress qdisc, and rate-limited there with a TBF
+// qdisc instead. A zero rateBitsPerSec is a no-op.
+func setupIngressBandwidth(netHandle *netlink.Handle, link netlink.Link, rateBitsPerSec uint64) error {
+	if rateBitsPerSec == 0 {
+		return nil
+	}
+
+	ifb := &netlink.Ifb{LinkAttrs: netlink.LinkAttrs{Name: ifbDeviceName}}
+	if err := netHandle.LinkAdd(ifb); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("Could not create %s: %s", ifbDeviceName, err)
+	}
+
+	ifbLink, err := netHandle.LinkByName(ifbDeviceName)
+	if err != nil {
+		return fmt.Errorf("Could not get %s: %s", ifbDeviceName, err)
+	}
+
+	if err := netHandle.LinkSetUp(ifbLink); err != nil {
+		return fmt.Errorf("Could not enable %s: %s", ifbDeviceName, err)
+	}
+
+	if err := netHandle.QdiscAdd(&netlink.Ingress{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_INGRESS,
+		},
+	}); err != nil {
+		return fmt.Errorf("Could not add ingress qdisc to %s: %s", link.Attrs().Name, err)
+	}
+
+	filter := &netlink.U32{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: link.Attrs().Index,
+			Parent:    netlink.MakeHandle(0xffff, 0),
+			Priority:  1,
+			Protocol:  uint16(unix.ETH_P_ALL),
+		},
+		Actions: []netlink.Action{netlink.NewMirredAction(ifbLink.Attrs().Index)},
+	}
+	if err := netHandle.FilterAdd(filter); err != nil {
+		return fmt.Errorf("Could not add redirect filter from %s to %s: %s", link.Attrs().Name, ifbDeviceName, err)
+	}
+
+	return netHandle.QdiscAdd(newTbfQdisc(ifbLink, rateBitsPerSec))
+}
+
+// setupBandwidth applies endpoint's configured ingress/egress
+// bandwidth limits to its host veth. It must be called from inside
+// the sandbox's network namespace, where the host veth lives.
+func setupBandwidth(endpoint *VirtualEndpoint) error {
+	if endpoint.IngressBandwidth == 0 && endpoint.EgressBandwidth == 0 {
+		return nil
+	}
+
+	netHandle, err := netlink.NewHandle()
+	if err != nil {
+		return err
+	}
+	defer netHandle.Delete()
+
+	vethLink, err := getLinkByName(netHandle, endpoint.NetPair.VirtIface.Name, &netlink.Veth{})
+	if err != nil {
+		return fmt.Errorf("Could not get veth interface: %s: %s", endpoint.NetPair.VirtIface.Name, err)
+	}
+
+	if err := setupEgressBandwidth(netHandle, vethLink, endpoint.EgressBandwidth); err != nil {
+		return err
+	}
+
+	return setupIngressBandwidth(netHandle, vethLink, endpoint.IngressBandwidth)
+}
+
 func untapNetworkPair(netPair NetworkInterfacePair) error {
 	netHandle, err := netlink.NewHandle()
 	if err != nil {
@@ -1133,11 +1293,19 @@ func deleteNetNS(netNSPath string) error {
 	return nil
 }
 
-func createVirtualNetworkEndpoint(idx int, ifName string, interworkingModel NetInterworkingModel) (*VirtualEndpoint, error) {
+func createVirtualNetworkEndpoint(idx int, ifName string, interworkingModel NetInterworkingModel, ingressBandwidth, egressBandwidth uint64) (*VirtualEndpoint, error) {
 	if idx < 0 {
 		return &VirtualEndpoint{}, fmt.Errorf("invalid network endpoint index: %d", idx)
 	}
 
+	if err := validateBandwidth(int64(ingressBandwidth)); err != nil {
+		return &VirtualEndpoint{}, fmt.Errorf("invalid ingress bandwidth: %s", err)
+	}
+
+	if err := validateBandwidth(int64(egressBandwidth)); err != nil {
+		return &VirtualEndpoint{}, fmt.Errorf("invalid egress bandwidth: %s", err)
+	}
+
 	uniqueID := uuid.Generate().String()
 
 	hardAddr := net.HardwareAddr{0x02, 0x00, 0xCA, 0xFE, byte(idx >> 8), byte(idx)}
@@ -1158,7 +1326,9 @@ func createVirtualNetworkEndpoint(idx int, ifName string, interworkingModel NetI
 			},
 			NetInterworkingModel: interworkingModel,
 		},
-		EndpointType: VirtualEndpointType,
+		EndpointType:     VirtualEndpointType,
+		IngressBandwidth: ingressBandwidth,
+		EgressBandwidth:  egressBandwidth,
 	}
 
 	if ifName != "" {
@@ -1261,7 +1431,7 @@ func createEndpointsFromScan(networkNSPath string, config NetworkConfig) ([]Endp
 					cnmLogger().WithField("interface", netInfo.Iface.Name).Info("VhostUser network interface found")
 					endpoint, err = createVhostUserEndpoint(netInfo, socketPath)
 				} else {
-					endpoint, err = createVirtualNetworkEndpoint(idx, netInfo.Iface.Name, config.InterworkingModel)
+					endpoint, err = createVirtualNetworkEndpoint(idx, netInfo.Iface.Name, config.InterworkingModel, config.IngressBandwidth, config.EgressBandwidth)
 				}
 			}
 
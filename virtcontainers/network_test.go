@@ -233,7 +233,7 @@ func TestCreateVirtualNetworkEndpoint(t *testing.T) {
 		EndpointType: VirtualEndpointType,
 	}
 
-	result, err := createVirtualNetworkEndpoint(4, "", DefaultNetInterworkingModel)
+	result, err := createVirtualNetworkEndpoint(4, "", DefaultNetInterworkingModel, 0, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -265,7 +265,7 @@ func TestCreateVirtualNetworkEndpointChooseIfaceName(t *testing.T) {
 		EndpointType: VirtualEndpointType,
 	}
 
-	result, err := createVirtualNetworkEndpoint(4, "eth1", DefaultNetInterworkingModel)
+	result, err := createVirtualNetworkEndpoint(4, "eth1", DefaultNetInterworkingModel, 0, 0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -291,7 +291,7 @@ func TestCreateVirtualNetworkEndpointInvalidArgs(t *testing.T) {
 	}
 
 	for _, d := range failingValues {
-		result, err := createVirtualNetworkEndpoint(d.idx, d.ifName, DefaultNetInterworkingModel)
+		result, err := createVirtualNetworkEndpoint(d.idx, d.ifName, DefaultNetInterworkingModel, 0, 0)
 		if err == nil {
 			t.Fatalf("expected invalid endpoint for %v, got %v", d, result)
 		}
@@ -359,6 +359,100 @@ func TestIsPhysicalIface(t *testing.T) {
 	}
 }
 
+func TestSetupBandwidth(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	testVethName := "testVeth0"
+
+	veth := &netlink.Veth{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: testVethName,
+		},
+		PeerName: "testVethPeer0",
+	}
+
+	n, err := ns.NewNS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer n.Close()
+
+	netnsHandle, err := netns.GetFromPath(n.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer netnsHandle.Close()
+
+	netlinkHandle, err := netlink.NewHandleAt(netnsHandle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer netlinkHandle.Delete()
+
+	if err := netlinkHandle.LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+
+	vethLink, err := netlinkHandle.LinkByName(testVethName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := netlinkHandle.LinkSetUp(vethLink); err != nil {
+		t.Fatal(err)
+	}
+
+	endpoint := &VirtualEndpoint{
+		NetPair: NetworkInterfacePair{
+			VirtIface: NetworkInterface{
+				Name: testVethName,
+			},
+		},
+	}
+
+	// A zero bandwidth is a no-op: no qdisc should appear on the veth.
+	err = doNetNS(n.Path(), func(_ ns.NetNS) error {
+		return setupBandwidth(endpoint)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qdiscs, err := netlinkHandle.QdiscList(vethLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qdiscs) != 0 {
+		t.Fatalf("expected no qdisc for zero bandwidth, got %+v", qdiscs)
+	}
+
+	endpoint.EgressBandwidth = 1000000
+
+	err = doNetNS(n.Path(), func(_ ns.NetNS) error {
+		return setupBandwidth(endpoint)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	qdiscs, err = netlinkHandle.QdiscList(vethLink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, q := range qdiscs {
+		if _, ok := q.(*netlink.Tbf); ok {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected a TBF qdisc configuring the egress bandwidth limit, got %+v", qdiscs)
+	}
+}
+
 func TestNetInterworkingModelIsValid(t *testing.T) {
 	tests := []struct {
 		name string
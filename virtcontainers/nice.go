@@ -0,0 +1,25 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "fmt"
+
+// minNice and maxNice match the range accepted by the kernel for a
+// process' nice value.
+const (
+	minNice = -20
+	maxNice = 19
+)
+
+// validateNice checks that nice is within the range the kernel accepts
+// for a process' nice value.
+func validateNice(nice int) error {
+	if nice < minNice || nice > maxNice {
+		return fmt.Errorf("nice value %d is out of range [%d, %d]", nice, minNice, maxNice)
+	}
+
+	return nil
+}
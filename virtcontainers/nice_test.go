@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "testing"
+
+func TestValidateNiceWithinRange(t *testing.T) {
+	for _, nice := range []int{-20, -1, 0, 1, 19} {
+		if err := validateNice(nice); err != nil {
+			t.Fatalf("expected nice %d to be valid: %v", nice, err)
+		}
+	}
+}
+
+func TestValidateNiceOutOfRange(t *testing.T) {
+	for _, nice := range []int{-21, 20} {
+		if err := validateNice(nice); err == nil {
+			t.Fatalf("expected nice %d to be rejected", nice)
+		}
+	}
+}
+
+// fakeNiceAgent behaves like noopAgent except it records whatever nice
+// value it is asked to set, for testing that Container.setProcessNice
+// passes its arguments through correctly.
+type fakeNiceAgent struct {
+	noopAgent
+	processID string
+	nice      int
+}
+
+func (a *fakeNiceAgent) setProcessNice(c *Container, processID string, nice int) error {
+	if err := validateNice(nice); err != nil {
+		return err
+	}
+
+	a.processID = processID
+	a.nice = nice
+
+	return nil
+}
+
+func TestFakeAgentCapturesNice(t *testing.T) {
+	agent := &fakeNiceAgent{}
+	sandbox := &Sandbox{agent: agent}
+	c := &Container{sandbox: sandbox, process: Process{Token: "foo"}}
+	c.state.State = StateRunning
+
+	if err := c.setProcessNice("foo", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if agent.processID != "foo" || agent.nice != 10 {
+		t.Fatalf("expected the fake agent to capture the nice value, got %+v", agent)
+	}
+}
+
+func TestFakeAgentRejectsOutOfRangeNice(t *testing.T) {
+	agent := &fakeNiceAgent{}
+	sandbox := &Sandbox{agent: agent}
+	c := &Container{sandbox: sandbox, process: Process{Token: "foo"}}
+	c.state.State = StateRunning
+
+	if err := c.setProcessNice("foo", 20); err == nil {
+		t.Fatal("expected an out-of-range nice value to be rejected")
+	}
+}
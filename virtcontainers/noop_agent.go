@@ -6,7 +6,10 @@
 package virtcontainers
 
 import (
+	"context"
+	"fmt"
 	"syscall"
+	"time"
 
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 )
@@ -26,6 +29,36 @@ func (n *noopAgent) createSandbox(sandbox *Sandbox) error {
 	return nil
 }
 
+// getClockSource is the Noop agent clocksource query implementation. It does nothing.
+func (n *noopAgent) getClockSource(sandbox *Sandbox) (string, []string, error) {
+	return "", nil, fmt.Errorf("getClockSource is not supported by the noop agent")
+}
+
+// getGuestMemInfo is the Noop agent guest meminfo implementation. It does nothing.
+func (n *noopAgent) getGuestMemInfo(sandbox *Sandbox) (*MemInfo, error) {
+	return nil, fmt.Errorf("getGuestMemInfo is not supported by the noop agent")
+}
+
+// getGuestLoad is the Noop agent guest load implementation. It does nothing.
+func (n *noopAgent) getGuestLoad(sandbox *Sandbox) (*LoadInfo, error) {
+	return nil, fmt.Errorf("getGuestLoad is not supported by the noop agent")
+}
+
+// getGuestTime is the Noop agent guest time implementation. It does nothing.
+func (n *noopAgent) getGuestTime(sandbox *Sandbox) (time.Time, error) {
+	return time.Time{}, fmt.Errorf("getGuestTime is not supported by the noop agent")
+}
+
+// inspectNetwork is the Noop agent guest network inspection implementation. It does nothing.
+func (n *noopAgent) inspectNetwork(sandbox *Sandbox) (*GuestNetworkState, error) {
+	return nil, fmt.Errorf("inspectNetwork is not supported by the noop agent")
+}
+
+// listGuestMounts is the Noop agent guest mount listing implementation. It does nothing.
+func (n *noopAgent) listGuestMounts(sandbox *Sandbox) ([]GuestMount, error) {
+	return nil, fmt.Errorf("listGuestMounts is not supported by the noop agent")
+}
+
 // capabilities returns empty capabilities, i.e no capabilties are supported.
 func (n *noopAgent) capabilities() capabilities {
 	return capabilities{}
@@ -46,6 +79,17 @@ func (n *noopAgent) startSandbox(sandbox *Sandbox) error {
 	return nil
 }
 
+// proxyPID is the Noop agent proxy pid getter. It never spawns a proxy,
+// so it always returns 0.
+func (n *noopAgent) proxyPID() int {
+	return 0
+}
+
+// applySysctls is the Noop agent sysctl implementation. It does nothing.
+func (n *noopAgent) applySysctls(sandbox *Sandbox, sysctls map[string]string) error {
+	return nil
+}
+
 // stopSandbox is the Noop agent Sandbox stopping implementation. It does nothing.
 func (n *noopAgent) stopSandbox(sandbox *Sandbox) error {
 	return nil
@@ -71,6 +115,13 @@ func (n *noopAgent) stopContainer(sandbox *Sandbox, c Container) error {
 	return nil
 }
 
+// reapOrphans is the Noop agent orphan reaping implementation. It has
+// no guest to reap orphans from, so it errors rather than silently
+// reporting none reaped.
+func (n *noopAgent) reapOrphans(sandbox *Sandbox, c Container) (int, error) {
+	return 0, fmt.Errorf("reapOrphans is not supported by the noop agent")
+}
+
 // signalProcess is the Noop agent Container signaling implementation. It does nothing.
 func (n *noopAgent) signalProcess(c *Container, processID string, signal syscall.Signal, all bool) error {
 	return nil
@@ -91,8 +142,19 @@ func (n *noopAgent) onlineCPUMem(cpus uint32) error {
 	return nil
 }
 
-// check is the Noop agent health checker. It does nothing.
-func (n *noopAgent) check() error {
+// resizeContainerStorage is the Noop agent storage resizer. There is no
+// guest storage to resize, so it always errors.
+func (n *noopAgent) resizeContainerStorage(sandbox *Sandbox, c Container, sizeBytes uint64) error {
+	if err := validateResizeContainerStorageSize(sizeBytes); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("resizeContainerStorage: the noop agent has no guest storage to resize")
+}
+
+// check is the Noop agent health checker. It does nothing and ignores ctx,
+// returning immediately regardless of any deadline the caller set.
+func (n *noopAgent) check(ctx context.Context) error {
 	return nil
 }
 
@@ -111,6 +173,40 @@ func (n *noopAgent) winsizeProcess(c *Container, processID string, height, width
 	return nil
 }
 
+// setOOMScoreAdj is the Noop agent oom_score_adj setter. It validates adj
+// but otherwise does nothing.
+func (n *noopAgent) setOOMScoreAdj(c *Container, processID string, adj int) error {
+	return validateOOMScoreAdj(adj)
+}
+
+// setProcessNice is the Noop agent nice value setter. It validates nice
+// but otherwise does nothing.
+func (n *noopAgent) setProcessNice(c *Container, processID string, nice int) error {
+	return validateNice(nice)
+}
+
+// applySeccomp is the Noop agent seccomp profile setter. It validates
+// profile but otherwise does nothing.
+func (n *noopAgent) applySeccomp(sandbox *Sandbox, c *Container, profile []byte) error {
+	return validateSeccompProfile(profile)
+}
+
+// applyRlimits is the Noop agent rlimit setter. It validates limits but
+// otherwise does nothing.
+func (n *noopAgent) applyRlimits(sandbox *Sandbox, c *Container, limits []Rlimit) error {
+	return validateRlimits(limits)
+}
+
+// trimGuestFS is the Noop agent fstrim runner. There is no guest to trim,
+// so it always errors.
+func (n *noopAgent) trimGuestFS(sandbox *Sandbox, mountpoint string) error {
+	if _, err := validateTrimMountpoint(mountpoint); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("trimGuestFS: the noop agent has no guest to trim")
+}
+
 // writeProcessStdin is the Noop agent process stdin writer. It does nothing.
 func (n *noopAgent) writeProcessStdin(c *Container, ProcessID string, data []byte) (int, error) {
 	return 0, nil
@@ -39,6 +39,26 @@ func TestNoopAgentInit(t *testing.T) {
 	}
 }
 
+func TestNoopAgentGetClockSource(t *testing.T) {
+	n := &noopAgent{}
+	sandbox := &Sandbox{}
+
+	_, _, err := n.getClockSource(sandbox)
+	if err == nil {
+		t.Fatal("noop agent should not support getClockSource")
+	}
+}
+
+func TestNoopAgentGetGuestMemInfo(t *testing.T) {
+	n := &noopAgent{}
+	sandbox := &Sandbox{}
+
+	_, err := n.getGuestMemInfo(sandbox)
+	if err == nil {
+		t.Fatal("noop agent should not support getGuestMemInfo")
+	}
+}
+
 func TestNoopAgentExec(t *testing.T) {
 	n := &noopAgent{}
 	cmd := Cmd{}
@@ -63,6 +83,16 @@ func TestNoopAgentStartSandbox(t *testing.T) {
 	}
 }
 
+func TestNoopAgentApplySysctls(t *testing.T) {
+	n := &noopAgent{}
+	sandbox := &Sandbox{}
+
+	err := n.applySysctls(sandbox, map[string]string{"net.core.somaxconn": "1024"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestNoopAgentStopSandbox(t *testing.T) {
 	n := &noopAgent{}
 	sandbox := &Sandbox{}
@@ -156,3 +186,49 @@ func TestNoopAgentResumeContainer(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestNoopAgentResizeContainerStorage(t *testing.T) {
+	n := &noopAgent{}
+	sandbox, container, err := testCreateNoopContainer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanUp()
+
+	if err := n.resizeContainerStorage(sandbox, *container, 0); err == nil {
+		t.Fatal("expected an error for a zero size")
+	}
+
+	if err := n.resizeContainerStorage(sandbox, *container, 1024); err == nil {
+		t.Fatal("noop agent should not support resizing guest storage")
+	}
+}
+
+func TestNoopAgentCapabilitiesAllUnsupported(t *testing.T) {
+	n := &noopAgent{}
+	caps := n.capabilities()
+
+	if caps.isBlockDeviceSupported() {
+		t.Fatal()
+	}
+
+	if caps.isBlockDeviceHotplugSupported() {
+		t.Fatal()
+	}
+
+	if caps.isMemoryBalloonSupported() {
+		t.Fatal()
+	}
+
+	if caps.isCPUHotplugSupported() {
+		t.Fatal()
+	}
+
+	if caps.isMemoryHotplugSupported() {
+		t.Fatal()
+	}
+
+	if caps.isOnlineCPUMemSupported() {
+		t.Fatal()
+	}
+}
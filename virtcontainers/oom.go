@@ -0,0 +1,25 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "fmt"
+
+// minOOMScoreAdj and maxOOMScoreAdj match the range accepted by the
+// kernel for /proc/<pid>/oom_score_adj.
+const (
+	minOOMScoreAdj = -1000
+	maxOOMScoreAdj = 1000
+)
+
+// validateOOMScoreAdj checks that adj is within the range the kernel
+// accepts for a process' oom_score_adj.
+func validateOOMScoreAdj(adj int) error {
+	if adj < minOOMScoreAdj || adj > maxOOMScoreAdj {
+		return fmt.Errorf("oom score adjustment %d is out of range [%d, %d]", adj, minOOMScoreAdj, maxOOMScoreAdj)
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "testing"
+
+func TestValidateOOMScoreAdjWithinRange(t *testing.T) {
+	for _, adj := range []int{-1000, -1, 0, 1, 1000} {
+		if err := validateOOMScoreAdj(adj); err != nil {
+			t.Fatalf("expected adj %d to be valid: %v", adj, err)
+		}
+	}
+}
+
+func TestValidateOOMScoreAdjOutOfRange(t *testing.T) {
+	for _, adj := range []int{-1001, 1001} {
+		if err := validateOOMScoreAdj(adj); err == nil {
+			t.Fatalf("expected adj %d to be rejected", adj)
+		}
+	}
+}
+
+// fakeOOMScoreAdjAgent behaves like noopAgent except it records whatever
+// oom_score_adj it is asked to set, for testing that Container.setOOMScoreAdj
+// passes its arguments through correctly.
+type fakeOOMScoreAdjAgent struct {
+	noopAgent
+	processID string
+	adj       int
+}
+
+func (a *fakeOOMScoreAdjAgent) setOOMScoreAdj(c *Container, processID string, adj int) error {
+	if err := validateOOMScoreAdj(adj); err != nil {
+		return err
+	}
+
+	a.processID = processID
+	a.adj = adj
+
+	return nil
+}
+
+func TestFakeAgentCapturesOOMScoreAdj(t *testing.T) {
+	agent := &fakeOOMScoreAdjAgent{}
+	sandbox := &Sandbox{agent: agent}
+	c := &Container{sandbox: sandbox, process: Process{Token: "foo"}}
+	c.state.State = StateRunning
+
+	if err := c.setOOMScoreAdj("foo", 500); err != nil {
+		t.Fatal(err)
+	}
+
+	if agent.processID != "foo" || agent.adj != 500 {
+		t.Fatalf("expected the fake agent to capture the oom_score_adj, got %+v", agent)
+	}
+}
+
+func TestFakeAgentRejectsOutOfRangeOOMScoreAdj(t *testing.T) {
+	agent := &fakeOOMScoreAdjAgent{}
+	sandbox := &Sandbox{agent: agent}
+	c := &Container{sandbox: sandbox, process: Process{Token: "foo"}}
+	c.state.State = StateRunning
+
+	if err := c.setOOMScoreAdj("foo", 1001); err == nil {
+		t.Fatal("expected an out-of-range oom_score_adj to be rejected")
+	}
+}
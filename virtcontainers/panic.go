@@ -0,0 +1,102 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"time"
+)
+
+// GuestPanicResult reports the outcome of checking whether a running
+// sandbox's guest kernel has panicked.
+type GuestPanicResult struct {
+	// Panicked is true when a guest kernel panic was detected.
+	Panicked bool
+
+	// Reason is a human-readable description of how the panic was
+	// detected and, when available, the reason the VMM reported. It is
+	// empty when Panicked is false.
+	Reason string
+}
+
+// detectGuestPanic checks whether sandbox s's guest kernel appears to
+// have panicked. It first asks the hypervisor directly, e.g. whether a
+// pvpanic device reported a GUEST_PANICKED event. Failing that, it
+// falls back to a heuristic: if the VMM process is alive but the agent
+// inside it has gone silent, a kernel panic is one of the few ways that
+// happens without taking the VMM down too.
+func detectGuestPanic(s *Sandbox) GuestPanicResult {
+	if panicked, reason := s.hypervisor.checkGuestPanic(); panicked {
+		return GuestPanicResult{Panicked: true, Reason: reason}
+	}
+
+	if !checkHypervisorHealth(s).Healthy {
+		// The VMM itself is gone; that's reconcileSandboxState's job,
+		// not a guest panic.
+		return GuestPanicResult{}
+	}
+
+	agentHealth := checkAgentHealth(s)
+	if agentHealth.Healthy {
+		return GuestPanicResult{}
+	}
+
+	return GuestPanicResult{
+		Panicked: true,
+		Reason:   fmt.Sprintf("agent unreachable while VMM is alive: %s", agentHealth.Message),
+	}
+}
+
+// reconcileGuestPanic checks sandbox s for a guest kernel panic and, if
+// one is detected, transitions its persisted state to StatePanicked and
+// records the reason. It is a no-op for a sandbox that isn't running.
+func reconcileGuestPanic(s *Sandbox) (GuestPanicResult, error) {
+	if s.state.State != StateRunning {
+		return GuestPanicResult{}, nil
+	}
+
+	result := detectGuestPanic(s)
+	if !result.Panicked {
+		return result, nil
+	}
+
+	s.state.PanicReason = result.Reason
+	s.state.PanicTime = time.Now()
+
+	if err := s.setSandboxState(StatePanicked); err != nil {
+		return result, fmt.Errorf("unable to record guest panic for sandbox %s: %v", s.id, err)
+	}
+
+	return result, nil
+}
+
+// CheckGuestPanic is the virtcontainers entry point for checking
+// sandboxID's guest kernel for a panic. If one is found, the sandbox is
+// transitioned to StatePanicked and the reason is persisted alongside
+// its state, where it is visible via StatusSandbox.
+func CheckGuestPanic(sandboxID string) (*GuestPanicResult, error) {
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	lockFile, err := rwLockSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockSandbox(lockFile)
+
+	s, err := fetchSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := reconcileGuestPanic(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
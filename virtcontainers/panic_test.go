@@ -0,0 +1,154 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectGuestPanicNotRunningIsNoop(t *testing.T) {
+	s := &Sandbox{state: State{State: StateStopped}}
+
+	result, err := reconcileGuestPanic(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Panicked {
+		t.Fatal("expected no panic to be reported for a sandbox that isn't running")
+	}
+}
+
+func TestDetectGuestPanicHealthyIsNotPanicked(t *testing.T) {
+	s := &Sandbox{
+		state:      State{State: StateRunning},
+		agent:      &fakeHealthAgent{},
+		hypervisor: &fakeHealthHypervisor{reportedPid: os.Getpid()},
+	}
+
+	result, err := reconcileGuestPanic(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Panicked {
+		t.Fatalf("expected no panic for a healthy sandbox, got %+v", result)
+	}
+
+	if s.state.State != StateRunning {
+		t.Fatalf("expected persisted state to remain running, got %s", s.state.State)
+	}
+}
+
+func TestDetectGuestPanicDeadVMMIsNotPanicked(t *testing.T) {
+	// An unreachable agent with a dead VMM is a stopped sandbox, not a
+	// panicked one: reconcileSandboxState handles that case.
+	s := &Sandbox{
+		state:      State{State: StateRunning},
+		agent:      &fakeHealthAgent{checkErr: fmt.Errorf("agent unreachable")},
+		hypervisor: &fakeHealthHypervisor{reportedPid: 1<<31 - 1},
+	}
+
+	result, err := reconcileGuestPanic(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Panicked {
+		t.Fatalf("expected a dead VMM not to be reported as a guest panic, got %+v", result)
+	}
+}
+
+func TestDetectGuestPanicHypervisorSignalTransitionsToPanicked(t *testing.T) {
+	sandboxID := "guest-panic-hypervisor-signal"
+
+	path := filepath.Join(runStoragePath, sandboxID)
+	if err := os.MkdirAll(path, dirMode); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	fakeHv := &fakeHealthHypervisor{reportedPid: os.Getpid()}
+	fakeHv.guestPanicked = true
+	fakeHv.guestPanicReason = "Kernel panic - not syncing: Fatal exception"
+
+	s := &Sandbox{
+		id:         sandboxID,
+		state:      State{State: StateRunning},
+		agent:      &fakeHealthAgent{},
+		hypervisor: fakeHv,
+		storage:    &filesystem{},
+	}
+
+	result, err := reconcileGuestPanic(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Panicked {
+		t.Fatal("expected a hypervisor-reported panic to be detected")
+	}
+
+	if result.Reason != fakeHv.guestPanicReason {
+		t.Fatalf("expected reason %q, got %q", fakeHv.guestPanicReason, result.Reason)
+	}
+
+	if s.state.State != StatePanicked {
+		t.Fatalf("expected persisted state to become panicked, got %s", s.state.State)
+	}
+
+	if s.state.PanicReason != fakeHv.guestPanicReason {
+		t.Fatalf("expected panic reason to be recorded, got %q", s.state.PanicReason)
+	}
+
+	if s.state.PanicTime.IsZero() {
+		t.Fatal("expected a panic time to be recorded")
+	}
+}
+
+func TestDetectGuestPanicAgentUnreachableTransitionsToPanicked(t *testing.T) {
+	sandboxID := "guest-panic-agent-unreachable"
+
+	path := filepath.Join(runStoragePath, sandboxID)
+	if err := os.MkdirAll(path, dirMode); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	s := &Sandbox{
+		id:         sandboxID,
+		state:      State{State: StateRunning},
+		agent:      &fakeHealthAgent{checkErr: fmt.Errorf("agent unreachable")},
+		hypervisor: &fakeHealthHypervisor{reportedPid: os.Getpid()},
+		storage:    &filesystem{},
+	}
+
+	result, err := reconcileGuestPanic(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Panicked {
+		t.Fatal("expected an unreachable agent with a live VMM to be detected as a guest panic")
+	}
+
+	if s.state.State != StatePanicked {
+		t.Fatalf("expected persisted state to become panicked, got %s", s.state.State)
+	}
+
+	if s.state.PanicReason == "" {
+		t.Fatal("expected a panic reason to be recorded")
+	}
+}
+
+func TestCheckGuestPanicNeedsSandboxID(t *testing.T) {
+	if _, err := CheckGuestPanic(""); err == nil {
+		t.Fatal("expected an error for an empty sandbox ID")
+	}
+}
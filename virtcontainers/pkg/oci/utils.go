@@ -112,6 +112,10 @@ type RuntimeConfig struct {
 	//Determines how the VM should be connected to the
 	//the container network interface
 	InterNetworkModel vc.NetInterworkingModel
+
+	// MaxConcurrentLaunches caps how many sandbox VMs may be launched at
+	// once on this host. Zero means unlimited.
+	MaxConcurrentLaunches uint32
 }
 
 // AddKernelParam allows the addition of new kernel parameters to an existing
@@ -354,6 +358,12 @@ func networkConfig(ocispec CompatOCISpec, config RuntimeConfig) (vc.NetworkConfi
 	}
 	netConf.InterworkingModel = config.InterNetworkModel
 
+	ingressBandwidth, _ := strconv.ParseUint(ocispec.Annotations[vcAnnotations.IngressBandwidthKey], 10, 64)
+	netConf.IngressBandwidth = ingressBandwidth
+
+	egressBandwidth, _ := strconv.ParseUint(ocispec.Annotations[vcAnnotations.EgressBandwidthKey], 10, 64)
+	netConf.EgressBandwidth = egressBandwidth
+
 	return netConf, nil
 }
 
@@ -594,18 +604,42 @@ func ContainerConfig(ocispec CompatOCISpec, bundlePath, cid, console string, det
 		}
 	}
 
+	// ForceReadonlyRootfs lets a hardened deployment require a read-only
+	// rootfs regardless of what the OCI spec itself requests.
+	forceReadonlyRootfs, _ := strconv.ParseBool(ocispec.Annotations[vcAnnotations.ForceReadonlyRootfsKey])
+
+	var seccompProfile []byte
+	if ocispec.Linux.Seccomp != nil {
+		var err error
+		if seccompProfile, err = json.Marshal(ocispec.Linux.Seccomp); err != nil {
+			return vc.ContainerConfig{}, err
+		}
+	}
+
+	var rlimits []vc.Rlimit
+	for _, r := range ocispec.Process.Rlimits {
+		rlimits = append(rlimits, vc.Rlimit{
+			Type: r.Type,
+			Hard: r.Hard,
+			Soft: r.Soft,
+		})
+	}
+
 	containerConfig := vc.ContainerConfig{
-		ID:             cid,
-		RootFs:         rootfs,
-		ReadonlyRootfs: ocispec.Spec.Root.Readonly,
-		Cmd:            cmd,
+		ID:                  cid,
+		RootFs:              rootfs,
+		ReadonlyRootfs:      ocispec.Spec.Root.Readonly,
+		ForceReadonlyRootfs: forceReadonlyRootfs,
+		Cmd:                 cmd,
 		Annotations: map[string]string{
 			vcAnnotations.ConfigJSONKey: string(ociSpecJSON),
 			vcAnnotations.BundlePathKey: bundlePath,
 		},
-		Mounts:      containerMounts(ocispec),
-		DeviceInfos: deviceInfos,
-		Resources:   resources,
+		Mounts:         containerMounts(ocispec),
+		DeviceInfos:    deviceInfos,
+		Resources:      resources,
+		SeccompProfile: seccompProfile,
+		Rlimits:        rlimits,
 	}
 
 	cType, err := ocispec.ContainerType()
@@ -53,6 +53,15 @@ func (m *VCMock) DeleteSandbox(sandboxID string) (vc.VCSandbox, error) {
 	return nil, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
 }
 
+// ForceDeleteSandbox implements the VC function of the same name.
+func (m *VCMock) ForceDeleteSandbox(sandboxID string) (vc.VCSandbox, error) {
+	if m.ForceDeleteSandboxFunc != nil {
+		return m.ForceDeleteSandboxFunc(sandboxID)
+	}
+
+	return nil, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
+}
+
 // FetchSandbox implements the VC function of the same name.
 func (m *VCMock) FetchSandbox(sandboxID string) (vc.VCSandbox, error) {
 	if m.FetchSandboxFunc != nil {
@@ -107,6 +116,15 @@ func (m *VCMock) StatusSandbox(sandboxID string) (vc.SandboxStatus, error) {
 	return vc.SandboxStatus{}, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
 }
 
+// StatsSandbox implements the VC function of the same name.
+func (m *VCMock) StatsSandbox(sandboxID string) (vc.SandboxStats, error) {
+	if m.StatsSandboxFunc != nil {
+		return m.StatsSandboxFunc(sandboxID)
+	}
+
+	return vc.SandboxStats{}, fmt.Errorf("%s: %s (%+v): sandboxID: %v", mockErrorPrefix, getSelf(), m, sandboxID)
+}
+
 // PauseSandbox implements the VC function of the same name.
 func (m *VCMock) PauseSandbox(sandboxID string) (vc.VCSandbox, error) {
 	if m.PauseSandboxFunc != nil {
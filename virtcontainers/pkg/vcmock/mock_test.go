@@ -316,6 +316,32 @@ func TestVCMockStatusSandbox(t *testing.T) {
 	assert.True(IsMockError(err))
 }
 
+func TestVCMockStatsSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	m := &VCMock{}
+	assert.Nil(m.StatsSandboxFunc)
+
+	_, err := m.StatsSandbox(testSandboxID)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+
+	m.StatsSandboxFunc = func(sandboxID string) (vc.SandboxStats, error) {
+		return vc.SandboxStats{}, nil
+	}
+
+	stats, err := m.StatsSandbox(testSandboxID)
+	assert.NoError(err)
+	assert.Equal(stats, vc.SandboxStats{})
+
+	// reset
+	m.StatsSandboxFunc = nil
+
+	_, err = m.StatsSandbox(testSandboxID)
+	assert.Error(err)
+	assert.True(IsMockError(err))
+}
+
 func TestVCMockStopSandbox(t *testing.T) {
 	assert := assert.New(t)
 
@@ -104,6 +104,11 @@ func (s *Sandbox) Status() vc.SandboxStatus {
 	return vc.SandboxStatus{}
 }
 
+// Stats implements the VCSandbox function of the same name.
+func (s *Sandbox) Stats() (vc.SandboxStats, error) {
+	return vc.SandboxStats{}, nil
+}
+
 // EnterContainer implements the VCSandbox function of the same name.
 func (s *Sandbox) EnterContainer(containerID string, cmd vc.Cmd) (vc.VCContainer, *vc.Process, error) {
 	return &Container{}, &vc.Process{}, nil
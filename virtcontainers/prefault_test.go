@@ -0,0 +1,78 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeGuestMemoryPrefaulter struct {
+	called  bool
+	sizeMiB uint32
+	err     error
+}
+
+func (f *fakeGuestMemoryPrefaulter) prefault(sizeMiB uint32) error {
+	f.called = true
+	f.sizeMiB = sizeMiB
+	return f.err
+}
+
+func TestPrefaultGuestMemoryRunsWhenEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				PrefaultMemory: true,
+				DefaultMemSz:   2048,
+			},
+		},
+	}
+
+	p := &fakeGuestMemoryPrefaulter{}
+	assert.NoError(s.prefaultGuestMemory(p))
+	assert.True(p.called)
+	assert.Equal(uint32(2048), p.sizeMiB)
+}
+
+func TestPrefaultGuestMemorySkippedWhenDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				PrefaultMemory: false,
+				DefaultMemSz:   2048,
+			},
+		},
+	}
+
+	p := &fakeGuestMemoryPrefaulter{}
+	assert.NoError(s.prefaultGuestMemory(p))
+	assert.False(p.called)
+}
+
+func TestPrefaultGuestMemoryPropagatesError(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				PrefaultMemory: true,
+				DefaultMemSz:   2048,
+			},
+		},
+	}
+
+	p := &fakeGuestMemoryPrefaulter{err: fmt.Errorf("failed to pin pages")}
+	err := s.prefaultGuestMemory(p)
+	assert.Error(err)
+	assert.True(p.called)
+}
@@ -41,6 +41,16 @@ type QemuState struct {
 	// HotpluggedCPUs is the list of CPUs that were hot-added
 	HotpluggedVCPUs []CPUDevice
 	UUID            string
+	// LaunchArgs is the qemu command line used to launch the sandbox's VM,
+	// with any secret-bearing arguments redacted. It is recorded for
+	// auditing and bug reports.
+	LaunchArgs []string
+	// BalloonTargetMiB is the most recently requested virtio-balloon
+	// target size, in MiB, set through setBalloonTarget.
+	BalloonTargetMiB uint32
+	// LaunchTiming is the per-phase timing breakdown of this sandbox's
+	// launch, recorded through recordLaunchPhase.
+	LaunchTiming LaunchTiming
 }
 
 // qemu is an Hypervisor interface implementation for the Linux qemu hypervisor.
@@ -355,6 +365,10 @@ func (q *qemu) createSandbox(sandboxConfig SandboxConfig) error {
 
 	devices = q.arch.appendConsole(devices, console)
 
+	if q.config.EnableGuestConsoleLog {
+		devices = q.appendConsoleLogFile(devices)
+	}
+
 	if initrdPath == "" {
 		devices, err = q.appendImage(devices)
 		if err != nil {
@@ -376,6 +390,17 @@ func (q *qemu) createSandbox(sandboxConfig SandboxConfig) error {
 	}
 
 	cpuModel := q.arch.cpuModel()
+	if q.config.CPUModel != "" {
+		cpuModel = q.config.CPUModel
+	}
+
+	for _, feature := range q.config.CPUFeatures {
+		cpuModel += ",+" + feature
+	}
+
+	if len(q.config.ExtraArgs) > 0 {
+		devices = append(devices, extraArgsDevice{args: q.config.ExtraArgs})
+	}
 
 	firmwarePath, err := sandboxConfig.HypervisorConfig.FirmwareAssetPath()
 	if err != nil {
@@ -429,7 +454,16 @@ func (q *qemu) startSandbox() error {
 		q.Logger().WithField("default-kernel-parameters", formatted).Debug()
 	}
 
+	q.state.LaunchArgs = redactLaunchArgs(q.buildLaunchArgs())
+	if err := q.sandbox.storage.storeHypervisorState(q.sandbox.id, q.state); err != nil {
+		q.Logger().WithError(err).Warn("Could not store VMM launch args")
+	}
+
 	strErr, err := govmmQemu.LaunchQemu(q.qemuConfig, newQMPLogger())
+	if logErr := q.appendVMMLog(strErr); logErr != nil {
+		q.Logger().WithError(logErr).Warn("Could not persist VMM stderr")
+	}
+
 	if err != nil {
 		return fmt.Errorf("%s", strErr)
 	}
@@ -437,6 +471,265 @@ func (q *qemu) startSandbox() error {
 	return nil
 }
 
+// vmmLogFile is the name, within a sandbox's run directory, of the file
+// its VMM's stderr is persisted to. LaunchQemu only gives us qemu's
+// stderr up to the point it daemonizes itself (see pid's comment on
+// Knobs.Daemonize), which is nonetheless where emulation warnings and
+// SEV launch errors typically show up.
+const vmmLogFile = "vmm.log"
+
+// appendVMMLog appends output, the VMM's captured stderr, to this
+// sandbox's persisted VMM log file. A launch that produced no stderr
+// output is a no-op.
+func (q *qemu) appendVMMLog(output string) error {
+	if output == "" {
+		return nil
+	}
+
+	w, err := openRotatingLogWriter(filepath.Join(q.sandbox.runPath, vmmLogFile), q.config.MaxLogSize, q.config.MaxLogFiles, 0640)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	_, err = w.Write([]byte(output))
+	return err
+}
+
+// consoleLogFile is the name, within a sandbox's run directory, of the
+// file the guest's boot console is mirrored to when
+// HypervisorConfig.EnableGuestConsoleLog is set.
+const consoleLogFile = "console.log"
+
+// loggedCharDevice wraps a govmm CharDevice to also mirror its traffic
+// to a host log file, via qemu's chardev logfile/logappend suboptions.
+// govmm's CharDevice doesn't expose those, so this appends them onto
+// the -chardev parameters it generates.
+type loggedCharDevice struct {
+	govmmQemu.CharDevice
+	logPath string
+}
+
+func (c loggedCharDevice) QemuParams(config *govmmQemu.Config) []string {
+	params := c.CharDevice.QemuParams(config)
+	if len(params) > 0 {
+		params[len(params)-1] += fmt.Sprintf(",logfile=%s,logappend=on", c.logPath)
+	}
+
+	return params
+}
+
+// extraArgsDevice renders HypervisorConfig.ExtraArgs directly onto the
+// qemu command line. It implements govmmQemu.Device purely to ride along
+// config.appendDevices(), since that is the only extension point the
+// vendored govmm snapshot exposes for arguments it has no typed Config
+// field for; it doesn't correspond to any single qemu device or object.
+type extraArgsDevice struct {
+	args []string
+}
+
+func (d extraArgsDevice) Valid() bool {
+	return len(d.args) > 0
+}
+
+func (d extraArgsDevice) QemuParams(config *govmmQemu.Config) []string {
+	return d.args
+}
+
+// appendConsoleLogFile finds the guest console chardev appendConsole
+// added to devices and wraps it in a loggedCharDevice, so the guest's
+// boot console is mirrored into this sandbox's console log file. It is
+// a no-op if the console chardev isn't present.
+func (q *qemu) appendConsoleLogFile(devices []govmmQemu.Device) []govmmQemu.Device {
+	logPath := filepath.Join(q.sandbox.runPath, consoleLogFile)
+
+	// qemu writes to logPath itself once launched, outside of our
+	// control, so rotation can only be applied here: before handing the
+	// path to qemu, rotate it if a prior run already grew it past the
+	// configured size.
+	if w, err := openRotatingLogWriter(logPath, q.config.MaxLogSize, q.config.MaxLogFiles, 0640); err != nil {
+		q.Logger().WithError(err).Warn("could not rotate console log")
+	} else {
+		w.Close()
+	}
+
+	for i, device := range devices {
+		cdev, ok := device.(govmmQemu.CharDevice)
+		if !ok || cdev.ID != consoleDeviceID {
+			continue
+		}
+
+		devices[i] = loggedCharDevice{CharDevice: cdev, logPath: logPath}
+		break
+	}
+
+	return devices
+}
+
+// buildLaunchArgs reconstructs, on a best-effort basis, the qemu command
+// line that LaunchQemu will use to launch the sandbox's VM. It is rebuilt
+// from q.qemuConfig's exported fields rather than captured verbatim from
+// LaunchQemu, since govmm assembles the final argv internally. It is good
+// enough for auditing and bug reports, which is all it is used for.
+func (q *qemu) buildLaunchArgs() []string {
+	config := q.qemuConfig
+
+	args := []string{config.Path}
+
+	if config.Machine.Type != "" {
+		machine := config.Machine.Type
+		if config.Machine.Acceleration != "" {
+			machine = fmt.Sprintf("%s,accel=%s", machine, config.Machine.Acceleration)
+		}
+		args = append(args, "-machine", machine)
+	}
+
+	if config.SMP.CPUs > 0 {
+		args = append(args, "-smp", fmt.Sprintf("%d", config.SMP.CPUs))
+	}
+
+	if config.Memory.Size != "" {
+		args = append(args, "-m", config.Memory.Size)
+	}
+
+	if config.CPUModel != "" {
+		args = append(args, "-cpu", config.CPUModel)
+	}
+
+	if config.Kernel.Path != "" {
+		args = append(args, "-kernel", config.Kernel.Path)
+	}
+
+	if config.Kernel.InitrdPath != "" {
+		args = append(args, "-initrd", config.Kernel.InitrdPath)
+	}
+
+	if config.Kernel.Params != "" {
+		args = append(args, "-append", config.Kernel.Params)
+	}
+
+	if config.Bios != "" {
+		args = append(args, "-bios", config.Bios)
+	}
+
+	for _, device := range config.Devices {
+		args = append(args, device.QemuParams(&config)...)
+	}
+
+	return args
+}
+
+// redactedArgValue replaces the value of a secret-bearing qemu argument.
+const redactedArgValue = "<redacted>"
+
+// secretArgPatterns matches qemu argument values known to carry secret
+// material, such as SEV secret injection paths.
+var secretArgPatterns = []string{"secret", "passphrase"}
+
+// redactLaunchArgs returns a copy of args with secret-bearing values
+// replaced with redactedArgValue. This covers both a flag name matching a
+// secret pattern (in which case the following argument is redacted) and a
+// comma-separated key=value pair within a single argument (qemu's usual
+// style for -object/-device parameters, e.g. "sev-guest,sev-secret-path=...").
+func redactLaunchArgs(args []string) []string {
+	redacted := make([]string, len(args))
+	copy(redacted, args)
+
+	for i, arg := range redacted {
+		redacted[i] = redactCommaSeparatedPairs(arg)
+
+		if looksLikeSecretArg(arg) && i+1 < len(redacted) {
+			redacted[i+1] = redactedArgValue
+		}
+	}
+
+	return redacted
+}
+
+// redactCommaSeparatedPairs redacts the value of any comma-separated
+// key=value pair within arg whose key looks secret-bearing.
+func redactCommaSeparatedPairs(arg string) string {
+	fields := strings.Split(arg, ",")
+
+	for i, field := range fields {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if looksLikeSecretArg(parts[0]) {
+			fields[i] = parts[0] + "=" + redactedArgValue
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+func looksLikeSecretArg(arg string) bool {
+	lower := strings.ToLower(arg)
+	for _, pattern := range secretArgPatterns {
+		if strings.Contains(lower, pattern) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// launchArgs returns the (redacted) command line used to launch the
+// sandbox's VM.
+func (q *qemu) launchArgs() []string {
+	return q.state.LaunchArgs
+}
+
+// setBalloonTarget requests that qemu's virtio-balloon device reclaim
+// memory down to targetMiB.
+//
+// The vendored govmm QMP client only exposes the handful of commands
+// virtcontainers already needs (device add/remove, lifecycle); it does
+// not wrap the QMP "balloon" command, so this cannot yet be driven over
+// QMP from here. The target is still validated and persisted so the
+// capability and CLI plumbing around it can be exercised and so a future
+// govmm update only needs to fill in the QMP call itself.
+func (q *qemu) setBalloonTarget(targetMiB uint32) error {
+	q.state.BalloonTargetMiB = targetMiB
+
+	if err := q.sandbox.storage.storeHypervisorState(q.sandbox.id, q.state); err != nil {
+		return err
+	}
+
+	return fmt.Errorf("setBalloonTarget: govmm's QMP client does not support the balloon command yet, target %d MiB recorded but not applied", targetMiB)
+}
+
+// pid returns the process ID of the running qemu VMM.
+//
+// qemu is launched with Knobs.Daemonize set (see createSandbox), which
+// makes qemu fork itself into the background; govmm's LaunchQemu blocks
+// until that fork happens and returns no pid at all, and the vendored
+// govmm snapshot has no -pidfile support to recover one directly. The
+// QMP socket path built for this sandbox is unique to its qemu
+// invocation, so matching it against /proc is the most reliable way
+// left to find the daemonized process.
+func (q *qemu) pid() (int, error) {
+	return findProcessByCmdlineToken(q.qmpMonitorCh.path)
+}
+
+// recordLaunchPhase adds d to the duration tracked for phase in this
+// sandbox's launch timing breakdown and persists it.
+func (q *qemu) recordLaunchPhase(phase launchPhase, d time.Duration) {
+	q.state.LaunchTiming.record(phase, d)
+
+	if err := q.sandbox.storage.storeHypervisorState(q.sandbox.id, q.state); err != nil {
+		q.Logger().WithError(err).Warn("Could not store launch timing")
+	}
+}
+
+// getLaunchTiming returns this sandbox's recorded launch timing
+// breakdown.
+func (q *qemu) getLaunchTiming() LaunchTiming {
+	return q.state.LaunchTiming
+}
+
 // waitSandbox will wait for the Sandbox's VM to be up and running.
 func (q *qemu) waitSandbox(timeout int) error {
 	defer func(qemu *qemu) {
@@ -856,6 +1149,42 @@ func (q *qemu) resumeSandbox() error {
 	return q.togglePauseSandbox(false)
 }
 
+// saveSandboxState drives a QEMU migrate-to-file of the paused VM's
+// memory and device state to path.
+//
+// SEV-encrypted VMs cannot be migrated by this method: the encrypted
+// guest memory is bound to the launch session's keys, which do not
+// survive a save/restore cycle, so QEMU's migration would either fail
+// or silently hand back unusable ciphertext.
+func (q *qemu) saveSandboxState(path string) error {
+	if q.sandbox.config.HypervisorConfig.MemEncrypt {
+		return fmt.Errorf("saveSandboxState: SEV-encrypted sandboxes do not support snapshot/restore")
+	}
+
+	return q.qmpMonitorCh.qmp.ExecuteMigrate(q.qmpMonitorCh.ctx, fmt.Sprintf("exec:cat > %s", path))
+}
+
+// restoreSandboxState drives a QEMU incoming migration, loading the
+// memory and device state previously saved by saveSandboxState from
+// path into this not-yet-started VM.
+func (q *qemu) restoreSandboxState(path string) error {
+	if q.sandbox.config.HypervisorConfig.MemEncrypt {
+		return fmt.Errorf("restoreSandboxState: SEV-encrypted sandboxes do not support snapshot/restore")
+	}
+
+	return q.qmpMonitorCh.qmp.ExecuteMigrationIncoming(q.qmpMonitorCh.ctx, fmt.Sprintf("exec:cat %s", path))
+}
+
+// checkGuestPanic always reports no panic: observing QEMU's
+// GUEST_PANICKED QMP event (emitted by the pvpanic device) would
+// require a persistent QMP event channel, which this driver does not
+// currently keep open outside of individual command calls. Guest
+// panics are instead caught by detectGuestPanic's agent-unreachable
+// heuristic.
+func (q *qemu) checkGuestPanic() (bool, string) {
+	return false, ""
+}
+
 // addDevice will add extra devices to Qemu command line.
 func (q *qemu) addDevice(devInfo interface{}, devType deviceType) error {
 	switch devType {
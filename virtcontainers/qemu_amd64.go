@@ -106,6 +106,13 @@ func (q *qemuAmd64) capabilities() capabilities {
 		caps.setBlockDeviceHotplugSupport()
 	}
 
+	// virtio-balloon relies on the guest exposing page state to the
+	// host, which SEV's memory encryption specifically hides. Advertise
+	// ballooning only for non-encrypted guests.
+	if !q.memEncrypt {
+		caps.setMemoryBalloonSupport()
+	}
+
 	return caps
 }
 
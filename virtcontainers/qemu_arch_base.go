@@ -116,6 +116,11 @@ const (
 	defaultBridgeBus        = "pcie.0"
 	maxDevIDSize            = 31
 	defaultMsize9p          = 8192
+
+	// consoleDeviceID is the chardev ID appendConsole gives the guest's
+	// boot console, used by appendConsoleLogFile to find it again when
+	// mirroring it to a host log file.
+	consoleDeviceID = "charconsole0"
 )
 
 // This is the PCI start address assigned to the first bridge that
@@ -226,6 +231,7 @@ func (q *qemuArchBase) kernelParameters(debug bool) []Param {
 func (q *qemuArchBase) capabilities() capabilities {
 	var caps capabilities
 	caps.setBlockDeviceHotplugSupport()
+	caps.setMemoryBalloonSupport()
 	return caps
 }
 
@@ -296,7 +302,7 @@ func (q *qemuArchBase) appendConsole(devices []govmmQemu.Device, path string) []
 		Driver:   govmmQemu.Console,
 		Backend:  govmmQemu.Socket,
 		DeviceID: "console0",
-		ID:       "charconsole0",
+		ID:       consoleDeviceID,
 		Path:     path,
 	}
 
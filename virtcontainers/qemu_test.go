@@ -265,6 +265,44 @@ func TestQemuGetSandboxConsole(t *testing.T) {
 	}
 }
 
+func TestQemuAppendConsoleLogFile(t *testing.T) {
+	assert := assert.New(t)
+
+	q := &qemu{
+		sandbox: &Sandbox{runPath: "/run/vc/sbs/testSandboxID"},
+	}
+
+	devices := []govmmQemu.Device{
+		govmmQemu.SerialDevice{ID: "serial0"},
+		govmmQemu.CharDevice{ID: consoleDeviceID, Path: "/run/vc/sbs/testSandboxID/console.sock"},
+	}
+
+	devices = q.appendConsoleLogFile(devices)
+
+	logged, ok := devices[1].(loggedCharDevice)
+	assert.True(ok, "console chardev should have been wrapped in a loggedCharDevice")
+	assert.Equal(consoleDeviceID, logged.ID)
+	assert.Equal(filepath.Join(q.sandbox.runPath, consoleLogFile), logged.logPath)
+
+	params := logged.QemuParams(&govmmQemu.Config{})
+	assert.Contains(params[len(params)-1], fmt.Sprintf("logfile=%s,logappend=on", logged.logPath))
+}
+
+func TestQemuAppendConsoleLogFileNoConsole(t *testing.T) {
+	assert := assert.New(t)
+
+	q := &qemu{
+		sandbox: &Sandbox{runPath: "/run/vc/sbs/testSandboxID"},
+	}
+
+	devices := []govmmQemu.Device{
+		govmmQemu.SerialDevice{ID: "serial0"},
+	}
+
+	result := q.appendConsoleLogFile(devices)
+	assert.Equal(devices, result)
+}
+
 func TestQemuCapabilities(t *testing.T) {
 	q := &qemu{
 		arch: &qemuArchBase{},
@@ -276,6 +314,72 @@ func TestQemuCapabilities(t *testing.T) {
 	}
 }
 
+func TestQemuBuildLaunchArgs(t *testing.T) {
+	q := &qemu{
+		qemuConfig: govmmQemu.Config{
+			Path: "/usr/bin/qemu-system-x86_64",
+			Machine: govmmQemu.Machine{
+				Type:         "q35",
+				Acceleration: "kvm",
+			},
+			SMP:    govmmQemu.SMP{CPUs: 2},
+			Memory: govmmQemu.Memory{Size: "2048M"},
+			Kernel: govmmQemu.Kernel{
+				Path:       "/kernel",
+				InitrdPath: "/initrd",
+				Params:     "foo=bar",
+			},
+		},
+	}
+
+	args := q.buildLaunchArgs()
+
+	expected := []string{
+		"/usr/bin/qemu-system-x86_64",
+		"-machine", "q35,accel=kvm",
+		"-smp", "2",
+		"-m", "2048M",
+		"-kernel", "/kernel",
+		"-initrd", "/initrd",
+		"-append", "foo=bar",
+	}
+
+	if !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Got %v\nExpecting %v", args, expected)
+	}
+}
+
+func TestQemuRedactLaunchArgsSecretArg(t *testing.T) {
+	args := []string{
+		"-object", "sev-guest,id=sev0,sev-secret-path=/run/vc/sev/secret",
+		"-object", "memory-backend-file,id=mem0",
+	}
+
+	redacted := redactLaunchArgs(args)
+
+	expected := []string{
+		"-object", "sev-guest,id=sev0,sev-secret-path=<redacted>",
+		"-object", "memory-backend-file,id=mem0",
+	}
+
+	if !reflect.DeepEqual(redacted, expected) {
+		t.Fatalf("Got %v\nExpecting %v", redacted, expected)
+	}
+}
+
+func TestQemuLaunchArgsReturnsStoredState(t *testing.T) {
+	q := &qemu{
+		state: QemuState{
+			LaunchArgs: []string{"/usr/bin/qemu-system-x86_64", "-m", "2048M"},
+		},
+	}
+
+	args := q.launchArgs()
+	if !reflect.DeepEqual(args, q.state.LaunchArgs) {
+		t.Fatalf("Got %v\nExpecting %v", args, q.state.LaunchArgs)
+	}
+}
+
 func TestQemuQemuPath(t *testing.T) {
 	assert := assert.New(t)
 
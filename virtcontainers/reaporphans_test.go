@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "testing"
+
+// fakeOrphanReaperAgent behaves like noopAgent except it simulates
+// finding and killing orphaned guest processes for whichever container
+// it is asked about, reporting how many it reaped.
+type fakeOrphanReaperAgent struct {
+	noopAgent
+	containerID string
+	reaped      int
+}
+
+func (a *fakeOrphanReaperAgent) reapOrphans(sandbox *Sandbox, c Container) (int, error) {
+	a.containerID = c.id
+	return a.reaped, nil
+}
+
+func TestContainerReapOrphansReturnsCountFromAgent(t *testing.T) {
+	agent := &fakeOrphanReaperAgent{reaped: 3}
+	sandbox := &Sandbox{agent: agent}
+	c := &Container{sandbox: sandbox, id: "container1"}
+
+	reaped, err := c.reapOrphans()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reaped != 3 {
+		t.Fatalf("expected 3 orphans reaped, got %d", reaped)
+	}
+
+	if agent.containerID != "container1" {
+		t.Fatalf("expected the agent to be asked about container1, got %v", agent.containerID)
+	}
+}
+
+func TestContainerReapOrphansNoneFound(t *testing.T) {
+	agent := &fakeOrphanReaperAgent{reaped: 0}
+	sandbox := &Sandbox{agent: agent}
+	c := &Container{sandbox: sandbox, id: "container2"}
+
+	reaped, err := c.reapOrphans()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reaped != 0 {
+		t.Fatalf("expected 0 orphans reaped, got %d", reaped)
+	}
+}
+
+func TestNoopAgentReapOrphansErrors(t *testing.T) {
+	agent := &noopAgent{}
+	sandbox := &Sandbox{agent: agent}
+	c := Container{sandbox: sandbox, id: "container3"}
+
+	if _, err := agent.reapOrphans(sandbox, c); err == nil {
+		t.Fatal("expected the noop agent to error on reapOrphans")
+	}
+}
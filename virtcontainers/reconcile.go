@@ -0,0 +1,110 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"time"
+)
+
+// SandboxReconcileResult reports the outcome of reconciling a sandbox's
+// persisted state against the real state of its VMM and agent.
+type SandboxReconcileResult struct {
+	// Consistent is true when the persisted state already matched
+	// reality and nothing needed to change.
+	Consistent bool
+
+	// Transitioned is true when the persisted state was moved to
+	// StateStopped to reflect a VMM that was actually found to be gone.
+	// It is never true when DryRun is set.
+	Transitioned bool
+
+	// DryRun is true when the check was performed without persisting
+	// any change.
+	DryRun bool
+
+	// AgentReachable reports whether the agent responded to a check()
+	// call. It is informational only: an unreachable agent alone does
+	// not trigger a state transition, since the VMM may simply be slow
+	// to answer.
+	AgentReachable bool
+
+	// Message is a human-readable summary of what reconciliation found.
+	Message string
+}
+
+// reconcileSandboxState compares sandbox s's persisted state against the
+// real liveness of its VMM and the reachability of its agent. If the
+// persisted state says the sandbox is running but the VMM process is
+// actually gone, it transitions the persisted state to StateStopped and
+// records why, unless dryRun is set, in which case it only reports what
+// it would have done.
+func reconcileSandboxState(s *Sandbox, dryRun bool) (*SandboxReconcileResult, error) {
+	if s.state.State != StateRunning {
+		return &SandboxReconcileResult{
+			Consistent: true,
+			Message:    fmt.Sprintf("sandbox is in state %q, nothing to reconcile", s.state.State),
+		}, nil
+	}
+
+	agentHealth := checkAgentHealth(s)
+	hypervisorHealth := checkHypervisorHealth(s)
+
+	if hypervisorHealth.Healthy {
+		return &SandboxReconcileResult{
+			Consistent:     true,
+			AgentReachable: agentHealth.Healthy,
+			Message:        "VMM is alive and persisted state matches reality",
+		}, nil
+	}
+
+	reason := fmt.Sprintf("VMM not running: %s", hypervisorHealth.Message)
+
+	if dryRun {
+		return &SandboxReconcileResult{
+			DryRun:         true,
+			AgentReachable: agentHealth.Healthy,
+			Message:        fmt.Sprintf("would transition sandbox %s to stopped: %s", s.id, reason),
+		}, nil
+	}
+
+	s.state.ExitReason = reason
+	s.state.ExitTime = time.Now()
+
+	if err := s.setSandboxState(StateStopped); err != nil {
+		return nil, fmt.Errorf("unable to reconcile sandbox %s: %v", s.id, err)
+	}
+
+	return &SandboxReconcileResult{
+		Transitioned:   true,
+		AgentReachable: agentHealth.Healthy,
+		Message:        fmt.Sprintf("transitioned sandbox %s to stopped: %s", s.id, reason),
+	}, nil
+}
+
+// ReconcileSandboxState is the virtcontainers entry point for
+// force-reconciling sandboxID's persisted state with the real state of
+// its VMM and agent, after e.g. a host crash may have left stale
+// "running" state behind. With dryRun set, it reports what it would do
+// without persisting any change.
+func ReconcileSandboxState(sandboxID string, dryRun bool) (*SandboxReconcileResult, error) {
+	if sandboxID == "" {
+		return nil, errNeedSandboxID
+	}
+
+	lockFile, err := rwLockSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlockSandbox(lockFile)
+
+	s, err := fetchSandbox(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	return reconcileSandboxState(s, dryRun)
+}
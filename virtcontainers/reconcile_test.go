@@ -0,0 +1,139 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReconcileSandboxStateNotRunningIsConsistent(t *testing.T) {
+	s := &Sandbox{state: State{State: StateStopped}}
+
+	result, err := reconcileSandboxState(s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Consistent {
+		t.Fatalf("expected a non-running sandbox to be reported consistent, got %+v", result)
+	}
+
+	if result.Transitioned {
+		t.Fatal("expected no state transition for a sandbox that isn't running")
+	}
+}
+
+func TestReconcileSandboxStateHealthyVMMIsConsistent(t *testing.T) {
+	s := &Sandbox{
+		state:      State{State: StateRunning},
+		agent:      &fakeHealthAgent{},
+		hypervisor: &fakeHealthHypervisor{reportedPid: os.Getpid()},
+	}
+
+	result, err := reconcileSandboxState(s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.Consistent {
+		t.Fatalf("expected a healthy VMM to be reported consistent, got %+v", result)
+	}
+
+	if result.Transitioned {
+		t.Fatal("expected no state transition for a healthy VMM")
+	}
+
+	if s.state.State != StateRunning {
+		t.Fatalf("expected persisted state to remain running, got %s", s.state.State)
+	}
+}
+
+func TestReconcileSandboxStateDeadVMMTransitionsToStopped(t *testing.T) {
+	sandboxID := "reconcile-dead-vmm"
+
+	path := filepath.Join(runStoragePath, sandboxID)
+	if err := os.MkdirAll(path, dirMode); err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+
+	s := &Sandbox{
+		id:         sandboxID,
+		state:      State{State: StateRunning},
+		agent:      &fakeHealthAgent{checkErr: fmt.Errorf("agent unreachable")},
+		hypervisor: &fakeHealthHypervisor{reportedPid: 1<<31 - 1},
+		storage:    &filesystem{},
+	}
+
+	result, err := reconcileSandboxState(s, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Consistent {
+		t.Fatal("expected a dead VMM to be reported inconsistent")
+	}
+
+	if !result.Transitioned {
+		t.Fatalf("expected a state transition for a dead VMM, got %+v", result)
+	}
+
+	if result.AgentReachable {
+		t.Fatal("expected AgentReachable to be false when the agent check fails")
+	}
+
+	if s.state.State != StateStopped {
+		t.Fatalf("expected persisted state to become stopped, got %s", s.state.State)
+	}
+
+	if s.state.ExitReason == "" {
+		t.Fatal("expected an exit reason to be recorded")
+	}
+
+	if s.state.ExitTime.IsZero() {
+		t.Fatal("expected an exit time to be recorded")
+	}
+}
+
+func TestReconcileSandboxStateDeadVMMDryRunDoesNotPersist(t *testing.T) {
+	s := &Sandbox{
+		id:         "reconcile-dead-vmm-dry-run",
+		state:      State{State: StateRunning},
+		agent:      &fakeHealthAgent{},
+		hypervisor: &fakeHealthHypervisor{reportedPid: 1<<31 - 1},
+		storage:    &filesystem{},
+	}
+
+	result, err := reconcileSandboxState(s, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !result.DryRun {
+		t.Fatal("expected DryRun to be set")
+	}
+
+	if result.Transitioned {
+		t.Fatal("expected no real transition in dry-run mode")
+	}
+
+	if s.state.State != StateRunning {
+		t.Fatalf("expected persisted state to be left untouched by dry-run, got %s", s.state.State)
+	}
+
+	if s.state.ExitReason != "" {
+		t.Fatal("expected no exit reason to be recorded in dry-run mode")
+	}
+}
+
+func TestReconcileSandboxStateNeedsSandboxID(t *testing.T) {
+	if _, err := ReconcileSandboxState("", false); err == nil {
+		t.Fatal("expected an error for an empty sandbox ID")
+	}
+}
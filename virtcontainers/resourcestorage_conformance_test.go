@@ -0,0 +1,314 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/device/drivers"
+)
+
+// RunStorageConformance exercises every resourceStorage interface method,
+// including error cases and the TypedDevice round-trip, against a fresh
+// instance returned by newStorage. Every resourceStorage implementation
+// should pass this unchanged, so a behavioral difference between backends
+// shows up here instead of as a one-off bug report. Callers are
+// responsible for pointing their backend's storage location (e.g.
+// configStoragePath/runStoragePath for filesystem, boltDBPath for
+// boltStorage) at a scratch location before calling this, and restoring
+// it afterwards; see TestFilesystemStorageConformance and
+// TestBoltStorageConformance.
+func RunStorageConformance(t *testing.T, newStorage func() resourceStorage) {
+	storage := newStorage()
+
+	t.Run("SandboxResourceRoundTrip", func(t *testing.T) {
+		testStorageSandboxResourceRoundTrip(t, storage, "conformance-sandbox-resource")
+	})
+
+	t.Run("ContainerResourceRoundTrip", func(t *testing.T) {
+		testStorageContainerResourceRoundTrip(t, storage, "conformance-container-resource")
+	})
+
+	t.Run("TypedDeviceRoundTrip", func(t *testing.T) {
+		testStorageTypedDeviceRoundTrip(t, storage, "conformance-device-roundtrip")
+	})
+
+	t.Run("SentinelErrors", func(t *testing.T) {
+		testStorageSentinelErrors(t, storage, "conformance-sentinel-errors")
+	})
+
+	t.Run("DeleteResources", func(t *testing.T) {
+		testStorageDeleteResources(t, storage, "conformance-delete-resources")
+	})
+
+	t.Run("ListSandboxes", func(t *testing.T) {
+		testStorageListSandboxes(t, storage, "conformance-list-sandboxes")
+	})
+
+	t.Run("FetchSandboxContainers", func(t *testing.T) {
+		testStorageFetchSandboxContainers(t, storage, "conformance-fetch-sandbox-containers")
+	})
+}
+
+func conformanceSandbox(storage resourceStorage, sandboxID string) *Sandbox {
+	return &Sandbox{
+		id:         sandboxID,
+		storage:    storage,
+		containers: []*Container{{id: testContainerID}},
+	}
+}
+
+func testStorageSandboxResourceRoundTrip(t *testing.T, storage resourceStorage, sandboxID string) {
+	if err := storage.createAllResources(conformanceSandbox(storage, sandboxID)); err != nil {
+		t.Fatalf("createAllResources failed: %v", err)
+	}
+
+	config := SandboxConfig{ID: sandboxID, Hostname: "conformance"}
+	if err := storage.storeSandboxResource(sandboxID, configFileType, config); err != nil {
+		t.Fatalf("storeSandboxResource(config) failed: %v", err)
+	}
+
+	fetchedConfig, err := storage.fetchSandboxConfig(sandboxID)
+	if err != nil {
+		t.Fatalf("fetchSandboxConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(config, fetchedConfig) {
+		t.Fatalf("fetched config %+v does not match stored config %+v", fetchedConfig, config)
+	}
+
+	state := State{State: StateReady, Pid: 42}
+	if err := storage.storeSandboxResource(sandboxID, stateFileType, state); err != nil {
+		t.Fatalf("storeSandboxResource(state) failed: %v", err)
+	}
+
+	fetchedState, err := storage.fetchSandboxState(sandboxID)
+	if err != nil {
+		t.Fatalf("fetchSandboxState failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(state, fetchedState) {
+		t.Fatalf("fetched state %+v does not match stored state %+v", fetchedState, state)
+	}
+
+	networkNS := NetworkNamespace{NetNsPath: "/some/netns/path"}
+	if err := storage.storeSandboxNetwork(sandboxID, networkNS); err != nil {
+		t.Fatalf("storeSandboxNetwork failed: %v", err)
+	}
+
+	fetchedNetwork, err := storage.fetchSandboxNetwork(sandboxID)
+	if err != nil {
+		t.Fatalf("fetchSandboxNetwork failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(networkNS, fetchedNetwork) {
+		t.Fatalf("fetched network %+v does not match stored network %+v", fetchedNetwork, networkNS)
+	}
+}
+
+func testStorageContainerResourceRoundTrip(t *testing.T, storage resourceStorage, sandboxID string) {
+	containerID := testContainerID
+
+	if err := storage.createAllResources(conformanceSandbox(storage, sandboxID)); err != nil {
+		t.Fatalf("createAllResources failed: %v", err)
+	}
+
+	containerConfig := ContainerConfig{ID: containerID}
+	if err := storage.storeContainerResource(sandboxID, containerID, configFileType, containerConfig); err != nil {
+		t.Fatalf("storeContainerResource(config) failed: %v", err)
+	}
+
+	fetchedConfig, err := storage.fetchContainerConfig(sandboxID, containerID)
+	if err != nil {
+		t.Fatalf("fetchContainerConfig failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(containerConfig, fetchedConfig) {
+		t.Fatalf("fetched container config %+v does not match stored config %+v", fetchedConfig, containerConfig)
+	}
+
+	state := State{State: StateRunning}
+	if err := storage.storeContainerResource(sandboxID, containerID, stateFileType, state); err != nil {
+		t.Fatalf("storeContainerResource(state) failed: %v", err)
+	}
+
+	fetchedStateField, err := storage.fetchContainerStateField(sandboxID, containerID)
+	if err != nil {
+		t.Fatalf("fetchContainerStateField failed: %v", err)
+	}
+
+	if fetchedStateField != state.State {
+		t.Fatalf("fetched state field %q does not match stored state %q", fetchedStateField, state.State)
+	}
+
+	mounts := []Mount{{Source: "/src", Destination: "/dst"}}
+	if err := storage.storeContainerMounts(sandboxID, containerID, mounts); err != nil {
+		t.Fatalf("storeContainerMounts failed: %v", err)
+	}
+
+	fetchedMounts, err := storage.fetchContainerMounts(sandboxID, containerID)
+	if err != nil {
+		t.Fatalf("fetchContainerMounts failed: %v", err)
+	}
+
+	if !reflect.DeepEqual(mounts, fetchedMounts) {
+		t.Fatalf("fetched mounts %+v do not match stored mounts %+v", fetchedMounts, mounts)
+	}
+}
+
+// testStorageTypedDeviceRoundTrip verifies that storeContainerDevices and
+// fetchContainerDevices round-trip each device's concrete type via the
+// shared TypedDevice representation, and that an FdDevice is rejected.
+func testStorageTypedDeviceRoundTrip(t *testing.T, storage resourceStorage, sandboxID string) {
+	containerID := testContainerID
+
+	if err := storage.createAllResources(conformanceSandbox(storage, sandboxID)); err != nil {
+		t.Fatalf("createAllResources failed: %v", err)
+	}
+
+	devices := []api.Device{
+		drivers.NewVFIODevice(config.DeviceInfo{ID: "vfio-device"}),
+		drivers.NewBlockDevice(config.DeviceInfo{ID: "block-device"}),
+		drivers.NewGenericDevice(config.DeviceInfo{ID: "generic-device"}),
+	}
+
+	if err := storage.storeContainerDevices(sandboxID, containerID, devices); err != nil {
+		t.Fatalf("storeContainerDevices failed: %v", err)
+	}
+
+	fetched, err := storage.fetchContainerDevices(sandboxID, containerID)
+	if err != nil {
+		t.Fatalf("fetchContainerDevices failed: %v", err)
+	}
+
+	if len(fetched) != len(devices) {
+		t.Fatalf("expected %d devices, got %d", len(devices), len(fetched))
+	}
+
+	for i, d := range devices {
+		if reflect.TypeOf(d) != reflect.TypeOf(fetched[i]) {
+			t.Fatalf("device %d: expected type %T, got %T", i, d, fetched[i])
+		}
+	}
+
+	fdDevices := []api.Device{drivers.NewFdDevice(config.DeviceInfo{ID: "fd-device"})}
+	if err := storage.storeContainerDevices(sandboxID, containerID, fdDevices); err == nil {
+		t.Fatal("expected storeContainerDevices to reject an FdDevice")
+	}
+}
+
+func testStorageSentinelErrors(t *testing.T, storage resourceStorage, sandboxID string) {
+	if err := storage.storeSandboxResource("", configFileType, SandboxConfig{}); !Is(err, errNeedSandboxID) {
+		t.Fatalf("expected errNeedSandboxID, got %v", err)
+	}
+
+	if err := storage.storeContainerResource(sandboxID, "", configFileType, ContainerConfig{}); !Is(err, errNeedContainerID) {
+		t.Fatalf("expected errNeedContainerID, got %v", err)
+	}
+
+	if _, err := storage.fetchSandboxConfig(""); !Is(err, errNeedSandboxID) {
+		t.Fatalf("expected errNeedSandboxID, got %v", err)
+	}
+
+	if err := storage.storeSandboxResource(sandboxID, sandboxResource(-1), nil); !Is(err, errInvalidResource) {
+		t.Fatalf("expected errInvalidResource, got %v", err)
+	}
+}
+
+func testStorageDeleteResources(t *testing.T, storage resourceStorage, sandboxID string) {
+	containerID := testContainerID
+
+	if err := storage.createAllResources(conformanceSandbox(storage, sandboxID)); err != nil {
+		t.Fatalf("createAllResources failed: %v", err)
+	}
+
+	if err := storage.storeContainerResource(sandboxID, containerID, configFileType, ContainerConfig{ID: containerID}); err != nil {
+		t.Fatalf("storeContainerResource failed: %v", err)
+	}
+
+	if err := storage.deleteContainerResources(sandboxID, containerID, nil); err != nil {
+		t.Fatalf("deleteContainerResources failed: %v", err)
+	}
+
+	if _, err := storage.fetchContainerConfig(sandboxID, containerID); err == nil {
+		t.Fatal("expected fetchContainerConfig to fail after deleteContainerResources")
+	}
+
+	if err := storage.deleteSandboxResources(sandboxID, nil); err != nil {
+		t.Fatalf("deleteSandboxResources failed: %v", err)
+	}
+
+	if _, err := storage.fetchSandboxConfig(sandboxID); err == nil {
+		t.Fatal("expected fetchSandboxConfig to fail after deleteSandboxResources")
+	}
+}
+
+// testStorageListSandboxes verifies that a sandbox with a stored state
+// resource shows up in listSandboxes, without assuming anything about
+// what other sandboxes a shared backend instance may also be listing.
+func testStorageListSandboxes(t *testing.T, storage resourceStorage, sandboxID string) {
+	if err := storage.createAllResources(conformanceSandbox(storage, sandboxID)); err != nil {
+		t.Fatalf("createAllResources failed: %v", err)
+	}
+
+	if err := storage.storeSandboxResource(sandboxID, stateFileType, State{State: StateRunning}); err != nil {
+		t.Fatalf("storeSandboxResource(state) failed: %v", err)
+	}
+
+	sandboxIDs, err := storage.listSandboxes()
+	if err != nil {
+		t.Fatalf("listSandboxes failed: %v", err)
+	}
+
+	for _, id := range sandboxIDs {
+		if id == sandboxID {
+			return
+		}
+	}
+
+	t.Fatalf("listSandboxes() = %v, expected it to include %q", sandboxIDs, sandboxID)
+}
+
+// testStorageFetchSandboxContainers verifies that every container with a
+// stored config resource under sandboxID shows up in
+// fetchSandboxContainers.
+func testStorageFetchSandboxContainers(t *testing.T, storage resourceStorage, sandboxID string) {
+	containerIDs := []string{"container-a", "container-b", "container-c"}
+
+	sandbox := &Sandbox{
+		id:      sandboxID,
+		storage: storage,
+	}
+
+	for _, containerID := range containerIDs {
+		sandbox.containers = append(sandbox.containers, &Container{id: containerID})
+	}
+
+	if err := storage.createAllResources(sandbox); err != nil {
+		t.Fatalf("createAllResources failed: %v", err)
+	}
+
+	for _, containerID := range containerIDs {
+		if err := storage.storeContainerResource(sandboxID, containerID, configFileType, ContainerConfig{ID: containerID}); err != nil {
+			t.Fatalf("storeContainerResource(config) failed for %s: %v", containerID, err)
+		}
+	}
+
+	fetchedIDs, err := storage.fetchSandboxContainers(sandboxID)
+	if err != nil {
+		t.Fatalf("fetchSandboxContainers failed: %v", err)
+	}
+
+	sort.Strings(fetchedIDs)
+	sort.Strings(containerIDs)
+	if !reflect.DeepEqual(fetchedIDs, containerIDs) {
+		t.Fatalf("fetchSandboxContainers() = %v, want %v", fetchedIDs, containerIDs)
+	}
+}
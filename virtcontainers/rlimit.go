@@ -0,0 +1,56 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "fmt"
+
+// Rlimit describes a single POSIX resource limit to be applied to a
+// container's process before exec in the guest, mirroring the OCI
+// spec's process.rlimits.
+type Rlimit struct {
+	// Type of the rlimit to set, e.g. "RLIMIT_NOFILE". Must be one of
+	// the names accepted by validateRlimits.
+	Type string
+	// Hard is the hard limit for the specified type.
+	Hard uint64
+	// Soft is the soft limit for the specified type.
+	Soft uint64
+}
+
+// rlimitTypes are the POSIX resource limit names accepted in an OCI
+// spec's process.rlimits, matching the RLIMIT_* constants documented in
+// getrlimit(2).
+var rlimitTypes = map[string]struct{}{
+	"RLIMIT_AS":         {},
+	"RLIMIT_CORE":       {},
+	"RLIMIT_CPU":        {},
+	"RLIMIT_DATA":       {},
+	"RLIMIT_FSIZE":      {},
+	"RLIMIT_LOCKS":      {},
+	"RLIMIT_MEMLOCK":    {},
+	"RLIMIT_MSGQUEUE":   {},
+	"RLIMIT_NICE":       {},
+	"RLIMIT_NOFILE":     {},
+	"RLIMIT_NPROC":      {},
+	"RLIMIT_RSS":        {},
+	"RLIMIT_RTPRIO":     {},
+	"RLIMIT_RTTIME":     {},
+	"RLIMIT_SIGPENDING": {},
+	"RLIMIT_STACK":      {},
+}
+
+// validateRlimits checks that every limit in limits has a type known to
+// the kernel's getrlimit(2). An empty or nil limits is valid and means
+// no rlimits are applied.
+func validateRlimits(limits []Rlimit) error {
+	for _, l := range limits {
+		if _, ok := rlimitTypes[l.Type]; !ok {
+			return fmt.Errorf("unknown rlimit type %q", l.Type)
+		}
+	}
+
+	return nil
+}
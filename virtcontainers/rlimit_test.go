@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "testing"
+
+func TestValidateRlimitsEmptyIsValid(t *testing.T) {
+	if err := validateRlimits(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateRlimitsKnownTypes(t *testing.T) {
+	limits := []Rlimit{
+		{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 1024},
+		{Type: "RLIMIT_NPROC", Hard: 64, Soft: 32},
+	}
+
+	if err := validateRlimits(limits); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateRlimitsUnknownType(t *testing.T) {
+	limits := []Rlimit{
+		{Type: "RLIMIT_NOT_A_REAL_LIMIT", Hard: 1, Soft: 1},
+	}
+
+	if err := validateRlimits(limits); err == nil {
+		t.Fatal("expected an unknown rlimit type to be rejected")
+	}
+}
+
+// fakeRlimitAgent behaves like noopAgent except it records whatever
+// rlimits it is asked to apply, for testing that Container.start
+// passes its configured rlimits through correctly.
+type fakeRlimitAgent struct {
+	noopAgent
+	limits []Rlimit
+}
+
+func (a *fakeRlimitAgent) applyRlimits(sandbox *Sandbox, c *Container, limits []Rlimit) error {
+	if err := validateRlimits(limits); err != nil {
+		return err
+	}
+
+	a.limits = limits
+
+	return nil
+}
+
+func TestFakeAgentCapturesRlimits(t *testing.T) {
+	agent := &fakeRlimitAgent{}
+	limits := []Rlimit{
+		{Type: "RLIMIT_NOFILE", Hard: 1024, Soft: 1024},
+	}
+
+	if err := agent.applyRlimits(nil, nil, limits); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(agent.limits) != 1 || agent.limits[0] != limits[0] {
+		t.Fatalf("expected the fake agent to capture the rlimits, got %v", agent.limits)
+	}
+}
+
+func TestFakeAgentRejectsUnknownRlimitType(t *testing.T) {
+	agent := &fakeRlimitAgent{}
+	limits := []Rlimit{
+		{Type: "RLIMIT_NOT_A_REAL_LIMIT", Hard: 1, Soft: 1},
+	}
+
+	if err := agent.applyRlimits(nil, nil, limits); err == nil {
+		t.Fatal("expected an unknown rlimit type to be rejected")
+	}
+}
@@ -13,8 +13,10 @@ import (
 	"path/filepath"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -263,6 +265,101 @@ func TestSandboxFileNegative(t *testing.T) {
 	}
 }
 
+func TestEnsureSandboxLockRecreatesMissingLockFile(t *testing.T) {
+	fs := filesystem{}
+	lockFile, lockDir, err := fs.sandboxURI(testSandboxID, lockFileType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(lockDir, dirMode); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(lockFile)
+
+	if err := ensureSandboxLock(testSandboxID); err != nil {
+		t.Fatalf("ensureSandboxLock() failed to recreate lock file: %v", err)
+	}
+
+	if _, err := os.Stat(lockFile); err != nil {
+		t.Fatalf("lock file %s was not recreated: %v", lockFile, err)
+	}
+}
+
+func TestLockSandboxRecreatesMissingLockFile(t *testing.T) {
+	fs := filesystem{}
+	lockFile, lockDir, err := fs.sandboxURI(testSandboxID, lockFileType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.MkdirAll(lockDir, dirMode); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(lockFile)
+
+	f, err := rLockSandbox(testSandboxID)
+	if err != nil {
+		t.Fatalf("rLockSandbox() failed on missing lock file: %v", err)
+	}
+
+	if err := unlockSandbox(f); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnsureSandboxLockFailingSandboxIDEmpty(t *testing.T) {
+	if err := ensureSandboxLock(""); err == nil {
+		t.Fatal("Empty sandbox IDs should not be allowed")
+	}
+}
+
+func TestRegisterMaxConcurrentLaunchesSerializesLaunches(t *testing.T) {
+	defer RegisterMaxConcurrentLaunches(0)
+	RegisterMaxConcurrentLaunches(1)
+
+	var current, maxObserved int32
+	var wg sync.WaitGroup
+
+	launches := 5
+	wg.Add(launches)
+
+	for i := 0; i < launches; i++ {
+		go func() {
+			defer wg.Done()
+
+			release := acquireLaunchSlot()
+			defer release()
+
+			n := atomic.AddInt32(&current, 1)
+			for {
+				max := atomic.LoadInt32(&maxObserved)
+				if n <= max || atomic.CompareAndSwapInt32(&maxObserved, max, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&current, -1)
+		}()
+	}
+
+	wg.Wait()
+
+	if maxObserved != 1 {
+		t.Fatalf("maxObserved concurrent launches = %d, want 1", maxObserved)
+	}
+}
+
+func TestRegisterMaxConcurrentLaunchesZeroIsUnlimited(t *testing.T) {
+	defer RegisterMaxConcurrentLaunches(0)
+	RegisterMaxConcurrentLaunches(0)
+
+	release := acquireLaunchSlot()
+	release2 := acquireLaunchSlot()
+	release()
+	release2()
+}
+
 func testStateValid(t *testing.T, stateStr stateString, expected bool) {
 	state := &State{
 		State: stateStr,
@@ -296,6 +393,40 @@ func TestValidTransitionFailingOldStateMismatch(t *testing.T) {
 	}
 }
 
+func TestValidateStateTransition(t *testing.T) {
+	tests := []struct {
+		from    stateString
+		to      stateString
+		isValid bool
+	}{
+		{StateReady, StateRunning, true},
+		{StateReady, StateStopped, true},
+		{StateReady, StatePaused, false},
+		{StateRunning, StatePaused, true},
+		{StateRunning, StateStopped, true},
+		{StateRunning, StatePanicked, true},
+		{StateRunning, StateReady, false},
+		{StatePaused, StateRunning, true},
+		{StatePaused, StateStopped, true},
+		{StatePaused, StateReady, false},
+		{StateStopped, StateRunning, true},
+		{StateStopped, StateReady, false},
+		{StateStopped, StatePaused, false},
+		{StatePanicked, StateStopped, true},
+		{StatePanicked, StateRunning, false},
+	}
+
+	for _, test := range tests {
+		err := validateStateTransition(test.from, test.to)
+		if test.isValid && err != nil {
+			t.Fatalf("expected %s -> %s to be valid, got error: %v", test.from, test.to, err)
+		}
+		if !test.isValid && err == nil {
+			t.Fatalf("expected %s -> %s to be invalid, got no error", test.from, test.to)
+		}
+	}
+}
+
 func TestVolumesSetSuccessful(t *testing.T) {
 	volumes := &Volumes{}
 
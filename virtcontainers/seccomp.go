@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// bpfSockFilterSize is the size in bytes of a single Linux
+// struct sock_filter instruction, the unit a raw BPF seccomp program is
+// made of.
+const bpfSockFilterSize = 8
+
+// validateSeccompProfile checks that profile is either a JSON-encoded OCI
+// seccomp spec or a raw BPF program (a sequence of 8-byte
+// struct sock_filter instructions). An empty profile is valid and means no
+// seccomp profile is applied.
+func validateSeccompProfile(profile []byte) error {
+	if len(profile) == 0 {
+		return nil
+	}
+
+	var ociSeccomp specs.LinuxSeccomp
+	if err := json.Unmarshal(profile, &ociSeccomp); err == nil {
+		return nil
+	}
+
+	if len(profile)%bpfSockFilterSize != 0 {
+		return fmt.Errorf("seccomp profile is neither valid JSON OCI seccomp nor a raw BPF program (length %d is not a multiple of %d)", len(profile), bpfSockFilterSize)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "testing"
+
+func TestValidateSeccompProfileEmptyIsValid(t *testing.T) {
+	if err := validateSeccompProfile(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateSeccompProfileValidOCISeccomp(t *testing.T) {
+	profile := []byte(`{"defaultAction":"SCMP_ACT_ALLOW"}`)
+	if err := validateSeccompProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateSeccompProfileValidBPF(t *testing.T) {
+	profile := make([]byte, bpfSockFilterSize*3)
+	if err := validateSeccompProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateSeccompProfileMalformed(t *testing.T) {
+	profile := []byte("not json and not a multiple of 8 bytes")
+	if err := validateSeccompProfile(profile); err == nil {
+		t.Fatal("expected a malformed seccomp profile to be rejected")
+	}
+}
+
+// fakeSeccompAgent behaves like noopAgent except it records whatever
+// seccomp profile it is asked to apply, for testing that
+// Container.start passes its configured profile through correctly.
+type fakeSeccompAgent struct {
+	noopAgent
+	profile []byte
+}
+
+func (a *fakeSeccompAgent) applySeccomp(sandbox *Sandbox, c *Container, profile []byte) error {
+	if err := validateSeccompProfile(profile); err != nil {
+		return err
+	}
+
+	a.profile = profile
+
+	return nil
+}
+
+func TestFakeAgentCapturesSeccompProfile(t *testing.T) {
+	agent := &fakeSeccompAgent{}
+	profile := []byte(`{"defaultAction":"SCMP_ACT_ALLOW"}`)
+
+	if err := validateSeccompProfile(profile); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := agent.applySeccomp(nil, nil, profile); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(agent.profile) != string(profile) {
+		t.Fatalf("expected the fake agent to capture the seccomp profile, got %q", agent.profile)
+	}
+}
+
+func TestFakeAgentRejectsMalformedSeccompProfile(t *testing.T) {
+	agent := &fakeSeccompAgent{}
+	profile := []byte("not json and not a multiple of 8 bytes")
+
+	if err := agent.applySeccomp(nil, nil, profile); err == nil {
+		t.Fatal("expected a malformed seccomp profile to be rejected")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"sync"
+)
+
+// SEVCertChain holds the platform certificate chain (ARK, ASK, PEK, CEK)
+// used to attest an SEV-backed sandbox.
+type SEVCertChain struct {
+	// Subjects holds the Subject of each certificate in the chain, in
+	// the order they appear in SEVCertChainPath.
+	Subjects []string
+}
+
+var (
+	sevCertChainCacheLock sync.Mutex
+	sevCertChainCache     = make(map[string]*SEVCertChain)
+)
+
+// loadSEVCertChain reads and parses the PEM-encoded SEV certificate chain
+// at path, caching the result so repeated lookups for the same path do
+// not re-read and re-parse the file.
+func loadSEVCertChain(path string) (*SEVCertChain, error) {
+	sevCertChainCacheLock.Lock()
+	defer sevCertChainCacheLock.Unlock()
+
+	if chain, ok := sevCertChainCache[path]; ok {
+		return chain, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read SEV certificate chain %s: %v", path, err)
+	}
+
+	var subjects []string
+	rest := data
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse SEV certificate chain %s: %v", path, err)
+		}
+
+		subjects = append(subjects, cert.Subject.String())
+	}
+
+	if len(subjects) == 0 {
+		return nil, fmt.Errorf("no certificates found in SEV certificate chain %s", path)
+	}
+
+	chain := &SEVCertChain{Subjects: subjects}
+	sevCertChainCache[path] = chain
+
+	return chain, nil
+}
+
+// SEVCertChainSubjects returns the Subject of each certificate in the SEV
+// certificate chain at path, loading (and caching) it if necessary.
+func SEVCertChainSubjects(path string) ([]string, error) {
+	chain, err := loadSEVCertChain(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return chain.Subjects, nil
+}
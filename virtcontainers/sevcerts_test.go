@@ -0,0 +1,126 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeSyntheticCertChain generates a minimal self-signed certificate chain
+// (standing in for ARK/ASK/PEK/CEK) and writes it, PEM-encoded, to a file
+// under dir, returning the file's path.
+func writeSyntheticCertChain(t *testing.T, dir string, subjects []string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+
+	var pemData []byte
+	for i, subject := range subjects {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i) + 1),
+			Subject:      pkix.Name{CommonName: subject},
+			NotBefore:    time.Unix(0, 0),
+			NotAfter:     time.Unix(0, 0).AddDate(100, 0, 0),
+		}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		assert.NoError(t, err)
+
+		pemData = append(pemData, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+
+	path := filepath.Join(dir, "sev_cert_chain.pem")
+	assert.NoError(t, ioutil.WriteFile(path, pemData, 0644))
+
+	return path
+}
+
+func TestLoadSEVCertChainSynthetic(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sev-cert-chain")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := writeSyntheticCertChain(t, dir, []string{"ARK", "ASK", "PEK", "CEK"})
+
+	chain, err := loadSEVCertChain(path)
+	assert.NoError(err)
+	assert.Len(chain.Subjects, 4)
+	for i, name := range []string{"ARK", "ASK", "PEK", "CEK"} {
+		assert.Contains(chain.Subjects[i], name)
+	}
+
+	// Second load should hit the cache and return the same data.
+	subjects, err := SEVCertChainSubjects(path)
+	assert.NoError(err)
+	assert.Equal(chain.Subjects, subjects)
+}
+
+func TestLoadSEVCertChainMissingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := loadSEVCertChain("/does/not/exist/sev_cert_chain.pem")
+	assert.Error(err)
+}
+
+func TestHypervisorConfigValidRequiresSEVCertChainWhenSEVEnabled(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sev-cert-chain")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	config := HypervisorConfig{
+		KernelPath: "foo",
+		ImagePath:  "bar",
+		MemEncrypt: true,
+	}
+
+	_, err = config.valid()
+	assert.Error(err, "SEV enabled with no SEVCertChainPath should fail validation")
+
+	config.SEVCertChainPath = filepath.Join(dir, "missing.pem")
+	_, err = config.valid()
+	assert.Error(err, "SEV enabled with an unreadable SEVCertChainPath should fail validation")
+
+	config.SEVCertChainPath = writeSyntheticCertChain(t, dir, []string{"ARK", "ASK"})
+	valid, err := config.valid()
+	assert.NoError(err)
+	assert.True(valid)
+}
+
+func TestHypervisorConfigValidDisablesGuestConsoleLogUnderSEV(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sev-cert-chain")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	config := HypervisorConfig{
+		KernelPath:            "foo",
+		ImagePath:             "bar",
+		MemEncrypt:            true,
+		SEVCertChainPath:      writeSyntheticCertChain(t, dir, []string{"ARK", "ASK"}),
+		EnableGuestConsoleLog: true,
+	}
+
+	valid, err := config.valid()
+	assert.NoError(err)
+	assert.True(valid)
+	assert.False(config.EnableGuestConsoleLog, "guest console logging must be disabled under SEV, even if requested")
+}
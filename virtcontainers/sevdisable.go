@@ -0,0 +1,59 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// sevDisableAnnotation lets someone debugging a guest image turn off SEV
+// memory encryption for a single sandbox, without touching the
+// hypervisor's global configuration. It only takes effect when the host
+// permits it via HypervisorConfig.SEVAllowDisable.
+const sevDisableAnnotation = "kata.sev.disable"
+
+// sevDisableRequested reports whether annotations asks for SEV memory
+// encryption to be disabled for this sandbox. A missing or unparseable
+// value is treated as false.
+func sevDisableRequested(annotations map[string]string) bool {
+	value, ok := annotations[sevDisableAnnotation]
+	if !ok {
+		return false
+	}
+
+	disable, err := strconv.ParseBool(value)
+	if err != nil {
+		return false
+	}
+
+	return disable
+}
+
+// resolveSEVDisable applies the kata.sev.disable annotation to config's
+// memory encryption setting, gated by
+// config.HypervisorConfig.SEVAllowDisable. It returns an error if
+// disabling is requested but not permitted by host policy, so a
+// production host that forbids the annotation rejects the sandbox
+// outright rather than silently launching it one way or the other.
+func resolveSEVDisable(config *SandboxConfig) error {
+	if !config.HypervisorConfig.MemEncrypt {
+		return nil
+	}
+
+	if !sevDisableRequested(config.Annotations) {
+		return nil
+	}
+
+	if !config.HypervisorConfig.SEVAllowDisable {
+		return fmt.Errorf("the %s annotation is forbidden by host policy: SEVAllowDisable is not enabled", sevDisableAnnotation)
+	}
+
+	virtLog.Warningf("%s annotation honored: disabling SEV memory encryption for sandbox %s for debugging", sevDisableAnnotation, config.ID)
+	config.HypervisorConfig.MemEncrypt = false
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSEVDisableRequestedTrue(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.True(sevDisableRequested(map[string]string{sevDisableAnnotation: "true"}))
+}
+
+func TestSEVDisableRequestedMissingOrUnparseable(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.False(sevDisableRequested(map[string]string{}))
+	assert.False(sevDisableRequested(map[string]string{sevDisableAnnotation: "not-a-bool"}))
+}
+
+func TestResolveSEVDisableNoopWhenMemEncryptDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		Annotations: map[string]string{sevDisableAnnotation: "true"},
+	}
+
+	assert.NoError(resolveSEVDisable(config))
+	assert.False(config.HypervisorConfig.MemEncrypt)
+}
+
+func TestResolveSEVDisableNoopWhenAnnotationAbsent(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{MemEncrypt: true},
+	}
+
+	assert.NoError(resolveSEVDisable(config))
+	assert.True(config.HypervisorConfig.MemEncrypt)
+}
+
+func TestResolveSEVDisableHonoredWhenPermitted(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{
+			MemEncrypt:      true,
+			SEVAllowDisable: true,
+		},
+		Annotations: map[string]string{sevDisableAnnotation: "true"},
+	}
+
+	assert.NoError(resolveSEVDisable(config))
+	assert.False(config.HypervisorConfig.MemEncrypt)
+}
+
+func TestResolveSEVDisableRejectedWhenForbiddenByPolicy(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{
+			MemEncrypt:      true,
+			SEVAllowDisable: false,
+		},
+		Annotations: map[string]string{sevDisableAnnotation: "true"},
+	}
+
+	err := resolveSEVDisable(config)
+	assert.Error(err)
+	assert.True(config.HypervisorConfig.MemEncrypt)
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSEVNoASID is returned when an SEV LAUNCH_START fails because every
+// ASID (address space identifier) the platform has is already assigned
+// to another guest. Callers should treat this as transient: a scheduler
+// can back off and retry the launch elsewhere, rather than treating it
+// as a hard launch failure.
+var ErrSEVNoASID = errors.New("no SEV ASID available: all ASIDs are in use")
+
+// sevRetResourceLimit is the AMD SEV firmware status code LAUNCH_START
+// returns when no ASID is free to hand out.
+const sevRetResourceLimit = 23
+
+// sevFirmwareError wraps a raw SEV firmware status code that doesn't map
+// to one of this package's distinct error values.
+type sevFirmwareError struct {
+	code int
+}
+
+func (e *sevFirmwareError) Error() string {
+	return fmt.Sprintf("SEV firmware command failed with status %d", e.code)
+}
+
+// sevFirmwareStatusError turns a raw SEV firmware status code into an
+// error, returning ErrSEVNoASID for ASID exhaustion so callers can
+// distinguish it from other launch failures.
+func sevFirmwareStatusError(code int) error {
+	if code == sevRetResourceLimit {
+		return ErrSEVNoASID
+	}
+
+	return &sevFirmwareError{code: code}
+}
+
+// sevLaunchStarter abstracts the SEV LAUNCH_START firmware call this
+// retry logic depends on, so it can be tested without real SEV
+// hardware. This tree does not otherwise implement the SEV
+// launch-session pipeline yet, so nothing in this codebase currently
+// provides a real sevLaunchStarter outside of tests.
+type sevLaunchStarter interface {
+	// launchStart attempts to start sandboxID's SEV launch session,
+	// returning ErrSEVNoASID (via sevFirmwareStatusError) if no ASID
+	// is currently available.
+	launchStart(sandboxID string) error
+}
+
+// SEVLaunchRetryConfig controls how launchStartWithRetry responds to
+// ASID exhaustion.
+type SEVLaunchRetryConfig struct {
+	// MaxRetries is how many additional attempts to make after the
+	// first one fails with ErrSEVNoASID. Zero means don't retry.
+	MaxRetries int
+
+	// Delay is how long to wait before each retry.
+	Delay time.Duration
+}
+
+// launchStartWithRetry calls starter.launchStart(sandboxID), retrying up
+// to cfg.MaxRetries times, waiting cfg.Delay between attempts, as long
+// as each attempt fails with ErrSEVNoASID. Any other error is returned
+// immediately without retrying.
+func launchStartWithRetry(starter sevLaunchStarter, sandboxID string, cfg SEVLaunchRetryConfig) error {
+	var err error
+
+	for attempt := 0; attempt <= cfg.MaxRetries; attempt++ {
+		err = starter.launchStart(sandboxID)
+		if err != ErrSEVNoASID {
+			return err
+		}
+
+		if attempt < cfg.MaxRetries && cfg.Delay > 0 {
+			time.Sleep(cfg.Delay)
+		}
+	}
+
+	return err
+}
+
+// startSEVLaunch starts sandbox s's SEV launch session via starter,
+// retrying on ASID exhaustion according to
+// s.config.HypervisorConfig.SEVLaunchMaxRetries and SEVLaunchRetryDelay.
+//
+// Nothing in the real launch path calls startSEVLaunch yet: as with
+// checkSEVLaunchMeasurement, this tree has no real sevLaunchStarter
+// outside of tests, so there is nothing to wire it to.
+func (s *Sandbox) startSEVLaunch(starter sevLaunchStarter) error {
+	cfg := SEVLaunchRetryConfig{
+		MaxRetries: s.config.HypervisorConfig.SEVLaunchMaxRetries,
+		Delay:      s.config.HypervisorConfig.SEVLaunchRetryDelay,
+	}
+
+	return launchStartWithRetry(starter, s.id, cfg)
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSEVLaunchStarter struct {
+	// failures is how many times launchStart should fail with
+	// ErrSEVNoASID before succeeding (or failing with err, if set).
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *fakeSEVLaunchStarter) launchStart(sandboxID string) error {
+	f.calls++
+
+	if f.calls <= f.failures {
+		return ErrSEVNoASID
+	}
+
+	return f.err
+}
+
+func TestSEVFirmwareStatusErrorDetectsASIDExhaustion(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(ErrSEVNoASID, sevFirmwareStatusError(sevRetResourceLimit))
+}
+
+func TestSEVFirmwareStatusErrorWrapsOtherCodes(t *testing.T) {
+	assert := assert.New(t)
+
+	err := sevFirmwareStatusError(7)
+	assert.Error(err)
+	assert.NotEqual(ErrSEVNoASID, err)
+}
+
+func TestLaunchStartWithRetrySucceedsAfterASIDBecomesAvailable(t *testing.T) {
+	assert := assert.New(t)
+
+	starter := &fakeSEVLaunchStarter{failures: 2}
+
+	err := launchStartWithRetry(starter, "sandbox1", SEVLaunchRetryConfig{MaxRetries: 2})
+	assert.NoError(err)
+	assert.Equal(3, starter.calls)
+}
+
+func TestLaunchStartWithRetryGivesUpAfterMaxRetries(t *testing.T) {
+	assert := assert.New(t)
+
+	starter := &fakeSEVLaunchStarter{failures: 5}
+
+	err := launchStartWithRetry(starter, "sandbox1", SEVLaunchRetryConfig{MaxRetries: 2})
+	assert.Equal(ErrSEVNoASID, err)
+	assert.Equal(3, starter.calls)
+}
+
+func TestLaunchStartWithRetryDoesNotRetryOtherErrors(t *testing.T) {
+	assert := assert.New(t)
+
+	starter := &fakeSEVLaunchStarter{err: fmt.Errorf("launch policy rejected")}
+
+	err := launchStartWithRetry(starter, "sandbox1", SEVLaunchRetryConfig{MaxRetries: 2})
+	assert.Error(err)
+	assert.NotEqual(ErrSEVNoASID, err)
+	assert.Equal(1, starter.calls)
+}
+
+func TestLaunchStartWithRetryWaitsBetweenAttempts(t *testing.T) {
+	assert := assert.New(t)
+
+	starter := &fakeSEVLaunchStarter{failures: 1}
+
+	start := time.Now()
+	err := launchStartWithRetry(starter, "sandbox1", SEVLaunchRetryConfig{MaxRetries: 1, Delay: 20 * time.Millisecond})
+	elapsed := time.Since(start)
+
+	assert.NoError(err)
+	assert.True(elapsed >= 20*time.Millisecond)
+}
+
+func TestSandboxStartSEVLaunchUsesConfiguredRetries(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		id: "sandbox1",
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				SEVLaunchMaxRetries: 2,
+			},
+		},
+	}
+
+	starter := &fakeSEVLaunchStarter{failures: 2}
+
+	assert.NoError(s.startSEVLaunch(starter))
+	assert.Equal(3, starter.calls)
+}
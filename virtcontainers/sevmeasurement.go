@@ -0,0 +1,94 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// ErrSEVMeasurementMismatch is returned by verifySEVLaunchMeasurement
+// when the firmware-reported LAUNCH_MEASURE digest does not match the
+// operator-provided expected value. Callers must treat this as fatal:
+// abort the launch and tear down whatever partial sandbox state was
+// created, rather than proceeding to LAUNCH_FINISH.
+var ErrSEVMeasurementMismatch = errors.New("SEV launch measurement does not match SEVExpectedMeasurement")
+
+// sevLaunchFirmware abstracts the SEV launch-session firmware calls this
+// verification step depends on, so it can be tested without real SEV
+// hardware. This tree does not otherwise implement the SEV
+// launch-session pipeline (LAUNCH_START's session negotiation, policy,
+// LAUNCH_FINISH) yet, so nothing in this codebase currently provides a
+// real sevLaunchFirmware outside of tests.
+type sevLaunchFirmware interface {
+	// launchMeasure returns the firmware-computed measurement for
+	// sandboxID's launch digest, as produced by LAUNCH_MEASURE.
+	launchMeasure(sandboxID string) ([]byte, error)
+}
+
+// loadSEVExpectedMeasurement reads the hex-encoded LAUNCH_MEASURE digest
+// an operator expects a sandbox's SEV launch to produce, from path.
+func loadSEVExpectedMeasurement(path string) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read SEV expected measurement %s: %v", path, err)
+	}
+
+	measurement, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("SEV expected measurement %s is not valid hex: %v", path, err)
+	}
+
+	return measurement, nil
+}
+
+// verifySEVLaunchMeasurement reads sandboxID's SEV launch measurement
+// from fw and compares it, in constant time, against expected. It
+// returns ErrSEVMeasurementMismatch if they differ, and a wrapped error
+// if fw itself fails.
+func verifySEVLaunchMeasurement(fw sevLaunchFirmware, sandboxID string, expected []byte) error {
+	measured, err := fw.launchMeasure(sandboxID)
+	if err != nil {
+		return fmt.Errorf("unable to read SEV launch measurement: %v", err)
+	}
+
+	if len(measured) != len(expected) || subtle.ConstantTimeCompare(measured, expected) != 1 {
+		return ErrSEVMeasurementMismatch
+	}
+
+	return nil
+}
+
+// checkSEVLaunchMeasurement checks the sandbox's SEV launch measurement,
+// as reported by fw, against s.config.HypervisorConfig.SEVExpectedMeasurementPath.
+// On mismatch, it stops the sandbox's VM before returning
+// ErrSEVMeasurementMismatch, so a bad launch is never left running. It
+// does nothing if no expected measurement is configured.
+func (s *Sandbox) checkSEVLaunchMeasurement(fw sevLaunchFirmware) error {
+	path := s.config.HypervisorConfig.SEVExpectedMeasurementPath
+	if path == "" {
+		return nil
+	}
+
+	expected, err := loadSEVExpectedMeasurement(path)
+	if err != nil {
+		return err
+	}
+
+	if err := verifySEVLaunchMeasurement(fw, s.id, expected); err != nil {
+		if stopErr := s.hypervisor.stopSandbox(); stopErr != nil {
+			s.Logger().WithError(stopErr).Warn("failed to stop sandbox after SEV launch measurement mismatch")
+		}
+
+		return err
+	}
+
+	return nil
+}
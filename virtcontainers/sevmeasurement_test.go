@@ -0,0 +1,123 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSEVLaunchFirmware struct {
+	measurement []byte
+	err         error
+}
+
+func (f *fakeSEVLaunchFirmware) launchMeasure(sandboxID string) ([]byte, error) {
+	return f.measurement, f.err
+}
+
+func TestVerifySEVLaunchMeasurementMatch(t *testing.T) {
+	assert := assert.New(t)
+
+	fw := &fakeSEVLaunchFirmware{measurement: []byte{0x01, 0x02, 0x03}}
+
+	err := verifySEVLaunchMeasurement(fw, "sandbox1", []byte{0x01, 0x02, 0x03})
+	assert.NoError(err)
+}
+
+func TestVerifySEVLaunchMeasurementMismatch(t *testing.T) {
+	assert := assert.New(t)
+
+	fw := &fakeSEVLaunchFirmware{measurement: []byte{0x01, 0x02, 0x03}}
+
+	err := verifySEVLaunchMeasurement(fw, "sandbox1", []byte{0x01, 0x02, 0xff})
+	assert.Equal(ErrSEVMeasurementMismatch, err)
+}
+
+func TestVerifySEVLaunchMeasurementDifferentLengthMismatch(t *testing.T) {
+	assert := assert.New(t)
+
+	fw := &fakeSEVLaunchFirmware{measurement: []byte{0x01, 0x02, 0x03}}
+
+	err := verifySEVLaunchMeasurement(fw, "sandbox1", []byte{0x01, 0x02})
+	assert.Equal(ErrSEVMeasurementMismatch, err)
+}
+
+func TestVerifySEVLaunchMeasurementFirmwareError(t *testing.T) {
+	assert := assert.New(t)
+
+	fw := &fakeSEVLaunchFirmware{err: fmt.Errorf("no launch session in progress")}
+
+	err := verifySEVLaunchMeasurement(fw, "sandbox1", []byte{0x01})
+	assert.Error(err)
+	assert.NotEqual(ErrSEVMeasurementMismatch, err)
+}
+
+func TestCheckSEVLaunchMeasurementStopsSandboxOnMismatch(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sev-measurement")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "expected")
+	assert.NoError(ioutil.WriteFile(path, []byte("010203\n"), 0644))
+
+	hyp := &mockHypervisor{}
+	s := &Sandbox{
+		id:         "sandbox1",
+		hypervisor: hyp,
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				SEVExpectedMeasurementPath: path,
+			},
+		},
+	}
+
+	fw := &fakeSEVLaunchFirmware{measurement: []byte{0x01, 0x02, 0xff}}
+
+	err = s.checkSEVLaunchMeasurement(fw)
+	assert.Equal(ErrSEVMeasurementMismatch, err)
+}
+
+func TestCheckSEVLaunchMeasurementSkippedWhenUnconfigured(t *testing.T) {
+	assert := assert.New(t)
+
+	s := &Sandbox{
+		id:         "sandbox1",
+		hypervisor: &mockHypervisor{},
+		config:     &SandboxConfig{},
+	}
+
+	err := s.checkSEVLaunchMeasurement(&fakeSEVLaunchFirmware{err: fmt.Errorf("should not be called")})
+	assert.NoError(err)
+}
+
+func TestLoadSEVExpectedMeasurementMissingFile(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := loadSEVExpectedMeasurement("/does/not/exist/measurement")
+	assert.Error(err)
+}
+
+func TestLoadSEVExpectedMeasurementInvalidHex(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sev-measurement")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "expected")
+	assert.NoError(ioutil.WriteFile(path, []byte("not-hex"), 0644))
+
+	_, err = loadSEVExpectedMeasurement(path)
+	assert.Error(err)
+}
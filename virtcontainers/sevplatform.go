@@ -0,0 +1,156 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sevDevPath is the SEV platform device through which the PSP driver
+// exposes its ioctl interface.
+const sevDevPath = "/dev/sev"
+
+// sevPDHCertExport is SEV_PDH_CERT_EXPORT, one of the SEV platform
+// commands multiplexed through the SEV_ISSUE_CMD ioctl, from the
+// kernel's include/uapi/linux/psp-sev.h.
+const sevPDHCertExport = 5
+
+// sevIOCIssueCmd is SEV_ISSUE_CMD, the single ioctl request number the
+// SEV platform driver multiplexes every SEV command through.
+var sevIOCIssueCmd = iowr('S', 0, unsafe.Sizeof(sevIssueCmd{}))
+
+// iowr computes a Linux _IOWR() ioctl request number.
+func iowr(t byte, nr, size uintptr) uintptr {
+	const (
+		iocNRBits   = 8
+		iocTypeBits = 8
+
+		iocNRShift   = 0
+		iocTypeShift = iocNRShift + iocNRBits
+		iocSizeShift = iocTypeShift + iocTypeBits
+		iocDirShift  = iocSizeShift + 14 // _IOC_SIZEBITS
+
+		iocRead  = 2
+		iocWrite = 1
+	)
+
+	dir := uintptr(iocRead | iocWrite)
+
+	return (dir << iocDirShift) | (uintptr(t) << iocTypeShift) | (nr << iocNRShift) | (size << iocSizeShift)
+}
+
+// sevIssueCmd mirrors the kernel's struct sev_issue_cmd.
+type sevIssueCmd struct {
+	Cmd   uint64
+	Data  uint64
+	Error uint32
+}
+
+// sevUserDataPDHCertExport mirrors the kernel's
+// struct sev_user_data_pdh_cert_export.
+type sevUserDataPDHCertExport struct {
+	PDHCertAddress   uint64
+	PDHCertLen       uint32
+	CertChainAddress uint64
+	CertChainLen     uint32
+}
+
+// sevPlatformIoctl abstracts the /dev/sev ioctl interface so the PDH
+// retrieval logic can be tested without a real SEV platform.
+type sevPlatformIoctl interface {
+	// pdhCertExport issues SEV_PDH_CERT_EXPORT and returns the raw PDH
+	// certificate bytes.
+	pdhCertExport() ([]byte, error)
+}
+
+// devSEVPlatform is the real sevPlatformIoctl, talking to sevDevPath.
+type devSEVPlatform struct{}
+
+func sevIssueCmdIoctl(fd uintptr, cmd uint32, dataPtr unsafe.Pointer) error {
+	issue := sevIssueCmd{
+		Cmd:  uint64(cmd),
+		Data: uint64(uintptr(dataPtr)),
+	}
+
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, fd, sevIOCIssueCmd, uintptr(unsafe.Pointer(&issue)))
+	if errno != 0 {
+		return fmt.Errorf("SEV_ISSUE_CMD ioctl failed (sev platform error %d): %v", issue.Error, errno)
+	}
+
+	return nil
+}
+
+func (d *devSEVPlatform) pdhCertExport() ([]byte, error) {
+	f, err := os.OpenFile(sevDevPath, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open %s: %v", sevDevPath, err)
+	}
+	defer f.Close()
+
+	// First call with a zero-length buffer: the platform reports back
+	// the certificate's actual required length (even though the call
+	// itself fails with "buffer too small") so we know how much to
+	// allocate for the real call below.
+	var probe sevUserDataPDHCertExport
+	if err := sevIssueCmdIoctl(f.Fd(), sevPDHCertExport, unsafe.Pointer(&probe)); err != nil && probe.PDHCertLen == 0 {
+		return nil, err
+	}
+
+	if probe.PDHCertLen == 0 {
+		return nil, fmt.Errorf("platform reported a zero-length PDH certificate")
+	}
+
+	buf := make([]byte, probe.PDHCertLen)
+	req := sevUserDataPDHCertExport{
+		PDHCertAddress: uint64(uintptr(unsafe.Pointer(&buf[0]))),
+		PDHCertLen:     probe.PDHCertLen,
+	}
+
+	if err := sevIssueCmdIoctl(f.Fd(), sevPDHCertExport, unsafe.Pointer(&req)); err != nil {
+		return nil, err
+	}
+
+	return buf[:req.PDHCertLen], nil
+}
+
+var (
+	platformPDHLock  sync.Mutex
+	platformPDH      []byte
+	platformPDHIoctl sevPlatformIoctl = &devSEVPlatform{}
+)
+
+// getPlatformPDH returns the host's SEV platform Diffie-Hellman
+// certificate, reading it via the /dev/sev ioctl interface on first call
+// and caching the result for subsequent calls.
+func getPlatformPDH() ([]byte, error) {
+	platformPDHLock.Lock()
+	defer platformPDHLock.Unlock()
+
+	if platformPDH != nil {
+		return platformPDH, nil
+	}
+
+	pdh, err := platformPDHIoctl.pdhCertExport()
+	if err != nil {
+		return nil, err
+	}
+
+	platformPDH = pdh
+
+	return platformPDH, nil
+}
+
+// GetPlatformPDH is the virtcontainers entry point for reading the host's
+// SEV platform Diffie-Hellman certificate, needed by external attestation
+// tooling to negotiate an SEV launch session.
+func GetPlatformPDH() ([]byte, error) {
+	return getPlatformPDH()
+}
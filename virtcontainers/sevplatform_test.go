@@ -0,0 +1,67 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSEVPlatformIoctl struct {
+	pdh   []byte
+	err   error
+	calls int
+}
+
+func (f *fakeSEVPlatformIoctl) pdhCertExport() ([]byte, error) {
+	f.calls++
+	return f.pdh, f.err
+}
+
+func TestGetPlatformPDHReturnsAndCachesCannedPDH(t *testing.T) {
+	assert := assert.New(t)
+
+	oldIoctl := platformPDHIoctl
+	oldCache := platformPDH
+	defer func() {
+		platformPDHIoctl = oldIoctl
+		platformPDH = oldCache
+	}()
+	platformPDH = nil
+
+	canned := []byte("canned-pdh-cert-bytes")
+	fake := &fakeSEVPlatformIoctl{pdh: canned}
+	platformPDHIoctl = fake
+
+	pdh, err := GetPlatformPDH()
+	assert.NoError(err)
+	assert.Equal(canned, pdh)
+
+	// A second call should be served from the cache, not the ioctl layer.
+	pdh, err = GetPlatformPDH()
+	assert.NoError(err)
+	assert.Equal(canned, pdh)
+	assert.Equal(1, fake.calls)
+}
+
+func TestGetPlatformPDHPropagatesIoctlError(t *testing.T) {
+	assert := assert.New(t)
+
+	oldIoctl := platformPDHIoctl
+	oldCache := platformPDH
+	defer func() {
+		platformPDHIoctl = oldIoctl
+		platformPDH = oldCache
+	}()
+	platformPDH = nil
+
+	platformPDHIoctl = &fakeSEVPlatformIoctl{err: fmt.Errorf("no SEV platform available")}
+
+	_, err := GetPlatformPDH()
+	assert.Error(err)
+}
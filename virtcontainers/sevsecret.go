@@ -0,0 +1,129 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// secretTableGUID identifies an SEV injected-secret GUID table to guest
+// firmware that knows to look for it, mirroring the "GUIDed structure"
+// convention OVMF uses for its SEV secret block.
+const secretTableGUID = "1e74f542-71dd-4d66-963e-ef4287ff173b"
+
+// maxSecretTableSize bounds the total size of a built secret table
+// (header plus payload) to the size of a single guest page, which is
+// what firmware reserves to receive it.
+const maxSecretTableSize = 4096
+
+// secretEntryHeaderSize is the per-entry overhead: a 16-byte GUID plus a
+// 4-byte little-endian total entry length.
+const secretEntryHeaderSize = 16 + 4
+
+// secretTableHeaderSize is the table-wide overhead: a 16-byte GUID plus a
+// 4-byte little-endian total table length (header and payload combined).
+const secretTableHeaderSize = 16 + 4
+
+// guidToBytes parses a standard "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+// GUID string into its 16-byte, mixed-endian wire representation (the
+// first three fields little-endian, the last two untouched), matching
+// the EFI_GUID layout guest firmware expects.
+func guidToBytes(guid string) ([16]byte, error) {
+	var out [16]byte
+
+	if len(guid) != 36 || guid[8] != '-' || guid[13] != '-' || guid[18] != '-' || guid[23] != '-' {
+		return out, fmt.Errorf("%q is not a valid GUID", guid)
+	}
+
+	hexDigits := guid[0:8] + guid[9:13] + guid[14:18] + guid[19:23] + guid[24:36]
+	raw, err := hex.DecodeString(hexDigits)
+	if err != nil || len(raw) != 16 {
+		return out, fmt.Errorf("%q is not a valid GUID", guid)
+	}
+
+	binary.LittleEndian.PutUint32(out[0:4], binary.BigEndian.Uint32(raw[0:4]))
+	binary.LittleEndian.PutUint16(out[4:6], binary.BigEndian.Uint16(raw[4:6]))
+	binary.LittleEndian.PutUint16(out[6:8], binary.BigEndian.Uint16(raw[6:8]))
+	copy(out[8:16], raw[8:16])
+
+	return out, nil
+}
+
+// BuildSecretTable constructs the measured secret table SEV guest
+// firmware expects to find when a caller injects secrets via an
+// injectSecret agent call. secrets is keyed by a standard GUID string
+// identifying each secret to the guest, mapped to the secret's raw bytes.
+//
+// header is the table-wide GUID and length; payload is the packed,
+// per-secret GUID/length/data entries that follow it, in a stable order
+// regardless of map iteration order. The two are returned separately so
+// callers that must measure or encrypt only the payload (not the
+// table-wide header) can do so without re-parsing.
+//
+// No injectSecret RPC exists in this tree's vendored agent protocol, and
+// the vendored QMP client (github.com/intel/govmm/qemu) has no SEV
+// LAUNCH_START/LAUNCH_FINISH support either, so there is no launch
+// session to inject a built table into even if the agent RPC existed.
+// Wiring this up is therefore blocked on both the agent and hypervisor
+// vendored surfaces, not just one of them; it is exercised only by its
+// own tests until either gap is closed upstream.
+func BuildSecretTable(secrets map[string][]byte) (header []byte, payload []byte, err error) {
+	if len(secrets) == 0 {
+		return nil, nil, fmt.Errorf("no secrets provided")
+	}
+
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+
+	for _, name := range names {
+		data := secrets[name]
+		if len(data) == 0 {
+			return nil, nil, fmt.Errorf("secret %q has no data", name)
+		}
+
+		guid, err := guidToBytes(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		entryLen := secretEntryHeaderSize + len(data)
+
+		buf.Write(guid[:])
+		if err := binary.Write(&buf, binary.LittleEndian, uint32(entryLen)); err != nil {
+			return nil, nil, err
+		}
+		buf.Write(data)
+	}
+
+	payload = buf.Bytes()
+
+	tableGUID, err := guidToBytes(secretTableGUID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalLen := secretTableHeaderSize + len(payload)
+	if totalLen > maxSecretTableSize {
+		return nil, nil, fmt.Errorf("secret table of %d bytes exceeds the %d byte limit", totalLen, maxSecretTableSize)
+	}
+
+	var headerBuf bytes.Buffer
+	headerBuf.Write(tableGUID[:])
+	if err := binary.Write(&headerBuf, binary.LittleEndian, uint32(totalLen)); err != nil {
+		return nil, nil, err
+	}
+
+	return headerBuf.Bytes(), payload, nil
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSecretTableKnownLayout(t *testing.T) {
+	assert := assert.New(t)
+
+	secrets := map[string][]byte{
+		"01234567-89ab-cdef-0123-456789abcdef": []byte("topsecret"),
+	}
+
+	header, payload, err := BuildSecretTable(secrets)
+	assert.NoError(err)
+
+	expectedTableGUID, err := guidToBytes(secretTableGUID)
+	assert.NoError(err)
+	assert.Equal(expectedTableGUID[:], header[:16])
+	assert.Equal(uint32(secretTableHeaderSize+len(payload)), binary.LittleEndian.Uint32(header[16:20]))
+
+	expectedEntryGUID, err := guidToBytes("01234567-89ab-cdef-0123-456789abcdef")
+	assert.NoError(err)
+	assert.Equal(expectedEntryGUID[:], payload[:16])
+	assert.Equal(uint32(secretEntryHeaderSize+len("topsecret")), binary.LittleEndian.Uint32(payload[16:20]))
+	assert.Equal([]byte("topsecret"), payload[20:])
+}
+
+func TestBuildSecretTableIsStableAcrossCalls(t *testing.T) {
+	assert := assert.New(t)
+
+	secrets := map[string][]byte{
+		"01234567-89ab-cdef-0123-456789abcdef": []byte("first"),
+		"fedcba98-7654-3210-fedc-ba9876543210": []byte("second"),
+	}
+
+	header1, payload1, err := BuildSecretTable(secrets)
+	assert.NoError(err)
+
+	header2, payload2, err := BuildSecretTable(secrets)
+	assert.NoError(err)
+
+	assert.Equal(header1, header2)
+	assert.Equal(payload1, payload2)
+}
+
+func TestBuildSecretTableOrdersEntriesByGUID(t *testing.T) {
+	assert := assert.New(t)
+
+	secrets := map[string][]byte{
+		"fedcba98-7654-3210-fedc-ba9876543210": []byte("second"),
+		"01234567-89ab-cdef-0123-456789abcdef": []byte("first"),
+	}
+
+	_, payload, err := BuildSecretTable(secrets)
+	assert.NoError(err)
+
+	firstGUID, err := guidToBytes("01234567-89ab-cdef-0123-456789abcdef")
+	assert.NoError(err)
+	assert.Equal(firstGUID[:], payload[:16])
+}
+
+func TestBuildSecretTableRejectsInvalidGUID(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := BuildSecretTable(map[string][]byte{
+		"not-a-guid": []byte("data"),
+	})
+	assert.Error(err)
+}
+
+func TestBuildSecretTableRejectsEmptySecretData(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := BuildSecretTable(map[string][]byte{
+		"01234567-89ab-cdef-0123-456789abcdef": {},
+	})
+	assert.Error(err)
+}
+
+func TestBuildSecretTableRejectsEmptyInput(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := BuildSecretTable(map[string][]byte{})
+	assert.Error(err)
+}
+
+func TestBuildSecretTableRejectsOversizedTable(t *testing.T) {
+	assert := assert.New(t)
+
+	_, _, err := BuildSecretTable(map[string][]byte{
+		"01234567-89ab-cdef-0123-456789abcdef": make([]byte, maxSecretTableSize),
+	})
+	assert.Error(err)
+}
+
+func TestGUIDToBytesRejectsMalformedGUID(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := guidToBytes("01234567-89ab-cdef-0123-456789abcde")
+	assert.Error(err)
+
+	_, err = guidToBytes("zzzzzzzz-89ab-cdef-0123-456789abcdef")
+	assert.Error(err)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// SEVSessionInfo is the subset of an SEV launch session an external
+// attestation service needs to verify a sandbox's launch. It
+// deliberately contains only values that are safe to hand to a third
+// party: the measurement and policy are meant to be disclosed to an
+// attester by design, and this tree does not perform the SEV
+// LAUNCH_START key exchange, so there is no TEK/TIK-wrapped material to
+// redact in the first place. Nothing resembling a raw secret ever
+// belongs in this struct.
+type SEVSessionInfo struct {
+	// SandboxID identifies the sandbox this session belongs to.
+	SandboxID string `json:"sandbox_id"`
+
+	// Policy holds the SEV guest owner policy bits the launch was
+	// started with.
+	Policy uint32 `json:"policy"`
+
+	// Measurement is the firmware-reported LAUNCH_MEASURE digest,
+	// hex-encoded.
+	Measurement string `json:"measurement"`
+}
+
+// buildSEVSessionInfo assembles sandbox s's exportable SEV launch
+// session info, reading the launch measurement from fw. It refuses to
+// run against a sandbox whose VM is already running, since the session
+// parameters an offline attester cares about only make sense to hand
+// off before (or instead of) letting the guest proceed.
+func buildSEVSessionInfo(s *Sandbox, fw sevLaunchFirmware) (*SEVSessionInfo, error) {
+	if s.state.State == StateRunning {
+		return nil, fmt.Errorf("cannot export SEV launch session for sandbox %s: sandbox is running", s.id)
+	}
+
+	measurement, err := fw.launchMeasure(s.id)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read SEV launch measurement: %v", err)
+	}
+
+	return &SEVSessionInfo{
+		SandboxID:   s.id,
+		Policy:      s.config.HypervisorConfig.SEVGuestPolicy,
+		Measurement: hex.EncodeToString(measurement),
+	}, nil
+}
+
+// ExportSEVSession writes sandbox s's SEV launch session info, as
+// reported by fw, to path as JSON, for consumption by an external
+// attestation service.
+func (s *Sandbox) ExportSEVSession(fw sevLaunchFirmware, path string) error {
+	info, err := buildSEVSessionInfo(s, fw)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal SEV session info: %v", err)
+	}
+
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("unable to write SEV session info to %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// ExportSEVSession writes sandboxID's SEV launch session info to path,
+// for an external attester to consume. As with checkSEVLaunchMeasurement,
+// this tree does not implement the SEV launch-session pipeline that
+// would supply a real sevLaunchFirmware, so this always fails until a
+// caller is wired up with one.
+func ExportSEVSession(sandboxID, path string) error {
+	if sandboxID == "" {
+		return errNeedSandboxID
+	}
+
+	lockFile, err := rLockSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+	defer unlockSandbox(lockFile)
+
+	s, err := fetchSandbox(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("cannot export SEV launch session for sandbox %s: exporting a session requires a sevLaunchFirmware implementation, which this tree does not yet provide outside tests", s.id)
+}
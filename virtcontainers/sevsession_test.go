@@ -0,0 +1,91 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportSEVSessionWritesSessionFile(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sev-session")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	s := &Sandbox{
+		id: "sandbox1",
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				SEVGuestPolicy: 0x1,
+			},
+		},
+	}
+
+	fw := &fakeSEVLaunchFirmware{measurement: []byte{0x01, 0x02, 0x03}}
+
+	path := filepath.Join(dir, "session.json")
+	assert.NoError(s.ExportSEVSession(fw, path))
+
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+
+	var info SEVSessionInfo
+	assert.NoError(json.Unmarshal(data, &info))
+
+	assert.Equal("sandbox1", info.SandboxID)
+	assert.Equal(uint32(0x1), info.Policy)
+	assert.Equal(hex.EncodeToString([]byte{0x01, 0x02, 0x03}), info.Measurement)
+}
+
+func TestExportSEVSessionRejectsRunningSandbox(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sev-session")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	s := &Sandbox{
+		id:     "sandbox1",
+		state:  State{State: StateRunning},
+		config: &SandboxConfig{},
+	}
+
+	fw := &fakeSEVLaunchFirmware{err: fmt.Errorf("no launch session in progress")}
+
+	path := filepath.Join(dir, "session.json")
+	err = s.ExportSEVSession(fw, path)
+	assert.Error(err)
+
+	_, statErr := os.Stat(path)
+	assert.True(os.IsNotExist(statErr))
+}
+
+func TestExportSEVSessionPropagatesFirmwareError(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "sev-session")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	s := &Sandbox{
+		id:     "sandbox1",
+		config: &SandboxConfig{},
+	}
+
+	fw := &fakeSEVLaunchFirmware{err: fmt.Errorf("no launch session in progress")}
+
+	path := filepath.Join(dir, "session.json")
+	assert.Error(s.ExportSEVSession(fw, path))
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/device/drivers"
+)
+
+// vfioSEVCapability abstracts checking whether a VFIO device is
+// compatible with SEV memory encryption, so this can be tested without a
+// real IOMMU. VFIO passthrough to an SEV guest requires the device to
+// sit in an IOMMU group by itself: any other device sharing the group
+// would have DMA access to the guest's memory without going through the
+// encryption the guest thinks it has, defeating the point of enabling
+// SEV in the first place.
+type vfioSEVCapability interface {
+	isSEVCompatible(device *drivers.VFIODevice) (bool, error)
+}
+
+// sysVFIOSEVCapability is the real vfioSEVCapability, backed by the
+// IOMMU group information under config.SysIOMMUPath.
+type sysVFIOSEVCapability struct{}
+
+func (sysVFIOSEVCapability) isSEVCompatible(device *drivers.VFIODevice) (bool, error) {
+	iommuGroup := filepath.Base(device.DeviceInfo.HostPath)
+	devicesPath := filepath.Join(config.SysIOMMUPath, iommuGroup, "devices")
+
+	deviceFiles, err := ioutil.ReadDir(devicesPath)
+	if err != nil {
+		return false, fmt.Errorf("unable to inspect IOMMU group for device %s: %v", device.DeviceInfo.HostPath, err)
+	}
+
+	return len(deviceFiles) == 1, nil
+}
+
+// validateSEVVFIOCompatibility checks each VFIO device in devices
+// against caps, returning an error naming the first incompatible device
+// it finds. It does nothing unless memEncrypt is true.
+func validateSEVVFIOCompatibility(memEncrypt bool, devices []api.Device, caps vfioSEVCapability) error {
+	if !memEncrypt {
+		return nil
+	}
+
+	for _, device := range devices {
+		vfioDevice, ok := device.(*drivers.VFIODevice)
+		if !ok {
+			continue
+		}
+
+		compatible, err := caps.isSEVCompatible(vfioDevice)
+		if err != nil {
+			return fmt.Errorf("unable to validate VFIO device %s for SEV compatibility: %v", vfioDevice.DeviceInfo.HostPath, err)
+		}
+
+		if !compatible {
+			return fmt.Errorf("VFIO device %s is not compatible with SEV memory encryption: its IOMMU group is shared with other devices", vfioDevice.DeviceInfo.HostPath)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/kata-containers/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/runtime/virtcontainers/device/drivers"
+)
+
+type fakeVFIOSEVCapability struct {
+	compatible map[string]bool
+	err        error
+}
+
+func (f *fakeVFIOSEVCapability) isSEVCompatible(device *drivers.VFIODevice) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+
+	return f.compatible[device.DeviceInfo.HostPath], nil
+}
+
+func TestValidateSEVVFIOCompatibilityNoopWhenMemEncryptDisabled(t *testing.T) {
+	assert := assert.New(t)
+
+	caps := &fakeVFIOSEVCapability{err: errNeedSandboxID}
+	devices := []api.Device{drivers.NewVFIODevice(config.DeviceInfo{HostPath: "/dev/vfio/1"})}
+
+	assert.NoError(validateSEVVFIOCompatibility(false, devices, caps))
+}
+
+func TestValidateSEVVFIOCompatibilityAcceptsCompatibleDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	caps := &fakeVFIOSEVCapability{compatible: map[string]bool{"/dev/vfio/1": true}}
+	devices := []api.Device{drivers.NewVFIODevice(config.DeviceInfo{HostPath: "/dev/vfio/1"})}
+
+	assert.NoError(validateSEVVFIOCompatibility(true, devices, caps))
+}
+
+func TestValidateSEVVFIOCompatibilityRejectsIncompatibleDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	caps := &fakeVFIOSEVCapability{compatible: map[string]bool{"/dev/vfio/1": false}}
+	devices := []api.Device{drivers.NewVFIODevice(config.DeviceInfo{HostPath: "/dev/vfio/1"})}
+
+	err := validateSEVVFIOCompatibility(true, devices, caps)
+	assert.Error(err)
+	assert.Contains(err.Error(), "/dev/vfio/1")
+}
+
+func TestValidateSEVVFIOCompatibilitySkipsNonVFIODevices(t *testing.T) {
+	assert := assert.New(t)
+
+	caps := &fakeVFIOSEVCapability{}
+	devices := []api.Device{drivers.NewFdDevice(config.DeviceInfo{ID: "fd-device"})}
+
+	assert.NoError(validateSEVVFIOCompatibility(true, devices, caps))
+}
+
+func TestValidateSEVVFIOCompatibilityPropagatesCapabilityError(t *testing.T) {
+	assert := assert.New(t)
+
+	caps := &fakeVFIOSEVCapability{err: errNeedSandboxID}
+	devices := []api.Device{drivers.NewVFIODevice(config.DeviceInfo{HostPath: "/dev/vfio/1"})}
+
+	assert.Error(validateSEVVFIOCompatibility(true, devices, caps))
+}
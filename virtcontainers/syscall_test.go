@@ -110,6 +110,48 @@ func TestBindMountReadonlySuccessful(t *testing.T) {
 	}
 }
 
+func TestForceReadonlyRootfsOverridesWritableSpec(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	// The OCI spec says the rootfs is writable, but ForceReadonlyRootfs
+	// must win end-to-end: the host bind mount it drives should still
+	// come up read-only.
+	config := ContainerConfig{
+		ReadonlyRootfs:      false,
+		ForceReadonlyRootfs: true,
+	}
+	if !config.effectiveRootfsReadonly() {
+		t.Fatal("expected ForceReadonlyRootfs to force a read-only rootfs")
+	}
+
+	sharedDir := filepath.Join(testDir, "forceReadonlyRootfsShared")
+	sandboxID := "forceReadonlyRootfsSandbox"
+	cID := "forceReadonlyRootfsContainer"
+	rootfs := filepath.Join(testDir, "forceReadonlyRootfsSrc")
+
+	dest := filepath.Join(sharedDir, sandboxID, cID, rootfsDir)
+	syscall.Unmount(dest, 0)
+	os.RemoveAll(sharedDir)
+	os.RemoveAll(rootfs)
+
+	if err := os.MkdirAll(rootfs, mountPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bindMountContainerRootfs(sharedDir, sandboxID, cID, rootfs, config.effectiveRootfsReadonly(), []string{testDir}); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Unmount(dest, 0)
+
+	// should not be able to create a file in the forced-readonly rootfs
+	destFile := filepath.Join(dest, "foo")
+	if _, err := os.OpenFile(destFile, os.O_CREATE, mountPerm); err == nil {
+		t.Fatal("expected write to a force-readonly rootfs to fail")
+	}
+}
+
 func TestEnsureDestinationExistsNonExistingSource(t *testing.T) {
 	err := ensureDestinationExists("", "")
 	if err == nil {
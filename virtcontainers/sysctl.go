@@ -0,0 +1,39 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sysctlAllowedPrefixes lists the sysctl key prefixes safe to apply to a
+// sandbox. As with most container runtimes, only "net.*" sysctls are
+// allowed: they are namespaced to the sandbox's network namespace,
+// whereas most other sysctls affect the guest kernel as a whole and
+// would be unsafe to let a workload set unchecked.
+var sysctlAllowedPrefixes = []string{"net."}
+
+// validateSysctls checks that every key in sysctls is namespaced to a
+// prefix in sysctlAllowedPrefixes.
+func validateSysctls(sysctls map[string]string) error {
+	for key := range sysctls {
+		allowed := false
+
+		for _, prefix := range sysctlAllowedPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("sysctl %q is not namespaced to an allowed prefix (%s)", key, strings.Join(sysctlAllowedPrefixes, ", "))
+		}
+	}
+
+	return nil
+}
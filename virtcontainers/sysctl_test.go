@@ -0,0 +1,57 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "testing"
+
+// fakeSysctlAgent behaves like noopAgent except it records whatever
+// sysctls it is asked to apply, for testing that Sandbox.startVM passes
+// its configured sysctls through correctly.
+type fakeSysctlAgent struct {
+	noopAgent
+	applied map[string]string
+}
+
+func (a *fakeSysctlAgent) applySysctls(sandbox *Sandbox, sysctls map[string]string) error {
+	a.applied = sysctls
+	return nil
+}
+
+func TestValidateSysctlsAllowsNamespacedKeys(t *testing.T) {
+	if err := validateSysctls(map[string]string{"net.core.somaxconn": "1024"}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateSysctlsRejectsDisallowedKey(t *testing.T) {
+	if err := validateSysctls(map[string]string{"kernel.shmmax": "1024"}); err == nil {
+		t.Fatal("expected an error for a sysctl outside the allowed prefixes")
+	}
+}
+
+func TestValidateSysctlsEmptyIsValid(t *testing.T) {
+	if err := validateSysctls(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFakeAgentCapturesAppliedSysctls(t *testing.T) {
+	agent := &fakeSysctlAgent{}
+	s := &Sandbox{agent: agent}
+
+	sysctls := map[string]string{"net.core.somaxconn": "1024"}
+	if err := validateSysctls(sysctls); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.agent.applySysctls(s, sysctls); err != nil {
+		t.Fatal(err)
+	}
+
+	if agent.applied["net.core.somaxconn"] != "1024" {
+		t.Fatalf("expected the fake agent to capture the applied sysctls, got %+v", agent.applied)
+	}
+}
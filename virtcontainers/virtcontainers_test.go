@@ -119,6 +119,7 @@ func TestMain(m *testing.M) {
 	runStoragePath = filepath.Join(testDir, storagePathSuffix, "run")
 
 	// set now that configStoragePath has been overridden.
+	boltDBPath = filepath.Join(configStoragePath, "vc.db")
 	sandboxDirConfig = filepath.Join(configStoragePath, testSandboxID)
 	sandboxFileConfig = filepath.Join(configStoragePath, testSandboxID, configFile)
 	sandboxDirState = filepath.Join(runStoragePath, testSandboxID)
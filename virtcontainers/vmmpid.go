@@ -0,0 +1,50 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procDir is the location of the process information pseudo-filesystem.
+// It is a variable so tests can point it at a fixture directory.
+var procDir = "/proc"
+
+// findProcessByCmdlineToken scans procDir for a running process whose
+// command line contains needle, returning its pid. The caller is
+// responsible for choosing a needle specific enough not to match an
+// unrelated process.
+func findProcessByCmdlineToken(needle string) (int, error) {
+	entries, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		return -1, err
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			// not a /proc/<pid> entry
+			continue
+		}
+
+		cmdline, err := ioutil.ReadFile(filepath.Join(procDir, entry.Name(), "cmdline"))
+		if err != nil {
+			// the process may have exited between the ReadDir and
+			// this ReadFile; skip it.
+			continue
+		}
+
+		if strings.Contains(string(cmdline), needle) {
+			return pid, nil
+		}
+	}
+
+	return -1, fmt.Errorf("no process found with %q in its command line", needle)
+}
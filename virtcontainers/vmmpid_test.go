@@ -0,0 +1,64 @@
+// Copyright (c) 2018 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindProcessByCmdlineToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vmmpid-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	origProcDir := procDir
+	procDir = dir
+	defer func() { procDir = origProcDir }()
+
+	if err := os.MkdirAll(filepath.Join(dir, "1234"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	cmdline := filepath.Join(dir, "1234", "cmdline")
+	if err := ioutil.WriteFile(cmdline, []byte("qemu-system-x86_64\x00-qmp\x00unix:/run/vc/sbx/abc/qmp.sock"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// a directory that isn't a pid should be ignored, not misreported.
+	if err := os.MkdirAll(filepath.Join(dir, "self"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	pid, err := findProcessByCmdlineToken("abc/qmp.sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pid != 1234 {
+		t.Fatalf("expected pid 1234, got %d", pid)
+	}
+}
+
+func TestFindProcessByCmdlineTokenNoMatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "vmmpid-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	origProcDir := procDir
+	procDir = dir
+	defer func() { procDir = origProcDir }()
+
+	if _, err := findProcessByCmdlineToken("nothing-matches-this"); err == nil {
+		t.Fatal("expected an error when no process matches")
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright (c) 2018 AMD Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import "fmt"
+
+const (
+	minAgentVSOCKPort = 1024
+	maxAgentVSOCKPort = 0xFFFFFFFF - 1
+)
+
+// validateAgentVSOCKPort checks that port is a valid, non-privileged vsock
+// port the agent can be configured to listen on. It rejects the well-known
+// VMADDR_PORT_ANY sentinel (the top of the uint32 range), which has no
+// meaning as a fixed listening port.
+func validateAgentVSOCKPort(port uint32) error {
+	if port < minAgentVSOCKPort || port > maxAgentVSOCKPort {
+		return fmt.Errorf("agent vsock port %d is out of range [%d, %d]", port, minAgentVSOCKPort, maxAgentVSOCKPort)
+	}
+	return nil
+}